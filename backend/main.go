@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"backend/pkg/api"
 	"backend/pkg/config"
+	"backend/pkg/logger"
 	"backend/pkg/manager"
+	"backend/pkg/market"
 	"backend/pkg/pool"
 	"os"
 	"os/signal"
@@ -37,6 +39,26 @@ func main() {
 	log.Printf("✓ 配置加载成功，共%d个trader参赛", len(cfg.Traders))
 	fmt.Println()
 
+	// 设置市场数据请求超时和并发上限
+	market.SetHTTPTimeout(cfg.MarketData.GetHTTPTimeout())
+	market.SetMaxConcurrency(cfg.MarketData.MaxConcurrency)
+
+	// 设置MACD周期参数（仅多时间框架模式可配置，标准模式使用默认12/26/9）
+	if mt := cfg.AnalysisMode.MultiTimeframe; mt != nil {
+		market.SetMACDParams(market.MACDParams{Fast: mt.MACD.Fast, Slow: mt.MACD.Slow, Signal: mt.MACD.Signal})
+		w := mt.ScoreWeights
+		log.Printf("⚖️  多时间框架评分权重: 排序 方向评分%.2f/一致性%.2f，一致性 趋势%.2f/动量%.2f/波动%.2f",
+			w.Total, w.Consistency, w.Trend, w.Momentum, w.Volatility)
+		l := mt.CandleLimits
+		log.Printf("🕯️  多时间框架K线数量: 1d=%d 4h=%d 1h=%d 15m=%d 3m=%d",
+			l.ForTimeframe("1d"), l.ForTimeframe("4h"), l.ForTimeframe("1h"), l.ForTimeframe("15m"), l.ForTimeframe("3m"))
+	}
+
+	// 设置关键事件的结构化日志格式
+	if err := logger.SetupEventLog(cfg.Logging.Format, cfg.Logging.JSONFile); err != nil {
+		log.Fatalf("❌ 初始化JSON日志失败: %v", err)
+	}
+
 	// 设置默认主流币种列表
 	pool.SetDefaultCoins(cfg.DefaultCoins)
 
@@ -66,13 +88,27 @@ func main() {
 			traderCfg,
 			cfg.MaxDailyLoss,
 			cfg.MaxDrawdown,
+			cfg.DeRiskDrawdown,         // 回撤减仓百分比（软风控）
 			cfg.StopTradingMinutes,
 			cfg.PositionStopLossPct,   // 单仓位止损百分比
 			cfg.PositionTakeProfitPct, // 单仓位止盈百分比（可选）
+			cfg.PositionMaxLossUSD,    // 单仓位最大亏损金额（可选）
+			cfg.MaxOpenPositions,      // 最大同时持仓数量
+			cfg.ReentryCooldownMinutes, // 平仓后再入场冷却时间
+			cfg.MaxHoldingHours,        // 最长持仓时间
+			cfg.MaxFundingRate,         // 开仓方向需支付的最大资金费率
+			cfg.RiskPerTradePct,        // 单笔交易风险预算
 			cfg.Leverage,              // 传递杠杆配置
 			cfg.SkipLiquidityCheck,    // 是否跳过流动性检查
+			cfg.MinOpenInterestValueUSD, // 流动性检查的最低持仓价值
 			cfg.AnalysisMode,          // 分析模式配置
 			cfg.Strategy,               // 策略配置
+			cfg.OpenConfirmation,       // 开仓确认配置
+			cfg.Prompt,                 // Prompt内容配置
+			cfg.RunLimit,               // 运行上限配置
+			cfg.InsufficientHistory,    // K线历史不足处理配置
+			cfg.Validation,             // 决策验证配置
+			cfg.Telegram,               // Telegram通知配置
 		)
 		if err != nil {
 			log.Fatalf("❌ 初始化trader失败: %v", err)
@@ -91,6 +127,10 @@ func main() {
 		if !traderCfg.Enabled {
 			continue
 		}
+		if traderCfg.InitialBalance <= 0 && traderCfg.AutoDetectInitialBalance {
+			fmt.Printf("  • %s (%s) - 初始资金: 自动检测\n", traderCfg.Name, strings.ToUpper(traderCfg.AIModel))
+			continue
+		}
 		fmt.Printf("  • %s (%s) - 初始资金: %.0f USDT\n",
 			traderCfg.Name, strings.ToUpper(traderCfg.AIModel), traderCfg.InitialBalance)
 	}
@@ -117,6 +157,7 @@ func main() {
 		cfg.APIServerConfig.AllowedOrigins,
 		cfg.APIServerConfig.EnableRateLimit,
 		cfg.APIServerConfig.RateLimitRPS,
+		cfg.APIServerConfig.AdminSecret,
 	)
 	
 	// 使用channel同步启动，检测启动失败
@@ -177,7 +218,7 @@ func main() {
 	fmt.Println()
 	log.Println("📛 收到退出信号，正在停止所有服务...")
 	
-	// 停止所有trader
+	// 停止所有trader的主循环（不再开始新的交易周期）
 	traderManager.StopAll()
 	
 	// 关闭API服务器
@@ -189,6 +230,19 @@ func main() {
 		log.Printf("✓ API服务器已关闭")
 	}
 
+	// 等待进行中的交易周期和平仓操作完成，保存状态并关闭数据库（最多等待30秒，再次收到信号时立即退出）
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer shutdownCancel()
+	go func() {
+		select {
+		case <-sigChan:
+			log.Println("📛 再次收到退出信号，不再等待进行中的操作")
+			shutdownCancel()
+		case <-shutdownCtx.Done():
+		}
+	}()
+	traderManager.ShutdownAll(shutdownCtx)
+
 	fmt.Println()
 	fmt.Println("👋 感谢使用AI交易竞赛系统！")
 }
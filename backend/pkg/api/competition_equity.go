@@ -0,0 +1,138 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"backend/pkg/logger"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultCompetitionEquityPoints 未指定resolution/points时公共时间轴的桶数
+const defaultCompetitionEquityPoints = 300
+
+// CompetitionEquityCurve 单个trader在公共时间轴上的收益率曲线
+type CompetitionEquityCurve struct {
+	TraderID       string  `json:"trader_id"`
+	TraderName     string  `json:"trader_name"`
+	AIModel        string  `json:"ai_model"`
+	InitialBalance float64 `json:"initial_balance"`
+	// PnLPct 与timestamps一一对应的收益率（%，相对该trader自己的初始余额）；第一个数据点之前为null
+	PnLPct []*float64 `json:"pnl_pct"`
+}
+
+// handleCompetitionEquity 所有trader的收益率曲线（对齐到同一时间轴，用于前端叠加绘制）
+// ?days=7 指定时间范围；可选 ?resolution=1h 或 ?points=500 指定时间桶大小（默认均分为300个桶）
+func (s *Server) handleCompetitionEquity(c *gin.Context) {
+	days := 7
+	if daysStr := c.Query("days"); daysStr != "" {
+		var err error
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 1 || days > 90 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days必须是1-90之间的整数"})
+			return
+		}
+	}
+
+	downsample, err := parseDownsampleOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	end := time.Now()
+	start := end.Add(-time.Duration(days) * 24 * time.Hour)
+	width := downsample.resolution
+	if width == 0 {
+		points := downsample.points
+		if points == 0 {
+			points = defaultCompetitionEquityPoints
+		}
+		width = end.Sub(start) / time.Duration(points)
+	}
+	if width < minDownsampleResolution {
+		width = minDownsampleResolution
+	}
+	// 时间轴按桶宽度对齐（不同请求之间桶的边界一致）
+	start = start.Truncate(width)
+	bucketCount := int(end.Sub(start)/width) + 1
+
+	timestamps := make([]string, bucketCount)
+	for i := range timestamps {
+		timestamps[i] = start.Add(time.Duration(i) * width).Format("2006-01-02 15:04:05")
+	}
+
+	traders := s.traderManager.GetAllTraders()
+	curves := make([]CompetitionEquityCurve, 0, len(traders))
+	for _, t := range traders {
+		// 按时间范围查询（90天×3分钟周期远超固定条数上限）
+		records, err := t.GetDecisionRecordsSince(start)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("获取 %s 历史数据失败: %v", t.GetName(), err),
+			})
+			return
+		}
+
+		initialBalance := traderInitialBalance(t, records)
+		curve := CompetitionEquityCurve{
+			TraderID:       t.GetID(),
+			TraderName:     t.GetName(),
+			AIModel:        t.GetAIModel(),
+			InitialBalance: initialBalance,
+			PnLPct:         make([]*float64, bucketCount),
+		}
+		if initialBalance > 0 {
+			alignEquityPct(curve.PnLPct, records, initialBalance, start, width)
+		}
+		curves = append(curves, curve)
+	}
+	sort.Slice(curves, func(i, j int) bool { return curves[i].TraderID < curves[j].TraderID })
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":               days,
+		"resolution_seconds": int64(width / time.Second),
+		"timestamps":         timestamps,
+		"traders":            curves,
+	})
+}
+
+// alignEquityPct 把trader的决策记录按时间桶填入公共时间轴（每个桶取最后一个记录的收益率）
+// 各trader扫描间隔不同，部分桶内没有记录：第一个记录之前保持null，之后沿用上一个桶的值；
+// 失败周期的净值为0，跳过
+func alignEquityPct(buckets []*float64, records []*logger.DecisionRecord, initialBalance float64, start time.Time, width time.Duration) {
+	sorted := make([]*logger.DecisionRecord, 0, len(records))
+	for _, record := range records {
+		if record.AccountState.TotalBalance > 0 && !record.Timestamp.Before(start) {
+			sorted = append(sorted, record)
+		}
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Timestamp.Before(sorted[j].Timestamp) })
+
+	first := -1
+	for _, record := range sorted {
+		idx := int(record.Timestamp.Sub(start) / width)
+		if idx >= len(buckets) {
+			idx = len(buckets) - 1
+		}
+		// TotalBalance字段实际存储的是TotalEquity
+		pct := (record.AccountState.TotalBalance - initialBalance) / initialBalance * 100
+		buckets[idx] = &pct
+		if first < 0 {
+			first = idx
+		}
+	}
+	if first < 0 {
+		return
+	}
+
+	for i := first + 1; i < len(buckets); i++ {
+		if buckets[i] == nil {
+			buckets[i] = buckets[i-1]
+		}
+	}
+}
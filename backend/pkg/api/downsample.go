@@ -0,0 +1,79 @@
+package api
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// 降采样参数范围
+const (
+	minDownsampleResolution = time.Minute
+	maxDownsamplePoints     = 10000
+)
+
+// downsampleOptions 曲线降采样参数（都为零值表示不降采样）
+type downsampleOptions struct {
+	resolution time.Duration // 按固定时间桶聚合（如1h）
+	points     int           // 按时间范围均分为N个桶
+}
+
+// parseDownsampleOptions 解析降采样query参数 ?resolution=1h 或 ?points=500（两者只能指定一个）
+func parseDownsampleOptions(c *gin.Context) (downsampleOptions, error) {
+	var opts downsampleOptions
+	resolutionStr := c.Query("resolution")
+	pointsStr := c.Query("points")
+	if resolutionStr != "" && pointsStr != "" {
+		return opts, fmt.Errorf("resolution和points只能指定一个")
+	}
+
+	if resolutionStr != "" {
+		resolution, err := time.ParseDuration(resolutionStr)
+		if err != nil || resolution < minDownsampleResolution {
+			return opts, fmt.Errorf("resolution必须是不小于1m的时间间隔（如15m、1h、4h）")
+		}
+		opts.resolution = resolution
+	}
+	if pointsStr != "" {
+		points, err := strconv.Atoi(pointsStr)
+		if err != nil || points < 2 || points > maxDownsamplePoints {
+			return opts, fmt.Errorf("points必须是2-%d之间的整数", maxDownsamplePoints)
+		}
+		opts.points = points
+	}
+	return opts, nil
+}
+
+// downsamplePoints 按时间桶降采样（每个桶保留最后一个点），第一个和最后一个点始终原样保留
+// timestamps与points一一对应，且必须按时间从旧到新排列
+func downsamplePoints[T any](points []T, timestamps []time.Time, opts downsampleOptions) []T {
+	n := len(points)
+	if n <= 2 || len(timestamps) != n {
+		return points
+	}
+
+	width := opts.resolution
+	if opts.points > 0 {
+		width = timestamps[n-1].Sub(timestamps[0]) / time.Duration(opts.points)
+	}
+	if width <= 0 {
+		return points
+	}
+
+	bucketOf := func(i int) int64 {
+		return int64(timestamps[i].Sub(timestamps[0]) / width)
+	}
+
+	result := make([]T, 0, n)
+	result = append(result, points[0])
+	for i := 1; i < n-1; i++ {
+		// 中间的点只保留每个桶的最后一个
+		if bucketOf(i) != bucketOf(i+1) {
+			result = append(result, points[i])
+		}
+	}
+	result = append(result, points[n-1])
+	return result
+}
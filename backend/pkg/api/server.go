@@ -2,15 +2,26 @@ package api
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"log"
 	"math"
 	"net/http"
+	"backend/pkg/logger"
 	"backend/pkg/manager"
+	"backend/pkg/metrics"
+	"backend/pkg/trader"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
 )
 
 // rateLimitEntry 限流条目（用于存储每个IP的请求计数）
@@ -61,6 +72,12 @@ func rateLimitCleanup() {
 // rateLimitMiddleware API请求限流中间件（基于IP）
 func rateLimitMiddleware(rps int) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// Prometheus抓取接口不限流（监控系统按固定间隔抓取）
+		if c.Request.URL.Path == metricsPath {
+			c.Next()
+			return
+		}
+
 		// 获取客户端IP
 		clientIP := c.ClientIP()
 		if clientIP == "" {
@@ -121,10 +138,35 @@ type Server struct {
 	allowedOrigins []string  // 允许的CORS来源
 	enableRateLimit bool    // 是否启用限流
 	rateLimitRPS    int     // 限流速率（请求/秒）
+	wsUpgrader      websocket.Upgrader // WebSocket升级器（来源检查与CORS配置一致）
+	wsConnections   int32              // 当前WebSocket连接数（使用atomic保护）
+	wsShutdown      chan struct{}      // 服务器关闭时通知所有WebSocket连接退出
+	wsShutdownOnce  sync.Once
+	adminSecret     string             // 管理接口共享密钥（为空时禁用管理接口）
+	healthMu        sync.Mutex         // 保护healthCache，同时保证同一时间只有一个探测在执行
+	healthCache     *healthProbeResult // 最近一次详细健康检查结果
 }
 
+// healthProbeCacheTTL 详细健康检查结果缓存时长
+// /health/detailed 无需鉴权，缓存结果避免频繁请求放大为对交易所和AI的探测请求
+const healthProbeCacheTTL = 30 * time.Second
+
+// healthProbeResult 详细健康检查结果
+type healthProbeResult struct {
+	checkedAt  time.Time
+	status     string
+	httpStatus int
+	traders    map[string][]trader.ComponentHealth
+}
+
+// adminSecretHeader 管理接口共享密钥的请求头
+const adminSecretHeader = "X-Admin-Secret"
+
+// metricsPath Prometheus抓取接口路径
+const metricsPath = "/metrics"
+
 // NewServer 创建API服务器
-func NewServer(traderManager *manager.TraderManager, port int, allowedOrigins []string, enableRateLimit bool, rateLimitRPS int) *Server {
+func NewServer(traderManager *manager.TraderManager, port int, allowedOrigins []string, enableRateLimit bool, rateLimitRPS int, adminSecret string) *Server {
 	// 设置为Release模式（减少日志输出）
 	gin.SetMode(gin.ReleaseMode)
 
@@ -145,6 +187,13 @@ func NewServer(traderManager *manager.TraderManager, port int, allowedOrigins []
 		allowedOrigins: allowedOrigins,
 		enableRateLimit: enableRateLimit,
 		rateLimitRPS:    rateLimitRPS,
+		wsShutdown:      make(chan struct{}),
+		adminSecret:     adminSecret,
+	}
+	s.wsUpgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 4096,
+		CheckOrigin:     s.checkWebSocketOrigin,
 	}
 
 	// 设置路由
@@ -177,7 +226,7 @@ func corsMiddleware(allowedOrigins []string) gin.HandlerFunc {
 		}
 		
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, "+adminSecretHeader)
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		if c.Request.Method == "OPTIONS" {
@@ -193,12 +242,17 @@ func corsMiddleware(allowedOrigins []string) gin.HandlerFunc {
 func (s *Server) setupRoutes() {
 	// 健康检查
 	s.router.Any("/health", s.handleHealth)
+	s.router.GET("/health/detailed", s.handleHealthDetailed)
+
+	// Prometheus监控指标（不受限流影响，按trader_id标签区分多个trader）
+	s.router.GET(metricsPath, gin.WrapH(metrics.Handler()))
 
 	// API路由组
 	api := s.router.Group("/api")
 	{
 		// 竞赛总览
 		api.GET("/competition", s.handleCompetition)
+		api.GET("/competition/equity", s.handleCompetitionEquity)
 
 		// Trader列表
 		api.GET("/traders", s.handleTraderList)
@@ -207,14 +261,52 @@ func (s *Server) setupRoutes() {
 		api.GET("/status", s.handleStatus)
 		api.GET("/account", s.handleAccount)
 		api.GET("/positions", s.handlePositions)
+		api.GET("/position", s.handlePosition)
 		api.GET("/decisions", s.handleDecisions)
 		api.GET("/decisions/latest", s.handleLatestDecisions)
+		api.GET("/decisions/diff", s.handleDecisionDiff)
+		api.GET("/strategy", s.handleStrategy)
 		api.GET("/statistics", s.handleStatistics)
 		api.GET("/equity-history", s.handleEquityHistory)
+		api.GET("/drawdown", s.handleDrawdown)
 		api.GET("/performance", s.handlePerformance)
+		api.GET("/trades", s.handleTrades)
+		api.GET("/trades/export", s.handleTradesExport)
+		api.GET("/forced-closes", s.handleForcedCloses)
+		api.GET("/report/daily", s.handleDailyReport)
+
+		// 保证金试算（只读取账户和持仓，不下单；每次调用都会请求交易所账户接口，因此需要管理密钥）
+		api.POST("/calc/margin", s.requireAdminSecret, s.handleCalcMargin)
+
+		// AI决策预览（只请求AI决策不执行，每次调用都会产生一次AI请求，因此需要管理密钥）
+		api.GET("/analyze", s.requireAdminSecret, s.handleAnalyze)
+
+		// 实时推送账户和持仓（WebSocket，替代轮询 /account 和 /positions）
+		api.GET("/ws", s.handleWebSocket)
+
+		// 管理接口（需要请求头X-Admin-Secret）
+		api.POST("/flatten", s.requireAdminSecret, s.handleFlatten)
+		api.POST("/pause", s.requireAdminSecret, s.handlePause)
+		api.POST("/resume", s.requireAdminSecret, s.handleResume)
+		api.PUT("/config", s.requireAdminSecret, s.handleUpdateConfig)
 	}
 }
 
+// requireAdminSecret 校验管理接口共享密钥（未配置admin_secret时管理接口禁用）
+func (s *Server) requireAdminSecret(c *gin.Context) {
+	if s.adminSecret == "" {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "管理接口未启用（未配置api_server_config.admin_secret）"})
+		return
+	}
+	provided := c.GetHeader(adminSecretHeader)
+	if subtle.ConstantTimeCompare([]byte(provided), []byte(s.adminSecret)) != 1 {
+		log.Printf("⚠️  管理接口鉴权失败: %s %s (来自 %s)", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "管理密钥无效"})
+		return
+	}
+	c.Next()
+}
+
 // handleHealth 健康检查
 func (s *Server) handleHealth(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
@@ -223,6 +315,66 @@ func (s *Server) handleHealth(c *gin.Context) {
 	})
 }
 
+// handleHealthDetailed 详细健康检查：实际探测每个trader的交易所、AI API和数据库，返回各组件状态和耗时
+// 任一关键组件不可用时返回503；探测结果缓存healthProbeCacheTTL，避免未鉴权请求频繁触发探测
+func (s *Server) handleHealthDetailed(c *gin.Context) {
+	result := s.detailedHealth()
+	c.JSON(result.httpStatus, gin.H{
+		"status":     result.status,
+		"time":       time.Now().Format(time.RFC3339),
+		"checked_at": result.checkedAt.Format(time.RFC3339),
+		"traders":    result.traders,
+	})
+}
+
+// detailedHealth 返回详细健康检查结果（缓存healthProbeCacheTTL内的结果，过期后重新探测）
+func (s *Server) detailedHealth() *healthProbeResult {
+	s.healthMu.Lock()
+	defer s.healthMu.Unlock()
+	if s.healthCache != nil && time.Since(s.healthCache.checkedAt) < healthProbeCacheTTL {
+		return s.healthCache
+	}
+
+	traders := s.traderManager.GetAllTraders()
+	results := make(map[string][]trader.ComponentHealth, len(traders))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for id, t := range traders {
+		wg.Add(1)
+		go func(id string, t *trader.AutoTrader) {
+			defer wg.Done()
+			components := t.ProbeHealth()
+			mu.Lock()
+			results[id] = components
+			mu.Unlock()
+		}(id, t)
+	}
+	wg.Wait()
+
+	status, httpStatus := "ok", http.StatusOK
+	for id, components := range results {
+		for _, component := range components {
+			if component.Status == trader.HealthStatusOK {
+				continue
+			}
+			if component.Critical {
+				status, httpStatus = "down", http.StatusServiceUnavailable
+			} else if status == "ok" {
+				status = "degraded"
+			}
+			log.Printf("⚠️  健康检查: [%s] %s 不可用: %s", id, component.Name, component.Error)
+		}
+	}
+
+	s.healthCache = &healthProbeResult{
+		checkedAt:  time.Now(),
+		status:     status,
+		httpStatus: httpStatus,
+		traders:    results,
+	}
+	return s.healthCache
+}
+
 // getTraderFromQuery 从query参数获取trader_id
 func (s *Server) getTraderFromQuery(c *gin.Context) (string, error) {
 	traderID := c.Query("trader_id")
@@ -341,6 +493,46 @@ func (s *Server) handlePositions(c *gin.Context) {
 	c.JSON(http.StatusOK, positions)
 }
 
+// handlePosition 单个持仓详情（?symbol=BTCUSDT&side=long，包含进场/出场逻辑、止盈止损和逻辑失效原因）
+func (s *Server) handlePosition(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	symbol := strings.ToUpper(c.Query("symbol"))
+	side := strings.ToLower(c.Query("side"))
+	if symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少symbol参数"})
+		return
+	}
+	if side != "long" && side != "short" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "side必须是long或short"})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	position, err := trader.GetPositionDetail(symbol, side)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取持仓详情失败: %v", err),
+		})
+		return
+	}
+	if position == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("未找到持仓 %s %s", symbol, side)})
+		return
+	}
+
+	c.JSON(http.StatusOK, position)
+}
+
 // handleDecisions 决策日志列表
 func (s *Server) handleDecisions(c *gin.Context) {
 	traderID, err := s.getTraderFromQuery(c)
@@ -392,6 +584,123 @@ func (s *Server) handleLatestDecisions(c *gin.Context) {
 	c.JSON(http.StatusOK, records)
 }
 
+// handleDecisionDiff 对比两个周期的决策记录（持仓增减、AI动作变化、候选币种和账户状态变化）
+func (s *Server) handleDecisionDiff(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	cycleA, errA := strconv.Atoi(c.Query("cycle_a"))
+	cycleB, errB := strconv.Atoi(c.Query("cycle_b"))
+	if errA != nil || errB != nil || cycleA < 1 || cycleB < 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cycle_a和cycle_b必须是正整数"})
+		return
+	}
+
+	diff, err := trader.GetDecisionDiff(cycleA, cycleB)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, diff)
+}
+
+// handleStrategy 当前策略、杠杆和风控参数，以及按当前账户重新渲染的System Prompt（不包含密钥）
+func (s *Server) handleStrategy(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trader.GetStrategyInfo())
+}
+
+// handleCalcMargin 试算假设开仓对保证金的影响（所需保证金、开仓后保证金使用率和可用余额、能否通过开仓安全检查）
+func (s *Server) handleCalcMargin(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var req struct {
+		Symbol          string  `json:"symbol"`
+		PositionSizeUSD float64 `json:"position_size_usd"`
+		Leverage        int     `json:"leverage"`
+	}
+	if err := json.NewDecoder(c.Request.Body).Decode(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求体无效: %v", err)})
+		return
+	}
+	if req.Symbol == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少symbol"})
+		return
+	}
+	if req.PositionSizeUSD <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "position_size_usd必须大于0"})
+		return
+	}
+	if req.Leverage < 1 || req.Leverage > 125 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "leverage必须在1-125之间"})
+		return
+	}
+
+	result, err := trader.CalcMargin(req.Symbol, req.PositionSizeUSD, req.Leverage)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("保证金试算失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// handleAnalyze 预览指定trader在当前行情下的AI决策（返回思维链和决策列表，不执行任何操作）
+func (s *Server) handleAnalyze(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	fullDecision, err := trader.Analyze()
+	if err != nil {
+		resp := gin.H{"error": err.Error()}
+		if fullDecision != nil {
+			resp["cot_trace"] = fullDecision.CoTTrace
+		}
+		c.JSON(http.StatusBadGateway, resp)
+		return
+	}
+
+	c.JSON(http.StatusOK, fullDecision)
+}
+
 // handleStatistics 统计信息
 func (s *Server) handleStatistics(c *gin.Context) {
 	traderID, err := s.getTraderFromQuery(c)
@@ -430,6 +739,13 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 		return
 	}
 
+	// 可选降采样（?resolution=1h 或 ?points=500），减小图表数据量
+	downsample, err := parseDownsampleOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// 获取尽可能多的历史数据（几天的数据）
 	// 每3分钟一个周期：10000条 = 约20天的数据
 	records, err := trader.GetDecisionRecordsFromDB(10000)
@@ -453,32 +769,10 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 		CycleNumber      int     `json:"cycle_number"`
 	}
 
-	// 从AutoTrader获取初始余额（用于计算盈亏百分比）
-	// 优先使用配置的initialBalance，确保与GetAccountInfo返回的值一致
-	initialBalance := 0.0
-	
-	// 方法1：从GetStatus获取（最可靠）
-	if status := trader.GetStatus(); status != nil {
-		if ib, ok := status["initial_balance"].(float64); ok && ib > 0 {
-			initialBalance = ib
-		}
-	}
-	
-	// 方法2：如果无法从status获取，尝试从trader实例直接获取（需要类型断言）
-	if initialBalance == 0 {
-		// 注意：这里需要根据实际的trader接口进行调整
-		// 如果trader是AutoTrader类型，可以直接访问initialBalance字段
-		// 但为了保持接口一致性，优先使用GetStatus()
-	}
-	
-	// 方法3：如果无法获取，且有历史记录，则从第一条记录获取（不推荐，但作为fallback）
-	if initialBalance == 0 && len(records) > 0 {
-		// 第一条记录的equity作为初始余额（可能不准确，因为可能已有持仓）
-		initialBalance = records[0].AccountState.TotalBalance
-		log.Printf("⚠️  使用第一条记录的equity作为初始余额: %.2f（建议检查配置）", initialBalance)
-	}
+	// 获取初始余额（用于计算盈亏百分比）
+	initialBalance := traderInitialBalance(trader, records)
 
-	// 如果还是无法获取，返回错误
+	// 如果无法获取，返回错误
 	if initialBalance == 0 {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": "无法获取初始余额",
@@ -487,6 +781,7 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 	}
 
 	var history []EquityPoint
+	var timestamps []time.Time // 与history一一对应，用于降采样
 	for _, record := range records {
 		// TotalBalance字段实际存储的是TotalEquity
 		totalEquity := record.AccountState.TotalBalance
@@ -516,6 +811,7 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 			MarginUsedPct:    record.AccountState.MarginUsedPct,
 			CycleNumber:      record.CycleNumber,
 		})
+		timestamps = append(timestamps, record.Timestamp)
 	}
 
 	// 确保数据按时间顺序排列（从旧到新，从左到右）- 如果数据库中是反序的，需要反转
@@ -528,11 +824,218 @@ func (s *Server) handleEquityHistory(c *gin.Context) {
 			// 如果第一个时间比最后一个时间晚，说明是反序的，需要反转
 			for i, j := 0, len(history)-1; i < j; i, j = i+1, j-1 {
 				history[i], history[j] = history[j], history[i]
+				timestamps[i], timestamps[j] = timestamps[j], timestamps[i]
 			}
 		}
 	}
 
-	c.JSON(http.StatusOK, history)
+	c.JSON(http.StatusOK, downsamplePoints(history, timestamps, downsample))
+}
+
+// traderInitialBalance 获取trader的初始余额（用于计算盈亏百分比）
+// 优先使用GetStatus中的initial_balance，确保与GetAccountInfo返回的值一致；
+// 无法获取时使用最早一条有效记录的净值（可能不准确，因为可能已有持仓），都没有时返回0
+// records按时间倒序（GetDecisionRecordsFromDB/GetDecisionRecordsSince），最早的记录在末尾
+func traderInitialBalance(at *trader.AutoTrader, records []*logger.DecisionRecord) float64 {
+	if status := at.GetStatus(); status != nil {
+		if ib, ok := status["initial_balance"].(float64); ok && ib > 0 {
+			return ib
+		}
+	}
+
+	// 失败周期的净值为0，跳过
+	for i := len(records) - 1; i >= 0; i-- {
+		if initialBalance := records[i].AccountState.TotalBalance; initialBalance > 0 {
+			log.Printf("⚠️  [%s] 使用最早一条记录的equity作为初始余额: %.2f（建议检查配置）", at.GetName(), initialBalance)
+			return initialBalance
+		}
+	}
+	return 0
+}
+
+// handleDrawdown 回撤曲线（根据决策记录中的账户净值重建滚动峰值）
+func (s *Server) handleDrawdown(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 可选降采样（与equity-history相同的参数），回撤在完整数据上计算后再降采样
+	downsample, err := parseDownsampleOptions(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 与equity-history使用相同的历史范围
+	records, err := at.GetDecisionRecordsFromDB(10000)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取历史数据失败: %v", err),
+		})
+		return
+	}
+
+	// TotalBalance字段实际存储的是TotalEquity；失败周期为0，由ComputeDrawdownCurve沿用上一个值
+	samples := make([]trader.EquitySample, 0, len(records))
+	for _, record := range records {
+		samples = append(samples, trader.EquitySample{
+			Timestamp: record.Timestamp,
+			Equity:    record.AccountState.TotalBalance,
+		})
+	}
+
+	type DrawdownPoint struct {
+		Timestamp   string  `json:"timestamp"`
+		Equity      float64 `json:"equity"`
+		Peak        float64 `json:"peak"`
+		DrawdownPct float64 `json:"drawdown_pct"`
+	}
+
+	curve := trader.ComputeDrawdownCurve(samples)
+	timestamps := make([]time.Time, 0, len(curve))
+	for _, point := range curve {
+		timestamps = append(timestamps, point.Timestamp)
+	}
+	curve = downsamplePoints(curve, timestamps, downsample)
+
+	points := make([]DrawdownPoint, 0, len(curve))
+	for _, point := range curve {
+		points = append(points, DrawdownPoint{
+			Timestamp:   point.Timestamp.Format("2006-01-02 15:04:05"),
+			Equity:      point.Equity,
+			Peak:        point.Peak,
+			DrawdownPct: point.DrawdownPct,
+		})
+	}
+
+	c.JSON(http.StatusOK, points)
+}
+
+// handleFlatten 紧急平掉指定trader的所有持仓（可选暂停交易 pause_minutes 分钟）
+func (s *Server) handleFlatten(c *gin.Context) {
+	// 破坏性操作必须显式指定trader，不回退到第一个trader
+	traderID := c.Query("trader_id")
+	if traderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少trader_id参数"})
+		return
+	}
+
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	pauseMinutes := 0
+	if pauseStr := c.Query("pause_minutes"); pauseStr != "" {
+		pauseMinutes, err = strconv.Atoi(pauseStr)
+		if err != nil || pauseMinutes < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pause_minutes必须是非负整数"})
+			return
+		}
+	}
+
+	log.Printf("🚨 [%s] 收到紧急平仓API请求（来自 %s，暂停 %d 分钟）", traderID, c.ClientIP(), pauseMinutes)
+	results, err := at.FlattenAll(time.Duration(pauseMinutes) * time.Minute)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": fmt.Sprintf("紧急平仓失败: %v", err)})
+		return
+	}
+
+	closed := 0
+	for _, result := range results {
+		if result.Success {
+			closed++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id":     traderID,
+		"total":         len(results),
+		"closed":        closed,
+		"failed":        len(results) - closed,
+		"pause_minutes": pauseMinutes,
+		"results":       results,
+	})
+}
+
+// handlePause 暂停指定trader的AI决策（止损保护继续运行）
+func (s *Server) handlePause(c *gin.Context) {
+	s.setTraderPaused(c, true)
+}
+
+// handleResume 恢复指定trader的AI决策
+func (s *Server) handleResume(c *gin.Context) {
+	s.setTraderPaused(c, false)
+}
+
+// setTraderPaused 设置指定trader的暂停状态（必须显式指定trader_id）
+func (s *Server) setTraderPaused(c *gin.Context, paused bool) {
+	traderID := c.Query("trader_id")
+	if traderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少trader_id参数"})
+		return
+	}
+
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	if paused {
+		at.Pause()
+	} else {
+		at.Resume()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"is_paused": at.IsPaused(),
+	})
+}
+
+// handleUpdateConfig 运行时修改指定trader的扫描间隔、杠杆和风控参数（请求体为只包含需修改字段的JSON）
+// 不支持的字段（API密钥、交易所等）直接拒绝，避免调用方误以为已生效
+func (s *Server) handleUpdateConfig(c *gin.Context) {
+	traderID := c.Query("trader_id")
+	if traderID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "缺少trader_id参数"})
+		return
+	}
+
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	var patch trader.ConfigPatch
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("请求体无效（只支持修改扫描间隔、杠杆和风控参数）: %v", err)})
+		return
+	}
+
+	if err := at.UpdateConfig(patch); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	log.Printf("⚙️  [%s] 运行时配置已通过API修改（来自 %s）", traderID, c.ClientIP())
+	c.JSON(http.StatusOK, gin.H{
+		"trader_id": traderID,
+		"config":    at.GetRuntimeConfig(),
+	})
 }
 
 // handlePerformance AI历史表现分析（用于展示AI学习和反思）
@@ -549,6 +1052,27 @@ func (s *Server) handlePerformance(c *gin.Context) {
 		return
 	}
 
+	// 指定strategy时只分析该策略最近N天的交易（用于对比共用同一账户的多个策略）
+	if strategy := c.Query("strategy"); strategy != "" {
+		days := 30
+		if daysStr := c.Query("days"); daysStr != "" {
+			days, err = strconv.Atoi(daysStr)
+			if err != nil || days < 1 || days > 3650 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "days必须是1-3650之间的整数"})
+				return
+			}
+		}
+		performance, err := trader.GetStrategyPerformanceFromDB(strategy, days)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": fmt.Sprintf("分析策略表现失败: %v", err),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, performance)
+		return
+	}
+
 	// 分析所有历史交易表现（从数据库获取）
 	// 使用一个很大的数字（10000）来确保获取所有记录
 	performance, err := trader.GetPerformanceFromDB(10000)
@@ -561,22 +1085,364 @@ func (s *Server) handlePerformance(c *gin.Context) {
 	c.JSON(http.StatusOK, performance)
 }
 
+// handleTrades 分页获取已平仓的交易（最新的在前）
+func (s *Server) handleTrades(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit必须是1-500之间的整数"})
+			return
+		}
+	}
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset必须是非负整数"})
+			return
+		}
+	}
+
+	trades, total, err := trader.GetTradesPaginatedFromDB(limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取交易记录失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"trades": trades,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
+
+// handleForcedCloses 获取最近的强制平仓记录（最新的在前，含风控触发类型）
+func (s *Server) handleForcedCloses(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit := 50
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > 500 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "limit必须是1-500之间的整数"})
+			return
+		}
+	}
+
+	records, err := trader.GetForcedClosesFromDB(limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取强制平仓记录失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
+
+// handleDailyReport 每日交易汇总（date为UTC日期，格式YYYY-MM-DD，默认今天）
+func (s *Server) handleDailyReport(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	date := time.Now().UTC()
+	if dateStr := c.Query("date"); dateStr != "" {
+		date, err = time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "date格式必须是YYYY-MM-DD"})
+			return
+		}
+	}
+
+	report, err := trader.GenerateDailyReport(date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("生成每日汇总失败: %v", err),
+		})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// handleTradesExport 导出最近N天已平仓的交易（CSV，用于记账/报税）
+func (s *Server) handleTradesExport(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	days := 30
+	if daysStr := c.Query("days"); daysStr != "" {
+		days, err = strconv.Atoi(daysStr)
+		if err != nil || days < 1 || days > 3650 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "days必须是1-3650之间的整数"})
+			return
+		}
+	}
+
+	trades, err := trader.GetClosedTradesFromDB(days)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("获取交易记录失败: %v", err),
+		})
+		return
+	}
+
+	filename := fmt.Sprintf("trades_%s_%dd_%s.csv", traderID, days, time.Now().Format("20060102"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	c.Status(http.StatusOK)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{
+		"trade_id", "symbol", "side", "open_time", "close_time", "open_price", "close_price",
+		"quantity", "leverage", "pnl", "pnl_pct", "duration", "is_forced",
+	})
+	for _, t := range trades {
+		closeTime := ""
+		if t.CloseTime != nil {
+			closeTime = t.CloseTime.Format(time.RFC3339)
+		}
+		quantity := t.CloseQuantity
+		if quantity == 0 {
+			quantity = t.OpenQuantity
+		}
+		w.Write([]string{
+			t.TradeID,
+			t.Symbol,
+			t.Side,
+			t.OpenTime.Format(time.RFC3339),
+			closeTime,
+			strconv.FormatFloat(t.OpenPrice, 'f', -1, 64),
+			strconv.FormatFloat(t.ClosePrice, 'f', -1, 64),
+			strconv.FormatFloat(quantity, 'f', -1, 64),
+			strconv.Itoa(t.OpenLeverage),
+			strconv.FormatFloat(t.PnL, 'f', 4, 64),
+			strconv.FormatFloat(t.PnLPct, 'f', 2, 64),
+			t.Duration,
+			strconv.FormatBool(t.IsForced),
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		log.Printf("⚠️  导出交易记录CSV失败 [%s]: %v", trader.GetName(), err)
+	}
+}
+
+// WebSocket推送配置
+const (
+	wsPushInterval   = 3 * time.Second  // 账户/持仓推送间隔（数据未变化时不重复推送）
+	wsMaxConnections = 50               // 最大并发WebSocket连接数
+	wsWriteTimeout   = 10 * time.Second // 单次写入超时
+	wsPongWait       = 60 * time.Second // 超过此时间未收到客户端消息/pong视为断开
+)
+
+// wsMessage WebSocket推送消息
+type wsMessage struct {
+	Type      string                   `json:"type"` // "snapshot" 或 "error"
+	TraderID  string                   `json:"trader_id"`
+	Timestamp string                   `json:"timestamp"`
+	Account   map[string]interface{}   `json:"account"`
+	Positions []map[string]interface{} `json:"positions"`
+	Error     string                   `json:"error,omitempty"`
+}
+
+// checkWebSocketOrigin 检查WebSocket请求来源（与CORS中间件的规则一致）
+func (s *Server) checkWebSocketOrigin(r *http.Request) bool {
+	if len(s.allowedOrigins) == 0 {
+		return true
+	}
+	origin := r.Header.Get("Origin")
+	for _, allowedOrigin := range s.allowedOrigins {
+		if origin == allowedOrigin {
+			return true
+		}
+	}
+	return false
+}
+
+// handleWebSocket 实时推送账户和持仓（WebSocket）
+// 每隔wsPushInterval获取一次账户和持仓，数据变化时推送给客户端
+func (s *Server) handleWebSocket(c *gin.Context) {
+	traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 限制并发连接数
+	if atomic.AddInt32(&s.wsConnections, 1) > wsMaxConnections {
+		atomic.AddInt32(&s.wsConnections, -1)
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"error": fmt.Sprintf("WebSocket连接数已达上限(%d)，请稍后再试", wsMaxConnections),
+		})
+		return
+	}
+	defer atomic.AddInt32(&s.wsConnections, -1)
+
+	conn, err := s.wsUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrade失败时已向客户端返回错误响应
+		log.Printf("⚠️  WebSocket升级失败 [%s]: %v", trader.GetName(), err)
+		return
+	}
+	defer conn.Close()
+
+	log.Printf("🔌 WebSocket已连接 [%s] (当前连接数: %d)", trader.GetName(), atomic.LoadInt32(&s.wsConnections))
+	defer log.Printf("🔌 WebSocket已断开 [%s]", trader.GetName())
+
+	// 读取循环：处理pong/close帧，客户端断开时通知推送循环退出
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn.SetReadLimit(1024)
+		conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		})
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(wsPongWait))
+		}
+	}()
+
+	ticker := time.NewTicker(wsPushInterval)
+	defer ticker.Stop()
+
+	var lastPayload []byte
+	for {
+		// 连接后立即推送一次，之后按间隔推送
+		msg := s.buildWebSocketSnapshot(traderID)
+		payload, _ := json.Marshal(msg)
+		if !bytes.Equal(payload, lastPayload) {
+			msg.Timestamp = time.Now().Format(time.RFC3339)
+			conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := conn.WriteJSON(msg); err != nil {
+				return
+			}
+			lastPayload = payload
+		}
+
+		select {
+		case <-ticker.C:
+			// 发送ping保持连接（数据未变化时也能检测到断开）
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+				return
+			}
+		case <-done:
+			return
+		case <-s.wsShutdown:
+			conn.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, "服务器关闭"),
+				time.Now().Add(wsWriteTimeout))
+			return
+		}
+	}
+}
+
+// buildWebSocketSnapshot 构建账户和持仓快照（不含时间戳，便于判断数据是否变化）
+func (s *Server) buildWebSocketSnapshot(traderID string) wsMessage {
+	msg := wsMessage{Type: "snapshot", TraderID: traderID}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		msg.Type = "error"
+		msg.Error = err.Error()
+	} else if account, err := trader.GetAccountInfo(); err != nil {
+		msg.Type = "error"
+		msg.Error = fmt.Sprintf("获取账户信息失败: %v", err)
+	} else if positions, err := trader.GetPositions(); err != nil {
+		msg.Type = "error"
+		msg.Error = fmt.Sprintf("获取持仓列表失败: %v", err)
+	} else {
+		msg.Account = account
+		msg.Positions = positions
+	}
+	return msg
+}
+
 // Start 启动服务器
 func (s *Server) Start() error {
 	addr := fmt.Sprintf(":%d", s.port)
 	log.Printf("🌐 API服务器启动在 http://localhost%s", addr)
 	log.Printf("📊 API文档:")
 	log.Printf("  • GET  /api/competition      - 竞赛总览（对比所有trader）")
+	log.Printf("  • GET  /api/competition/equity?days=7 - 所有trader的收益率曲线（对齐到同一时间轴）")
 	log.Printf("  • GET  /api/traders          - Trader列表")
 	log.Printf("  • GET  /api/status?trader_id=xxx     - 指定trader的系统状态")
 	log.Printf("  • GET  /api/account?trader_id=xxx    - 指定trader的账户信息")
 	log.Printf("  • GET  /api/positions?trader_id=xxx  - 指定trader的持仓列表")
 	log.Printf("  • GET  /api/decisions?trader_id=xxx  - 指定trader的决策日志")
 	log.Printf("  • GET  /api/decisions/latest?trader_id=xxx - 指定trader的最新决策")
+	log.Printf("  • GET  /api/decisions/diff?trader_id=xxx&cycle_a=10&cycle_b=11 - 对比两个周期的决策")
+	log.Printf("  • GET  /api/strategy?trader_id=xxx - 当前策略、杠杆、风控参数和System Prompt")
+	log.Printf("  • POST /api/calc/margin?trader_id=xxx - 保证金试算（symbol/position_size_usd/leverage，不下单，需要请求头X-Admin-Secret）")
 	log.Printf("  • GET  /api/statistics?trader_id=xxx - 指定trader的统计信息")
 	log.Printf("  • GET  /api/equity-history?trader_id=xxx - 指定trader的收益率历史数据")
+	log.Printf("  • GET  /api/drawdown?trader_id=xxx - 指定trader的回撤曲线（按决策记录中的净值重建峰值）")
 	log.Printf("  • GET  /api/performance?trader_id=xxx - 指定trader的AI学习表现分析")
+	log.Printf("  • GET  /api/performance?trader_id=xxx&strategy=name&days=30 - 指定策略最近N天的表现")
+	log.Printf("  • GET  /api/trades?trader_id=xxx&limit=50&offset=0 - 分页获取已平仓交易（含进场/出场/平仓逻辑）")
+	log.Printf("  • GET  /api/forced-closes?trader_id=xxx&limit=50 - 最近的强制平仓记录（含触发类型）")
+	log.Printf("  • GET  /api/trades/export?trader_id=xxx&days=30 - 导出已平仓交易（CSV）")
+	log.Printf("  • GET  /api/report/daily?trader_id=xxx&date=YYYY-MM-DD - 指定trader的每日交易汇总（UTC日期，默认今天）")
+	log.Printf("  • WS   /api/ws?trader_id=xxx          - 实时推送指定trader的账户和持仓")
+	log.Printf("  • POST /api/flatten?trader_id=xxx&pause_minutes=60 - 紧急平掉指定trader的所有持仓（需要请求头X-Admin-Secret）")
+	log.Printf("  • POST /api/pause?trader_id=xxx   - 暂停指定trader的AI决策，止损保护继续运行（需要请求头X-Admin-Secret）")
+	log.Printf("  • POST /api/resume?trader_id=xxx  - 恢复指定trader的AI决策（需要请求头X-Admin-Secret）")
 	log.Printf("  • GET  /health               - 健康检查")
+	log.Printf("  • GET  /health/detailed      - 详细健康检查（探测交易所、AI和数据库，结果缓存30秒）")
+	log.Printf("  • GET  /metrics              - Prometheus监控指标")
 	log.Println()
 	
 	// 创建http.Server以便支持优雅关闭
@@ -594,5 +1460,7 @@ func (s *Server) Shutdown(ctx context.Context) error {
 		return nil
 	}
 	log.Printf("🛑 正在关闭API服务器...")
+	// http.Server.Shutdown不会关闭已升级的WebSocket连接，需要单独通知
+	s.wsShutdownOnce.Do(func() { close(s.wsShutdown) })
 	return s.httpServer.Shutdown(ctx)
 }
@@ -0,0 +1,290 @@
+package backtest
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/pkg/decision"
+	"backend/pkg/logger"
+	"backend/pkg/market"
+)
+
+// paperPosition 模拟持仓
+type paperPosition struct {
+	Symbol     string
+	Side       string // "long" or "short"
+	EntryPrice float64
+	Quantity   float64
+	Leverage   int
+	Margin     float64 // 占用保证金（按剩余数量计）
+	StopLoss   float64
+	TakeProfit float64
+	OpenTime   time.Time
+	EntryLogic string
+	ExitLogic  string
+	MarkPrice  float64 // 最近一次更新的标记价格
+}
+
+// PaperTrader 模拟交易账户（回测用，按K线价格成交，不考虑滑点和资金费）
+type PaperTrader struct {
+	balance   float64                   // 钱包余额（已实现盈亏和手续费计入后）
+	feeRate   float64                   // 单边手续费率（按成交名义价值计）
+	positions map[string]*paperPosition // key: symbol_side
+	trades    []logger.TradeOutcome     // 已平仓交易（旧→新）
+}
+
+// NewPaperTrader 创建模拟交易账户
+func NewPaperTrader(initialBalance, feeRate float64) *PaperTrader {
+	return &PaperTrader{
+		balance:   initialBalance,
+		feeRate:   feeRate,
+		positions: make(map[string]*paperPosition),
+	}
+}
+
+// Open 按指定价格开仓（同一币种同一方向已有持仓时返回错误，与实盘行为一致）
+func (pt *PaperTrader) Open(d *decision.Decision, side string, price float64, t time.Time) error {
+	key := d.Symbol + "_" + side
+	if _, exists := pt.positions[key]; exists {
+		return fmt.Errorf("%s 已有%s仓，拒绝重复开仓", d.Symbol, side)
+	}
+	if price <= 0 || d.PositionSizeUSD <= 0 || d.Leverage <= 0 {
+		return fmt.Errorf("%s 开仓参数无效（价格%.4f，仓位%.2f，杠杆%d）", d.Symbol, price, d.PositionSizeUSD, d.Leverage)
+	}
+
+	margin := d.PositionSizeUSD / float64(d.Leverage)
+	fee := d.PositionSizeUSD * pt.feeRate
+	if margin+fee > pt.availableBalance() {
+		return fmt.Errorf("%s 保证金不足（需要%.2f，可用%.2f）", d.Symbol, margin+fee, pt.availableBalance())
+	}
+
+	pt.balance -= fee
+	pt.positions[key] = &paperPosition{
+		Symbol:     d.Symbol,
+		Side:       side,
+		EntryPrice: price,
+		Quantity:   d.PositionSizeUSD / price,
+		Leverage:   d.Leverage,
+		Margin:     margin,
+		StopLoss:   d.StopLoss,
+		TakeProfit: d.TakeProfit,
+		OpenTime:   t,
+		EntryLogic: d.Reasoning,
+		ExitLogic:  d.ExitReasoning,
+		MarkPrice:  price,
+	}
+	return nil
+}
+
+// Close 按指定价格平仓（fraction为平仓比例0-1，1表示全部平仓），返回本次实现盈亏
+func (pt *PaperTrader) Close(symbol, side string, price, fraction float64, t time.Time, reason string, wasStopLoss bool) (float64, error) {
+	key := symbol + "_" + side
+	pos, exists := pt.positions[key]
+	if !exists {
+		return 0, fmt.Errorf("%s 没有%s仓", symbol, side)
+	}
+	if fraction <= 0 || fraction > 1 {
+		fraction = 1
+	}
+
+	quantity := pos.Quantity * fraction
+	margin := pos.Margin * fraction
+	pnl := positionPnL(side, pos.EntryPrice, price, quantity)
+	fee := quantity * price * pt.feeRate
+	pt.balance += pnl - fee
+
+	pnlPct := 0.0
+	if margin > 0 {
+		pnlPct = pnl / margin * 100
+	}
+
+	pt.trades = append(pt.trades, logger.TradeOutcome{
+		Symbol:        symbol,
+		Side:          side,
+		Quantity:      quantity,
+		Leverage:      pos.Leverage,
+		OpenPrice:     pos.EntryPrice,
+		ClosePrice:    price,
+		PositionValue: quantity * pos.EntryPrice,
+		MarginUsed:    margin,
+		PnL:           pnl,
+		PnLPct:        pnlPct,
+		Duration:      t.Sub(pos.OpenTime).String(),
+		OpenTime:      pos.OpenTime,
+		CloseTime:     t,
+		WasStopLoss:   wasStopLoss,
+		CloseReason:   reason,
+		EntryLogic:    pos.EntryLogic,
+		ExitLogic:     pos.ExitLogic,
+		CloseLogic:    reason,
+	})
+
+	if fraction >= 1 {
+		delete(pt.positions, key)
+	} else {
+		pos.Quantity -= quantity
+		pos.Margin -= margin
+	}
+	return pnl, nil
+}
+
+// UpdateStops 更新持仓的止损/止盈价格（≤0表示不修改）
+func (pt *PaperTrader) UpdateStops(symbol, side string, stopLoss, takeProfit float64) error {
+	pos, exists := pt.positions[symbol+"_"+side]
+	if !exists {
+		return fmt.Errorf("%s 没有%s仓", symbol, side)
+	}
+	if stopLoss > 0 {
+		pos.StopLoss = stopLoss
+	}
+	if takeProfit > 0 {
+		pos.TakeProfit = takeProfit
+	}
+	return nil
+}
+
+// ClearStops 清除持仓的止损止盈（对应实盘的cancel_orders）
+func (pt *PaperTrader) ClearStops(symbol, side string) {
+	if pos, exists := pt.positions[symbol+"_"+side]; exists {
+		pos.StopLoss = 0
+		pos.TakeProfit = 0
+	}
+}
+
+// CheckBrackets 用一根K线检查该币种持仓的止损/止盈是否触发，触发则按止损/止盈价平仓
+// 同一根K线内止损和止盈都触及时无法判断先后，保守地按止损处理
+func (pt *PaperTrader) CheckBrackets(symbol string, kline market.Kline, t time.Time) {
+	for _, side := range []string{"long", "short"} {
+		pos, exists := pt.positions[symbol+"_"+side]
+		if !exists {
+			continue
+		}
+		pos.MarkPrice = kline.Close
+
+		var slHit, tpHit bool
+		if side == "long" {
+			slHit = pos.StopLoss > 0 && kline.Low <= pos.StopLoss
+			tpHit = pos.TakeProfit > 0 && kline.High >= pos.TakeProfit
+		} else {
+			slHit = pos.StopLoss > 0 && kline.High >= pos.StopLoss
+			tpHit = pos.TakeProfit > 0 && kline.Low <= pos.TakeProfit
+		}
+
+		switch {
+		case slHit:
+			pt.Close(symbol, side, pos.StopLoss, 1, t, fmt.Sprintf("止损触发（%.4f）", pos.StopLoss), true)
+		case tpHit:
+			pt.Close(symbol, side, pos.TakeProfit, 1, t, fmt.Sprintf("止盈触发（%.4f）", pos.TakeProfit), false)
+		}
+	}
+}
+
+// HasPosition 是否持有该币种该方向的仓位
+func (pt *PaperTrader) HasPosition(symbol, side string) bool {
+	_, exists := pt.positions[symbol+"_"+side]
+	return exists
+}
+
+// Equity 账户净值（钱包余额 + 未实现盈亏，按最近一次标记价格计算）
+func (pt *PaperTrader) Equity() float64 {
+	equity := pt.balance
+	for _, pos := range pt.positions {
+		equity += positionPnL(pos.Side, pos.EntryPrice, pos.MarkPrice, pos.Quantity)
+	}
+	return equity
+}
+
+// availableBalance 可用余额（钱包余额 - 已用保证金）
+func (pt *PaperTrader) availableBalance() float64 {
+	available := pt.balance
+	for _, pos := range pt.positions {
+		available -= pos.Margin
+	}
+	return available
+}
+
+// AccountInfo 构建传给AI的账户信息
+func (pt *PaperTrader) AccountInfo(initialBalance float64) decision.AccountInfo {
+	equity := pt.Equity()
+
+	var marginUsed, netExposure, grossExposure float64
+	for _, pos := range pt.positions {
+		marginUsed += pos.Margin
+		notional := pos.Quantity * pos.MarkPrice
+		grossExposure += notional
+		if pos.Side == "long" {
+			netExposure += notional
+		} else {
+			netExposure -= notional
+		}
+	}
+
+	info := decision.AccountInfo{
+		TotalEquity:      equity,
+		AvailableBalance: pt.availableBalance(),
+		TotalPnL:         equity - initialBalance,
+		MarginUsed:       marginUsed,
+		PositionCount:    len(pt.positions),
+		NetExposureUSD:   netExposure,
+		GrossExposure:    grossExposure,
+	}
+	if initialBalance > 0 {
+		info.TotalPnLPct = info.TotalPnL / initialBalance * 100
+	}
+	if equity > 0 {
+		info.MarginUsedPct = marginUsed / equity * 100
+		info.NetExposurePct = netExposure / equity * 100
+		info.GrossExposurePct = grossExposure / equity * 100
+	}
+	return info
+}
+
+// PositionInfos 构建传给AI的持仓列表（按币种和方向排序，保证prompt稳定）
+func (pt *PaperTrader) PositionInfos() []decision.PositionInfo {
+	keys := make([]string, 0, len(pt.positions))
+	for key := range pt.positions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	infos := make([]decision.PositionInfo, 0, len(keys))
+	for _, key := range keys {
+		pos := pt.positions[key]
+		pnl := positionPnL(pos.Side, pos.EntryPrice, pos.MarkPrice, pos.Quantity)
+		pnlPct := 0.0
+		if pos.Margin > 0 {
+			pnlPct = pnl / pos.Margin * 100
+		}
+		infos = append(infos, decision.PositionInfo{
+			Symbol:           pos.Symbol,
+			Side:             pos.Side,
+			EntryPrice:       pos.EntryPrice,
+			MarkPrice:        pos.MarkPrice,
+			Quantity:         pos.Quantity,
+			Leverage:         pos.Leverage,
+			UnrealizedPnL:    pnl,
+			UnrealizedPnLPct: pnlPct,
+			MarginUsed:       pos.Margin,
+			UpdateTime:       pos.OpenTime.UnixMilli(),
+			StopLoss:         pos.StopLoss,
+			TakeProfit:       pos.TakeProfit,
+			EntryLogic:       &decision.EntryLogic{Reasoning: pos.EntryLogic, Timestamp: pos.OpenTime},
+			ExitLogic:        &decision.ExitLogic{Reasoning: pos.ExitLogic, Timestamp: pos.OpenTime},
+		})
+	}
+	return infos
+}
+
+// Trades 已平仓交易（旧→新）
+func (pt *PaperTrader) Trades() []logger.TradeOutcome {
+	return pt.trades
+}
+
+// positionPnL 计算持仓盈亏
+func positionPnL(side string, entryPrice, price, quantity float64) float64 {
+	if side == "long" {
+		return (price - entryPrice) * quantity
+	}
+	return (entryPrice - price) * quantity
+}
@@ -0,0 +1,331 @@
+package backtest
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"backend/pkg/config"
+	"backend/pkg/decision"
+	"backend/pkg/logger"
+	"backend/pkg/market"
+	"backend/pkg/mcp"
+	"backend/pkg/trader"
+)
+
+// 回测默认配置
+const (
+	defaultStepInterval = "15m"  // 每根K线调用一次决策引擎
+	defaultFeeRate      = 0.0004 // 单边手续费率（taker 0.04%）
+	warmupBars          = 1000   // 回放开始前额外加载的K线数量（与实盘每个时间框架拉取的数量一致）
+	stubWaitResponse    = `[{"symbol": "ALL", "action": "wait", "reasoning": "回测占位AI：不交易"}]`
+)
+
+// contextTimeframes 决策引擎构建Context时需要的时间框架（多时间框架分析 + 候选币种的3分钟数据）
+var contextTimeframes = []string{"1d", "4h", "1h", "15m", "3m"}
+
+// Runner 回测运行器
+// 一次性拉取回测区间的历史K线，然后逐根K线回放：用截至当前K线的数据构建decision.Context，
+// 调用GetFullDecision获取决策，再由PaperTrader模拟成交
+type Runner struct {
+	config    trader.AutoTraderConfig
+	symbols   []string
+	start     time.Time
+	end       time.Time
+	interval  string      // 回放步长（K线时间框架）
+	mcpClient *mcp.Client // AI客户端（可以是mcp.NewStub创建的离线客户端）
+	feeRate   float64
+}
+
+// Result 回测结果
+type Result struct {
+	Performance    *logger.PerformanceAnalysis `json:"performance"` // 胜率、盈亏比、夏普比率等（与实盘统计口径一致）
+	InitialBalance float64                     `json:"initial_balance"`
+	FinalEquity    float64                     `json:"final_equity"`
+	TotalReturnPct float64                     `json:"total_return_pct"`
+	MaxDrawdownPct float64                     `json:"max_drawdown_pct"` // 按每步净值计算的最大回撤
+	Steps          int                         `json:"steps"`            // 回放的K线数量（决策周期数）
+	EquityCurve    []trader.DrawdownPoint      `json:"equity_curve"`     // 每步的净值、峰值和回撤（与/api/drawdown口径一致）
+}
+
+// NewRunner 创建回测运行器（mcpClient为nil时使用始终返回wait的占位AI，用于验证数据和流程）
+func NewRunner(cfg trader.AutoTraderConfig, symbols []string, start, end time.Time, mcpClient *mcp.Client) *Runner {
+	if mcpClient == nil {
+		mcpClient = mcp.NewStub(func(systemPrompt, userPrompt string) (string, error) {
+			return stubWaitResponse, nil
+		})
+	}
+
+	normalized := make([]string, 0, len(symbols))
+	for _, symbol := range symbols {
+		normalized = append(normalized, market.Normalize(symbol))
+	}
+
+	return &Runner{
+		config:    cfg,
+		symbols:   normalized,
+		start:     start,
+		end:       end,
+		interval:  defaultStepInterval,
+		mcpClient: mcpClient,
+		feeRate:   defaultFeeRate,
+	}
+}
+
+// SetInterval 设置回放步长（如"15m"、"1h"）
+func (r *Runner) SetInterval(interval string) error {
+	if market.IntervalDuration(interval) == 0 {
+		return fmt.Errorf("不支持的回放步长: %s", interval)
+	}
+	r.interval = interval
+	return nil
+}
+
+// SetFeeRate 设置单边手续费率（如0.0004表示0.04%）
+func (r *Runner) SetFeeRate(feeRate float64) {
+	r.feeRate = feeRate
+}
+
+// Run 执行回测
+func (r *Runner) Run() (*Result, error) {
+	if len(r.symbols) == 0 {
+		return nil, fmt.Errorf("回测币种列表为空")
+	}
+	if !r.end.After(r.start) {
+		return nil, fmt.Errorf("回测结束时间必须晚于开始时间")
+	}
+	if r.config.InitialBalance <= 0 {
+		return nil, fmt.Errorf("初始金额必须大于0")
+	}
+	if r.config.MultiTimeframeConfig == nil {
+		return nil, fmt.Errorf("缺少多时间框架配置")
+	}
+
+	source, err := r.loadHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	// 回放时间不是真实时间，基于TTL的缓存会返回过期数据，回测中关闭
+	mtConfig := *r.config.MultiTimeframeConfig
+	mtConfig.EnableCache = false
+
+	paper := NewPaperTrader(r.config.InitialBalance, r.feeRate)
+	result := &Result{InitialBalance: r.config.InitialBalance}
+	equitySamples := []trader.EquitySample{{Timestamp: r.start, Equity: r.config.InitialBalance}}
+	step := market.IntervalDuration(r.interval)
+
+	log.Printf("🔁 开始回测: %v, %s ~ %s, 步长%s",
+		r.symbols, r.start.Format("2006-01-02 15:04"), r.end.Format("2006-01-02 15:04"), r.interval)
+
+	for now := r.start.Truncate(step).Add(step); !now.After(r.end); now = now.Add(step) {
+		source.SetTime(now)
+		result.Steps++
+
+		// 1. 用刚收盘的K线检查止损/止盈（在AI决策之前，与实盘挂单先于下一次决策成交一致）
+		for _, symbol := range r.symbols {
+			if kline, ok := source.LatestKline(symbol, r.interval); ok {
+				paper.CheckBrackets(symbol, kline, now)
+			}
+		}
+
+		// 2. 构建Context并获取决策
+		ctx := r.buildContext(source, paper, &mtConfig, now, result.Steps)
+		fullDecision, err := decision.GetFullDecision(ctx, r.mcpClient)
+		if err != nil {
+			log.Printf("⚠️  [%s] 获取决策失败: %v", now.Format("2006-01-02 15:04"), err)
+		} else {
+			// 先平仓后开仓，与实盘执行顺序一致
+			for _, d := range sortDecisionsForExecution(fullDecision.Decisions) {
+				if err := r.applyDecision(paper, source, &d, now); err != nil {
+					log.Printf("  ⚠️  [%s] %s %s 未执行: %v", now.Format("2006-01-02 15:04"), d.Symbol, d.Action, err)
+				}
+			}
+		}
+
+		// 3. 记录净值
+		equitySamples = append(equitySamples, trader.EquitySample{Timestamp: now, Equity: paper.Equity()})
+	}
+
+	// 回测结束时按最后价格平掉所有持仓，计入交易统计
+	for _, pos := range paper.PositionInfos() {
+		paper.Close(pos.Symbol, pos.Side, pos.MarkPrice, 1, r.end, "回测结束平仓", false)
+	}
+
+	result.FinalEquity = paper.Equity()
+	result.EquityCurve = trader.ComputeDrawdownCurve(equitySamples)
+	result.MaxDrawdownPct = trader.MaxDrawdownPct(result.EquityCurve)
+	result.TotalReturnPct = (result.FinalEquity - result.InitialBalance) / result.InitialBalance * 100
+	result.Performance = trader.SummarizeTradeOutcomes(paper.Trades())
+
+	log.Printf("🏁 回测完成: %d个周期, %d笔交易, 收益%.2f%%, 最大回撤%.2f%%, 胜率%.1f%%, 夏普%.2f",
+		result.Steps, result.Performance.TotalTrades, result.TotalReturnPct, result.MaxDrawdownPct,
+		result.Performance.WinRate, result.Performance.SharpeRatio)
+
+	return result, nil
+}
+
+// loadHistory 一次性拉取所有币种在各时间框架上的历史K线
+func (r *Runner) loadHistory() (*market.HistoricalSource, error) {
+	timeframes := contextTimeframes
+	if !containsString(timeframes, r.interval) {
+		timeframes = append(append([]string{}, timeframes...), r.interval)
+	}
+
+	source := market.NewHistoricalSource()
+	for _, symbol := range r.symbols {
+		for _, tf := range timeframes {
+			if err := source.Load(symbol, tf, r.start, r.end, warmupBars); err != nil {
+				return nil, fmt.Errorf("加载%s %s历史K线失败: %w", symbol, tf, err)
+			}
+			log.Printf("📥 已加载 %s %s 历史K线 %d根", symbol, tf, len(source.Klines(symbol, tf)))
+		}
+	}
+	return source, nil
+}
+
+// buildContext 用模拟账户和回放时间构建决策上下文
+func (r *Runner) buildContext(source market.Source, paper *PaperTrader, mtConfig *config.MultiTimeframeConfig, now time.Time, callCount int) *decision.Context {
+	candidates := make([]decision.CandidateCoin, 0, len(r.symbols))
+	for _, symbol := range r.symbols {
+		candidates = append(candidates, decision.CandidateCoin{Symbol: symbol, Sources: []string{"backtest"}})
+	}
+
+	return &decision.Context{
+		CurrentTime:             now.Format("2006-01-02 15:04:05"),
+		Now:                     now,
+		RuntimeMinutes:          int(now.Sub(r.start).Minutes()),
+		CallCount:               callCount,
+		BTCETHLeverage:          r.config.BTCETHLeverage,
+		AltcoinLeverage:         r.config.AltcoinLeverage,
+		SymbolLeverageOverrides: r.config.SymbolLeverageOverrides,
+		Account:                 paper.AccountInfo(r.config.InitialBalance),
+		Positions:               paper.PositionInfos(),
+		CandidateCoins:          candidates,
+		SkipLiquidityCheck:      true, // 历史数据没有持仓量，无法做流动性检查
+		AnalysisMode:            r.config.AnalysisMode,
+		MultiTimeframeConfig:    mtConfig,
+		StrategyName:            r.config.StrategyName,
+		IncludeDerivativesTrend: false, // 历史数据没有OI和资金费率
+		IncludeConcentration:    r.config.IncludeConcentration,
+		ConcentrationWarnHHI:    r.config.ConcentrationWarnHHI,
+		MinKlineBars:            r.config.MinKlineBars,
+		InsufficientHistoryMode: r.config.InsufficientHistoryMode,
+		ValidationMode:          r.config.ValidationMode,
+		RiskPerTradePct:         r.config.RiskPerTradePct,
+		MinRiskReward:           r.config.MinRiskReward,
+		MinStopATRMultiple:      r.config.MinStopATRMultiple,
+		MaxPositionNotionalPct:  r.config.MaxPositionNotionalPct,
+		MarketSource:            source,
+	}
+}
+
+// applyDecision 在模拟账户上执行一条决策（成交价为当前回放时间最后一根已收盘K线的收盘价）
+func (r *Runner) applyDecision(paper *PaperTrader, source *market.HistoricalSource, d *decision.Decision, now time.Time) error {
+	var price float64
+	if kline, ok := source.LatestKline(d.Symbol, r.interval); ok {
+		price = kline.Close
+	}
+
+	switch d.Action {
+	case "open_long", "open_short":
+		if price <= 0 {
+			return fmt.Errorf("没有可用的成交价格")
+		}
+		side := strings.TrimPrefix(d.Action, "open_")
+		if err := paper.Open(d, side, price, now); err != nil {
+			return err
+		}
+		log.Printf("  📝 [%s] 开%s仓 %s @ %.4f, 仓位%.2f USDT, %dx",
+			now.Format("2006-01-02 15:04"), side, d.Symbol, price, d.PositionSizeUSD, d.Leverage)
+
+	case "close_long", "close_short":
+		if price <= 0 {
+			return fmt.Errorf("没有可用的成交价格")
+		}
+		side := strings.TrimPrefix(d.Action, "close_")
+		fraction := 1.0
+		if d.ClosePercent > 0 && d.ClosePercent < 100 {
+			fraction = d.ClosePercent / 100
+		}
+		pnl, err := paper.Close(d.Symbol, side, price, fraction, now, d.Reasoning, false)
+		if err != nil {
+			return err
+		}
+		log.Printf("  📝 [%s] 平%s仓 %s @ %.4f (%.0f%%), 盈亏%+.2f USDT",
+			now.Format("2006-01-02 15:04"), side, d.Symbol, price, fraction*100, pnl)
+
+	case "update_sl", "update_tp":
+		stopLoss, takeProfit := d.StopLoss, d.TakeProfit
+		if d.Action == "update_sl" {
+			takeProfit = 0
+		} else {
+			stopLoss = 0
+		}
+		// 决策中没有方向，按持仓方向更新（对冲持仓时两个方向都更新）
+		updated := false
+		for _, side := range []string{"long", "short"} {
+			if paper.HasPosition(d.Symbol, side) {
+				paper.UpdateStops(d.Symbol, side, stopLoss, takeProfit)
+				updated = true
+			}
+		}
+		if !updated {
+			return fmt.Errorf("没有持仓")
+		}
+
+	case "update_sl_trailing":
+		return fmt.Errorf("回测暂不支持移动止损")
+
+	case "cancel_orders":
+		cancelled := false
+		for _, side := range []string{"long", "short"} {
+			if paper.HasPosition(d.Symbol, side) {
+				paper.ClearStops(d.Symbol, side)
+				cancelled = true
+			}
+		}
+		if !cancelled {
+			return fmt.Errorf("没有持仓")
+		}
+	}
+
+	return nil
+}
+
+// sortDecisionsForExecution 按执行优先级排序：先平仓，再调整止损止盈，最后开仓
+func sortDecisionsForExecution(decisions []decision.Decision) []decision.Decision {
+	priority := func(action string) int {
+		switch {
+		case strings.HasPrefix(action, "close_"):
+			return 0
+		case strings.HasPrefix(action, "update_"), action == "cancel_orders":
+			return 1
+		case strings.HasPrefix(action, "open_"):
+			return 2
+		default:
+			return 3
+		}
+	}
+
+	sorted := make([]decision.Decision, 0, len(decisions))
+	for p := 0; p <= 3; p++ {
+		for _, d := range decisions {
+			if priority(d.Action) == p {
+				sorted = append(sorted, d)
+			}
+		}
+	}
+	return sorted
+}
+
+// containsString 判断字符串切片是否包含指定值
+func containsString(values []string, target string) bool {
+	for _, v := range values {
+		if v == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,52 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"backend/pkg/market"
+	"backend/pkg/trader"
+)
+
+// stopLossSimInterval 止损回放使用的K线周期（实盘每10秒按标记价格检查，1分钟K线是能拉到的最细粒度）
+const stopLossSimInterval = "1m"
+
+// SimulateStopLoss 用历史K线回放单仓位强制止损（position_stop_loss_pct），检查在[from, to]期间的插针行情中是否会触发
+// 盈亏公式与实盘的单仓位止损检查一致，但按每根K线的极值计算（做多看最低价、做空看最高价）而不是实时标记价格，
+// 因此结果偏保守：K线内的瞬间插针在实盘中可能恰好落在两次检查之间而不会触发，且标记价格的插针通常比成交价小
+// 返回是否触发、触发的K线开盘时间、以及触发前（含触发K线）最差的盈亏百分比；未触发时为整个区间内最差的盈亏百分比
+func SimulateStopLoss(symbol string, side string, entryPrice float64, leverage int, from, to time.Time, stopPct float64) (triggered bool, triggerTime time.Time, worstPnLPct float64, err error) {
+	if side != "long" && side != "short" {
+		return false, time.Time{}, 0, fmt.Errorf("side必须是long或short: %s", side)
+	}
+	if entryPrice <= 0 || leverage <= 0 || stopPct <= 0 {
+		return false, time.Time{}, 0, fmt.Errorf("入场价、杠杆和止损百分比必须大于0")
+	}
+	if !to.After(from) {
+		return false, time.Time{}, 0, fmt.Errorf("结束时间必须晚于开始时间")
+	}
+
+	klines, err := market.FetchKlinesRange(symbol, stopLossSimInterval, from, to)
+	if err != nil {
+		return false, time.Time{}, 0, err
+	}
+	if len(klines) == 0 {
+		return false, time.Time{}, 0, fmt.Errorf("%s 在 %s ~ %s 没有K线数据", symbol, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	for i, k := range klines {
+		// 做多的最差价格是最低价，做空的最差价格是最高价
+		worstPrice := k.Low
+		if side == "short" {
+			worstPrice = k.High
+		}
+		pnlPct := trader.PositionPnLPct(side, entryPrice, worstPrice, leverage)
+		if i == 0 || pnlPct < worstPnLPct {
+			worstPnLPct = pnlPct
+		}
+		if -pnlPct >= stopPct {
+			return true, time.UnixMilli(k.OpenTime), worstPnLPct, nil
+		}
+	}
+	return false, time.Time{}, worstPnLPct, nil
+}
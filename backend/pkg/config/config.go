@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/pelletier/go-toml/v2"
@@ -16,13 +17,17 @@ type TraderConfig struct {
 	AIModel string `toml:"ai_model"` // "qwen" or "deepseek"
 
 	// 交易平台选择
-	Exchange string `toml:"exchange"` // "aster"
+	Exchange string `toml:"exchange"` // "aster" 或 "binance"
 
 	// Aster配置
 	AsterUser       string `toml:"aster_user,omitempty"`        // Aster主钱包地址
 	AsterSigner     string `toml:"aster_signer,omitempty"`      // Aster API钱包地址
 	AsterPrivateKey string `toml:"aster_private_key,omitempty"` // Aster API钱包私钥
 
+	// Binance配置
+	BinanceAPIKey    string `toml:"binance_api_key,omitempty"`    // Binance API Key
+	BinanceSecretKey string `toml:"binance_secret_key,omitempty"` // Binance Secret Key
+
 	// AI配置
 	QwenKey     string `toml:"qwen_key,omitempty"`
 	DeepSeekKey string `toml:"deepseek_key,omitempty"`
@@ -32,14 +37,102 @@ type TraderConfig struct {
 	CustomAPIKey    string `toml:"custom_api_key,omitempty"`
 	CustomModelName string `toml:"custom_model_name,omitempty"`
 
+	// AI请求配置（可选）
+	AITimeoutSeconds int  `toml:"ai_timeout_seconds,omitempty"` // 单次AI请求超时（秒，0使用默认300秒，每次重试单独计时）
+	AIMaxRetries     *int `toml:"ai_max_retries,omitempty"`     // 超时/网络错误/5xx时的重试次数（不设置时默认2次，0表示不重试）
+
+	// AI采样参数（可选，对本trader使用的所有模型生效）
+	AITemperature *float64 `toml:"ai_temperature,omitempty"` // 采样温度（0-2，不设置时默认0.5，越低决策越稳定可复现）
+	AITopP        float64  `toml:"ai_top_p,omitempty"`       // 核采样概率（0-1，0表示不设置，使用服务端默认值）
+	AIMaxTokens   int      `toml:"ai_max_tokens,omitempty"`  // 最大输出token数（0使用默认4000）
+	AIStream      bool     `toml:"ai_stream,omitempty"`      // 是否以SSE流式接收AI响应（默认false，解析结果与非流式一致）
+
+	// 多模型投票（可选）：每个模型使用上面配置的密钥，对同一上下文分别决策，开平仓决策需达到最少同意票数
+	EnsembleModels   []string `toml:"ensemble_models,omitempty"`    // 参与投票的模型（"deepseek"/"qwen"/"custom"，少于2个时使用单模型）
+	EnsembleMinAgree int      `toml:"ensemble_min_agree,omitempty"` // 开平仓决策至少需要的同意票数（0表示过半数）
+
 	InitialBalance      float64 `toml:"initial_balance"`
 	ScanIntervalMinutes int     `toml:"scan_interval_minutes"`
+
+	// 自动检测初始余额（可选）：未设置initial_balance时，首次启动以当前钱包余额作为初始余额并保存到数据库，重启后沿用
+	AutoDetectInitialBalance bool `toml:"auto_detect_initial_balance,omitempty"`
+
+	// 自适应扫描间隔（可选）：按参考币种的15分钟ATR%调整AI决策周期，波动大时缩短到最小间隔，波动小时延长到最大间隔
+	AdaptiveScanInterval   bool   `toml:"adaptive_scan_interval,omitempty"`
+	MinScanIntervalMinutes int    `toml:"min_scan_interval_minutes,omitempty"` // 高波动时的扫描间隔（分钟，默认1）
+	MaxScanIntervalMinutes int    `toml:"max_scan_interval_minutes,omitempty"` // 低波动时的扫描间隔（分钟，默认scan_interval_minutes的2倍，最多60）
+	ScanReferenceSymbol    string `toml:"scan_reference_symbol,omitempty"`     // 计算波动率的参考币种（默认BTCUSDT）
+
+	// 候选币种数量（可选，默认20，最多100）：每个周期从币种池取评分最高的前N个币种交给AI分析
+	// 数量越多，每个周期拉取的K线/OI数据和发送给AI的prompt越大（AI调用成本和耗时随之增加）；专注少数币种时可调小以节省成本
+	CandidatePoolSize int `toml:"candidate_pool_size,omitempty"`
+
+	// 限价开仓超时（可选，分钟，默认15）：AI使用order_type=limit开仓时，超过该时间未完全成交则撤单（已部分成交的数量保留为持仓）
+	LimitOrderTimeoutMinutes int `toml:"limit_order_timeout_minutes,omitempty"`
+
+	// 单仓位止损检查间隔（可选，秒，默认10，最小3）：每次检查都会查询交易所持仓（有持仓时还会查询余额），
+	// 按API限频额度调整，限频较紧的账户可以调大，波动剧烈时可以调小
+	StopLossCheckIntervalSeconds int `toml:"stop_loss_check_interval_seconds,omitempty"`
+
+	// 行情数据最大时长（可选，分钟，默认为2倍K线周期）：开仓前最新K线收盘时间早于该时长则跳过开仓（币种暂停交易或数据源卡住时避免按失效价格下单）
+	MaxDataAgeMinutes int `toml:"max_data_age_minutes,omitempty"`
+
+	// 资金费率捕获模式（可选，默认关闭）：将资金费率绝对值≥funding_capture_min_rate且2小时内结算的币种加入候选，
+	// 并在prompt中提示AI可在结算前开收取方向的仓位、结算后平仓
+	FundingCaptureMode    bool    `toml:"funding_capture_mode,omitempty"`
+	FundingCaptureMinRate float64 `toml:"funding_capture_min_rate,omitempty"` // 小数形式（默认0.0005，即0.05%）
+
+	// 币种黑白名单（可选）：黑名单中的币种不会出现在候选币种中也不会开仓；白名单非空时只交易白名单中的币种
+	// 已有持仓不受影响，仍由AI管理（平仓、调整止盈止损），只是平仓后不会再开仓
+	SymbolAllowlist []string `toml:"symbol_allowlist,omitempty"`
+	SymbolDenylist  []string `toml:"symbol_denylist,omitempty"`
+
+	// 相关性过滤（可选，0-1，默认0表示不检查）：待开仓币种与已有同方向持仓的1小时收益率相关系数超过该值时跳过开仓，
+	// 避免同时做多BTC、ETH和多个高度联动的山寨币，实际上是放大了同一笔BTC方向的押注
+	MaxCorrelation float64 `toml:"max_correlation,omitempty"`
+
+	// 单币种连续亏损冷却（可选，默认0表示不启用）：同一币种在窗口期内最近连续亏损达到该笔数时，暂停该币种开仓一段时间
+	// 只影响该币种（其他币种照常交易），比账户级的熔断和风控范围更小
+	SymbolLossStreak        int     `toml:"symbol_loss_streak,omitempty"`
+	SymbolLossWindowHours   float64 `toml:"symbol_loss_window_hours,omitempty"`   // 统计连续亏损的窗口（小时，默认24）
+	SymbolLossCooldownHours float64 `toml:"symbol_loss_cooldown_hours,omitempty"` // 从最后一笔亏损平仓开始的冷却时长（小时，默认12）
+
+	// 每日最多开仓次数（可选，默认0表示不限制）：与日盈亏同时重置，达到后当天不再开新仓（平仓不受影响），避免频繁开平仓累积手续费
+	MaxDailyTrades int `toml:"max_daily_trades,omitempty"`
+
+	// 会话边界平仓（可选，默认false）：启动时平掉已有持仓（从空仓开始，不接管没有持仓逻辑的仓位）；
+	// 停止时平掉所有持仓（收到退出信号优雅停止时执行）
+	FlattenOnStartup  bool `toml:"flatten_on_startup,omitempty"`
+	FlattenOnShutdown bool `toml:"flatten_on_shutdown,omitempty"`
+
+	// 交易手续费（可选，用于计算交易记录的净盈亏）
+	TakerFeeBps float64 `toml:"taker_fee_bps,omitempty"` // 吃单手续费（基点，如5表示0.05%），开仓和平仓各扣一次，0表示不扣除
+
+	// 决策记录Webhook（可选，每条决策记录以JSON POST到该地址）
+	WebhookURL string `toml:"webhook_url,omitempty"`
+
+	// 决策记录保留天数（可选，默认30天），每天清理一次更早的记录（始终保留最近的记录供表现分析使用）
+	DecisionRetentionDays int `toml:"decision_retention_days,omitempty"`
+
+	// 决策记录详细程度（可选，默认"full"）：长期运行时减少数据库写入
+	// "full"：保存所有记录；"summary"：只保存有操作（非hold/wait）或失败的周期；
+	// "minimal"：保存所有记录，但成功且无操作的周期不保存InputPrompt/CoTTrace
+	LogVerbosity string `toml:"log_verbosity,omitempty"`
+
+	// 熔断（可选）：连续多个周期失败（构建上下文或AI调用失败）后暂停交易一段时间，避免持续请求故障的接口
+	CircuitBreakerFailures       int `toml:"circuit_breaker_failures,omitempty"`        // 触发熔断的连续失败周期数（默认5）
+	CircuitBreakerBackoffMinutes int `toml:"circuit_breaker_backoff_minutes,omitempty"` // 熔断后暂停的分钟数（默认30）
+
+	// 停牌/下架处理（可选）：持仓币种的行情数据连续多个周期获取失败（且只有该币种失败）时告警，并按交易所持仓标记价格平仓
+	HaltedSymbolFailures int    `toml:"halted_symbol_failures,omitempty"` // 判定为停牌/下架的连续失败周期数（默认3）
+	HaltedSymbolAction   string `toml:"halted_symbol_action,omitempty"`   // "close"：告警并平仓（默认）；"alert"：只告警
 }
 
 // LeverageConfig 杠杆配置
 type LeverageConfig struct {
 	BTCETHLeverage  int `toml:"btc_eth_leverage"` // BTC和ETH的杠杆倍数（主账户建议5-50，子账户≤5）
 	AltcoinLeverage int `toml:"altcoin_leverage"` // 山寨币的杠杆倍数（主账户建议5-20，子账户≤5）
+	SymbolOverrides map[string]int `toml:"symbol_overrides"` // 单币种杠杆上限（如 DOGEUSDT = 3），优先于上面的分组杠杆
 }
 
 // AnalysisModeConfig 分析模式配置
@@ -72,12 +165,127 @@ type MultiTimeframeConfig struct {
 	
 	// 回调入场策略配置（"顺大逆小"策略）
 	PullbackEntry PullbackEntryConfig `toml:"pullback_entry"`
+
+	// MACD周期参数（默认12/26/9，所有时间框架共用）
+	MACD MACDParams `toml:"macd"`
+
+	// 评分权重：候选币排序中方向评分与一致性评分的占比，以及一致性评分中各维度的占比
+	ScoreWeights ScoreWeights `toml:"score_weights"`
+
+	// 每个时间框架获取的K线数量
+	CandleLimits MultiTimeframeCandleLimits `toml:"candle_limits"`
+}
+
+// K线数量范围（上限与交易所单次请求上限、回测预热K线数量一致）
+const (
+	DefaultCandleLimit = 1000
+	MaxCandleLimit     = 1000
+	minCandleLimit     = 100 // 指标成熟所需的最少K线数量（RSI/ATR等平滑指标的下限）
+)
+
+// MultiTimeframeCandleLimits 每个时间框架获取的K线数量（0表示使用默认值1000）
+type MultiTimeframeCandleLimits struct {
+	Daily    int `toml:"daily"`    // 日线K线数量（1000根约3年，可适当减少）
+	Hourly4  int `toml:"hourly4"`  // 4小时K线数量
+	Hourly1  int `toml:"hourly1"`  // 1小时K线数量
+	Minute15 int `toml:"minute15"` // 15分钟K线数量
+	Minute3  int `toml:"minute3"`  // 3分钟K线数量
+}
+
+// ForTimeframe 获取指定时间框架的K线数量（未配置时返回默认值）
+func (l MultiTimeframeCandleLimits) ForTimeframe(timeframe string) int {
+	var limit int
+	switch timeframe {
+	case "1d":
+		limit = l.Daily
+	case "4h":
+		limit = l.Hourly4
+	case "1h":
+		limit = l.Hourly1
+	case "15m":
+		limit = l.Minute15
+	case "3m":
+		limit = l.Minute3
+	}
+	if limit <= 0 {
+		return DefaultCandleLimit
+	}
+	return limit
+}
+
+// validate 验证K线数量足够计算成熟的MACD/RSI指标（MACD的EMA需要约3倍慢线周期收敛，再加信号线周期）
+func (l MultiTimeframeCandleLimits) validate(macd MACDParams) error {
+	minLimit := 3*macd.Slow + macd.Signal
+	if minLimit < minCandleLimit {
+		minLimit = minCandleLimit
+	}
+	for _, tf := range []struct {
+		name  string
+		limit int
+	}{
+		{"daily", l.Daily}, {"hourly4", l.Hourly4}, {"hourly1", l.Hourly1}, {"minute15", l.Minute15}, {"minute3", l.Minute3},
+	} {
+		if tf.limit == 0 {
+			continue
+		}
+		if tf.limit < minLimit || tf.limit > MaxCandleLimit {
+			return fmt.Errorf("multi_timeframe.candle_limits.%s必须在%d-%d之间（至少%d根才能保证MACD(%d/%d/%d)和RSI指标成熟），当前: %d",
+				tf.name, minLimit, MaxCandleLimit, minLimit, macd.Fast, macd.Slow, macd.Signal, tf.limit)
+		}
+	}
+	return nil
+}
+
+// ScoreWeights 多时间框架评分权重（total+consistency、trend+momentum+volatility两组各自总和应为1.0）
+type ScoreWeights struct {
+	Total       float64 `toml:"total"`       // 排序时方向总评分权重（默认0.7）
+	Consistency float64 `toml:"consistency"` // 排序时多时间框架一致性评分权重（默认0.3）
+	Trend       float64 `toml:"trend"`       // 一致性评分中趋势（EMA方向）一致性权重（默认0.35）
+	Momentum    float64 `toml:"momentum"`    // 一致性评分中动量（MACD方向）一致性权重（默认0.45）
+	Volatility  float64 `toml:"volatility"`  // 一致性评分中波动（RSI位置）一致性权重（默认0.2）
+}
+
+// WithDefaults 返回填充默认值后的权重（每组权重全部为0表示未配置，使用默认值）
+func (w ScoreWeights) WithDefaults() ScoreWeights {
+	if w.Total == 0 && w.Consistency == 0 {
+		w.Total = 0.7
+		w.Consistency = 0.3
+	}
+	if w.Trend == 0 && w.Momentum == 0 && w.Volatility == 0 {
+		w.Trend = 0.35
+		w.Momentum = 0.45
+		w.Volatility = 0.2
+	}
+	return w
+}
+
+// validate 验证权重非负且每组总和约为1.0
+func (w ScoreWeights) validate() error {
+	if w.Total < 0 || w.Consistency < 0 || w.Trend < 0 || w.Momentum < 0 || w.Volatility < 0 {
+		return fmt.Errorf("multi_timeframe.score_weights权重不能为负数")
+	}
+	if sum := w.Total + w.Consistency; sum < 0.99 || sum > 1.01 {
+		return fmt.Errorf("multi_timeframe.score_weights的total+consistency总和应为1.0，当前: %.2f", sum)
+	}
+	if sum := w.Trend + w.Momentum + w.Volatility; sum < 0.99 || sum > 1.01 {
+		return fmt.Errorf("multi_timeframe.score_weights的trend+momentum+volatility总和应为1.0，当前: %.2f", sum)
+	}
+	return nil
+}
+
+// MACDParams MACD周期参数（0表示使用默认值）
+type MACDParams struct {
+	Fast   int `toml:"fast"`   // 快线EMA周期（默认12）
+	Slow   int `toml:"slow"`   // 慢线EMA周期（默认26）
+	Signal int `toml:"signal"` // 信号线周期（默认9）
 }
 
 // PullbackEntryConfig 回调入场策略配置
 type PullbackEntryConfig struct {
 	Enable     bool    `toml:"enable"`      // 是否启用回调入场策略（默认true）
 	BonusScore float64 `toml:"bonus_score"` // 回调入场加分（默认0.15，范围0-0.3）
+	RequireStochRSICross bool `toml:"require_stoch_rsi_cross"` // 反转信号是否要求1h/15m的StochRSI从超卖区金叉（做空为超买区死叉），默认false
+	RequireEMA200Trend   bool `toml:"require_ema200_trend"`    // 大周期做多趋势是否要求价格在日线EMA200上方（做空要求在下方），日线不足200根时不检查，默认false
 }
 
 // MultiTimeframeCacheTTL 多时间框架缓存TTL配置
@@ -97,13 +305,29 @@ type Config struct {
 	APIServerPort      int                 `toml:"api_server_port"`
 	MaxDailyLoss        float64             `toml:"max_daily_loss"`          // 最大日亏损百分比（账户级别风控）
 	MaxDrawdown         float64             `toml:"max_drawdown"`            // 最大回撤百分比（账户级别风控）
+	DeRiskDrawdown      float64             `toml:"de_risk_drawdown"`        // 回撤减仓百分比（低于max_drawdown，超过时平掉表现最差的一半持仓，不暂停交易；0表示不启用）
 	StopTradingMinutes  int                 `toml:"stop_trading_minutes"`    // 触发风控后暂停时长（分钟）
 	PositionStopLossPct float64             `toml:"position_stop_loss_pct"` // 单仓位止损百分比（默认10%）
 	PositionTakeProfitPct float64           `toml:"position_take_profit_pct"` // 单仓位止盈百分比（可选，>0时强制止盈，≤0时由AI自行判断）
+	PositionMaxLossUSD  float64             `toml:"position_max_loss_usd"`   // 单仓位最大亏损金额（USDT，未实现亏损超过时强制平仓，与百分比止损同时生效；0表示不启用）
+	MaxOpenPositions    int                 `toml:"max_open_positions"`      // 最大同时持仓数量（0表示不限制）
+	ReentryCooldownMinutes int              `toml:"reentry_cooldown_minutes"` // 平仓后再入场冷却时间（分钟，0表示不限制）
+	MaxHoldingHours     float64             `toml:"max_holding_hours"`       // 最长持仓时间（小时，超过后强制平仓，0表示不限制）
+	MaxFundingRate      float64             `toml:"max_funding_rate"`        // 开仓方向需支付的最大资金费率（小数，0表示不限制）
+	RiskPerTradePct     float64             `toml:"risk_per_trade_pct"`      // 单笔交易风险预算（占账户净值百分比，按入场价到止损的距离限制仓位，0表示不限制）
 	Leverage            LeverageConfig      `toml:"leverage"`                // 杠杆配置
 	SkipLiquidityCheck bool                `toml:"skip_liquidity_check"`    // 是否跳过流动性检查（默认false，开启后可以交易流动性差的币种）
+	MinOpenInterestValueUSD float64        `toml:"min_open_interest_value_usd"` // 流动性检查的最低持仓价值（USD），默认15000000
 	AnalysisMode       AnalysisModeConfig  `toml:"analysis_mode"`           // 分析模式配置
 	Strategy           StrategyConfig      `toml:"strategy"`                // 交易策略配置
+	OpenConfirmation   OpenConfirmationConfig `toml:"open_confirmation"`    // 开仓确认延迟配置
+	Prompt             PromptConfig        `toml:"prompt"`                  // Prompt内容配置
+	RunLimit           RunLimitConfig      `toml:"run_limit"`               // 运行上限配置（用于有限时长的实盘测试）
+	InsufficientHistory InsufficientHistoryConfig `toml:"insufficient_history"` // K线历史不足的币种处理配置
+	Validation         ValidationConfig    `toml:"validation"`              // AI决策验证配置
+	MarketData         MarketDataConfig    `toml:"market_data"`             // 市场数据请求配置
+	Telegram           TelegramConfig      `toml:"telegram"`                // Telegram通知配置（可选）
+	Logging            LoggingConfig       `toml:"logging"`                 // 日志格式配置（可选）
 	
 	// API服务器配置
 	APIServerConfig   APIServerConfig    `toml:"api_server_config"`       // API服务器配置
@@ -114,11 +338,71 @@ type StrategyConfig struct {
 	Name string `toml:"name"` // 策略名称（对应strategies文件夹下的文件名，不含.txt扩展名）
 }
 
+// OpenConfirmationConfig 开仓确认延迟配置
+// 启用后，AI新提出的开仓不会立即执行，需要在下一个周期再次提出（相同币种+方向）才会执行
+type OpenConfirmationConfig struct {
+	Enable        bool `toml:"enable"`         // 是否启用开仓确认（默认false）
+	WindowMinutes int  `toml:"window_minutes"` // 确认窗口（分钟），超过此时间未确认则作废（0表示使用2倍扫描间隔）
+}
+
+// PromptConfig Prompt内容配置（控制发送给AI的可选数据）
+type PromptConfig struct {
+	IncludeDerivativesTrend bool    `toml:"include_derivatives_trend"` // 是否在prompt中包含OI和资金费率的短期趋势（默认false）
+	IncludeConcentration    bool    `toml:"include_concentration"`     // 是否在prompt中包含持仓集中度（默认false）
+	ConcentrationWarnHHI    float64 `toml:"concentration_warn_hhi"`    // 集中度（HHI）超过此值时提示AI分散或减仓（默认0.6，范围0-1）
+}
+
+// RunLimitConfig 运行上限配置
+// 达到周期数或运行时长上限后自动停止trader（0表示不限制）
+type RunLimitConfig struct {
+	MaxCycles         int  `toml:"max_cycles"`          // 最大AI决策周期数（0表示不限制）
+	MaxRuntimeMinutes int  `toml:"max_runtime_minutes"` // 最大运行时长（分钟，0表示不限制）
+	FlattenOnStop     bool `toml:"flatten_on_stop"`     // 达到上限停止时是否平掉所有持仓（默认false）
+}
+
+// InsufficientHistoryConfig K线历史不足的币种处理配置
+// 新上市币种K线数量不足时，MACD/EMA等指标无法计算（会被置为0），容易误导AI
+type InsufficientHistoryConfig struct {
+	MinBars int    `toml:"min_bars"` // 每个时间框架所需的最少K线数量（默认35，MACD需要35根）
+	Mode    string `toml:"mode"`     // 处理方式："flag"（在prompt中标注指标不可靠）或 "exclude"（从候选币种中排除），默认"flag"
+}
+
+// ValidationConfig AI决策验证配置
+type ValidationConfig struct {
+	Mode                   string  `toml:"mode"`                      // 验证模式："strict"（任一决策无效则整批拒绝）或 "lenient"（只丢弃无效决策，执行其余有效决策），默认"strict"
+	MinRiskReward          float64 `toml:"min_risk_reward"`           // 开仓最低风险回报比（如2表示止盈距离至少是止损距离的2倍），0表示不检查
+	MinStopATRMultiple     float64 `toml:"min_stop_atr_multiple"`     // 开仓止损距入场价的最小ATR倍数，0表示不检查（默认）
+	MaxPositionNotionalPct float64 `toml:"max_position_notional_pct"` // 单笔开仓仓位价值上限（占账户净值百分比，与杠杆无关），0表示不限制
+}
+
+// MarketDataConfig 市场数据请求配置
+type MarketDataConfig struct {
+	HTTPTimeoutSeconds int `toml:"http_timeout_seconds"` // K线/OI/资金费率请求超时（秒），默认10
+	MaxConcurrency     int `toml:"max_concurrency"`      // 最大并发请求数（避免触发交易所限流），默认10
+}
+
+// LoggingConfig 日志格式配置
+// 控制台始终输出人类可读的日志；format为"json"时，开仓、平仓、强制平仓和风控触发等关键事件另以JSON行输出，便于日志系统采集
+type LoggingConfig struct {
+	Format   string `toml:"format"`    // "text"（默认，只输出控制台日志）或 "json"
+	JSONFile string `toml:"json_file"` // JSON日志文件路径（为空时输出到标准输出，控制台日志输出到标准错误）
+}
+
+// TelegramConfig Telegram通知配置
+// bot_token和chat_id都配置后启用，开仓、平仓、强制平仓和账户风控触发时推送消息
+type TelegramConfig struct {
+	BotToken           string `toml:"bot_token"`             // Telegram Bot Token（通过@BotFather创建）
+	ChatID             string `toml:"chat_id"`               // 接收通知的Chat ID
+	DailyReport        bool   `toml:"daily_report"`          // 是否每天推送前一天（UTC日期）的交易汇总
+	DailyReportHourUTC int    `toml:"daily_report_hour_utc"` // 推送每日汇总的UTC整点（0-23，默认0）
+}
+
 // APIServerConfig API服务器配置
 type APIServerConfig struct {
 	AllowedOrigins []string `toml:"allowed_origins"` // 允许的CORS来源（空数组表示允许所有来源，生产环境应配置具体域名）
 	EnableRateLimit bool    `toml:"enable_rate_limit"` // 是否启用API请求限流（默认true）
 	RateLimitRPS    int     `toml:"rate_limit_rps"`    // 每个IP每秒允许的请求数（默认100）
+	AdminSecret     string  `toml:"admin_secret"`      // 管理接口共享密钥（请求头X-Admin-Secret，为空时禁用紧急平仓等管理接口）
 }
 
 // LoadConfig 从TOML文件加载配置
@@ -195,6 +479,7 @@ func (c *Config) Validate() error {
 	}
 
 	traderIDs := make(map[string]bool)
+	firstExchange := ""
 	for i, trader := range c.Traders {
 		if trader.ID == "" {
 			return fmt.Errorf("trader[%d]: ID不能为空", i)
@@ -210,18 +495,101 @@ func (c *Config) Validate() error {
 		if trader.AIModel != "qwen" && trader.AIModel != "deepseek" && trader.AIModel != "custom" {
 			return fmt.Errorf("trader[%d]: ai_model必须是 'qwen', 'deepseek' 或 'custom'", i)
 		}
+		if trader.AITimeoutSeconds < 0 || trader.AITimeoutSeconds > 1800 {
+			return fmt.Errorf("trader[%d]: ai_timeout_seconds必须在0-1800之间（0表示使用默认值300秒）", i)
+		}
+		if trader.AIMaxRetries != nil && (*trader.AIMaxRetries < 0 || *trader.AIMaxRetries > 10) {
+			return fmt.Errorf("trader[%d]: ai_max_retries必须在0-10之间", i)
+		}
+		if trader.AITemperature != nil && (*trader.AITemperature < 0 || *trader.AITemperature > 2) {
+			return fmt.Errorf("trader[%d]: ai_temperature必须在0-2之间", i)
+		}
+		if trader.AITopP < 0 || trader.AITopP > 1 {
+			return fmt.Errorf("trader[%d]: ai_top_p必须在0-1之间（0表示不设置）", i)
+		}
+		if trader.AIMaxTokens < 0 || trader.AIMaxTokens > 65536 {
+			return fmt.Errorf("trader[%d]: ai_max_tokens必须在0-65536之间（0表示使用默认值4000）", i)
+		}
+		if trader.TakerFeeBps < 0 || trader.TakerFeeBps > 100 {
+			return fmt.Errorf("trader[%d]: taker_fee_bps必须在0-100之间（基点，如5表示0.05%%）", i)
+		}
+		if trader.DecisionRetentionDays < 0 {
+			return fmt.Errorf("trader[%d]: decision_retention_days不能为负数（0表示使用默认值30天）", i)
+		}
+		if trader.LogVerbosity != "" && trader.LogVerbosity != "full" && trader.LogVerbosity != "summary" && trader.LogVerbosity != "minimal" {
+			return fmt.Errorf("trader[%d]: log_verbosity必须是full、summary或minimal", i)
+		}
+		if trader.CandidatePoolSize < 0 || trader.CandidatePoolSize > MaxCandidatePoolSize {
+			return fmt.Errorf("trader[%d]: candidate_pool_size必须在1-%d之间（0表示使用默认值20）", i, MaxCandidatePoolSize)
+		}
+		if trader.LimitOrderTimeoutMinutes < 0 || trader.LimitOrderTimeoutMinutes > 1440 {
+			return fmt.Errorf("trader[%d]: limit_order_timeout_minutes必须在1-1440之间（0表示使用默认值15分钟）", i)
+		}
+		if trader.StopLossCheckIntervalSeconds != 0 && (trader.StopLossCheckIntervalSeconds < 3 || trader.StopLossCheckIntervalSeconds > 300) {
+			return fmt.Errorf("trader[%d]: stop_loss_check_interval_seconds必须在3-300之间（0表示使用默认值10秒）", i)
+		}
+		if trader.FundingCaptureMinRate < 0 || trader.FundingCaptureMinRate >= 0.05 {
+			return fmt.Errorf("trader[%d]: funding_capture_min_rate必须在0-0.05之间（小数形式，0表示使用默认值0.0005）", i)
+		}
+		if trader.MaxCorrelation < 0 || trader.MaxCorrelation > 1 {
+			return fmt.Errorf("trader[%d]: max_correlation必须在0-1之间（0表示不检查）", i)
+		}
+		if trader.MaxDailyTrades < 0 {
+			return fmt.Errorf("trader[%d]: max_daily_trades不能为负数（0表示不限制）", i)
+		}
+		if trader.SymbolLossStreak < 0 || trader.SymbolLossWindowHours < 0 || trader.SymbolLossCooldownHours < 0 {
+			return fmt.Errorf("trader[%d]: symbol_loss_streak、symbol_loss_window_hours和symbol_loss_cooldown_hours不能为负数（0表示不启用或使用默认值）", i)
+		}
+		if trader.MaxDataAgeMinutes < 0 {
+			return fmt.Errorf("trader[%d]: max_data_age_minutes不能为负数（0表示使用默认值：2倍K线周期）", i)
+		}
+		if trader.CircuitBreakerFailures < 0 || trader.CircuitBreakerBackoffMinutes < 0 {
+			return fmt.Errorf("trader[%d]: circuit_breaker_failures和circuit_breaker_backoff_minutes不能为负数（0表示使用默认值）", i)
+		}
+		if trader.HaltedSymbolFailures < 0 {
+			return fmt.Errorf("trader[%d]: halted_symbol_failures不能为负数（0表示使用默认值3）", i)
+		}
+		if trader.HaltedSymbolAction != "" && trader.HaltedSymbolAction != "close" && trader.HaltedSymbolAction != "alert" {
+			return fmt.Errorf("trader[%d]: halted_symbol_action必须是close或alert", i)
+		}
+		ensembleModels := make(map[string]bool)
+		for _, model := range trader.EnsembleModels {
+			if model != "qwen" && model != "deepseek" && model != "custom" {
+				return fmt.Errorf("trader[%d]: ensemble_models中的模型必须是 'qwen', 'deepseek' 或 'custom'", i)
+			}
+			if ensembleModels[model] {
+				return fmt.Errorf("trader[%d]: ensemble_models中的模型 '%s' 重复", i, model)
+			}
+			ensembleModels[model] = true
+		}
+		if trader.EnsembleMinAgree < 0 || (len(trader.EnsembleModels) > 1 && trader.EnsembleMinAgree > len(trader.EnsembleModels)) {
+			return fmt.Errorf("trader[%d]: ensemble_min_agree必须在0-%d之间（0表示过半数）", i, len(trader.EnsembleModels))
+		}
 
 		// 验证交易平台配置
 		if trader.Exchange == "" {
 			trader.Exchange = "aster" // 默认使用Aster
 		}
-		if trader.Exchange != "aster" {
-			return fmt.Errorf("trader[%d]: exchange必须是 'aster'", i)
+		switch trader.Exchange {
+		case "aster":
+			// 验证Aster配置
+			if trader.AsterUser == "" || trader.AsterSigner == "" || trader.AsterPrivateKey == "" {
+				return fmt.Errorf("trader[%d]: 使用Aster时必须配置aster_user, aster_signer和aster_private_key", i)
+			}
+		case "binance":
+			// 验证Binance配置
+			if trader.BinanceAPIKey == "" || trader.BinanceSecretKey == "" {
+				return fmt.Errorf("trader[%d]: 使用Binance时必须配置binance_api_key和binance_secret_key", i)
+			}
+		default:
+			return fmt.Errorf("trader[%d]: exchange必须是 'aster' 或 'binance'", i)
 		}
 
-		// 验证Aster配置
-		if trader.AsterUser == "" || trader.AsterSigner == "" || trader.AsterPrivateKey == "" {
-			return fmt.Errorf("trader[%d]: 使用Aster时必须配置aster_user, aster_signer和aster_private_key", i)
+		// 市场数据API是全局的，所有trader必须使用同一交易所
+		if firstExchange == "" {
+			firstExchange = trader.Exchange
+		} else if trader.Exchange != firstExchange {
+			return fmt.Errorf("trader[%d]: 所有trader必须使用同一交易所（市场数据API为全局设置），当前为 '%s'，之前为 '%s'", i, trader.Exchange, firstExchange)
 		}
 
 		// 验证扫描间隔
@@ -234,10 +602,21 @@ func (c *Config) Validate() error {
 		if trader.ScanIntervalMinutes > 60 {
 			return fmt.Errorf("trader[%d]: scan_interval_minutes不应超过60分钟", i)
 		}
+		if trader.MinScanIntervalMinutes < 0 || trader.MinScanIntervalMinutes > 60 || trader.MaxScanIntervalMinutes < 0 || trader.MaxScanIntervalMinutes > 60 {
+			return fmt.Errorf("trader[%d]: min_scan_interval_minutes和max_scan_interval_minutes必须在1-60分钟之间（0表示使用默认值）", i)
+		}
+		if trader.AdaptiveScanInterval && trader.GetMinScanInterval() > trader.GetMaxScanInterval() {
+			return fmt.Errorf("trader[%d]: min_scan_interval_minutes（%d）不能大于max_scan_interval_minutes（%d）", i,
+				int(trader.GetMinScanInterval().Minutes()), int(trader.GetMaxScanInterval().Minutes()))
+		}
 
 		// 验证初始余额
-		if trader.InitialBalance <= 0 {
-			return fmt.Errorf("trader[%d]: initial_balance必须大于0", i)
+		if trader.InitialBalance < 0 || (trader.InitialBalance == 0 && !trader.AutoDetectInitialBalance) {
+			return fmt.Errorf("trader[%d]: initial_balance必须大于0（或设置auto_detect_initial_balance = true自动检测）", i)
+		}
+
+		if trader.WebhookURL != "" && !strings.HasPrefix(trader.WebhookURL, "http://") && !strings.HasPrefix(trader.WebhookURL, "https://") {
+			return fmt.Errorf("trader[%d]: webhook_url必须以http://或https://开头", i)
 		}
 
 		if trader.AIModel == "qwen" && trader.QwenKey == "" {
@@ -277,6 +656,17 @@ func (c *Config) Validate() error {
 	if c.Leverage.AltcoinLeverage > 125 {
 		return fmt.Errorf("leverage.altcoin_leverage不应超过125（交易所上限）")
 	}
+	if len(c.Leverage.SymbolOverrides) > 0 {
+		// 币种名统一为大写，与交易所返回的symbol一致
+		overrides := make(map[string]int, len(c.Leverage.SymbolOverrides))
+		for symbol, leverage := range c.Leverage.SymbolOverrides {
+			if leverage <= 0 || leverage > 125 {
+				return fmt.Errorf("leverage.symbol_overrides.%s必须在1-125之间", symbol)
+			}
+			overrides[strings.ToUpper(strings.TrimSpace(symbol))] = leverage
+		}
+		c.Leverage.SymbolOverrides = overrides
+	}
 
 	// 验证风险控制参数
 	if c.MaxDailyLoss < 0 || c.MaxDailyLoss > 100 {
@@ -285,12 +675,51 @@ func (c *Config) Validate() error {
 	if c.MaxDrawdown < 0 || c.MaxDrawdown > 100 {
 		return fmt.Errorf("max_drawdown必须在0-100之间（百分比）")
 	}
+	if c.DeRiskDrawdown < 0 || c.DeRiskDrawdown > 100 {
+		return fmt.Errorf("de_risk_drawdown必须在0-100之间（百分比，0表示不启用）")
+	}
+	if c.DeRiskDrawdown > 0 && c.MaxDrawdown > 0 && c.DeRiskDrawdown >= c.MaxDrawdown {
+		return fmt.Errorf("de_risk_drawdown（%.2f）必须小于max_drawdown（%.2f）", c.DeRiskDrawdown, c.MaxDrawdown)
+	}
 	if c.PositionStopLossPct < 0 || c.PositionStopLossPct > 100 {
 		return fmt.Errorf("position_stop_loss_pct必须在0-100之间（百分比）")
 	}
+	if c.PositionMaxLossUSD < 0 {
+		return fmt.Errorf("position_max_loss_usd不能为负数（0表示不启用）")
+	}
 	if c.StopTradingMinutes < 0 {
 		return fmt.Errorf("stop_trading_minutes不能为负数")
 	}
+	if c.MaxOpenPositions < 0 {
+		return fmt.Errorf("max_open_positions不能为负数（0表示不限制）")
+	}
+	if c.ReentryCooldownMinutes < 0 {
+		return fmt.Errorf("reentry_cooldown_minutes不能为负数（0表示不限制）")
+	}
+	if c.MaxHoldingHours < 0 {
+		return fmt.Errorf("max_holding_hours不能为负数（0表示不限制）")
+	}
+	if c.MaxFundingRate < 0 || c.MaxFundingRate >= 0.01 {
+		return fmt.Errorf("max_funding_rate必须在0-0.01之间（小数形式，如0.0005表示0.05%%，0表示不限制）")
+	}
+	if c.RiskPerTradePct < 0 || c.RiskPerTradePct > 10 {
+		return fmt.Errorf("risk_per_trade_pct必须在0-10之间（占账户净值百分比，如1表示每笔最多亏损净值的1%%，0表示不限制）")
+	}
+	if c.MinOpenInterestValueUSD < 0 {
+		return fmt.Errorf("min_open_interest_value_usd不能为负数")
+	}
+	if c.MinOpenInterestValueUSD == 0 {
+		c.MinOpenInterestValueUSD = 15_000_000 // 默认15M USD
+	}
+	if c.RunLimit.MaxCycles < 0 {
+		return fmt.Errorf("run_limit.max_cycles不能为负数")
+	}
+	if c.RunLimit.MaxRuntimeMinutes < 0 {
+		return fmt.Errorf("run_limit.max_runtime_minutes不能为负数")
+	}
+	if c.OpenConfirmation.WindowMinutes < 0 {
+		return fmt.Errorf("open_confirmation.window_minutes不能为负数")
+	}
 
 	// 验证API服务器配置
 	if c.APIServerPort <= 0 || c.APIServerPort > 65535 {
@@ -312,6 +741,68 @@ func (c *Config) Validate() error {
 		fmt.Printf("⚠️  警告: 山寨币杠杆设置为%dx，如果使用子账户可能会失败（子账户限制≤5x）\n", c.Leverage.AltcoinLeverage)
 	}
 
+	// 设置Prompt内容默认值
+	if c.Prompt.ConcentrationWarnHHI < 0 || c.Prompt.ConcentrationWarnHHI > 1 {
+		return fmt.Errorf("prompt.concentration_warn_hhi必须在0-1之间")
+	}
+	if c.Prompt.ConcentrationWarnHHI == 0 {
+		c.Prompt.ConcentrationWarnHHI = 0.6 // 默认0.6（2个等权持仓的HHI为0.5）
+	}
+
+	// 设置K线历史不足处理默认值
+	if c.InsufficientHistory.MinBars < 0 {
+		return fmt.Errorf("insufficient_history.min_bars不能为负数")
+	}
+	if c.InsufficientHistory.MinBars == 0 {
+		c.InsufficientHistory.MinBars = 35 // 默认35根（MACD需要35根K线）
+	}
+	if c.InsufficientHistory.Mode == "" {
+		c.InsufficientHistory.Mode = "flag"
+	}
+	if c.InsufficientHistory.Mode != "flag" && c.InsufficientHistory.Mode != "exclude" {
+		return fmt.Errorf("insufficient_history.mode必须是 'flag' 或 'exclude'")
+	}
+
+	// 设置决策验证模式默认值
+	if c.Validation.Mode == "" {
+		c.Validation.Mode = "strict"
+	}
+	if c.Validation.Mode != "strict" && c.Validation.Mode != "lenient" {
+		return fmt.Errorf("validation.mode必须是 'strict' 或 'lenient'")
+	}
+	if c.Validation.MinRiskReward < 0 || c.Validation.MinRiskReward > 20 {
+		return fmt.Errorf("validation.min_risk_reward必须在0-20之间（0表示不检查）")
+	}
+	if c.Validation.MinStopATRMultiple < 0 || c.Validation.MinStopATRMultiple > 10 {
+		return fmt.Errorf("validation.min_stop_atr_multiple必须在0-10之间（0表示不检查）")
+	}
+	if c.Validation.MaxPositionNotionalPct < 0 || c.Validation.MaxPositionNotionalPct > 1000 {
+		return fmt.Errorf("validation.max_position_notional_pct必须在0-1000之间（0表示不限制）")
+	}
+
+	// 设置市场数据请求超时默认值
+	if c.MarketData.HTTPTimeoutSeconds == 0 {
+		c.MarketData.HTTPTimeoutSeconds = 10
+	}
+	if c.MarketData.HTTPTimeoutSeconds < 1 || c.MarketData.HTTPTimeoutSeconds > 120 {
+		return fmt.Errorf("market_data.http_timeout_seconds必须在1-120之间")
+	}
+	if c.MarketData.MaxConcurrency == 0 {
+		c.MarketData.MaxConcurrency = 10
+	}
+	if c.MarketData.MaxConcurrency < 1 || c.MarketData.MaxConcurrency > 100 {
+		return fmt.Errorf("market_data.max_concurrency必须在1-100之间")
+	}
+	if (c.Telegram.BotToken == "") != (c.Telegram.ChatID == "") {
+		return fmt.Errorf("telegram.bot_token和telegram.chat_id必须同时配置（都留空表示不启用通知）")
+	}
+	if c.Telegram.DailyReportHourUTC < 0 || c.Telegram.DailyReportHourUTC > 23 {
+		return fmt.Errorf("telegram.daily_report_hour_utc必须在0-23之间")
+	}
+	if c.Logging.Format != "" && c.Logging.Format != "text" && c.Logging.Format != "json" {
+		return fmt.Errorf("logging.format必须是 'text' 或 'json'")
+	}
+
 	// 设置分析模式默认值
 	if c.AnalysisMode.Mode == "" {
 		c.AnalysisMode.Mode = "standard" // 默认使用标准模式
@@ -384,6 +875,34 @@ func (c *Config) Validate() error {
 				mt.PullbackEntry.BonusScore = 0.3 // 最大加分0.3
 			}
 		}
+
+		// 设置默认MACD参数并验证（K线默认按1000根获取，慢线周期上限200保证指标成熟）
+		if mt.MACD.Fast == 0 {
+			mt.MACD.Fast = 12
+		}
+		if mt.MACD.Slow == 0 {
+			mt.MACD.Slow = 26
+		}
+		if mt.MACD.Signal == 0 {
+			mt.MACD.Signal = 9
+		}
+		if mt.MACD.Fast < 2 || mt.MACD.Slow > 200 || mt.MACD.Fast >= mt.MACD.Slow {
+			return fmt.Errorf("multi_timeframe.macd: 需满足 2 <= fast < slow <= 200，当前fast=%d, slow=%d", mt.MACD.Fast, mt.MACD.Slow)
+		}
+		if mt.MACD.Signal < 2 || mt.MACD.Signal > 50 {
+			return fmt.Errorf("multi_timeframe.macd.signal必须在2-50之间，当前: %d", mt.MACD.Signal)
+		}
+
+		// 设置默认评分权重并验证
+		mt.ScoreWeights = mt.ScoreWeights.WithDefaults()
+		if err := mt.ScoreWeights.validate(); err != nil {
+			return err
+		}
+
+		// 验证K线数量（依赖上面的MACD参数）
+		if err := mt.CandleLimits.validate(mt.MACD); err != nil {
+			return err
+		}
 	}
 
 	return nil
@@ -393,3 +912,159 @@ func (c *Config) Validate() error {
 func (tc *TraderConfig) GetScanInterval() time.Duration {
 	return time.Duration(tc.ScanIntervalMinutes) * time.Minute
 }
+
+// GetMinScanInterval 获取自适应扫描的最小间隔（高波动时使用，未配置时为1分钟）
+func (tc *TraderConfig) GetMinScanInterval() time.Duration {
+	if tc.MinScanIntervalMinutes <= 0 {
+		return time.Minute
+	}
+	return time.Duration(tc.MinScanIntervalMinutes) * time.Minute
+}
+
+// GetMaxScanInterval 获取自适应扫描的最大间隔（低波动时使用，未配置时为扫描间隔的2倍，最多60分钟）
+func (tc *TraderConfig) GetMaxScanInterval() time.Duration {
+	if tc.MaxScanIntervalMinutes <= 0 {
+		minutes := tc.ScanIntervalMinutes * 2
+		if minutes > 60 {
+			minutes = 60
+		}
+		return time.Duration(minutes) * time.Minute
+	}
+	return time.Duration(tc.MaxScanIntervalMinutes) * time.Minute
+}
+
+// MaxCandidatePoolSize 候选币种数量上限（避免prompt过大和行情请求过多）
+const MaxCandidatePoolSize = 100
+
+// GetCandidatePoolSize 获取候选币种数量（未配置时为20，超过上限时取上限）
+func (tc *TraderConfig) GetCandidatePoolSize() int {
+	if tc.CandidatePoolSize <= 0 {
+		return 20
+	}
+	if tc.CandidatePoolSize > MaxCandidatePoolSize {
+		return MaxCandidatePoolSize
+	}
+	return tc.CandidatePoolSize
+}
+
+// GetLimitOrderTimeout 获取限价开仓单的超时时间（未配置时为15分钟）
+func (tc *TraderConfig) GetLimitOrderTimeout() time.Duration {
+	minutes := tc.LimitOrderTimeoutMinutes
+	if minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GetStopLossCheckInterval 获取单仓位止损检查间隔（未配置时为10秒）
+func (tc *TraderConfig) GetStopLossCheckInterval() time.Duration {
+	if tc.StopLossCheckIntervalSeconds <= 0 {
+		return 10 * time.Second
+	}
+	return time.Duration(tc.StopLossCheckIntervalSeconds) * time.Second
+}
+
+// GetSymbolLossWindow 获取单币种连续亏损的统计窗口（未配置时为24小时）
+func (tc *TraderConfig) GetSymbolLossWindow() time.Duration {
+	if tc.SymbolLossWindowHours <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(tc.SymbolLossWindowHours * float64(time.Hour))
+}
+
+// GetSymbolLossCooldown 获取单币种连续亏损后的冷却时长（未配置时为12小时）
+func (tc *TraderConfig) GetSymbolLossCooldown() time.Duration {
+	if tc.SymbolLossCooldownHours <= 0 {
+		return 12 * time.Hour
+	}
+	return time.Duration(tc.SymbolLossCooldownHours * float64(time.Hour))
+}
+
+// GetFundingCaptureMinRate 获取资金费率捕获模式的最低资金费率绝对值（未配置时为0.0005，即0.05%）
+func (tc *TraderConfig) GetFundingCaptureMinRate() float64 {
+	if tc.FundingCaptureMinRate <= 0 {
+		return 0.0005
+	}
+	return tc.FundingCaptureMinRate
+}
+
+// GetMaxDataAge 获取开仓前允许的行情数据最大时长（未配置时返回0，由交易器按2倍K线周期计算）
+func (tc *TraderConfig) GetMaxDataAge() time.Duration {
+	return time.Duration(tc.MaxDataAgeMinutes) * time.Minute
+}
+
+// GetDecisionRetention 获取决策记录保留时长（未配置时为30天）
+func (tc *TraderConfig) GetDecisionRetention() time.Duration {
+	days := tc.DecisionRetentionDays
+	if days <= 0 {
+		days = 30
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetLogVerbosity 获取决策记录详细程度（未配置时为full）
+func (tc *TraderConfig) GetLogVerbosity() string {
+	if tc.LogVerbosity == "" {
+		return "full"
+	}
+	return tc.LogVerbosity
+}
+
+// GetCircuitBreakerFailures 获取触发熔断的连续失败周期数（未配置时为5）
+func (tc *TraderConfig) GetCircuitBreakerFailures() int {
+	if tc.CircuitBreakerFailures <= 0 {
+		return 5
+	}
+	return tc.CircuitBreakerFailures
+}
+
+// GetCircuitBreakerBackoff 获取熔断后的暂停时长（未配置时为30分钟）
+func (tc *TraderConfig) GetCircuitBreakerBackoff() time.Duration {
+	minutes := tc.CircuitBreakerBackoffMinutes
+	if minutes <= 0 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// GetHaltedSymbolFailures 获取判定为停牌/下架的连续行情失败周期数（未配置时为3）
+func (tc *TraderConfig) GetHaltedSymbolFailures() int {
+	if tc.HaltedSymbolFailures <= 0 {
+		return 3
+	}
+	return tc.HaltedSymbolFailures
+}
+
+// GetHaltedSymbolAction 获取停牌/下架的处理方式（未配置时为close）
+func (tc *TraderConfig) GetHaltedSymbolAction() string {
+	if tc.HaltedSymbolAction == "" {
+		return "close"
+	}
+	return tc.HaltedSymbolAction
+}
+
+// GetAITimeout 获取单次AI请求超时时间（0表示使用AI客户端默认值）
+func (tc *TraderConfig) GetAITimeout() time.Duration {
+	return time.Duration(tc.AITimeoutSeconds) * time.Second
+}
+
+// GetAIMaxRetries 获取AI请求重试次数（未配置时返回-1，表示使用AI客户端默认值）
+func (tc *TraderConfig) GetAIMaxRetries() int {
+	if tc.AIMaxRetries == nil {
+		return -1
+	}
+	return *tc.AIMaxRetries
+}
+
+// GetAITemperature 获取AI采样温度（未配置时返回-1，表示使用AI客户端默认值）
+func (tc *TraderConfig) GetAITemperature() float64 {
+	if tc.AITemperature == nil {
+		return -1
+	}
+	return *tc.AITemperature
+}
+
+// GetHTTPTimeout 获取市场数据请求超时时间
+func (m MarketDataConfig) GetHTTPTimeout() time.Duration {
+	return time.Duration(m.HTTPTimeoutSeconds) * time.Second
+}
@@ -11,6 +11,9 @@ import (
 	_ "modernc.org/sqlite"
 )
 
+// busyTimeoutMs 数据库被其他连接锁定时的最长等待时间（毫秒）
+const busyTimeoutMs = 5000
+
 // DBManager 数据库管理器，管理多个SQLite数据库连接
 type DBManager struct {
 	databases map[string]*sql.DB
@@ -59,14 +62,19 @@ func (dm *DBManager) GetDB(dbName string) (*sql.DB, error) {
 	dbPath := filepath.Join(dm.dbDir, dbName+".db")
 
 	// 打开数据库连接
-	connStr := fmt.Sprintf("file:%s?cache=shared&mode=rwc", dbPath)
+	// 每个trader各自打开同一批数据库文件，且每10秒的止损检查与AI决策周期会并发写入：
+	// - 不使用共享缓存（cache=shared下锁冲突直接返回"database table is locked"，busy_timeout不生效）
+	// - busy_timeout：遇到其他连接持有写锁时最多等待5秒，而不是立即返回"database is locked"
+	// - WAL：读写互不阻塞，写入只和写入串行
+	// 通过_pragma参数设置，连接池重建连接时也会生效
+	connStr := fmt.Sprintf("file:%s?mode=rwc&_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)", dbPath, busyTimeoutMs)
 	db, err := sql.Open("sqlite", connStr)
 	if err != nil {
 		return nil, fmt.Errorf("打开数据库 %s 失败: %w", dbName, err)
 	}
 
 	// 设置连接池参数
-	db.SetMaxOpenConns(1) // SQLite建议每个数据库文件只使用一个连接
+	db.SetMaxOpenConns(1) // SQLite同一时间只允许一个写入者，单连接让本进程内的写入在连接池排队而不是争抢文件锁
 	db.SetMaxIdleConns(1)
 
 	// 测试连接
@@ -108,6 +116,21 @@ func (dm *DBManager) Close() error {
 	return firstErr
 }
 
+// CheckWritable 检查数据库是否可写（在health_check库中写入一条探测记录，用于健康检查）
+func (dm *DBManager) CheckWritable() error {
+	db, err := dm.GetDB("health_check")
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS health_check (id INTEGER PRIMARY KEY, checked_at DATETIME NOT NULL)`); err != nil {
+		return fmt.Errorf("创建健康检查表失败: %w", err)
+	}
+	if _, err := db.Exec(`INSERT OR REPLACE INTO health_check (id, checked_at) VALUES (1, CURRENT_TIMESTAMP)`); err != nil {
+		return fmt.Errorf("写入健康检查记录失败: %w", err)
+	}
+	return nil
+}
+
 // GetDBPath 获取数据库文件路径（用于备份等操作）
 func (dm *DBManager) GetDBPath(dbName string) string {
 	return filepath.Join(dm.dbDir, dbName+".db")
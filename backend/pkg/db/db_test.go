@@ -0,0 +1,105 @@
+package db_test
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/pkg/db"
+	"backend/pkg/storage"
+)
+
+// TestConcurrentWrites 多个trader（各自的DBManager打开同一批数据库文件）并发写入决策和交易记录时不应出现"database is locked"
+func TestConcurrentWrites(t *testing.T) {
+	const (
+		traders          = 2
+		writersPerTrader = 4
+		writesPerWriter  = 20
+	)
+	dir := t.TempDir()
+
+	type traderStorage struct {
+		id        string
+		decisions *storage.DecisionStorage
+		trades    *storage.TradeStorage
+	}
+	var storages []traderStorage
+	var managers []*db.DBManager
+	for i := 0; i < traders; i++ {
+		dm, err := db.NewDBManager(dir)
+		if err != nil {
+			t.Fatalf("NewDBManager() error = %v", err)
+		}
+		t.Cleanup(func() { dm.Close() })
+		managers = append(managers, dm)
+
+		decisions, err := storage.NewDecisionStorage(dm)
+		if err != nil {
+			t.Fatalf("NewDecisionStorage() error = %v", err)
+		}
+		trades, err := storage.NewTradeStorage(dm)
+		if err != nil {
+			t.Fatalf("NewTradeStorage() error = %v", err)
+		}
+		storages = append(storages, traderStorage{id: fmt.Sprintf("trader_%d", i), decisions: decisions, trades: trades})
+	}
+
+	var wg sync.WaitGroup
+	errCh := make(chan error, traders*writersPerTrader*writesPerWriter*2)
+	for _, s := range storages {
+		for w := 0; w < writersPerTrader; w++ {
+			wg.Add(1)
+			go func(s traderStorage, w int) {
+				defer wg.Done()
+				for n := 0; n < writesPerWriter; n++ {
+					now := time.Now()
+					if err := s.decisions.LogDecision(s.id, &storage.DecisionRecord{
+						Timestamp:   now,
+						CycleNumber: w*writesPerWriter + n,
+						Success:     true,
+					}); err != nil {
+						errCh <- fmt.Errorf("LogDecision: %w", err)
+					}
+					if err := s.trades.LogTrade(&storage.TradeRecord{
+						TradeID:   fmt.Sprintf("%s_%d_%d", s.id, w, n),
+						Symbol:    "BTCUSDT",
+						Side:      "long",
+						OpenTime:  now.Add(-time.Hour),
+						OpenPrice: 50000,
+						CloseTime: &now,
+						Success:   true,
+					}); err != nil {
+						errCh <- fmt.Errorf("LogTrade: %w", err)
+					}
+				}
+			}(s, w)
+		}
+	}
+	wg.Wait()
+	close(errCh)
+
+	for err := range errCh {
+		if strings.Contains(err.Error(), "locked") {
+			t.Errorf("concurrent write hit lock error: %v", err)
+		} else {
+			t.Errorf("concurrent write failed: %v", err)
+		}
+	}
+
+	want := traders * writersPerTrader * writesPerWriter
+	for dbName, table := range map[string]string{"decision_logs": "decisions", "trade_history": "trades"} {
+		database, err := managers[0].GetDB(dbName)
+		if err != nil {
+			t.Fatalf("GetDB(%s) error = %v", dbName, err)
+		}
+		var count int
+		if err := database.QueryRow("SELECT COUNT(*) FROM " + table).Scan(&count); err != nil {
+			t.Fatalf("count %s: %v", table, err)
+		}
+		if count != want {
+			t.Errorf("%s rows = %d, want %d", table, count, want)
+		}
+	}
+}
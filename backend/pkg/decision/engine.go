@@ -4,10 +4,13 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"sort"
 	"backend/pkg/config"
 	"backend/pkg/logger"
 	"backend/pkg/market"
 	"backend/pkg/mcp"
+	"backend/pkg/metrics"
 	"strings"
 	"time"
 )
@@ -27,6 +30,9 @@ type PositionInfo struct {
 	UpdateTime       int64          `json:"update_time"` // 持仓更新时间戳（毫秒）
 	StopLoss         float64        `json:"stop_loss,omitempty"` // 当前设置的止损价格（如果有）
 	TakeProfit       float64        `json:"take_profit,omitempty"` // 当前设置的止盈价格（如果有）
+	TrailingDistancePct float64     `json:"trailing_distance_pct,omitempty"` // 移动止损距离百分比（已启用移动止损时）
+	TrailingBestPrice   float64     `json:"trailing_best_price,omitempty"`   // 移动止损跟踪的最优价格
+	TakeProfitLevels    []TakeProfitLevel `json:"take_profit_levels,omitempty"` // 阶梯止盈（已设置时）
 	EntryLogic       *EntryLogic    `json:"entry_logic,omitempty"` // 进场逻辑
 	ExitLogic        *ExitLogic     `json:"exit_logic,omitempty"`  // 出场逻辑
 	LogicInvalid     bool           `json:"logic_invalid,omitempty"` // 逻辑是否失效
@@ -42,6 +48,13 @@ type AccountInfo struct {
 	MarginUsed       float64 `json:"margin_used"`       // 已用保证金
 	MarginUsedPct    float64 `json:"margin_used_pct"`   // 保证金使用率
 	PositionCount    int     `json:"position_count"`    // 持仓数量
+	NetExposureUSD   float64 `json:"net_exposure_usd"`  // 净敞口（多头名义价值 - 空头名义价值）
+	NetExposurePct   float64 `json:"net_exposure_pct"`  // 净敞口占净值百分比
+	GrossExposure    float64 `json:"gross_exposure"`    // 总敞口（多头名义价值 + 空头名义价值）
+	GrossExposurePct float64 `json:"gross_exposure_pct"` // 总敞口占净值百分比
+	ConcentrationHHI   float64 `json:"concentration_hhi"`    // 持仓集中度（赫芬达尔指数，0-1）
+	LargestPositionPct float64 `json:"largest_position_pct"` // 最大持仓占总敞口百分比
+	LargestPosition    string  `json:"largest_position"`     // 最大持仓（symbol_side）
 }
 
 // CandidateCoin 候选币种（来自币种池）
@@ -50,9 +63,17 @@ type CandidateCoin struct {
 	Sources []string `json:"sources"` // 币种来源
 }
 
+// FundingOpportunity 资金费率捕获机会（资金费率绝对值较高且即将结算的币种）
+type FundingOpportunity struct {
+	Symbol          string  `json:"symbol"`
+	FundingRate     float64 `json:"funding_rate"`      // 资金费率（小数，正数表示多头支付给空头）
+	NextFundingTime int64   `json:"next_funding_time"` // 下次结算时间（毫秒时间戳）
+}
+
 // Context 交易上下文（传递给AI的完整信息）
 type Context struct {
 	CurrentTime        string                  `json:"current_time"`
+	Now                time.Time               `json:"-"` // 决策时间（回测时为回放时间，零值表示使用当前时间），持仓时长等按此计算
 	RuntimeMinutes     int                     `json:"runtime_minutes"`
 	CallCount          int                     `json:"call_count"`
 	Account            AccountInfo             `json:"account"`
@@ -63,16 +84,71 @@ type Context struct {
 	RecentForcedCloses []string                `json:"-"` // 最近的强制平仓记录（用于AI参考）
 	BTCETHLeverage     int                     `json:"-"` // BTC/ETH杠杆倍数（从配置读取）
 	AltcoinLeverage    int                     `json:"-"` // 山寨币杠杆倍数（从配置读取）
+	SymbolLeverageOverrides map[string]int     `json:"-"` // 单币种杠杆上限（优先于BTC/ETH和山寨币的分组杠杆，从配置读取）
 	SkipLiquidityCheck  bool                    `json:"-"` // 是否跳过流动性检查（从配置读取）
+	MinOpenInterestValueUSD float64             `json:"-"` // 流动性检查的最低持仓价值（USD，≤0时使用DefaultMinOpenInterestValueUSD）
 	AnalysisMode       string                  `json:"-"` // 分析模式（固定为"multi_timeframe"）
 	MultiTimeframeConfig *config.MultiTimeframeConfig `json:"-"` // 多时间框架配置
 	StrategyName string `json:"-"` // 策略名称（从配置读取）
+	IncludeDerivativesTrend bool `json:"-"` // 是否在prompt中包含OI和资金费率的短期趋势（从配置读取）
+	IncludeConcentration    bool    `json:"-"` // 是否在prompt中包含持仓集中度（从配置读取）
+	ConcentrationWarnHHI    float64 `json:"-"` // 集中度提示阈值（从配置读取）
+	MinKlineBars            int    `json:"-"` // 每个时间框架所需的最少K线数量（从配置读取）
+	InsufficientHistoryMode string `json:"-"` // K线不足时的处理方式："flag" 或 "exclude"（从配置读取）
+	ValidationMode          string `json:"-"` // 决策验证模式："strict" 或 "lenient"（从配置读取）
+	MarketSource            market.Source `json:"-"` // 市场数据来源（nil表示实盘API，回测时为历史K线）
+	TraderID                string        `json:"-"` // Trader标识（用于监控指标的trader_id标签）
+	EnsembleMembers         []EnsembleMember `json:"-"` // 多模型投票的AI模型（少于2个时使用单模型决策）
+	EnsembleMinAgree        int              `json:"-"` // 开平仓决策至少需要的同意票数（0表示过半数）
+	RiskPerTradePct         float64 `json:"-"` // 单笔交易风险预算（占账户净值百分比，0表示不限制，从配置读取）
+	MinRiskReward           float64 `json:"-"` // 开仓最低风险回报比（0表示不检查，从配置读取）
+	MinStopATRMultiple      float64 `json:"-"` // 开仓止损距离的最小ATR倍数（0表示不检查，从配置读取）
+	MaxPositionNotionalPct  float64 `json:"-"` // 单笔开仓仓位价值上限（占账户净值百分比，与杠杆无关，0表示不限制，从配置读取）
+	StreamResponses         bool    `json:"-"` // 是否以SSE流式接收AI响应（解析结果与非流式一致，从配置读取）
+	FundingOpportunities    []FundingOpportunity `json:"-"` // 资金费率捕获机会（仅启用资金费率捕获模式时）
+	HourlyClosePrices       map[string][]float64 `json:"-"` // 多时间框架分析已获取的1小时K线收盘价（旧→新，symbol -> 收盘价），执行开仓的相关性检查时复用
+}
+
+// currentTime 上下文的当前时间（回测时为回放时间）
+func (ctx *Context) currentTime() time.Time {
+	if ctx.Now.IsZero() {
+		return time.Now()
+	}
+	return ctx.Now
+}
+
+// validationLimits 决策验证使用的账户净值、杠杆上限和风控参数
+type validationLimits struct {
+	accountEquity          float64
+	btcEthLeverage         int
+	altcoinLeverage        int
+	symbolLeverage         map[string]int
+	source                 market.Source // nil表示实盘API
+	riskPerTradePct        float64
+	minRiskReward          float64
+	minStopATRMultiple     float64
+	maxPositionNotionalPct float64
+}
+
+// validationLimits 从上下文中取出决策验证参数
+func (ctx *Context) validationLimits() validationLimits {
+	return validationLimits{
+		accountEquity:          ctx.Account.TotalEquity,
+		btcEthLeverage:         ctx.BTCETHLeverage,
+		altcoinLeverage:        ctx.AltcoinLeverage,
+		symbolLeverage:         ctx.SymbolLeverageOverrides,
+		source:                 ctx.MarketSource,
+		riskPerTradePct:        ctx.RiskPerTradePct,
+		minRiskReward:          ctx.MinRiskReward,
+		minStopATRMultiple:     ctx.MinStopATRMultiple,
+		maxPositionNotionalPct: ctx.MaxPositionNotionalPct,
+	}
 }
 
 // Decision AI的交易决策
 type Decision struct {
 	Symbol          string  `json:"symbol"`
-	Action          string  `json:"action"` // "open_long", "open_short", "close_long", "close_short", "hold", "wait"
+	Action          string  `json:"action"` // "open_long", "open_short", "close_long", "close_short", "update_tp", "update_sl", "update_sl_trailing", "cancel_orders", "hold", "wait"
 	Leverage        int     `json:"leverage,omitempty"`
 	PositionSizeUSD float64 `json:"position_size_usd,omitempty"`
 	StopLoss        float64 `json:"stop_loss,omitempty"`
@@ -81,16 +157,55 @@ type Decision struct {
 	RiskUSD         float64 `json:"risk_usd,omitempty"`   // 最大美元风险
 	Reasoning       string  `json:"reasoning"`            // 进场逻辑（开仓时）或平仓理由（平仓时）
 	ExitReasoning   string  `json:"exit_reasoning,omitempty"` // 出场逻辑规划（仅在开仓时提供）
+	TrailingDistancePct float64 `json:"trailing_distance_pct,omitempty"` // 移动止损距离百分比（仅update_sl_trailing，相对持仓以来最优价的回撤）
+	ClosePercent    float64 `json:"close_percent,omitempty"` // 平仓比例（仅close_long/close_short，1-100，不填或100表示全部平仓）
+	TakeProfitLevels []TakeProfitLevel `json:"take_profit_levels,omitempty"` // 阶梯止盈（仅开仓和update_tp，按盈利方向排列，设置后take_profit取最远一档）
+	OrderType       string  `json:"order_type,omitempty"`  // 开仓订单类型（仅开仓）："market"（默认）或 "limit"
+	LimitPrice      float64 `json:"limit_price,omitempty"` // 限价开仓价格（仅order_type为limit时，做多低于当前价，做空高于当前价）
+}
+
+// IsLimitOrder 是否为限价开仓
+func (d *Decision) IsLimitOrder() bool {
+	return d.OrderType == "limit"
+}
+
+// TakeProfitLevel 阶梯止盈的一档：价格到达Price时平掉Pct%的持仓（按设置时的持仓数量计算）
+type TakeProfitLevel struct {
+	Price float64 `json:"price"`
+	Pct   float64 `json:"pct"`
 }
 
+// MaxTakeProfitLevels 阶梯止盈最多档数
+const MaxTakeProfitLevels = 3
+
+// 移动止损距离百分比的允许范围
+const (
+	MinTrailingDistancePct = 0.5
+	MaxTrailingDistancePct = 50.0
+)
+
+// MaxLimitPriceDistancePct 限价开仓价格偏离当前价的最大百分比（太远的限价单基本不会成交）
+const MaxLimitPriceDistancePct = 5.0
+
+// DefaultMinOpenInterestValueUSD 流动性检查默认的最低持仓价值（15M USD）
+const DefaultMinOpenInterestValueUSD = 15_000_000.0
+
 // FullDecision AI的完整决策（包含思维链）
 type FullDecision struct {
 	UserPrompt string     `json:"user_prompt"` // 发送给AI的输入prompt
 	CoTTrace   string     `json:"cot_trace"`   // 思维链分析（AI输出）
 	Decisions  []Decision `json:"decisions"`   // 具体决策列表
+	DroppedDecisions []DroppedDecision `json:"dropped_decisions,omitempty"` // 宽松验证模式下被丢弃的无效决策
+	ModelTraces      []logger.ModelTrace `json:"model_traces,omitempty"`     // 多模型投票时每个模型的原始输出
 	Timestamp  time.Time  `json:"timestamp"`
 }
 
+// DroppedDecision 验证失败被丢弃的决策及其缺陷
+type DroppedDecision struct {
+	Decision Decision `json:"decision"`
+	Defect   string   `json:"defect"` // 验证失败原因
+}
+
 // GetFullDecision 获取AI的完整交易决策（批量分析所有币种和持仓）
 // 使用多时间框架分析模式
 func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error) {
@@ -107,26 +222,39 @@ func GetFullDecision(ctx *Context, mcpClient *mcp.Client) (*FullDecision, error)
 	}
 
 	// 3. 构建 System Prompt（固定规则）和 User Prompt（动态数据）
-	// 判断是否只交易一个币种
-	isSingleSymbol := len(ctx.Positions) == 0 || func() bool {
-		symbolSet := make(map[string]bool)
-		for _, pos := range ctx.Positions {
-			symbolSet[pos.Symbol] = true
-		}
-		return len(symbolSet) == 1
-	}()
-	systemPrompt := buildSystemPrompt(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, isSingleSymbol, ctx.StrategyName)
+	systemPrompt := BuildSystemPrompt(ctx)
 
-	// 4. 调用AI API（使用 system + user prompt）
-	aiResponse, err := mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	// 4. 配置了多个模型时，分别决策后投票
+	if len(ctx.EnsembleMembers) > 1 {
+		return getEnsembleDecision(ctx, systemPrompt, userPrompt)
+	}
+
+	// 调用AI API（使用 system + user prompt，临时性失败由mcp.Client按退避策略重试）
+	callStart := time.Now()
+	var aiResponse string
+	if ctx.StreamResponses {
+		aiResponse, err = mcpClient.CallWithMessagesStream(systemPrompt, userPrompt)
+	} else {
+		aiResponse, err = mcpClient.CallWithMessages(systemPrompt, userPrompt)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("调用AI API失败: %w", err)
+		metrics.ObserveAICall(ctx.TraderID, time.Since(callStart), err)
+		// 返回带输入prompt的部分结果，调用方仍可保存用于debug
+		return &FullDecision{UserPrompt: userPrompt, Timestamp: time.Now()}, fmt.Errorf("调用AI API失败: %w", err)
 	}
+	aiLatency := time.Since(callStart)
 
-	// 5. 解析AI响应
-	decision, err := parseFullDecisionResponse(aiResponse, ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage)
+	// 5. 解析AI响应（无法解析的响应也计为AI调用失败）
+	decision, err := parseFullDecisionResponse(aiResponse, ctx.validationLimits(), ctx.ValidationMode == "lenient")
+	metrics.ObserveAICall(ctx.TraderID, aiLatency, err)
 	if err != nil {
-		return nil, fmt.Errorf("解析AI响应失败: %w", err)
+		// 保留思维链和输入prompt（用于debug）
+		if decision == nil {
+			decision = &FullDecision{}
+		}
+		decision.Timestamp = time.Now()
+		decision.UserPrompt = userPrompt
+		return decision, fmt.Errorf("解析AI响应失败: %w", err)
 	}
 
 	decision.Timestamp = time.Now()
@@ -171,6 +299,16 @@ func fetchMarketDataForContext(ctx *Context) error {
 		positionSymbols[pos.Symbol] = true
 	}
 
+	// 流动性检查的持仓价值门槛
+	minOIValue := ctx.MinOpenInterestValueUSD
+	if minOIValue <= 0 {
+		minOIValue = DefaultMinOpenInterestValueUSD
+	}
+	minOIValueInMillions := minOIValue / 1_000_000
+	if !ctx.SkipLiquidityCheck {
+		log.Printf("💧 流动性检查门槛: 持仓价值 ≥ %.2fM USD（持仓币种不受限制）", minOIValueInMillions)
+	}
+
 	// 统计变量
 	successCount := 0
 	failedCount := 0
@@ -184,7 +322,7 @@ func fetchMarketDataForContext(ctx *Context) error {
 		log.Printf("  🔍 处理币种: %s (持仓: %v)", symbol, isExistingPosition)
 
 		// 获取市场数据
-		data, err := market.Get(symbol)
+		data, err := market.GetFrom(ctx.MarketSource, symbol, "3m", 1000)
 		if err != nil {
 			failedCount++
 			failedReasons[symbol] = fmt.Sprintf("获取市场数据失败: %v", err)
@@ -227,12 +365,12 @@ func fetchMarketDataForContext(ctx *Context) error {
 				oiValue := data.OpenInterest.Latest * data.CurrentPrice
 				oiValueInMillions := oiValue / 1_000_000 // 转换为百万美元单位
 
-				// 流动性过滤：持仓价值低于15M USD的币种不做
-				if oiValueInMillions < 15 {
+				// 流动性过滤：持仓价值低于配置门槛的币种不做
+				if oiValue < minOIValue {
 					filteredCount++
-					filteredReasons[symbol] = fmt.Sprintf("持仓价值过低: %.2fM USD < 15M", oiValueInMillions)
-					log.Printf("    ⚠️  %s: 持仓价值过低(%.2fM USD < 15M)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
-						symbol, oiValueInMillions, data.OpenInterest.Latest, data.CurrentPrice)
+					filteredReasons[symbol] = fmt.Sprintf("持仓价值过低: %.2fM USD < %.2fM", oiValueInMillions, minOIValueInMillions)
+					log.Printf("    ⚠️  %s: 持仓价值过低(%.2fM USD < %.2fM)，跳过此币种 [持仓量:%.0f × 价格:%.4f]",
+						symbol, oiValueInMillions, minOIValueInMillions, data.OpenInterest.Latest, data.CurrentPrice)
 					continue
 				}
 
@@ -279,8 +417,38 @@ func calculateMaxCandidates(ctx *Context) int {
 	return len(ctx.CandidateCoins)
 }
 
+// BuildSystemPrompt 按决策上下文构建完整的 System Prompt（策略提示词、动态仓位配置和风控约束）
+func BuildSystemPrompt(ctx *Context) string {
+	// 判断是否只交易一个币种
+	isSingleSymbol := len(ctx.Positions) == 0 || func() bool {
+		symbolSet := make(map[string]bool)
+		for _, pos := range ctx.Positions {
+			symbolSet[pos.Symbol] = true
+		}
+		return len(symbolSet) == 1
+	}()
+	systemPrompt := buildSystemPrompt(ctx.Account.TotalEquity, ctx.BTCETHLeverage, ctx.AltcoinLeverage, ctx.SymbolLeverageOverrides, isSingleSymbol, ctx.StrategyName)
+	if ctx.RiskPerTradePct > 0 {
+		riskBudget := ctx.Account.TotalEquity * ctx.RiskPerTradePct / 100
+		systemPrompt += fmt.Sprintf("**单笔风险预算**: 每笔开仓在止损处的亏损不超过 %.2f USDT（净值的%.2f%%）\n"+
+			"   - 最大仓位 = %.2f / 止损距离%%（入场价到止损价的距离占入场价的比例），超出的仓位会被系统自动下调\n\n",
+			riskBudget, ctx.RiskPerTradePct, riskBudget)
+	}
+	if ctx.MinRiskReward > 0 {
+		systemPrompt += fmt.Sprintf("**最低风险回报比**: 开仓的风险回报比（|止盈价-当前价| / |当前价-止损价|）必须 ≥ %.2f:1，否则开仓会被系统拒绝\n\n", ctx.MinRiskReward)
+	}
+	if ctx.MinStopATRMultiple > 0 {
+		systemPrompt += fmt.Sprintf("**最小止损距离**: 开仓止损距入场价必须 ≥ %.2f倍ATR(14)（3分钟K线），否则开仓会被系统拒绝\n\n", ctx.MinStopATRMultiple)
+	}
+	if ctx.MaxPositionNotionalPct > 0 {
+		systemPrompt += fmt.Sprintf("**单笔仓位价值上限**: 无论杠杆多少，单笔开仓position_size_usd不能超过账户净值的%.0f%%（当前%.0f USDT），否则开仓会被系统拒绝\n\n",
+			ctx.MaxPositionNotionalPct, ctx.Account.TotalEquity*ctx.MaxPositionNotionalPct/100)
+	}
+	return systemPrompt
+}
+
 // buildSystemPrompt 构建 System Prompt（固定规则，可缓存）
-func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage int, isSingleSymbol bool, strategyName string) string {
+func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage int, symbolLeverage map[string]int, isSingleSymbol bool, strategyName string) string {
 	// 验证策略名称
 	if strategyName == "" {
 		log.Printf("⚠️  策略名称为空，使用默认策略 'base_prompt'")
@@ -323,9 +491,35 @@ func buildSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage in
 		sb.WriteString("**保证金**: 总使用率 ≤ 90%（多币种模式）\n\n")
 	}
 
+	// 单币种杠杆上限（覆盖上面的分组杠杆）
+	if len(symbolLeverage) > 0 {
+		symbols := make([]string, 0, len(symbolLeverage))
+		for symbol := range symbolLeverage {
+			symbols = append(symbols, symbol)
+		}
+		sort.Strings(symbols)
+		sb.WriteString("**单币种杠杆上限**（优先于上面的分组杠杆）:\n")
+		for _, symbol := range symbols {
+			leverage := symbolLeverage[symbol]
+			sb.WriteString(fmt.Sprintf("   - %s: 最高%dx杠杆，仓位价值上限%.0f USDT\n", symbol, leverage, accountEquity*float64(leverage)*0.9))
+		}
+		sb.WriteString("\n")
+	}
+
 	return sb.String()
 }
 
+// LeverageForSymbol 返回币种的杠杆上限：优先使用单币种配置，否则BTC/ETH和山寨币分别使用分组杠杆
+func LeverageForSymbol(symbol string, btcEthLeverage, altcoinLeverage int, symbolLeverage map[string]int) int {
+	if leverage, ok := symbolLeverage[symbol]; ok && leverage > 0 {
+		return leverage
+	}
+	if symbol == "BTCUSDT" || symbol == "ETHUSDT" {
+		return btcEthLeverage
+	}
+	return altcoinLeverage
+}
+
 // buildDefaultSystemPrompt 构建默认系统提示词（向后兼容，当策略文件加载失败时使用）
 func buildDefaultSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeverage int, isSingleSymbol bool) string {
 	// 这里保留原来的完整提示词逻辑作为fallback
@@ -339,6 +533,7 @@ func buildDefaultSystemPrompt(accountEquity float64, btcEthLeverage, altcoinLeve
 func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, error) {
 	// 创建多时间框架分析器
 	analyzer := NewMultiTimeframeAnalyzer(ctx.MultiTimeframeConfig)
+	analyzer.source = ctx.MarketSource
 	
 	// 执行分析
 	result, err := analyzer.Analyze(ctx)
@@ -349,6 +544,14 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 	if len(result.SymbolScores) == 0 {
 		return "", fmt.Errorf("多时间框架分析结果为空，无可用币种数据")
 	}
+
+	// 保存1小时K线收盘价，开仓前的相关性检查直接复用，不再重复请求K线
+	ctx.HourlyClosePrices = make(map[string][]float64, len(result.DataMap))
+	for symbol, data := range result.DataMap {
+		if data.Hourly1Data != nil && len(data.Hourly1Data.ClosePrices) > 0 {
+			ctx.HourlyClosePrices[symbol] = data.Hourly1Data.ClosePrices
+		}
+	}
 	
 	// 构建prompt
 	var sb strings.Builder
@@ -366,6 +569,22 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 	sb.WriteString(fmt.Sprintf("**账户**: 净值%.2f | 余额%.2f (%.1f%%) | 盈亏%.2f (%.2f%%) | 保证金%.1f%% | 持仓%d个\n\n",
 		ctx.Account.TotalEquity, ctx.Account.AvailableBalance, availablePct,
 		ctx.Account.TotalPnL, ctx.Account.TotalPnLPct, ctx.Account.MarginUsedPct, ctx.Account.PositionCount))
+	if ctx.Account.PositionCount > 0 {
+		// 净敞口反映真实的方向性风险（对冲时保证金占用高但净敞口可能接近0）
+		sb.WriteString(fmt.Sprintf("**敞口**: 净敞口%+.2f (%+.1f%%) | 总敞口%.2f (%.1f%%)\n\n",
+			ctx.Account.NetExposureUSD, ctx.Account.NetExposurePct, ctx.Account.GrossExposure, ctx.Account.GrossExposurePct))
+	}
+	
+	// 持仓集中度（仅作参考，不同于硬性仓位上限）
+	if ctx.IncludeConcentration && ctx.Account.PositionCount > 1 {
+		sb.WriteString(fmt.Sprintf("**集中度**: HHI %.2f | 最大持仓%s占总敞口%.1f%%\n",
+			ctx.Account.ConcentrationHHI, ctx.Account.LargestPosition, ctx.Account.LargestPositionPct))
+		if ctx.ConcentrationWarnHHI > 0 && ctx.Account.ConcentrationHHI >= ctx.ConcentrationWarnHHI {
+			sb.WriteString(fmt.Sprintf("⚠️ 持仓集中度偏高（HHI %.2f ≥ %.2f），组合风险主要集中在%s，建议考虑分散持仓或适当减仓该持仓\n",
+				ctx.Account.ConcentrationHHI, ctx.ConcentrationWarnHHI, ctx.Account.LargestPosition))
+		}
+		sb.WriteString("\n")
+	}
 	
 	// 当前持仓 - 多时间框架分析
 	if len(ctx.Positions) > 0 {
@@ -373,7 +592,7 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 		for i, pos := range ctx.Positions {
 			holdingDuration := ""
 			if pos.UpdateTime > 0 {
-				durationMs := time.Now().UnixMilli() - pos.UpdateTime
+				durationMs := ctx.currentTime().UnixMilli() - pos.UpdateTime
 				durationMin := durationMs / (1000 * 60)
 				if durationMin < 60 {
 					holdingDuration = fmt.Sprintf(" | 持仓时长%d分钟", durationMin)
@@ -416,6 +635,10 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 			} else {
 				sb.WriteString("- 止损价: 未设置\n")
 			}
+			if pos.TrailingDistancePct > 0 {
+				sb.WriteString(fmt.Sprintf("- 移动止损: 已启用，距最优价回撤 %.2f%% 触发（最优价: %.4f，系统自动跟踪）\n",
+					pos.TrailingDistancePct, pos.TrailingBestPrice))
+			}
 			if pos.TakeProfit > 0 {
 				sb.WriteString(fmt.Sprintf("- 止盈价: %.4f", pos.TakeProfit))
 				if pos.Side == "long" {
@@ -430,6 +653,9 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 			} else {
 				sb.WriteString("- 止盈价: 未设置\n")
 			}
+			if len(pos.TakeProfitLevels) > 0 {
+				sb.WriteString(fmt.Sprintf("- 阶梯止盈: %s\n", FormatTakeProfitLevels(pos.TakeProfitLevels)))
+			}
 			sb.WriteString("\n")
 			
 			// 显示进场/出场逻辑和检查结果（无论是否有逻辑都显示，让AI了解情况）
@@ -479,13 +705,26 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 		
 		sb.WriteString(fmt.Sprintf("### %d. %s\n\n", i+1, symbol))
 		
-		// 根据币种类型确定杠杆倍数
-		leverage := ctx.AltcoinLeverage
-		if symbol == "BTCUSDT" || symbol == "ETHUSDT" {
-			leverage = ctx.BTCETHLeverage
-		}
+		// 根据币种确定杠杆倍数（单币种配置优先）
+		leverage := LeverageForSymbol(symbol, ctx.BTCETHLeverage, ctx.AltcoinLeverage, ctx.SymbolLeverageOverrides)
 		sb.WriteString(fmt.Sprintf("**杠杆倍数**：%d\n\n", leverage))
 		
+		// K线历史不足的币种：明确标注指标不可靠，避免AI基于被置为0的指标做决策
+		if len(data.InsufficientTimeframes) > 0 {
+			sb.WriteString(fmt.Sprintf("**⚠️ 历史数据不足**：%s K线少于%d根，指标不可靠（MACD/EMA等可能显示为0），请谨慎对待\n\n",
+				strings.Join(data.InsufficientTimeframes, "、"), ctx.MinKlineBars))
+		}
+		
+		// OI和资金费率短期趋势（OI与价格同向/背离是重要的持仓结构信号）
+		if ctx.IncludeDerivativesTrend && data.Hourly1Data != nil {
+			if trend := market.FormatDerivativesTrend(data.Hourly1Data); trend != "" {
+				sb.WriteString(fmt.Sprintf("**衍生品趋势**：%s | 价格4h变化 %+.2f%%\n\n", trend, data.Hourly1Data.PriceChange4h))
+			}
+		}
+		
+		// 日线长期均线（日线数据不再完整发送，只提供EMA50/EMA200和金叉死叉作为趋势过滤）
+		sb.WriteString(formatDailyEMATrend(data.DailyData))
+		
 		// 注释掉评分信息，让AI自己判断
 		// sb.WriteString(fmt.Sprintf("**评分**: 做多%.2f | 做空%.2f | 推荐方向: **%s**\n\n",
 		// 	score.LongScore.WeightedScore, score.ShortScore.WeightedScore,
@@ -512,6 +751,7 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 		if data.Hourly1Data != nil {
 			sb.WriteString("**1小时 (1h) 数据**:\n")
 			sb.WriteString(formatMarketDataForMultiTimeframe(data.Hourly1Data))
+			sb.WriteString(formatStochRSISignal(data.Hourly1Data))
 			sb.WriteString("\n")
 		}
 		
@@ -519,6 +759,7 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 		if data.Minute15Data != nil {
 			sb.WriteString("**15分钟 (15m) 数据**:\n")
 			sb.WriteString(formatMarketDataForMultiTimeframe(data.Minute15Data))
+			sb.WriteString(formatStochRSISignal(data.Minute15Data))
 			sb.WriteString("\n")
 		}
 		
@@ -760,6 +1001,11 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 		log.Printf("ℹ️  Performance数据为空，无法显示历史表现分析")
 	}
 	
+	// 资金费率捕获机会
+	if len(ctx.FundingOpportunities) > 0 {
+		sb.WriteString(formatFundingOpportunities(ctx.FundingOpportunities))
+	}
+
 	// 最近的强制平仓记录
 	if len(ctx.RecentForcedCloses) > 0 {
 		sb.WriteString("## 🛑 最近的强制平仓记录\n\n")
@@ -780,12 +1026,13 @@ func buildMultiTimeframePrompt(ctx *Context, mcpClient *mcp.Client) (string, err
 }
 
 // parseFullDecisionResponse 解析AI的完整决策响应
-func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthLeverage, altcoinLeverage int) (*FullDecision, error) {
-	// 1. 提取思维链
-	cotTrace := extractCoTTrace(aiResponse)
+// lenient为true时，无效决策被单独丢弃（记录在DroppedDecisions中），其余有效决策照常返回
+func parseFullDecisionResponse(aiResponse string, limits validationLimits, lenient bool) (*FullDecision, error) {
+	// 1. 提取JSON决策列表
+	decisions, jsonStart, err := extractDecisions(aiResponse)
 
-	// 2. 提取JSON决策列表
-	decisions, err := extractDecisions(aiResponse)
+	// 2. 提取思维链（决策JSON之前的内容）
+	cotTrace := extractCoTTrace(aiResponse, jsonStart)
 	if err != nil {
 		return &FullDecision{
 			CoTTrace:  cotTrace,
@@ -794,7 +1041,15 @@ func parseFullDecisionResponse(aiResponse string, accountEquity float64, btcEthL
 	}
 
 	// 3. 验证决策（需要市场数据用于入场价验证）
-	if err := validateDecisionsWithMarketData(decisions, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+	if lenient {
+		validDecisions, dropped := filterInvalidDecisions(decisions, limits)
+		return &FullDecision{
+			CoTTrace:         cotTrace,
+			Decisions:        validDecisions,
+			DroppedDecisions: dropped,
+		}, nil
+	}
+	if err := validateDecisionsWithMarketData(decisions, limits); err != nil {
 		return &FullDecision{
 			CoTTrace:  cotTrace,
 			Decisions: decisions,
@@ -837,6 +1092,50 @@ func formatMarketDataForMultiTimeframe(data *market.Data) string {
 	return result.String()
 }
 
+// formatStochRSISignal 格式化StochRSI的超买超卖和交叉状态（用于1h/15m回调入场择时，K线不足时返回空）
+// 当前%K/%D已由market.Format输出，这里只补充信号状态和上一根K线的值
+func formatStochRSISignal(data *market.Data) string {
+	if data == nil || (data.StochK == 0 && data.StochD == 0) {
+		return ""
+	}
+
+	var state string
+	switch {
+	case market.StochRSICrossUp(data):
+		state = "超卖区金叉（%K上穿%D，回调可能结束）"
+	case market.StochRSICrossDown(data):
+		state = "超买区死叉（%K下穿%D，反弹可能结束）"
+	case data.StochK < market.StochRSIOversold:
+		state = "超卖"
+	case data.StochK > market.StochRSIOverbought:
+		state = "超买"
+	default:
+		state = "中性"
+	}
+	return fmt.Sprintf("   StochRSI信号: %s（上一根 %%K %.1f / %%D %.1f）\n",
+		state, data.PrevStochK, data.PrevStochD)
+}
+
+// formatDailyEMATrend 格式化日线EMA50/EMA200位置和最近的金叉死叉（日线K线不足200根时返回空）
+func formatDailyEMATrend(data *market.Data) string {
+	if data == nil || data.CurrentEMA200 <= 0 || data.CurrentPrice <= 0 {
+		return ""
+	}
+
+	position := "下方"
+	if data.CurrentPrice > data.CurrentEMA200 {
+		position = "上方"
+	}
+	line := fmt.Sprintf("**日线长期均线**：EMA50 %.4f | EMA200 %.4f，价格位于EMA200%s", data.CurrentEMA50, data.CurrentEMA200, position)
+	switch data.EMACross {
+	case market.EMACrossGolden:
+		line += fmt.Sprintf(" | 最近%d根日线EMA50上穿EMA200（金叉）", market.EMACrossLookbackBars)
+	case market.EMACrossDeath:
+		line += fmt.Sprintf(" | 最近%d根日线EMA50下穿EMA200（死叉）", market.EMACrossLookbackBars)
+	}
+	return line + "\n\n"
+}
+
 // calculateSingleTimeframeScore 计算单个时间框架的质量评分
 // 重构版本：修复RSI评分逻辑，改进评分算法
 func calculateSingleTimeframeScore(data *market.Data) float64 {
@@ -907,99 +1206,40 @@ func calculateSingleTimeframeScore(data *market.Data) float64 {
 }
 
 
-// extractCoTTrace 提取思维链分析
-func extractCoTTrace(response string) string {
-	// 查找JSON数组的开始位置
-	jsonStart := strings.Index(response, "[")
-
-	if jsonStart > 0 {
-		// 思维链是JSON数组之前的内容
-		return strings.TrimSpace(response[:jsonStart])
-	}
-
-	// 如果找不到JSON，整个响应都是思维链
-	return strings.TrimSpace(response)
-}
-
-// extractDecisions 提取JSON决策列表
-func extractDecisions(response string) ([]Decision, error) {
-	// 直接查找JSON数组 - 找第一个完整的JSON数组
-	arrayStart := strings.Index(response, "[")
-	if arrayStart == -1 {
-		return nil, fmt.Errorf("无法找到JSON数组起始")
-	}
-
-	// 从 [ 开始，匹配括号找到对应的 ]
-	arrayEnd := findMatchingBracket(response, arrayStart)
-	if arrayEnd == -1 {
-		return nil, fmt.Errorf("无法找到JSON数组结束")
-	}
-
-	jsonContent := strings.TrimSpace(response[arrayStart : arrayEnd+1])
-
-	// 🔧 修复常见的JSON格式错误：缺少引号的字段值
-	// 匹配: "reasoning": 内容"}  或  "reasoning": 内容}  (没有引号)
-	// 修复为: "reasoning": "内容"}
-	// 使用简单的字符串扫描而不是正则表达式
-	jsonContent = fixMissingQuotes(jsonContent)
-
-	// 解析JSON
-	var decisions []Decision
-	if err := json.Unmarshal([]byte(jsonContent), &decisions); err != nil {
-		return nil, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", err, jsonContent)
-	}
-
-	return decisions, nil
-}
-
-// fixMissingQuotes 替换中文引号为英文引号（避免输入法自动转换）
-func fixMissingQuotes(jsonStr string) string {
-	jsonStr = strings.ReplaceAll(jsonStr, "\u201c", "\"") // "
-	jsonStr = strings.ReplaceAll(jsonStr, "\u201d", "\"") // "
-	jsonStr = strings.ReplaceAll(jsonStr, "\u2018", "'")  // '
-	jsonStr = strings.ReplaceAll(jsonStr, "\u2019", "'")  // '
-	return jsonStr
-}
-
 // validateDecisionsWithMarketData 验证所有决策（使用市场数据获取实际价格）
-func validateDecisionsWithMarketData(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
-	for i, decision := range decisions {
-		if err := validateDecisionWithMarketData(&decision, accountEquity, btcEthLeverage, altcoinLeverage); err != nil {
+// 验证可能下调开仓仓位（单笔风险预算），因此按下标传入指针，修改会保留在decisions中
+func validateDecisionsWithMarketData(decisions []Decision, limits validationLimits) error {
+	for i := range decisions {
+		if err := validateDecisionWithMarketData(&decisions[i], limits); err != nil {
 			return fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
 		}
 	}
 	return nil
 }
 
-// validateDecisions 验证所有决策（兼容旧接口，内部调用新接口）
-func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
-	return validateDecisionsWithMarketData(decisions, accountEquity, btcEthLeverage, altcoinLeverage)
-}
-
-// findMatchingBracket 查找匹配的右括号
-func findMatchingBracket(s string, start int) int {
-	if start >= len(s) || s[start] != '[' {
-		return -1
-	}
-
-	depth := 0
-	for i := start; i < len(s); i++ {
-		switch s[i] {
-		case '[':
-			depth++
-		case ']':
-			depth--
-			if depth == 0 {
-				return i
-			}
+// filterInvalidDecisions 逐个验证决策，返回有效决策和被丢弃的无效决策（宽松验证模式使用）
+func filterInvalidDecisions(decisions []Decision, limits validationLimits) ([]Decision, []DroppedDecision) {
+	valid := make([]Decision, 0, len(decisions))
+	var dropped []DroppedDecision
+	for i := range decisions {
+		decision := decisions[i]
+		if err := validateDecisionWithMarketData(&decision, limits); err != nil {
+			log.Printf("⚠️  决策 #%d (%s %s) 验证失败，已丢弃: %v", i+1, decision.Symbol, decision.Action, err)
+			dropped = append(dropped, DroppedDecision{Decision: decision, Defect: err.Error()})
+			continue
 		}
+		valid = append(valid, decision)
 	}
+	return valid, dropped
+}
 
-	return -1
+// validateDecisions 验证所有决策（兼容旧接口，内部调用新接口）
+func validateDecisions(decisions []Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+	return validateDecisionsWithMarketData(decisions, validationLimits{accountEquity: accountEquity, btcEthLeverage: btcEthLeverage, altcoinLeverage: altcoinLeverage})
 }
 
 // validateDecisionWithMarketData 验证单个决策的有效性（使用实际市场价格）
-func validateDecisionWithMarketData(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
+func validateDecisionWithMarketData(d *Decision, limits validationLimits) error {
 	// 验证action
 	validActions := map[string]bool{
 		"open_long":   true,
@@ -1008,6 +1248,8 @@ func validateDecisionWithMarketData(d *Decision, accountEquity float64, btcEthLe
 		"close_short": true,
 		"update_tp":   true, // 更新止盈
 		"update_sl":   true, // 更新止损
+		"update_sl_trailing": true, // 设置移动止损
+		"cancel_orders":      true, // 撤销止损止盈（不平仓）
 		"hold":        true,
 		"wait":        true,
 	}
@@ -1016,15 +1258,19 @@ func validateDecisionWithMarketData(d *Decision, accountEquity float64, btcEthLe
 		return fmt.Errorf("无效的action: %s", d.Action)
 	}
 
+	// 订单类型只对开仓有效
+	if d.OrderType != "" && d.OrderType != "market" && d.OrderType != "limit" {
+		return fmt.Errorf("无效的order_type: %s（只能是market或limit）", d.OrderType)
+	}
+	if d.IsLimitOrder() && d.Action != "open_long" && d.Action != "open_short" {
+		return fmt.Errorf("order_type=limit只能用于开仓（%s）", d.Action)
+	}
+
 	// 开仓操作必须提供完整参数
 	if d.Action == "open_long" || d.Action == "open_short" {
-		// 根据币种使用配置的杠杆上限
-		maxLeverage := altcoinLeverage          // 山寨币使用配置的杠杆
-		maxPositionValue := accountEquity * float64(altcoinLeverage) * 0.9 // 山寨币最多配置杠杆的90% * 账户净值
-		if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" {
-			maxLeverage = btcEthLeverage          // BTC和ETH使用配置的杠杆
-			maxPositionValue = accountEquity * float64(btcEthLeverage) * 0.9 // BTC/ETH最多配置杠杆的90% * 账户净值
-		}
+		// 根据币种使用配置的杠杆上限（单币种配置优先，其次BTC/ETH和山寨币分组杠杆）
+		maxLeverage := LeverageForSymbol(d.Symbol, limits.btcEthLeverage, limits.altcoinLeverage, limits.symbolLeverage)
+		maxPositionValue := limits.accountEquity * float64(maxLeverage) * 0.9 // 最多配置杠杆的90% * 账户净值
 
 		if d.Leverage <= 0 || d.Leverage > maxLeverage {
 			return fmt.Errorf("杠杆必须在1-%d之间（%s，当前配置上限%d倍）: %d", maxLeverage, d.Symbol, maxLeverage, d.Leverage)
@@ -1032,13 +1278,16 @@ func validateDecisionWithMarketData(d *Decision, accountEquity float64, btcEthLe
 		if d.PositionSizeUSD <= 0 {
 			return fmt.Errorf("仓位大小必须大于0: %.2f", d.PositionSizeUSD)
 		}
+		if err := NormalizeTakeProfitLevels(d, strings.TrimPrefix(d.Action, "open_")); err != nil {
+			return err
+		}
 		
 		// 验证保证金使用率（主要验证逻辑）
 		// 保证金 = 仓位价值 / 杠杆
 		marginRequired := d.PositionSizeUSD / float64(d.Leverage)
 		// 使用50%保证金使用率限制（适用于单币种模式的更安全限制）
 		maxMarginUsedPct := 50.0 
-		maxMarginAllowed := accountEquity * (maxMarginUsedPct / 100.0)
+		maxMarginAllowed := limits.accountEquity * (maxMarginUsedPct / 100.0)
 		
 		// 验证保证金使用率（加1%容差以避免浮点数精度问题）
 		tolerance_margin := maxMarginAllowed * 0.01 // 1%容差
@@ -1051,16 +1300,25 @@ func validateDecisionWithMarketData(d *Decision, accountEquity float64, btcEthLe
 		tolerance := maxPositionValue * 0.01 // 1%容差
 		if d.PositionSizeUSD > maxPositionValue+tolerance {
 			// 计算实际杠杆倍数
-			effectiveLeverage := d.PositionSizeUSD / accountEquity
+			effectiveLeverage := d.PositionSizeUSD / limits.accountEquity
 			if d.Symbol == "BTCUSDT" || d.Symbol == "ETHUSDT" {
 				return fmt.Errorf("BTC/ETH单币种仓位价值不能超过%.0f USDT（%.1f倍账户净值），实际: %.0f USDT（%.1f倍账户净值）", 
-					maxPositionValue, maxPositionValue/accountEquity, d.PositionSizeUSD, effectiveLeverage)
+					maxPositionValue, maxPositionValue/limits.accountEquity, d.PositionSizeUSD, effectiveLeverage)
 			} else {
 				return fmt.Errorf("山寨币单币种仓位价值不能超过%.0f USDT（%.1f倍账户净值），实际: %.0f USDT（%.1f倍账户净值）", 
-					maxPositionValue, maxPositionValue/accountEquity, d.PositionSizeUSD, effectiveLeverage)
+					maxPositionValue, maxPositionValue/limits.accountEquity, d.PositionSizeUSD, effectiveLeverage)
 			}
 		}
 		
+		// 验证单笔仓位价值占净值比例（与杠杆无关，限制单币种集中度）
+		if limits.maxPositionNotionalPct > 0 {
+			maxNotional := limits.accountEquity * limits.maxPositionNotionalPct / 100
+			if d.PositionSizeUSD > maxNotional*1.01 { // 1%容差
+				return fmt.Errorf("%s仓位价值不能超过账户净值的%.0f%%（%.0f USDT），实际: %.0f USDT（%.1f%%账户净值，%dx杠杆）",
+					d.Symbol, limits.maxPositionNotionalPct, maxNotional, d.PositionSizeUSD, d.PositionSizeUSD/limits.accountEquity*100, d.Leverage)
+			}
+		}
+
 		if d.StopLoss <= 0 || d.TakeProfit <= 0 {
 			return fmt.Errorf("止损和止盈必须大于0")
 		}
@@ -1077,12 +1335,31 @@ func validateDecisionWithMarketData(d *Decision, accountEquity float64, btcEthLe
 		}
 
 		// 验证入场价在止损和止盈之间（合理范围）
-		// 注意：不再硬编码风险回报比检查，相信AI会根据提示词自行判断
-		currentPrice, err := getCurrentMarketPrice(d.Symbol)
+		// 注意：风险回报比默认不检查，相信AI会根据提示词自行判断；配置了最低风险回报比时才强制检查
+		marketData, err := getCurrentMarketData(limits.source, d.Symbol)
 		if err != nil {
 			// 如果获取价格失败，拒绝该决策（避免使用不准确的价格进行验证）
 			return fmt.Errorf("获取 %s 当前价格失败: %v，拒绝该决策以确保安全性", d.Symbol, err)
 		}
+		currentPrice := marketData.CurrentPrice
+
+		// 限价开仓：限价必须在当前价的有利一侧（做多低于当前价，做空高于当前价）且偏离不太远，
+		// 以下止损止盈、风险回报比和止损距离的检查都以限价作为入场价
+		if d.IsLimitOrder() {
+			if d.LimitPrice <= 0 {
+				return fmt.Errorf("限价开仓必须提供limit_price")
+			}
+			if d.Action == "open_long" && d.LimitPrice >= currentPrice {
+				return fmt.Errorf("做多限价%.4f必须低于当前价%.4f（否则请使用市价开仓）", d.LimitPrice, currentPrice)
+			}
+			if d.Action == "open_short" && d.LimitPrice <= currentPrice {
+				return fmt.Errorf("做空限价%.4f必须高于当前价%.4f（否则请使用市价开仓）", d.LimitPrice, currentPrice)
+			}
+			if distancePct := math.Abs(d.LimitPrice-currentPrice) / currentPrice * 100; distancePct > MaxLimitPriceDistancePct {
+				return fmt.Errorf("限价%.4f偏离当前价%.4f达%.2f%%，超过%.0f%%上限", d.LimitPrice, currentPrice, distancePct, MaxLimitPriceDistancePct)
+			}
+			currentPrice = d.LimitPrice
+		}
 		
 		// 验证入场价在止损和止盈之间（合理范围）
 		entryPriceValid := false
@@ -1099,13 +1376,57 @@ func validateDecisionWithMarketData(d *Decision, accountEquity float64, btcEthLe
 		}
 		
 		if !entryPriceValid {
-			return fmt.Errorf("当前市场价格%.4f不在止损%.4f和止盈%.4f的合理范围内（%s）",
+			return fmt.Errorf("入场价%.4f不在止损%.4f和止盈%.4f的合理范围内（%s）",
 				currentPrice, d.StopLoss, d.TakeProfit, d.Action)
 		}
+
+		// 阶梯止盈的第一档也必须在盈利方向（最远一档已由上面的检查保证）
+		if len(d.TakeProfitLevels) > 0 {
+			first := d.TakeProfitLevels[0].Price
+			if (d.Action == "open_long" && first <= currentPrice) || (d.Action == "open_short" && first >= currentPrice) {
+				return fmt.Errorf("阶梯止盈第一档%.4f必须在当前价%.4f的盈利方向（%s）", first, currentPrice, d.Action)
+			}
+		}
+
+		// 最低风险回报比（以当前价作为入场价；上面已保证当前价在止损和止盈之间，风险距离大于0）
+		if limits.minRiskReward > 0 {
+			riskReward := math.Abs(d.TakeProfit-currentPrice) / math.Abs(currentPrice-d.StopLoss)
+			if riskReward < limits.minRiskReward {
+				return fmt.Errorf("风险回报比%.2f:1低于最低要求%.2f:1（当前价%.4f，止损%.4f，止盈%.4f）",
+					riskReward, limits.minRiskReward, currentPrice, d.StopLoss, d.TakeProfit)
+			}
+		}
+
+		// 验证止损距离相对波动率（ATR）不能过近，否则正常波动就会触发止损
+		// 倍数为0（默认）时不检查；ATR不可用（K线不足）时跳过该检查
+		if limits.minStopATRMultiple > 0 && marketData.CurrentATR14 > 0 {
+			stopDistance := math.Abs(currentPrice - d.StopLoss)
+			if stopDistance < marketData.CurrentATR14*limits.minStopATRMultiple {
+				side := "long"
+				if d.Action == "open_short" {
+					side = "short"
+				}
+				return fmt.Errorf("止损%.4f距入场价%.4f过近（%.4f，%.2f%%），仅为ATR(%.4f)的%.2f倍，小于最低%.2f倍，建议止损不近于%.4f",
+					d.StopLoss, currentPrice, stopDistance, stopDistance/currentPrice*100, marketData.CurrentATR14,
+					stopDistance/marketData.CurrentATR14, limits.minStopATRMultiple,
+					market.SuggestStopLoss(marketData, side, limits.minStopATRMultiple))
+			}
+		}
+
+		// 单笔风险预算：仓位 × 止损距离% 超过 净值 × 风险比例 时，把仓位下调到预算内
+		if limits.riskPerTradePct > 0 {
+			capPositionSizeByRisk(d, currentPrice, limits.accountEquity, limits.riskPerTradePct)
+		}
 	}
 
 	// 验证update_tp操作
 	if d.Action == "update_tp" {
+		// 阶梯止盈的方向需要持仓方向，这里只检查档位本身（单调性在执行时按持仓方向检查）
+		if len(d.TakeProfitLevels) > 0 {
+			if err := NormalizeTakeProfitLevels(d, ""); err != nil {
+				return err
+			}
+		}
 		if d.TakeProfit <= 0 {
 			return fmt.Errorf("update_tp必须提供有效的take_profit价格: %.4f", d.TakeProfit)
 		}
@@ -1126,22 +1447,124 @@ func validateDecisionWithMarketData(d *Decision, accountEquity float64, btcEthLe
 		}
 	}
 
+	// 验证update_sl_trailing操作
+	if d.Action == "update_sl_trailing" {
+		if d.Symbol == "" {
+			return fmt.Errorf("update_sl_trailing必须提供symbol")
+		}
+		if d.TrailingDistancePct < MinTrailingDistancePct || d.TrailingDistancePct > MaxTrailingDistancePct {
+			return fmt.Errorf("update_sl_trailing的trailing_distance_pct必须在%.1f%%-%.0f%%之间: %.2f%%",
+				MinTrailingDistancePct, MaxTrailingDistancePct, d.TrailingDistancePct)
+		}
+	}
+
+	// 验证cancel_orders操作（持仓是否存在在执行时检查，这里只验证参数）
+	if d.Action == "cancel_orders" && d.Symbol == "" {
+		return fmt.Errorf("cancel_orders必须提供symbol")
+	}
+
+	// 验证平仓比例（部分平仓）
+	if d.Action == "close_long" || d.Action == "close_short" {
+		if d.ClosePercent != 0 && (d.ClosePercent < 1 || d.ClosePercent > 100) {
+			return fmt.Errorf("%s的close_percent必须在1-100之间: %.2f", d.Action, d.ClosePercent)
+		}
+	}
+
+	return nil
+}
+
+// NormalizeTakeProfitLevels 验证阶梯止盈并把take_profit设为最远一档（验证和执行update_tp时使用）
+// side为"long"/"short"时检查价格按盈利方向单调（做多递增、做空递减），为空时只要求单调；
+// 每档比例必须大于0，合计不超过100%
+func NormalizeTakeProfitLevels(d *Decision, side string) error {
+	levels := d.TakeProfitLevels
+	if len(levels) == 0 {
+		return nil
+	}
+	if len(levels) > MaxTakeProfitLevels {
+		return fmt.Errorf("阶梯止盈最多%d档，实际%d档", MaxTakeProfitLevels, len(levels))
+	}
+
+	totalPct := 0.0
+	for i, level := range levels {
+		if level.Price <= 0 {
+			return fmt.Errorf("阶梯止盈第%d档价格必须大于0: %.4f", i+1, level.Price)
+		}
+		if level.Pct <= 0 || level.Pct > 100 {
+			return fmt.Errorf("阶梯止盈第%d档比例必须在0-100之间: %.2f", i+1, level.Pct)
+		}
+		totalPct += level.Pct
+	}
+	if totalPct > 100.01 { // 容差，避免浮点数精度问题
+		return fmt.Errorf("阶梯止盈比例合计不能超过100%%，实际%.2f%%", totalPct)
+	}
+
+	if len(levels) > 1 {
+		increasing := levels[1].Price > levels[0].Price
+		switch {
+		case side == "long" && !increasing:
+			return fmt.Errorf("做多的阶梯止盈价格必须逐档递增")
+		case side == "short" && increasing:
+			return fmt.Errorf("做空的阶梯止盈价格必须逐档递减")
+		}
+		for i := 1; i < len(levels); i++ {
+			if (levels[i].Price > levels[i-1].Price) != increasing || levels[i].Price == levels[i-1].Price {
+				return fmt.Errorf("阶梯止盈价格必须按盈利方向单调排列（第%d档%.4f，第%d档%.4f）",
+					i, levels[i-1].Price, i+1, levels[i].Price)
+			}
+		}
+	}
+
+	d.TakeProfit = levels[len(levels)-1].Price
 	return nil
 }
 
+// FormatTakeProfitLevels 格式化阶梯止盈（如 "105.0000×50% → 110.0000×50%"）
+func FormatTakeProfitLevels(levels []TakeProfitLevel) string {
+	parts := make([]string, 0, len(levels))
+	for _, level := range levels {
+		parts = append(parts, fmt.Sprintf("%.4f×%.0f%%", level.Price, level.Pct))
+	}
+	return strings.Join(parts, " → ")
+}
+
+// capPositionSizeByRisk 按单笔风险预算限制开仓仓位（固定比例风险）
+// 止损处亏损 = 仓位价值 × |入场价-止损价|/入场价，最大仓位 = 净值 × riskPerTradePct% / 止损距离%
+func capPositionSizeByRisk(d *Decision, entryPrice, accountEquity, riskPerTradePct float64) {
+	if entryPrice <= 0 || accountEquity <= 0 {
+		return
+	}
+	stopDistancePct := math.Abs(entryPrice-d.StopLoss) / entryPrice
+	if stopDistancePct <= 0 {
+		return
+	}
+
+	riskBudget := accountEquity * riskPerTradePct / 100
+	impliedRisk := d.PositionSizeUSD * stopDistancePct
+	if impliedRisk <= riskBudget*1.01 { // 1%容差，避免浮点数精度问题
+		return
+	}
+
+	maxPositionSize := riskBudget / stopDistancePct
+	log.Printf("⚖️  %s %s 止损处亏损 %.2f USDT 超过单笔风险预算 %.2f USDT（止损距离%.2f%%），仓位从 %.2f 下调至 %.2f USDT",
+		d.Symbol, d.Action, impliedRisk, riskBudget, stopDistancePct*100, d.PositionSizeUSD, maxPositionSize)
+	d.PositionSizeUSD = maxPositionSize
+	d.RiskUSD = riskBudget
+}
+
 // validateDecision 验证单个决策的有效性（兼容旧接口）
 func validateDecision(d *Decision, accountEquity float64, btcEthLeverage, altcoinLeverage int) error {
-	return validateDecisionWithMarketData(d, accountEquity, btcEthLeverage, altcoinLeverage)
+	return validateDecisionWithMarketData(d, validationLimits{accountEquity: accountEquity, btcEthLeverage: btcEthLeverage, altcoinLeverage: altcoinLeverage})
 }
 
-// getCurrentMarketPrice 获取当前市场价格
-func getCurrentMarketPrice(symbol string) (float64, error) {
-	marketData, err := market.Get(symbol)
+// getCurrentMarketData 获取当前市场数据（价格无效时返回错误，source为nil时使用实盘API）
+func getCurrentMarketData(source market.Source, symbol string) (*market.Data, error) {
+	marketData, err := market.GetFrom(source, symbol, "3m", 1000)
 	if err != nil {
-		return 0, fmt.Errorf("获取市场数据失败: %w", err)
+		return nil, fmt.Errorf("获取市场数据失败: %w", err)
 	}
 	if marketData.CurrentPrice <= 0 {
-		return 0, fmt.Errorf("当前价格无效: %.4f", marketData.CurrentPrice)
+		return nil, fmt.Errorf("当前价格无效: %.4f", marketData.CurrentPrice)
 	}
-	return marketData.CurrentPrice, nil
+	return marketData, nil
 }
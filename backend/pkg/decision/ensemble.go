@@ -0,0 +1,198 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"backend/pkg/logger"
+	"backend/pkg/mcp"
+	"backend/pkg/metrics"
+)
+
+// EnsembleMember 参与多模型投票的AI模型
+type EnsembleMember struct {
+	Model  string      // 模型名称（deepseek/qwen/custom）
+	Client *mcp.Client // 该模型的AI客户端
+}
+
+// ensembleResult 单个模型的决策结果
+type ensembleResult struct {
+	model    string
+	decision *FullDecision
+	err      error
+}
+
+// isVotedAction 需要多模型投票的动作（开平仓），其余动作（调整止盈止损、观望）采用主模型的决策
+func isVotedAction(action string) bool {
+	switch action {
+	case "open_long", "open_short", "close_long", "close_short":
+		return true
+	}
+	return false
+}
+
+// getEnsembleDecision 用多个模型对同一上下文分别决策，开平仓决策只保留达到最少同意票数的（同币种同动作）
+// 调整止盈止损等其他决策采用主模型（第一个成功返回的模型）的决策
+func getEnsembleDecision(ctx *Context, systemPrompt, userPrompt string) (*FullDecision, error) {
+	members := ctx.EnsembleMembers
+	minAgree := ctx.EnsembleMinAgree
+	if minAgree <= 0 || minAgree > len(members) {
+		minAgree = len(members)/2 + 1 // 默认过半数
+	}
+	log.Printf("🗳️  多模型投票决策: %d个模型，开平仓至少需要%d票", len(members), minAgree)
+
+	// 并发调用所有模型（每个模型独立解析和验证）
+	results := make([]ensembleResult, len(members))
+	var wg sync.WaitGroup
+	for i, member := range members {
+		wg.Add(1)
+		go func(i int, member EnsembleMember) {
+			defer wg.Done()
+			results[i] = callEnsembleMember(ctx, member, systemPrompt, userPrompt)
+		}(i, member)
+	}
+	wg.Wait()
+
+	// 记录每个模型的原始输出，并统计开平仓票数（每个模型对同一币种同一动作只计一票）
+	traces := make([]logger.ModelTrace, 0, len(results))
+	votes := make(map[string]int)
+	var succeeded []ensembleResult
+	var firstErr error
+	for _, result := range results {
+		trace := logger.ModelTrace{Model: result.model}
+		if result.decision != nil {
+			trace.CoTTrace = result.decision.CoTTrace
+			if len(result.decision.Decisions) > 0 {
+				decisionJSON, _ := json.MarshalIndent(result.decision.Decisions, "", "  ")
+				trace.DecisionJSON = string(decisionJSON)
+			}
+		}
+		if result.err != nil {
+			trace.Error = result.err.Error()
+			log.Printf("⚠️  [%s] 模型决策失败，不参与投票: %v", result.model, result.err)
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", result.model, result.err)
+			}
+		} else {
+			succeeded = append(succeeded, result)
+			voted := make(map[string]bool)
+			for _, d := range result.decision.Decisions {
+				key := d.Symbol + "_" + d.Action
+				if isVotedAction(d.Action) && !voted[key] {
+					voted[key] = true
+					votes[key]++
+				}
+			}
+		}
+		traces = append(traces, trace)
+	}
+
+	ensemble := &FullDecision{
+		UserPrompt:  userPrompt,
+		CoTTrace:    combineModelTraces(traces),
+		ModelTraces: traces,
+		Timestamp:   time.Now(),
+	}
+	if len(succeeded) == 0 {
+		return ensemble, fmt.Errorf("所有模型决策失败: %w", firstErr)
+	}
+
+	// 开平仓：按模型顺序取第一个提出该决策的模型的参数；其他动作：只采用主模型的决策
+	primary := succeeded[0]
+	added := make(map[string]bool)
+	for _, result := range succeeded {
+		for _, d := range result.decision.Decisions {
+			key := d.Symbol + "_" + d.Action
+			if !isVotedAction(d.Action) {
+				if result.model == primary.model {
+					ensemble.Decisions = append(ensemble.Decisions, d)
+				}
+				continue
+			}
+			if added[key] {
+				continue
+			}
+			added[key] = true
+			if votes[key] >= minAgree {
+				ensemble.Decisions = append(ensemble.Decisions, d)
+				continue
+			}
+			defect := fmt.Sprintf("多模型投票未达成一致（%d/%d票，需要%d票）", votes[key], len(members), minAgree)
+			ensemble.DroppedDecisions = append(ensemble.DroppedDecisions, DroppedDecision{Decision: d, Defect: defect})
+		}
+		// 主模型在宽松验证模式下丢弃的无效决策也保留记录
+		if result.model == primary.model {
+			ensemble.DroppedDecisions = append(ensemble.DroppedDecisions, result.decision.DroppedDecisions...)
+		}
+	}
+
+	ensemble.Decisions = dropConflictingOpens(ensemble)
+	log.Printf("🗳️  多模型投票完成: %d/%d个模型成功，保留%d个决策，丢弃%d个未达成一致的决策",
+		len(succeeded), len(members), len(ensemble.Decisions), len(ensemble.DroppedDecisions))
+	return ensemble, nil
+}
+
+// callEnsembleMember 调用单个模型并解析决策
+func callEnsembleMember(ctx *Context, member EnsembleMember, systemPrompt, userPrompt string) ensembleResult {
+	callStart := time.Now()
+	call := member.Client.CallWithMessages
+	if ctx.StreamResponses {
+		call = member.Client.CallWithMessagesStream
+	}
+	aiResponse, err := call(systemPrompt, userPrompt)
+	if err != nil {
+		metrics.ObserveAICall(ctx.TraderID, time.Since(callStart), err)
+		return ensembleResult{model: member.Model, err: fmt.Errorf("调用AI API失败: %w", err)}
+	}
+	aiLatency := time.Since(callStart)
+
+	decision, err := parseFullDecisionResponse(aiResponse, ctx.validationLimits(), ctx.ValidationMode == "lenient")
+	metrics.ObserveAICall(ctx.TraderID, aiLatency, err)
+	if err != nil {
+		return ensembleResult{model: member.Model, decision: decision, err: fmt.Errorf("解析AI响应失败: %w", err)}
+	}
+	return ensembleResult{model: member.Model, decision: decision}
+}
+
+// dropConflictingOpens 同一币种同时通过了开多和开空（最少同意票数配置得较低时可能出现），两个都丢弃
+func dropConflictingOpens(ensemble *FullDecision) []Decision {
+	openSides := make(map[string]map[string]bool)
+	for _, d := range ensemble.Decisions {
+		if d.Action == "open_long" || d.Action == "open_short" {
+			if openSides[d.Symbol] == nil {
+				openSides[d.Symbol] = make(map[string]bool)
+			}
+			openSides[d.Symbol][d.Action] = true
+		}
+	}
+
+	kept := make([]Decision, 0, len(ensemble.Decisions))
+	for _, d := range ensemble.Decisions {
+		if (d.Action == "open_long" || d.Action == "open_short") && len(openSides[d.Symbol]) > 1 {
+			ensemble.DroppedDecisions = append(ensemble.DroppedDecisions, DroppedDecision{Decision: d, Defect: "多模型投票结果冲突（同一币种同时开多和开空）"})
+			continue
+		}
+		kept = append(kept, d)
+	}
+	return kept
+}
+
+// combineModelTraces 合并各模型的思维链（用于决策记录的cot_trace字段）
+func combineModelTraces(traces []logger.ModelTrace) string {
+	var sb strings.Builder
+	for i, trace := range traces {
+		if i > 0 {
+			sb.WriteString("\n\n")
+		}
+		sb.WriteString(fmt.Sprintf("=== %s ===\n", trace.Model))
+		if trace.Error != "" {
+			sb.WriteString(fmt.Sprintf("❌ 决策失败: %s\n", trace.Error))
+		}
+		sb.WriteString(trace.CoTTrace)
+	}
+	return sb.String()
+}
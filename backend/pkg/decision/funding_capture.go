@@ -0,0 +1,34 @@
+package decision
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// formatFundingOpportunities 格式化资金费率捕获机会（资金费率捕获模式下加入prompt）
+func formatFundingOpportunities(opportunities []FundingOpportunity) string {
+	var sb strings.Builder
+	sb.WriteString("## 💰 资金费率捕获机会\n\n")
+	sb.WriteString("以下币种资金费率绝对值较高且即将结算，结算时刻持有收取方向的仓位可获得资金费收入（仓位价值 × 资金费率）：\n\n")
+
+	for i, opp := range opportunities {
+		receiver := "空头收取（open_short）"
+		if opp.FundingRate < 0 {
+			receiver = "多头收取（open_long）"
+		}
+		minutes := time.Until(time.UnixMilli(opp.NextFundingTime)).Minutes()
+		if minutes < 0 {
+			minutes = 0
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s | 资金费率 %+.4f%% | %s | 距结算 %.0f 分钟（%s）\n",
+			i+1, opp.Symbol, opp.FundingRate*100, receiver, minutes,
+			time.UnixMilli(opp.NextFundingTime).Format("15:04")))
+	}
+
+	sb.WriteString("\n**资金费率捕获规则**:\n")
+	sb.WriteString("- 只在结算前入场，且技术面不明显反向时才开仓（资金费收入通常小于一次不利波动，仓位不宜过大）\n")
+	sb.WriteString("- 仍需设置止损；exit_reasoning中写明\"资金费率捕获，XX:XX结算后平仓\"\n")
+	sb.WriteString("- 结算后（资金费已到账）在下一个周期平仓，除非有独立的趋势理由继续持有\n\n")
+	return sb.String()
+}
@@ -0,0 +1,286 @@
+package decision
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxErrorSnippetLen 错误信息中原始JSON片段的最大长度
+const maxErrorSnippetLen = 2000
+
+// jsonCandidate AI响应中一个可能是决策列表的JSON片段
+type jsonCandidate struct {
+	start int    // 在响应中的起始位置
+	text  string // 原始片段（未修复）
+}
+
+// extractCoTTrace 提取思维链分析（决策JSON之前的内容）
+// jsonStart为决策JSON在响应中的起始位置（-1表示没有找到JSON，整个响应都是思维链）
+func extractCoTTrace(response string, jsonStart int) string {
+	if jsonStart < 0 || jsonStart > len(response) {
+		return strings.TrimSpace(response)
+	}
+
+	cotTrace := strings.TrimSpace(response[:jsonStart])
+	// 去掉JSON代码块的开头标记（```json）
+	for _, fence := range []string{"```json", "```JSON", "```"} {
+		if strings.HasSuffix(cotTrace, fence) {
+			cotTrace = strings.TrimSpace(strings.TrimSuffix(cotTrace, fence))
+			break
+		}
+	}
+	return cotTrace
+}
+
+// extractDecisions 从AI响应中提取JSON决策列表，返回决策和JSON在响应中的起始位置
+// 响应中可能有多个JSON数组（思维链中的示例、代码块中的最终决策等），取最后一个能解析成决策列表的数组；
+// 没有数组时接受单个决策对象。每个片段依次尝试：原样解析 → 修复常见格式错误 → 再替换中文引号
+func extractDecisions(response string) ([]Decision, int, error) {
+	candidates := findJSONCandidates(response, '[', ']')
+	if len(candidates) == 0 {
+		// 有 [ 但没有完整的数组：响应可能被截断，不能只执行其中一部分决策
+		if start := strings.Index(response, "["); start >= 0 {
+			return nil, -1, fmt.Errorf("无法找到JSON数组结束（响应可能被截断）\nJSON内容: %s", truncateSnippet(response[start:]))
+		}
+		// 完全没有数组时，尝试单个决策对象（如 {"symbol": ..., "action": ...}）
+		candidates = findJSONCandidates(response, '{', '}')
+		if len(candidates) == 0 {
+			return nil, -1, fmt.Errorf("无法找到JSON数组起始")
+		}
+	}
+
+	var lastErr error
+	lastRaw := ""
+	for i := len(candidates) - 1; i >= 0; i-- {
+		candidate := candidates[i]
+		decisions, err := parseDecisionCandidate(candidate.text)
+		if err == nil {
+			return decisions, candidate.start, nil
+		}
+		// 错误信息使用最后一个片段（通常是AI的最终决策）
+		if lastErr == nil {
+			lastErr = err
+			lastRaw = candidate.text
+		}
+	}
+
+	return nil, -1, fmt.Errorf("JSON解析失败: %w\nJSON内容: %s", lastErr, truncateSnippet(lastRaw))
+}
+
+// parseDecisionCandidate 解析单个JSON片段，依次尝试原样解析、修复格式错误、替换中文引号
+func parseDecisionCandidate(raw string) ([]Decision, error) {
+	decisions, firstErr := parseDecisionJSON(raw)
+	if firstErr == nil {
+		return decisions, nil
+	}
+
+	repaired := repairJSON(raw)
+	if repaired != raw {
+		if decisions, err := parseDecisionJSON(repaired); err == nil {
+			return decisions, nil
+		}
+	}
+
+	// 中文引号可能是字符串内容，也可能是被输入法替换的分隔符，只在前面都失败时替换
+	if quoted := fixMissingQuotes(raw); quoted != raw {
+		if decisions, err := parseDecisionJSON(repairJSON(quoted)); err == nil {
+			return decisions, nil
+		}
+	}
+
+	return nil, firstErr
+}
+
+// parseDecisionJSON 把JSON解析为决策列表（数组元素必须是对象；单个对象视为只有一个决策）
+func parseDecisionJSON(content string) ([]Decision, error) {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "{") {
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal([]byte(content), &fields); err != nil {
+			return nil, err
+		}
+		if _, ok := fields["action"]; !ok {
+			return nil, fmt.Errorf("JSON对象不是决策（缺少action字段）")
+		}
+		content = "[" + content + "]"
+	}
+
+	// 先确认是对象数组，排除思维链中的 ["BTCUSDT"]、[1, 2] 等非决策数组
+	var objects []map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &objects); err != nil {
+		return nil, err
+	}
+
+	var decisions []Decision
+	if err := json.Unmarshal([]byte(content), &decisions); err != nil {
+		return nil, err
+	}
+	return decisions, nil
+}
+
+// findJSONCandidates 查找响应中所有顶层的括号片段（按出现顺序，括号匹配时跳过JSON字符串内的括号）
+// 代码块（```json ... ```）内的数组也会被找到，代码块标记本身不影响匹配
+func findJSONCandidates(response string, open, close byte) []jsonCandidate {
+	var candidates []jsonCandidate
+	for i := 0; i < len(response); i++ {
+		if response[i] != open {
+			continue
+		}
+		end := findMatchingBracket(response, i, open, close)
+		if end == -1 {
+			continue
+		}
+		candidates = append(candidates, jsonCandidate{start: i, text: response[i : end+1]})
+		i = end
+	}
+	return candidates
+}
+
+// findMatchingBracket 查找匹配的右括号（跳过JSON字符串内的括号和转义字符），找不到时返回-1
+func findMatchingBracket(s string, start int, open, close byte) int {
+	if start >= len(s) || s[start] != open {
+		return -1
+	}
+
+	depth := 0
+	inString := false
+	for i := start; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++ // 跳过转义字符
+			case '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+
+	return -1
+}
+
+// repairJSON 修复AI常见的JSON格式错误：
+//   - 尾随逗号：[{...},] 或 {"a": 1,}
+//   - 注释：// 行注释和 /* 块注释 */
+//   - 字符串内未转义的换行符和制表符
+//   - 字符串外的全角逗号和冒号
+func repairJSON(content string) string {
+	var b strings.Builder
+	b.Grow(len(content))
+
+	inString := false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+
+		if inString {
+			switch c {
+			case '\\':
+				b.WriteByte(c)
+				if i+1 < len(content) {
+					i++
+					b.WriteByte(content[i])
+				}
+			case '"':
+				inString = false
+				b.WriteByte(c)
+			case '\n':
+				b.WriteString(`\n`)
+			case '\r':
+				b.WriteString(`\r`)
+			case '\t':
+				b.WriteString(`\t`)
+			default:
+				b.WriteByte(c)
+			}
+			continue
+		}
+
+		switch {
+		case c == '"':
+			inString = true
+			b.WriteByte(c)
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			// 行注释：跳到行尾
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			if i < len(content) {
+				b.WriteByte('\n')
+			}
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			// 块注释：跳到 */
+			end := strings.Index(content[i+2:], "*/")
+			if end == -1 {
+				i = len(content)
+			} else {
+				i += 2 + end + 1
+			}
+		case c == ',' && (nextNonSpace(content, i+1) == ']' || nextNonSpace(content, i+1) == '}'):
+			// 尾随逗号：丢弃
+		case strings.HasPrefix(content[i:], "，"):
+			b.WriteByte(',')
+			i += len("，") - 1
+		case strings.HasPrefix(content[i:], "："):
+			b.WriteByte(':')
+			i += len("：") - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	// 去掉注释或转换全角逗号后可能产生新的尾随逗号，有修改时再处理一遍
+	repaired := b.String()
+	if repaired != content {
+		return repairJSON(repaired)
+	}
+	return repaired
+}
+
+// nextNonSpace 返回从start开始的第一个非空白字符（没有时返回0）
+func nextNonSpace(s string, start int) byte {
+	for i := start; i < len(s); i++ {
+		switch s[i] {
+		case ' ', '\t', '\n', '\r':
+			continue
+		default:
+			return s[i]
+		}
+	}
+	return 0
+}
+
+// fixMissingQuotes 替换中文引号为英文引号（避免输入法自动转换）
+func fixMissingQuotes(jsonStr string) string {
+	jsonStr = strings.ReplaceAll(jsonStr, "\u201c", "\"") // "
+	jsonStr = strings.ReplaceAll(jsonStr, "\u201d", "\"") // "
+	jsonStr = strings.ReplaceAll(jsonStr, "\u2018", "'")  // '
+	jsonStr = strings.ReplaceAll(jsonStr, "\u2019", "'")  // '
+	return jsonStr
+}
+
+// truncateSnippet 截断错误信息中的原始片段（避免日志过长）
+func truncateSnippet(s string) string {
+	if len(s) <= maxErrorSnippetLen {
+		return s
+	}
+	end := maxErrorSnippetLen
+	for end > 0 && !utf8.RuneStart(s[end]) {
+		end--
+	}
+	return s[:end] + "...(已截断)"
+}
@@ -0,0 +1,198 @@
+package decision
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestExtractDecisions(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		wantSymbols []string
+		wantActions []string
+		wantErr     bool
+	}{
+		{
+			name:        "plain array",
+			response:    `[{"symbol":"BTCUSDT","action":"hold","reasoning":"震荡"}]`,
+			wantSymbols: []string{"BTCUSDT"},
+			wantActions: []string{"hold"},
+		},
+		{
+			name:        "fenced code block after chain of thought",
+			response:    "分析：BTC趋势向上。\n```json\n[{\"symbol\":\"BTCUSDT\",\"action\":\"open_long\",\"leverage\":5}]\n```",
+			wantSymbols: []string{"BTCUSDT"},
+			wantActions: []string{"open_long"},
+		},
+		{
+			name:        "trailing commas",
+			response:    `[{"symbol":"ETHUSDT","action":"close_short",},]`,
+			wantSymbols: []string{"ETHUSDT"},
+			wantActions: []string{"close_short"},
+		},
+		{
+			name:        "line and block comments",
+			response:    "[\n  // 主要决策\n  {\"symbol\":\"SOLUSDT\", /* 平仓 */ \"action\":\"close_long\"}\n]",
+			wantSymbols: []string{"SOLUSDT"},
+			wantActions: []string{"close_long"},
+		},
+		{
+			name:        "unescaped newline inside string",
+			response:    "[{\"symbol\":\"BTCUSDT\",\"action\":\"hold\",\"reasoning\":\"第一行\n第二行\"}]",
+			wantSymbols: []string{"BTCUSDT"},
+			wantActions: []string{"hold"},
+		},
+		{
+			name:        "full-width comma and colon",
+			response:    `[{"symbol"："BTCUSDT"，"action"："wait"}]`,
+			wantSymbols: []string{"BTCUSDT"},
+			wantActions: []string{"wait"},
+		},
+		{
+			name:        "chinese quotes",
+			response:    "[{\u201csymbol\u201d:\u201cBTCUSDT\u201d,\u201caction\u201d:\u201chold\u201d}]",
+			wantSymbols: []string{"BTCUSDT"},
+			wantActions: []string{"hold"},
+		},
+		{
+			name:        "brackets inside strings",
+			response:    `[{"symbol":"BTCUSDT","action":"hold","reasoning":"区间[60000, 62000]内震荡}"}]`,
+			wantSymbols: []string{"BTCUSDT"},
+			wantActions: []string{"hold"},
+		},
+		{
+			name: "multiple arrays uses the last decision array",
+			response: "示例格式：[{\"symbol\":\"XXXUSDT\",\"action\":\"wait\"}]\n" +
+				"最终决策：\n```json\n[{\"symbol\":\"BTCUSDT\",\"action\":\"open_short\"},{\"symbol\":\"ETHUSDT\",\"action\":\"hold\"}]\n```",
+			wantSymbols: []string{"BTCUSDT", "ETHUSDT"},
+			wantActions: []string{"open_short", "hold"},
+		},
+		{
+			name:        "non-decision arrays after the decisions are skipped",
+			response:    "[{\"symbol\":\"BTCUSDT\",\"action\":\"hold\"}]\n关注币种：[\"BTCUSDT\", \"ETHUSDT\"]，周期 [1, 4]",
+			wantSymbols: []string{"BTCUSDT"},
+			wantActions: []string{"hold"},
+		},
+		{
+			name:        "single decision object without array",
+			response:    `决策：{"symbol":"BTCUSDT","action":"close_long","reasoning":"止盈"}`,
+			wantSymbols: []string{"BTCUSDT"},
+			wantActions: []string{"close_long"},
+		},
+		{
+			name:        "empty array",
+			response:    "无需操作\n[]",
+			wantSymbols: []string{},
+			wantActions: []string{},
+		},
+		{
+			name:     "truncated array",
+			response: `[{"symbol":"BTCUSDT","action":"open_long"},{"symbol":"ETHUS`,
+			wantErr:  true,
+		},
+		{
+			name:     "object without action",
+			response: `{"symbol":"BTCUSDT"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "no json at all",
+			response: "市场不明朗，继续观望。",
+			wantErr:  true,
+		},
+		{
+			name:     "unrepairable json",
+			response: `[{"symbol": BTCUSDT, "action": hold}]`,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decisions, start, err := extractDecisions(tt.response)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("extractDecisions() = %+v, expected error", decisions)
+				}
+				if start != -1 {
+					t.Errorf("start = %d, want -1 on error", start)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("extractDecisions() error = %v", err)
+			}
+			if start < 0 || start >= len(tt.response) {
+				t.Fatalf("start = %d out of range", start)
+			}
+			if len(decisions) != len(tt.wantSymbols) {
+				t.Fatalf("got %d decisions, want %d: %+v", len(decisions), len(tt.wantSymbols), decisions)
+			}
+			for i, d := range decisions {
+				if d.Symbol != tt.wantSymbols[i] || d.Action != tt.wantActions[i] {
+					t.Errorf("decision[%d] = %s/%s, want %s/%s", i, d.Symbol, d.Action, tt.wantSymbols[i], tt.wantActions[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractDecisionsKeepsReasoningText(t *testing.T) {
+	response := "[{\"symbol\":\"BTCUSDT\",\"action\":\"hold\",\"reasoning\":\"第一行\n第二行\"}]"
+	decisions, _, err := extractDecisions(response)
+	if err != nil {
+		t.Fatalf("extractDecisions() error = %v", err)
+	}
+	if decisions[0].Reasoning != "第一行\n第二行" {
+		t.Errorf("Reasoning = %q", decisions[0].Reasoning)
+	}
+}
+
+func TestExtractCoTTrace(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     string
+	}{
+		{"fenced json", "思考过程\n```json\n[]\n```", "思考过程"},
+		{"plain fence", "思考过程\n```\n[]\n```", "思考过程"},
+		{"no fence", "思考过程\n[]", "思考过程"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, start, err := extractDecisions(tt.response)
+			if err != nil {
+				t.Fatalf("extractDecisions() error = %v", err)
+			}
+			if got := extractCoTTrace(tt.response, start); got != tt.want {
+				t.Errorf("extractCoTTrace() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if got := extractCoTTrace("  只有思维链  ", -1); got != "只有思维链" {
+		t.Errorf("extractCoTTrace() without JSON = %q", got)
+	}
+}
+
+func TestTruncateSnippet(t *testing.T) {
+	short := "短内容"
+	if got := truncateSnippet(short); got != short {
+		t.Errorf("truncateSnippet(short) = %q", got)
+	}
+
+	long := strings.Repeat("决", maxErrorSnippetLen)
+	got := truncateSnippet(long)
+	if !strings.HasSuffix(got, "...(已截断)") {
+		t.Errorf("truncated snippet should end with marker")
+	}
+	if !utf8.ValidString(got) {
+		t.Errorf("truncated snippet is not valid UTF-8")
+	}
+	if len(got) > maxErrorSnippetLen+len("...(已截断)") {
+		t.Errorf("truncated snippet too long: %d bytes", len(got))
+	}
+}
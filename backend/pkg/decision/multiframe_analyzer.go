@@ -15,6 +15,7 @@ import (
 type MultiTimeframeAnalyzer struct {
 	config *config.MultiTimeframeConfig
 	cache  *TimeframeDataCache
+	source market.Source // 市场数据来源（nil表示实盘API）
 }
 
 // NewMultiTimeframeAnalyzer 创建多时间框架分析器
@@ -38,6 +39,8 @@ type UnifiedTimeframeData struct {
 	Hourly1Data  *market.Data // 1小时数据
 	Minute15Data *market.Data // 15分钟数据
 	Minute3Data  *market.Data // 3分钟数据
+
+	InsufficientTimeframes []string // K线数量不足的时间框架（如 "4h(20根)"），为空表示历史充足
 }
 
 // SymbolScore 币种评分（支持多空双向）
@@ -97,6 +100,9 @@ func (mta *MultiTimeframeAnalyzer) Analyze(ctx *Context) (*MultiTimeframeAnalysi
 	// 2. 统一获取所有时间框架数据（避免重复）
 	dataMap := mta.fetchAllTimeframesUnified(symbolSet)
 	
+	// 2.5. 检测K线历史不足的币种（新上市币种），按配置标注或排除
+	mta.handleInsufficientHistory(dataMap, ctx)
+	
 	// 3. 计算每个币种的评分（支持多空双向）
 	scores := mta.calculateDirectionalScores(dataMap)
 	
@@ -146,7 +152,7 @@ func (mta *MultiTimeframeAnalyzer) fetchAllTimeframesUnified(symbolSet map[strin
 			
 			data := &UnifiedTimeframeData{Symbol: s}
 			
-			// 并发获取5个时间框架
+			// 并发获取5个时间框架（K线数量按配置，默认1000根，确保指标成熟）
 			type result struct {
 				name string
 				data *market.Data
@@ -157,23 +163,23 @@ func (mta *MultiTimeframeAnalyzer) fetchAllTimeframesUnified(symbolSet map[strin
 			
 			// 并发获取
 			go func() {
-				data, err := mta.fetchTimeframeData(s, "1d", 1000) // 日线：1000根，确保指标成熟
+				data, err := mta.fetchTimeframeData(s, "1d", mta.config.CandleLimits.ForTimeframe("1d"))
 				results <- result{"1d", data, err}
 			}()
 			go func() {
-				data, err := mta.fetchTimeframeData(s, "4h", 1000) // 4小时：1000根，确保指标成熟
+				data, err := mta.fetchTimeframeData(s, "4h", mta.config.CandleLimits.ForTimeframe("4h"))
 				results <- result{"4h", data, err}
 			}()
 			go func() {
-				data, err := mta.fetchTimeframeData(s, "1h", 1000) // 1小时：1000根，确保指标成熟
+				data, err := mta.fetchTimeframeData(s, "1h", mta.config.CandleLimits.ForTimeframe("1h"))
 				results <- result{"1h", data, err}
 			}()
 			go func() {
-				data, err := mta.fetchTimeframeData(s, "15m", 1000) // 15分钟：1000根，确保指标成熟
+				data, err := mta.fetchTimeframeData(s, "15m", mta.config.CandleLimits.ForTimeframe("15m"))
 				results <- result{"15m", data, err}
 			}()
 			go func() {
-				data, err := mta.fetchTimeframeData(s, "3m", 1000) // 3分钟：1000根，确保指标成熟
+				data, err := mta.fetchTimeframeData(s, "3m", mta.config.CandleLimits.ForTimeframe("3m"))
 				results <- result{"3m", data, err}
 			}()
 			
@@ -220,6 +226,49 @@ func (mta *MultiTimeframeAnalyzer) fetchAllTimeframesUnified(symbolSet map[strin
 	return dataMap
 }
 
+// handleInsufficientHistory 检测K线历史不足的币种
+// 只检查发送给AI的时间框架（4h、1h、15m）；持仓币种始终保留并标注，
+// 候选币种在mode="exclude"时从分析结果中排除
+func (mta *MultiTimeframeAnalyzer) handleInsufficientHistory(dataMap map[string]*UnifiedTimeframeData, ctx *Context) {
+	if ctx.MinKlineBars <= 0 {
+		return
+	}
+	
+	positionSymbols := make(map[string]bool)
+	for _, pos := range ctx.Positions {
+		positionSymbols[pos.Symbol] = true
+	}
+	
+	for symbol, data := range dataMap {
+		timeframes := []struct {
+			name string
+			data *market.Data
+		}{
+			{"4h", data.Hourly4Data},
+			{"1h", data.Hourly1Data},
+			{"15m", data.Minute15Data},
+		}
+		
+		data.InsufficientTimeframes = nil
+		for _, tf := range timeframes {
+			if tf.data != nil && tf.data.KlineCount < ctx.MinKlineBars {
+				data.InsufficientTimeframes = append(data.InsufficientTimeframes, fmt.Sprintf("%s(%d根)", tf.name, tf.data.KlineCount))
+			}
+		}
+		
+		if len(data.InsufficientTimeframes) == 0 {
+			continue
+		}
+		
+		if ctx.InsufficientHistoryMode == "exclude" && !positionSymbols[symbol] {
+			log.Printf("⚠️  %s K线历史不足（%v < %d根），已从候选币种中排除", symbol, data.InsufficientTimeframes, ctx.MinKlineBars)
+			delete(dataMap, symbol)
+			continue
+		}
+		log.Printf("⚠️  %s K线历史不足（%v < %d根），将在prompt中标注指标不可靠", symbol, data.InsufficientTimeframes, ctx.MinKlineBars)
+	}
+}
+
 // fetchTimeframeData 获取指定时间框架的数据（支持缓存）
 func (mta *MultiTimeframeAnalyzer) fetchTimeframeData(symbol, timeframe string, limit int) (*market.Data, error) {
 	if mta.cache != nil {
@@ -228,7 +277,7 @@ func (mta *MultiTimeframeAnalyzer) fetchTimeframeData(symbol, timeframe string,
 		}
 	}
 	
-	data, err := market.GetWithTimeframe(symbol, timeframe, limit)
+	data, err := market.GetFrom(mta.source, symbol, timeframe, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -342,6 +391,12 @@ func (mta *MultiTimeframeAnalyzer) calculateScoreForDirection(data *UnifiedTimef
 	return detail
 }
 
+// OBV斜率确认方向的阈值和加分（斜率单位：每根K线净流入的平均成交量倍数）
+const (
+	obvConfirmSlope = 0.1
+	obvConfirmBonus = 0.05
+)
+
 // calculateSingleTimeframeScore 计算单个时间框架的评分（支持多空方向）
 func (mta *MultiTimeframeAnalyzer) calculateSingleTimeframeScore(data *market.Data, direction string) float64 {
 	if data == nil {
@@ -429,6 +484,11 @@ func (mta *MultiTimeframeAnalyzer) calculateSingleTimeframeScore(data *market.Da
 	
 	score = score / float64(count)
 	
+	// 4. 成交量确认：OBV斜率与方向一致时小幅加分（量价配合），不参与平均
+	if (direction == "long" && data.OBVSlope > obvConfirmSlope) || (direction != "long" && data.OBVSlope < -obvConfirmSlope) {
+		score += obvConfirmBonus
+	}
+	
 	// 限制在0-1范围内
 	if score < 0 {
 		score = 0
@@ -470,9 +530,10 @@ func (mta *MultiTimeframeAnalyzer) calculateMultiDimensionalConsistency(data *Un
 	// 3. 波动一致性（RSI位置）
 	volatilityConsistency := mta.calculateVolatilityConsistency(timeframes)
 	
-	// 重新设计的权重：动量一致性最重要（0.45），趋势一致性次之（0.35），波动一致性补充（0.2）
+	// 权重可在score_weights中配置，默认：动量一致性最重要（0.45），趋势一致性次之（0.35），波动一致性补充（0.2）
 	// 去除日线后，动量更能反映短期多时间框架的一致性
-	consistency := trendConsistency*0.35 + momentumConsistency*0.45 + volatilityConsistency*0.2
+	weights := mta.config.ScoreWeights.WithDefaults()
+	consistency := trendConsistency*weights.Trend + momentumConsistency*weights.Momentum + volatilityConsistency*weights.Volatility
 	
 	return consistency
 }
@@ -608,10 +669,11 @@ func (mta *MultiTimeframeAnalyzer) sortSymbolsByScore(scores map[string]*SymbolS
 		score  float64
 	}
 	
+	// 结合总体评分和一致性评分（权重可在score_weights中配置，默认0.7/0.3）
+	weights := mta.config.ScoreWeights.WithDefaults()
 	scoredList := make([]scoredSymbol, 0, len(scores))
 	for symbol, score := range scores {
-		// 结合总体评分和一致性评分
-		combinedScore := score.TotalScore*0.7 + score.ConsistencyScore*0.3
+		combinedScore := score.TotalScore*weights.Total + score.ConsistencyScore*weights.Consistency
 		scoredList = append(scoredList, scoredSymbol{symbol: symbol, score: combinedScore})
 	}
 	
@@ -744,14 +806,23 @@ func (mta *MultiTimeframeAnalyzer) calculatePullbackEntryBonus(data *UnifiedTime
 	return bonus
 }
 
+// MinTrendADX 大周期判定为有效趋势所需的最小ADX（低于此值视为震荡，不参与趋势判断）
+const MinTrendADX = 20.0
+
+// hasTrendStrength 检查时间框架的ADX是否足以确认趋势（ADX为0表示K线不足无法计算，此时跳过ADX门槛）
+func hasTrendStrength(data *market.Data) bool {
+	return data.CurrentADX == 0 || data.CurrentADX > MinTrendADX
+}
+
 // detectMajorTrend 检测大周期趋势方向（日线 + 4小时）
+// 只有ADX > MinTrendADX 的时间框架才计入趋势，避免在震荡行情中触发回调入场
 // 返回：方向（"long"/"short"/"neutral"）+ 趋势强度（0-1）
 func (mta *MultiTimeframeAnalyzer) detectMajorTrend(data *UnifiedTimeframeData) (string, float64) {
 	var bullishCount, bearishCount int
 	var totalStrength float64
 	
 	// 检查日线
-	if data.DailyData != nil && data.DailyData.CurrentEMA20 > 0 && data.DailyData.CurrentPrice > 0 {
+	if data.DailyData != nil && data.DailyData.CurrentEMA20 > 0 && data.DailyData.CurrentPrice > 0 && hasTrendStrength(data.DailyData) {
 		priceAboveEMA := data.DailyData.CurrentPrice > data.DailyData.CurrentEMA20
 		macdPositive := data.DailyData.CurrentMACD > 0
 		
@@ -765,7 +836,7 @@ func (mta *MultiTimeframeAnalyzer) detectMajorTrend(data *UnifiedTimeframeData)
 	}
 	
 	// 检查4小时
-	if data.Hourly4Data != nil && data.Hourly4Data.CurrentEMA20 > 0 && data.Hourly4Data.CurrentPrice > 0 {
+	if data.Hourly4Data != nil && data.Hourly4Data.CurrentEMA20 > 0 && data.Hourly4Data.CurrentPrice > 0 && hasTrendStrength(data.Hourly4Data) {
 		priceAboveEMA := data.Hourly4Data.CurrentPrice > data.Hourly4Data.CurrentEMA20
 		macdPositive := data.Hourly4Data.CurrentMACD > 0
 		
@@ -780,9 +851,15 @@ func (mta *MultiTimeframeAnalyzer) detectMajorTrend(data *UnifiedTimeframeData)
 	
 	// 判断趋势方向
 	if bullishCount > bearishCount && bullishCount >= 1 {
+		if !mta.onEMA200Side(data.DailyData, "long") {
+			return "neutral", 0
+		}
 		strength := totalStrength / float64(bullishCount+bearishCount)
 		return "long", strength
 	} else if bearishCount > bullishCount && bearishCount >= 1 {
+		if !mta.onEMA200Side(data.DailyData, "short") {
+			return "neutral", 0
+		}
 		strength := totalStrength / float64(bullishCount+bearishCount)
 		return "short", strength
 	}
@@ -790,6 +867,18 @@ func (mta *MultiTimeframeAnalyzer) detectMajorTrend(data *UnifiedTimeframeData)
 	return "neutral", 0
 }
 
+// onEMA200Side 检查价格是否位于日线EMA200的趋势同侧（做多在上方，做空在下方）
+// 未启用require_ema200_trend或日线K线不足200根（EMA200为0）时不检查，返回true
+func (mta *MultiTimeframeAnalyzer) onEMA200Side(daily *market.Data, direction string) bool {
+	if !mta.config.PullbackEntry.RequireEMA200Trend || daily == nil || daily.CurrentEMA200 <= 0 {
+		return true
+	}
+	if direction == "long" {
+		return daily.CurrentPrice > daily.CurrentEMA200
+	}
+	return daily.CurrentPrice < daily.CurrentEMA200
+}
+
 // detectSmallTimeframePullback 检测小周期是否回调（1小时 + 15分钟）
 // 返回：是否回调 + 回调强度（0-1）
 func (mta *MultiTimeframeAnalyzer) detectSmallTimeframePullback(data *UnifiedTimeframeData, majorTrend string) (bool, float64) {
@@ -901,6 +990,16 @@ func (mta *MultiTimeframeAnalyzer) checkReversalSignalForTimeframe(data *market.
 		return false, 0
 	}
 	
+	// 可选：要求StochRSI确认反转（做多需从超卖区金叉，做空需从超买区死叉；K线不足时视为未确认）
+	if mta.config.PullbackEntry.RequireStochRSICross {
+		if majorTrend == "long" && !market.StochRSICrossUp(data) {
+			return false, 0
+		}
+		if majorTrend == "short" && !market.StochRSICrossDown(data) {
+			return false, 0
+		}
+	}
+	
 	var signalCount int
 	var totalStrength float64
 	
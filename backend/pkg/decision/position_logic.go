@@ -18,6 +18,9 @@ type PositionLogic struct {
 	ExitLogic  *ExitLogic  `json:"exit_logic"`  // 出场逻辑
 	StopLoss   float64     `json:"stop_loss,omitempty"`   // 当前设置的止损价格（与逻辑一起持久化）
 	TakeProfit float64     `json:"take_profit,omitempty"` // 当前设置的止盈价格（与逻辑一起持久化）
+	TrailingDistancePct float64 `json:"trailing_distance_pct,omitempty"` // 移动止损距离百分比（0表示未启用）
+	TrailingBestPrice   float64 `json:"trailing_best_price,omitempty"`   // 移动止损跟踪的最优价格（做多为最高价，做空为最低价）
+	TakeProfitLevels    []TakeProfitLevel `json:"take_profit_levels,omitempty"` // 阶梯止盈（为空表示单一止盈，设置时TakeProfit为最远一档）
 }
 
 // EntryLogic 进场逻辑
@@ -0,0 +1,132 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// 关键事件（开仓、平仓、强制平仓、风控触发）的结构化日志
+// 控制台始终输出原有的人类可读日志；启用JSON格式后，同一事件另以JSON行输出（带trader_id、cycle、symbol、action字段），便于ELK等日志系统采集
+
+// eventLogger 全局事件日志（默认只输出到控制台）
+var eventLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	eventLogger.Store(slog.New(consoleHandler{}))
+}
+
+// SetupEventLog 配置事件日志格式
+// format为"json"时，关键事件额外以JSON行写入path（为空时写入标准输出，与写入标准错误的控制台日志分开）
+func SetupEventLog(format, path string) error {
+	if format != "json" {
+		eventLogger.Store(slog.New(consoleHandler{}))
+		return nil
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "" {
+		if dir := filepath.Dir(path); dir != "." {
+			if err := os.MkdirAll(dir, 0755); err != nil {
+				return fmt.Errorf("创建日志目录失败: %w", err)
+			}
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("打开JSON日志文件失败: %w", err)
+		}
+		w = f
+	}
+
+	eventLogger.Store(slog.New(fanoutHandler{consoleHandler{}, slog.NewJSONHandler(w, nil)}))
+	return nil
+}
+
+// Logger 单个trader的事件日志记录器（每条事件自动带上trader_id）
+type Logger struct {
+	traderID string
+}
+
+// NewLogger 创建事件日志记录器
+func NewLogger(traderID string) *Logger {
+	return &Logger{traderID: traderID}
+}
+
+// Info 记录普通事件，msg为控制台显示的人类可读日志，attrs为JSON日志的附加字段（键值对）
+func (l *Logger) Info(cycle int64, symbol, action, msg string, attrs ...any) {
+	l.log(slog.LevelInfo, cycle, symbol, action, msg, attrs)
+}
+
+// Warn 记录需要关注的事件（强制平仓、风控触发等）
+func (l *Logger) Warn(cycle int64, symbol, action, msg string, attrs ...any) {
+	l.log(slog.LevelWarn, cycle, symbol, action, msg, attrs)
+}
+
+func (l *Logger) log(level slog.Level, cycle int64, symbol, action, msg string, attrs []any) {
+	traderID := ""
+	if l != nil {
+		traderID = l.traderID
+	}
+	args := append([]any{"trader_id", traderID, "cycle", cycle, "symbol", symbol, "action", action}, attrs...)
+	eventLogger.Load().Log(context.Background(), level, msg, args...)
+}
+
+// consoleHandler 控制台输出：只打印消息本身，保持原有的人类可读格式
+type consoleHandler struct{}
+
+func (consoleHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= slog.LevelInfo
+}
+
+func (consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	log.Print(r.Message)
+	return nil
+}
+
+func (h consoleHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h consoleHandler) WithGroup(string) slog.Handler { return h }
+
+// fanoutHandler 将同一条日志分发给多个handler
+type fanoutHandler []slog.Handler
+
+func (f fanoutHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	for _, h := range f {
+		if h.Enabled(ctx, level) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f fanoutHandler) Handle(ctx context.Context, r slog.Record) error {
+	var errs []error
+	for _, h := range f {
+		if h.Enabled(ctx, r.Level) {
+			errs = append(errs, h.Handle(ctx, r.Clone()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (f fanoutHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithAttrs(attrs)
+	}
+	return handlers
+}
+
+func (f fanoutHandler) WithGroup(name string) slog.Handler {
+	handlers := make(fanoutHandler, len(f))
+	for i, h := range f {
+		handlers[i] = h.WithGroup(name)
+	}
+	return handlers
+}
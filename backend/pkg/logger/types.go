@@ -16,6 +16,15 @@ type DecisionRecord struct {
 	ExecutionLog   []string           `json:"execution_log"`    // 执行日志
 	Success        bool               `json:"success"`         // 是否成功
 	ErrorMessage   string             `json:"error_message"`   // 错误信息（如果有）
+	ModelTraces    []ModelTrace       `json:"model_traces,omitempty"` // 多模型投票时每个模型的原始输出
+}
+
+// ModelTrace 多模型投票中单个模型的原始输出
+type ModelTrace struct {
+	Model        string `json:"model"`                   // 模型名称（deepseek/qwen/custom）
+	CoTTrace     string `json:"cot_trace"`               // 该模型的思维链
+	DecisionJSON string `json:"decision_json,omitempty"` // 该模型给出的决策JSON（验证后）
+	Error        string `json:"error,omitempty"`         // 调用或解析失败的原因
 }
 
 // AccountSnapshot 账户状态快照
@@ -61,6 +70,7 @@ type DecisionAction struct {
 	Error        string    `json:"error"`         // 错误信息
 	IsForced     bool      `json:"is_forced"`     // 是否强制平仓
 	ForcedReason string    `json:"forced_reason"` // 强制平仓原因（如果is_forced为true）
+	PartialClose bool      `json:"partial_close,omitempty"` // 是否部分平仓（平仓后仍有剩余持仓）
 }
 
 // TradeRecord 单笔完整交易记录（开仓+平仓配对）
@@ -100,6 +110,26 @@ type TradeRecord struct {
 	WasStopLoss bool   `json:"was_stop_loss"` // 是否止损（亏损且强制平仓）
 	Success     bool   `json:"success"`       // 是否成功（开仓和平仓都成功）
 	Error       string `json:"error"`         // 错误信息（如果有）
+	Strategy    string `json:"strategy"`      // 产生该交易的策略名称
+}
+
+// DailyReport 每日交易汇总（按UTC日期统计当天平仓的交易）
+type DailyReport struct {
+	TraderID        string        `json:"trader_id"`
+	Date            string        `json:"date"`              // 统计日期（YYYY-MM-DD，UTC）
+	StartEquity     float64       `json:"start_equity"`      // 当天第一条决策记录的账户净值（没有记录时为0）
+	EndEquity       float64       `json:"end_equity"`        // 当天最后一条决策记录的账户净值
+	EquityChange    float64       `json:"equity_change"`     // 净值变化（含未实现盈亏）
+	EquityChangePct float64       `json:"equity_change_pct"` // 净值变化百分比
+	RealizedPnL     float64       `json:"realized_pnl"`      // 当天平仓交易的已实现盈亏合计
+	TotalTrades     int           `json:"total_trades"`      // 当天平仓的交易数
+	WinningTrades   int           `json:"winning_trades"`    // 盈利交易数
+	LosingTrades    int           `json:"losing_trades"`     // 亏损交易数
+	WinRate         float64       `json:"win_rate"`          // 胜率（百分比）
+	ForcedCloses    int           `json:"forced_closes"`     // 强制平仓（止损/止盈/风控）次数
+	BestTrade       *TradeOutcome `json:"best_trade,omitempty"`  // 盈亏最高的交易
+	WorstTrade      *TradeOutcome `json:"worst_trade,omitempty"` // 盈亏最低的交易
+	GeneratedAt     time.Time     `json:"generated_at"`
 }
 
 // Statistics 统计信息
@@ -132,22 +162,24 @@ type TradeOutcome struct {
 	ExitLogic     string    `json:"exit_logic"`     // 出场逻辑（开仓时规划的）
 	CloseLogic    string    `json:"close_logic"`    // 平仓逻辑（直接平仓的理由）
 	ForcedCloseLogic string `json:"forced_close_logic"` // 强制平仓逻辑
+	Strategy      string    `json:"strategy,omitempty"` // 产生该交易的策略名称（系统外开仓和旧记录为空）
 }
 
 // PerformanceAnalysis 交易表现分析
 type PerformanceAnalysis struct {
-	TotalTrades   int                           `json:"total_trades"`   // 总交易数
-	WinningTrades int                           `json:"winning_trades"` // 盈利交易数
-	LosingTrades  int                           `json:"losing_trades"`  // 亏损交易数
-	WinRate       float64                       `json:"win_rate"`       // 胜率
-	AvgWin        float64                       `json:"avg_win"`        // 平均盈利
-	AvgLoss       float64                       `json:"avg_loss"`       // 平均亏损
-	ProfitFactor  float64                       `json:"profit_factor"`  // 盈亏比
-	SharpeRatio   float64                       `json:"sharpe_ratio"`   // 夏普比率（风险调整后收益）
-	RecentTrades  []TradeOutcome                `json:"recent_trades"`  // 最近N笔交易
-	SymbolStats   map[string]*SymbolPerformance `json:"symbol_stats"`   // 各币种表现
-	BestSymbol    string                        `json:"best_symbol"`    // 表现最好的币种
-	WorstSymbol   string                        `json:"worst_symbol"`   // 表现最差的币种
+	TotalTrades   int                             `json:"total_trades"`             // 总交易数
+	WinningTrades int                             `json:"winning_trades"`           // 盈利交易数
+	LosingTrades  int                             `json:"losing_trades"`            // 亏损交易数
+	WinRate       float64                         `json:"win_rate"`                 // 胜率
+	AvgWin        float64                         `json:"avg_win"`                  // 平均盈利
+	AvgLoss       float64                         `json:"avg_loss"`                 // 平均亏损
+	ProfitFactor  float64                         `json:"profit_factor"`            // 盈亏比
+	SharpeRatio   float64                         `json:"sharpe_ratio"`             // 夏普比率（风险调整后收益）
+	RecentTrades  []TradeOutcome                  `json:"recent_trades"`            // 最近N笔交易
+	SymbolStats   map[string]*SymbolPerformance   `json:"symbol_stats"`             // 各币种表现
+	StrategyStats map[string]*StrategyPerformance `json:"strategy_stats,omitempty"` // 各策略表现（按交易记录的策略名称分组，用于多策略对比）
+	BestSymbol    string                          `json:"best_symbol"`              // 表现最好的币种
+	WorstSymbol   string                          `json:"worst_symbol"`             // 表现最差的币种
 }
 
 // SymbolPerformance 币种表现统计
@@ -161,6 +193,17 @@ type SymbolPerformance struct {
 	AvgPnL        float64 `json:"avg_pn_l"`       // 平均盈亏
 }
 
+// StrategyPerformance 策略表现统计
+type StrategyPerformance struct {
+	Strategy      string  `json:"strategy"`       // 策略名称
+	TotalTrades   int     `json:"total_trades"`   // 交易次数
+	WinningTrades int     `json:"winning_trades"` // 盈利次数
+	LosingTrades  int     `json:"losing_trades"`  // 亏损次数
+	WinRate       float64 `json:"win_rate"`       // 胜率
+	TotalPnL      float64 `json:"total_pn_l"`     // 总盈亏
+	AvgPnL        float64 `json:"avg_pn_l"`       // 平均盈亏
+}
+
 // MarketEnvironmentSnapshot 市场环境快照
 // 记录当前市场的整体状态（趋势、波动率、情绪等）
 type MarketEnvironmentSnapshot struct {
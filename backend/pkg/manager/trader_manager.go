@@ -1,6 +1,7 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"backend/pkg/config"
@@ -23,7 +24,7 @@ func NewTraderManager() *TraderManager {
 }
 
 // AddTrader 添加一个trader
-func (tm *TraderManager) AddTrader(cfg config.TraderConfig, maxDailyLoss, maxDrawdown float64, stopTradingMinutes int, positionStopLossPct, positionTakeProfitPct float64, leverage config.LeverageConfig, skipLiquidityCheck bool, analysisMode config.AnalysisModeConfig, strategy config.StrategyConfig) error {
+func (tm *TraderManager) AddTrader(cfg config.TraderConfig, maxDailyLoss, maxDrawdown, deRiskDrawdown float64, stopTradingMinutes int, positionStopLossPct, positionTakeProfitPct, positionMaxLossUSD float64, maxOpenPositions, reentryCooldownMinutes int, maxHoldingHours, maxFundingRate, riskPerTradePct float64, leverage config.LeverageConfig, skipLiquidityCheck bool, minOpenInterestValueUSD float64, analysisMode config.AnalysisModeConfig, strategy config.StrategyConfig, openConfirmation config.OpenConfirmationConfig, prompt config.PromptConfig, runLimit config.RunLimitConfig, insufficientHistory config.InsufficientHistoryConfig, validation config.ValidationConfig, telegram config.TelegramConfig) error {
 	tm.mu.Lock()
 	defer tm.mu.Unlock()
 
@@ -40,25 +41,90 @@ func (tm *TraderManager) AddTrader(cfg config.TraderConfig, maxDailyLoss, maxDra
 		AsterUser:             cfg.AsterUser,
 		AsterSigner:           cfg.AsterSigner,
 		AsterPrivateKey:       cfg.AsterPrivateKey,
+		BinanceAPIKey:         cfg.BinanceAPIKey,
+		BinanceSecretKey:      cfg.BinanceSecretKey,
 		UseQwen:               cfg.AIModel == "qwen",
 		DeepSeekKey:           cfg.DeepSeekKey,
 		QwenKey:               cfg.QwenKey,
 		CustomAPIURL:          cfg.CustomAPIURL,
 		CustomAPIKey:          cfg.CustomAPIKey,
 		CustomModelName:       cfg.CustomModelName,
+		AITimeout:             cfg.GetAITimeout(),     // 单次AI请求超时
+		AIMaxRetries:          cfg.GetAIMaxRetries(),  // AI请求重试次数
+		AITemperature:         cfg.GetAITemperature(), // AI采样参数
+		AITopP:                cfg.AITopP,
+		AIMaxTokens:           cfg.AIMaxTokens,
+		StreamResponses:       cfg.AIStream,          // 流式接收AI响应
+		EnsembleModels:        cfg.EnsembleModels,     // 多模型投票
+		EnsembleMinAgree:      cfg.EnsembleMinAgree,   // 开平仓最少同意票数
 		ScanInterval:          cfg.GetScanInterval(),
+		AdaptiveScanInterval:  cfg.AdaptiveScanInterval,   // 按波动率调整扫描间隔
+		MinScanInterval:       cfg.GetMinScanInterval(),
+		MaxScanInterval:       cfg.GetMaxScanInterval(),
+		ScanReferenceSymbol:   cfg.ScanReferenceSymbol,
 		InitialBalance:        cfg.InitialBalance,
+		AutoDetectInitialBalance: cfg.AutoDetectInitialBalance,
+		TakerFeeBps:           cfg.TakerFeeBps,       // 吃单手续费（基点）
+		CandidatePoolSize:     cfg.GetCandidatePoolSize(), // 候选币种数量
+		LimitOrderTimeout:     cfg.GetLimitOrderTimeout(), // 限价开仓单超时
+		StopLossCheckInterval: cfg.GetStopLossCheckInterval(), // 单仓位止损检查间隔
+		MaxDataAge:            cfg.GetMaxDataAge(),        // 行情数据最大时长
+		FundingCaptureMode:    cfg.FundingCaptureMode,     // 资金费率捕获模式
+		FundingCaptureMinRate: cfg.GetFundingCaptureMinRate(),
+		SymbolAllowlist:       cfg.SymbolAllowlist,   // 币种白名单
+		SymbolDenylist:        cfg.SymbolDenylist,    // 币种黑名单
+		MaxCorrelation:        cfg.MaxCorrelation,    // 同方向持仓相关性上限
+		SymbolLossStreak:      cfg.SymbolLossStreak,  // 单币种连续亏损冷却
+		SymbolLossWindow:      cfg.GetSymbolLossWindow(),
+		SymbolLossCooldown:    cfg.GetSymbolLossCooldown(),
+		MaxDailyTrades:        cfg.MaxDailyTrades,    // 每日最多开仓次数
+		FlattenOnStartup:      cfg.FlattenOnStartup,  // 启动时平掉已有持仓
+		FlattenOnShutdown:     cfg.FlattenOnShutdown, // 停止时平掉所有持仓
+		WebhookURL:            cfg.WebhookURL,
+		DecisionRetention:     cfg.GetDecisionRetention(), // 决策记录保留时长
+		LogVerbosity:          cfg.GetLogVerbosity(),      // 决策记录详细程度
+		CircuitBreakerFailures: cfg.GetCircuitBreakerFailures(), // 连续失败周期熔断阈值
+		CircuitBreakerBackoff:  cfg.GetCircuitBreakerBackoff(),  // 熔断后暂停时长
+		HaltedSymbolFailures:   cfg.GetHaltedSymbolFailures(),   // 停牌/下架判定的连续行情失败周期数
+		HaltedSymbolAction:     cfg.GetHaltedSymbolAction(),     // 停牌/下架处理方式
 		BTCETHLeverage:        leverage.BTCETHLeverage,  // 使用配置的杠杆倍数
 		AltcoinLeverage:       leverage.AltcoinLeverage, // 使用配置的杠杆倍数
+		SymbolLeverageOverrides: leverage.SymbolOverrides, // 单币种杠杆上限
 		MaxDailyLoss:          maxDailyLoss,
 		MaxDrawdown:           maxDrawdown,
+		DeRiskDrawdown:        deRiskDrawdown,        // 回撤减仓百分比
 		PositionStopLossPct:   positionStopLossPct,   // 单仓位止损百分比
 		PositionTakeProfitPct: positionTakeProfitPct, // 单仓位止盈百分比（可选）
+		PositionMaxLossUSD:    positionMaxLossUSD,    // 单仓位最大亏损金额（可选）
+		MaxOpenPositions:      maxOpenPositions,      // 最大同时持仓数量（0表示不限制）
+		ReentryCooldown:       time.Duration(reentryCooldownMinutes) * time.Minute, // 平仓后再入场冷却时间
+		MaxHoldingTime:        time.Duration(maxHoldingHours * float64(time.Hour)), // 最长持仓时间
+		MaxFundingRate:        maxFundingRate,        // 开仓方向需支付的最大资金费率
+		RiskPerTradePct:       riskPerTradePct,       // 单笔交易风险预算（占净值百分比）
 		StopTradingTime:       time.Duration(stopTradingMinutes) * time.Minute,
 		SkipLiquidityCheck:    skipLiquidityCheck, // 是否跳过流动性检查
+		MinOpenInterestValueUSD: minOpenInterestValueUSD, // 流动性检查的最低持仓价值
 		AnalysisMode:           analysisMode.Mode, // 分析模式
 		MultiTimeframeConfig:  analysisMode.MultiTimeframe, // 多时间框架配置
 		StrategyName:           strategy.Name, // 策略名称
+		OpenConfirmationEnabled: openConfirmation.Enable, // 是否启用开仓确认
+		OpenConfirmationWindow:  time.Duration(openConfirmation.WindowMinutes) * time.Minute,
+		IncludeDerivativesTrend: prompt.IncludeDerivativesTrend, // 是否在prompt中包含OI/资金费率趋势
+		IncludeConcentration:    prompt.IncludeConcentration,    // 是否在prompt中包含持仓集中度
+		ConcentrationWarnHHI:    prompt.ConcentrationWarnHHI,    // 集中度提示阈值
+		MaxCycles:               runLimit.MaxCycles,                                         // 最大周期数
+		MaxRuntime:              time.Duration(runLimit.MaxRuntimeMinutes) * time.Minute, // 最大运行时长
+		FlattenOnStop:           runLimit.FlattenOnStop,                                     // 达到上限时是否平仓
+		MinKlineBars:            insufficientHistory.MinBars,                                // 每个时间框架最少K线数量
+		InsufficientHistoryMode: insufficientHistory.Mode,                                   // K线不足时的处理方式
+		ValidationMode:          validation.Mode,                                            // 决策验证模式
+		MinRiskReward:           validation.MinRiskReward,                                   // 开仓最低风险回报比
+		MinStopATRMultiple:      validation.MinStopATRMultiple,                              // 开仓止损距离的最小ATR倍数
+		MaxPositionNotionalPct:  validation.MaxPositionNotionalPct,                          // 单笔仓位价值上限（占净值百分比）
+		TelegramBotToken:        telegram.BotToken,                                          // Telegram通知（可选）
+		TelegramChatID:          telegram.ChatID,
+		DailyReport:             telegram.DailyReport,                                       // 每日汇总推送
+		DailyReportHourUTC:      telegram.DailyReportHourUTC,
 	}
 
 	// 创建trader实例
@@ -135,6 +201,25 @@ func (tm *TraderManager) StopAll() {
 	}
 }
 
+// ShutdownAll 优雅停止所有trader（并行等待进行中的操作完成、保存状态并关闭数据库，ctx控制最长等待时间）
+func (tm *TraderManager) ShutdownAll(ctx context.Context) {
+	tm.mu.RLock()
+	defer tm.mu.RUnlock()
+
+	log.Println("⏹  优雅停止所有Trader...")
+	var wg sync.WaitGroup
+	for _, t := range tm.traders {
+		wg.Add(1)
+		go func(t *trader.AutoTrader) {
+			defer wg.Done()
+			if err := t.Shutdown(ctx); err != nil {
+				log.Printf("⚠️  %s 停止时出错: %v", t.GetName(), err)
+			}
+		}(t)
+	}
+	wg.Wait()
+}
+
 // GetComparisonData 获取对比数据
 func (tm *TraderManager) GetComparisonData() (map[string]interface{}, error) {
 	tm.mu.RLock()
@@ -2,14 +2,14 @@ package market
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"math"
-	"net/http"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 )
 
 // 全局变量：当前使用的交易所API基础URL
@@ -19,18 +19,25 @@ var (
 	exchangeMutex      sync.RWMutex
 )
 
-// SetExchange 设置使用的交易所（仅支持aster）
+// SetExchange 设置使用的交易所（支持aster和binance）
 func SetExchange(exchange string) {
 	exchangeMutex.Lock()
 	defer exchangeMutex.Unlock()
 
 	currentExchange = strings.ToLower(exchange)
+	// 切换交易所后旧交易所的K线缓存不再有效
+	defer ClearCache()
 	
-	if currentExchange == "aster" {
+	switch currentExchange {
+	case "aster":
 		// Aster 使用其自己的API端点
 		baseAPIURL = "https://fapi.asterdex.com"
 		log.Printf("📊 市场数据API: 已切换到Aster平台")
-	} else {
+	case "binance":
+		// Binance U本位合约API（与Aster的K线/OI/资金费率接口路径一致）
+		baseAPIURL = "https://fapi.binance.com"
+		log.Printf("📊 市场数据API: 已切换到Binance平台")
+	default:
 		// 默认使用Aster
 		currentExchange = "aster"
 		baseAPIURL = "https://fapi.asterdex.com"
@@ -41,15 +48,38 @@ func SetExchange(exchange string) {
 // Data 市场数据结构
 type Data struct {
 	Symbol            string
+	KlineCount        int            // 实际获取到的K线数量（新上市币种可能不足以计算指标）
 	CurrentPrice      float64
 	PriceChange1h     float64 // 1小时价格变化百分比
 	PriceChange4h     float64 // 4小时价格变化百分比
 	CurrentEMA20      float64
+	CurrentEMA50      float64 // K线不足50根时为0
+	CurrentEMA200     float64 // K线不足200根时为0
+	EMACross          string  // 最近EMACrossLookbackBars根K线内EMA50与EMA200的交叉（EMACrossGolden/EMACrossDeath，没有交叉或K线不足时为空）
 	CurrentMACD       float64
 	CurrentRSI7       float64
+	CurrentATR14      float64 // 14周期ATR（K线不足时为0）
+	BollingerUpper    float64 // 布林带上轨（20周期，2倍标准差；K线不足时为0）
+	BollingerMiddle   float64 // 布林带中轨（20周期SMA）
+	BollingerLower    float64 // 布林带下轨
+	CurrentADX        float64 // 14周期ADX趋势强度（K线不足时为0）
+	StochK            float64 // StochRSI %K（RSI 14，Stoch 14，平滑3/3；K线不足时为0）
+	StochD            float64 // StochRSI %D（%K的3周期均线）
+	PrevStochK        float64 // 上一根K线的StochRSI %K（用于判断金叉/死叉）
+	PrevStochD        float64 // 上一根K线的StochRSI %D
+	CurrentOBV        float64 // 能量潮OBV累计值（从第一根K线开始累计，只看趋势不看绝对值）
+	OBVSlope          float64 // 最近10根K线OBV的线性回归斜率÷平均成交量（>0资金流入，<0资金流出；K线不足或无成交量时为0）
+	CurrentVWAP       float64 // 本次获取的K线窗口内的成交量加权均价（典型价×成交量，无成交量时为0）
+	DataAge           time.Duration // 最新K线收盘时间距现在的时长（K线仍在形成中时为0；只在实盘获取时计算，回测为0）
 	OpenInterest      *OIData
 	FundingRate       float64
+	NextFundingTime   int64   // 下次资金费结算时间（毫秒时间戳，获取失败或回测时为0）
+	MarkPrice         float64 // 标记价格（资金费率接口返回，获取失败或回测时为0）
+	IndexPrice        float64 // 指数价格（资金费率接口返回，获取失败或回测时为0）
 	IntradaySeries    *IntradayData
+	OIHistory         []HistoryPoint // OI短期滚动历史（旧→新，用于计算OI趋势）
+	FundingHistory    []HistoryPoint // 资金费率短期滚动历史（旧→新，用于计算资金费率趋势）
+	ClosePrices       []float64      `json:"-"` // 本次获取的K线收盘价（旧→新，供相关性等需要完整序列的计算复用）
 }
 
 // OIData Open Interest数据
@@ -64,7 +94,7 @@ type IntradayData struct {
 	VolumeValues []float64 // 成交量序列
 	EMA20Values []float64
 	MACDValues  []float64 // MACD HIST（柱状图）= DIF - DEA
-	DIFValues   []float64 // DIF序列（MACD线）= EMA12 - EMA26
+	DIFValues   []float64 // DIF序列（MACD线）= EMA(fast) - EMA(slow)，默认EMA12 - EMA26
 	DEAValues   []float64 // DEA序列（信号线）= DIF的9期EMA
 	RSI7Values  []float64
 	RSI14Values []float64
@@ -97,22 +127,108 @@ func GetWithTimeframe(symbol, timeframe string, limit int) (*Data, error) {
 		return nil, fmt.Errorf("获取%s K线成功但返回空数组", timeframe)
 	}
 
+	data := BuildData(symbol, timeframe, klines)
+	data.DataAge = klineDataAge(klines)
+
+	// 获取OI数据
+	oiData, err := getOpenInterestData(symbol)
+	if err != nil {
+		// OI失败不影响整体,使用默认值
+		oiData = &OIData{Latest: 0, Average: 0}
+		log.Printf("⚠️  获取 %s OI数据失败，使用默认值: %v", symbol, err)
+	}
+	if err == nil {
+		data.OIHistory = recordHistory(oiHistoryBySymbol, symbol, oiData.Latest)
+	} else {
+		data.OIHistory = snapshotHistory(oiHistoryBySymbol, symbol)
+	}
+	data.OpenInterest = oiData
+
+	// 获取Funding Rate
+	funding, err := getFundingRate(symbol)
+	if err != nil {
+		log.Printf("⚠️  获取 %s 资金费率失败: %v", symbol, err)
+		funding = &FundingInfo{Symbol: symbol}
+	}
+	if err == nil {
+		data.FundingHistory = recordHistory(fundingHistoryBySymbol, symbol, funding.Rate)
+	} else {
+		data.FundingHistory = snapshotHistory(fundingHistoryBySymbol, symbol)
+	}
+	data.FundingRate = funding.Rate
+	data.NextFundingTime = funding.NextFundingTime
+	data.MarkPrice = funding.MarkPrice
+	data.IndexPrice = funding.IndexPrice
+
+	return data, nil
+}
+
+// klineDataAge 计算最新K线的数据时长（正在形成的K线收盘时间在未来，返回0；币种停止交易时最新K线会越来越旧）
+func klineDataAge(klines []Kline) time.Duration {
+	age := time.Since(time.UnixMilli(klines[len(klines)-1].CloseTime))
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// BuildData 根据K线计算价格变化和技术指标（不请求交易所API，OI和资金费率需调用方填充）
+// klines需按时间顺序排列（旧→新）且不能为空；实盘和回测共用此计算逻辑
+func BuildData(symbol, timeframe string, klines []Kline) *Data {
 	// 计算当前指标 (基于指定时间框架的最新数据)
 	currentPrice := klines[len(klines)-1].Close
 	currentEMA20 := calculateEMA(klines, 20)
-	currentMACD := calculateMACD(klines)
+	currentEMA50 := calculateEMA(klines, 50)
+	currentEMA200 := calculateEMA(klines, 200)
+	emaCross := detectEMACross(klines, 50, 200, EMACrossLookbackBars)
+	macdParams := getMACDParams()
+	currentMACD := calculateMACD(klines, macdParams.Fast, macdParams.Slow, macdParams.Signal)
 	currentRSI7 := calculateRSI(klines, 7)
+	currentATR14 := calculateATR(klines, 14)
+	bollUpper, bollMiddle, bollLower := calculateBollinger(klines, 20, 2)
+	currentADX := calculateADX(klines, 14)
+	stochK, stochD := calculateStochRSI(klines, 14, 14, 3, 3)
+	prevStochK, prevStochD := math.NaN(), math.NaN()
+	if len(klines) > 1 {
+		prevStochK, prevStochD = calculateStochRSI(klines[:len(klines)-1], 14, 14, 3, 3)
+	}
+	currentOBV, obvSlope := calculateOBV(klines, OBVSlopePeriod)
+	currentVWAP := calculateVWAP(klines)
 	
 	// 处理NaN值：如果计算结果为NaN，使用0作为默认值（向后兼容）
 	if math.IsNaN(currentEMA20) {
 		currentEMA20 = 0
 	}
+	if math.IsNaN(currentEMA50) {
+		currentEMA50 = 0
+	}
+	if math.IsNaN(currentEMA200) {
+		currentEMA200 = 0
+	}
 	if math.IsNaN(currentMACD) {
 		currentMACD = 0
 	}
 	if math.IsNaN(currentRSI7) {
 		currentRSI7 = 0
 	}
+	if math.IsNaN(currentATR14) {
+		currentATR14 = 0
+	}
+	if math.IsNaN(bollMiddle) {
+		bollUpper, bollMiddle, bollLower = 0, 0, 0
+	}
+	if math.IsNaN(currentADX) {
+		currentADX = 0
+	}
+	if math.IsNaN(stochK) {
+		stochK, stochD = 0, 0
+	}
+	if math.IsNaN(prevStochK) {
+		prevStochK, prevStochD = 0, 0
+	}
+	if math.IsNaN(obvSlope) {
+		obvSlope = 0
+	}
 
 	// 计算价格变化百分比
 	// 对于不同时间框架，计算对应的时间段变化
@@ -179,36 +295,96 @@ func GetWithTimeframe(symbol, timeframe string, limit int) (*Data, error) {
 		}
 	}
 
-	// 获取OI数据
-	oiData, err := getOpenInterestData(symbol)
-	if err != nil {
-		// OI失败不影响整体,使用默认值
-		oiData = &OIData{Latest: 0, Average: 0}
-		log.Printf("⚠️  获取 %s OI数据失败，使用默认值: %v", symbol, err)
+	// 计算日内系列数据（根据时间框架调整）
+	intradayData := calculateIntradaySeriesForTimeframe(klines, timeframe)
+
+	closePrices := make([]float64, len(klines))
+	for i, k := range klines {
+		closePrices[i] = k.Close
 	}
 
-	// 获取Funding Rate
-	fundingRate, err := getFundingRate(symbol)
-	if err != nil {
-		log.Printf("⚠️  获取 %s 资金费率失败: %v", symbol, err)
-		fundingRate = 0
+	return &Data{
+		Symbol:          symbol,
+		KlineCount:      len(klines),
+		CurrentPrice:    currentPrice,
+		PriceChange1h:   priceChange1h,
+		PriceChange4h:   priceChange4h,
+		CurrentEMA20:    currentEMA20,
+		CurrentEMA50:    currentEMA50,
+		CurrentEMA200:   currentEMA200,
+		EMACross:        emaCross,
+		CurrentMACD:     currentMACD,
+		CurrentRSI7:     currentRSI7,
+		CurrentATR14:    currentATR14,
+		BollingerUpper:  bollUpper,
+		BollingerMiddle: bollMiddle,
+		BollingerLower:  bollLower,
+		CurrentADX:      currentADX,
+		StochK:          stochK,
+		StochD:          stochD,
+		PrevStochK:      prevStochK,
+		PrevStochD:      prevStochD,
+		CurrentOBV:      currentOBV,
+		OBVSlope:        obvSlope,
+		CurrentVWAP:     currentVWAP,
+		IntradaySeries:  intradayData,
+		ClosePrices:     closePrices,
 	}
+}
 
-	// 计算日内系列数据（根据时间框架调整）
-	intradayData := calculateIntradaySeriesForTimeframe(klines, timeframe)
+// SuggestStopLoss 根据ATR计算建议止损价：做多为 当前价 - multiplier*ATR，做空为 当前价 + multiplier*ATR
+// ATR不可用（K线不足）或参数无效时返回0
+func SuggestStopLoss(data *Data, side string, multiplier float64) float64 {
+	if data == nil || data.CurrentATR14 <= 0 || data.CurrentPrice <= 0 || multiplier <= 0 {
+		return 0
+	}
 
-	return &Data{
-		Symbol:         symbol,
-		CurrentPrice:   currentPrice,
-		PriceChange1h:  priceChange1h,
-		PriceChange4h:  priceChange4h,
-		CurrentEMA20:   currentEMA20,
-		CurrentMACD:    currentMACD,
-		CurrentRSI7:    currentRSI7,
-		OpenInterest:   oiData,
-		FundingRate:    fundingRate,
-		IntradaySeries: intradayData,
-	}, nil
+	distance := multiplier * data.CurrentATR14
+	switch side {
+	case "long":
+		if distance >= data.CurrentPrice {
+			return 0
+		}
+		return data.CurrentPrice - distance
+	case "short":
+		return data.CurrentPrice + distance
+	default:
+		return 0
+	}
+}
+
+// StochRSI超买/超卖阈值
+const (
+	StochRSIOversold   = 20.0
+	StochRSIOverbought = 80.0
+)
+
+// OBVSlopePeriod 计算OBV斜率使用的K线数量
+const OBVSlopePeriod = 10
+
+// EMA50/EMA200交叉
+const (
+	EMACrossGolden       = "golden" // 金叉：EMA50上穿EMA200
+	EMACrossDeath        = "death"  // 死叉：EMA50下穿EMA200
+	EMACrossLookbackBars = 5        // 只看最近N根K线内发生的交叉
+)
+
+// StochRSICrossUp 判断StochRSI是否从超卖区金叉（上一根%K在超卖区且不高于%D，当前%K上穿%D）
+// K线不足（StochRSI为0）时返回false
+func StochRSICrossUp(data *Data) bool {
+	if data == nil || (data.PrevStochK == 0 && data.PrevStochD == 0) {
+		return false
+	}
+	return data.PrevStochK < StochRSIOversold && data.PrevStochK <= data.PrevStochD && data.StochK > data.StochD
+}
+
+// StochRSICrossDown 判断StochRSI是否从超买区死叉（上一根%K在超买区且不低于%D，当前%K下穿%D）
+// K线不足（StochRSI为0）时返回false
+func StochRSICrossDown(data *Data) bool {
+	if data == nil || (data.PrevStochK == 0 && data.PrevStochD == 0) {
+		return false
+	}
+	return data.PrevStochK > StochRSIOverbought && data.PrevStochK >= data.PrevStochD && data.StochK < data.StochD
 }
 
 // safeGetLastN 安全地获取序列的最后N个值
@@ -252,7 +428,8 @@ func calculateIntradaySeriesForTimeframe(klines []Kline, timeframe string) *Intr
 	data.EMA20Values = safeGetLastN(fullEma20Seq, 7)
 
 	// 2. MACD序列（DIF、DEA、HIST）
-	fullDifSeq, fullDeaSeq, fullHistSeq := calculateMACDSequence(klines)
+	macdParams := getMACDParams()
+	fullDifSeq, fullDeaSeq, fullHistSeq := calculateMACDSequence(klines, macdParams.Fast, macdParams.Slow, macdParams.Signal)
 	data.DIFValues = safeGetLastN(fullDifSeq, 7)
 	data.DEAValues = safeGetLastN(fullDeaSeq, 7)
 	data.MACDValues = safeGetLastN(fullHistSeq, 7)
@@ -272,8 +449,25 @@ func Get(symbol string) (*Data, error) {
 	return GetWithTimeframe(symbol, "3m", 1000)
 }
 
-// getKlines 获取K线数据（支持多平台）
+// GetClosePrices 获取最近limit根K线的收盘价（旧→新，优先使用缓存，本周期已获取过的K线不会重复请求）
+func GetClosePrices(symbol, interval string, limit int) ([]float64, error) {
+	klines, err := getKlines(Normalize(symbol), interval, limit)
+	if err != nil {
+		return nil, err
+	}
+	closes := make([]float64, len(klines))
+	for i, k := range klines {
+		closes[i] = k.Close
+	}
+	return closes, nil
+}
+
+// getKlines 获取K线数据（支持多平台，优先使用缓存）
 func getKlines(symbol, interval string, limit int) ([]Kline, error) {
+	if klines, ok := getCachedKlines(symbol, interval, limit); ok {
+		return klines, nil
+	}
+
 	exchangeMutex.RLock()
 	apiURL := baseAPIURL
 	exchangeMutex.RUnlock()
@@ -281,28 +475,32 @@ func getKlines(symbol, interval string, limit int) ([]Kline, error) {
 	url := fmt.Sprintf("%s/fapi/v1/klines?symbol=%s&interval=%s&limit=%d",
 		apiURL, symbol, interval, limit)
 
-	resp, err := http.Get(url)
+	klines, err := requestKlines(url)
 	if err != nil {
-		return nil, fmt.Errorf("请求失败: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
+	setCachedKlines(symbol, interval, limit, klines)
+	return klines, nil
+}
 
-	// 检查HTTP状态码
-	if resp.StatusCode != http.StatusOK {
-		// 尝试解析错误响应
-		var errorResp struct {
-			Code int    `json:"code"`
-			Msg  string `json:"msg"`
-		}
-		if json.Unmarshal(body, &errorResp) == nil {
-			return nil, fmt.Errorf("API错误 (状态码 %d): code=%d, msg=%s", resp.StatusCode, errorResp.Code, errorResp.Msg)
+// requestKlines 请求K线接口并解析响应（不使用缓存）
+func requestKlines(url string) ([]Kline, error) {
+	body, err := doGet(url)
+	if err != nil {
+		// 检查HTTP状态码，尝试解析错误响应
+		var statusErr *httpStatusError
+		if errors.As(err, &statusErr) {
+			var errorResp struct {
+				Code int    `json:"code"`
+				Msg  string `json:"msg"`
+			}
+			if json.Unmarshal(statusErr.Body, &errorResp) == nil {
+				return nil, fmt.Errorf("API错误 (状态码 %d): code=%d, msg=%s", statusErr.StatusCode, errorResp.Code, errorResp.Msg)
+			}
+			return nil, fmt.Errorf("API错误 (状态码 %d): %s", statusErr.StatusCode, string(statusErr.Body))
 		}
-		return nil, fmt.Errorf("API错误 (状态码 %d): %s", resp.StatusCode, string(body))
+		return nil, err
 	}
 
 	// 尝试解析为数组格式（正常响应）
@@ -432,6 +630,29 @@ func calculateEMASequence(klines []Kline, period int) []float64 {
 	return sequence
 }
 
+// detectEMACross 检测最近lookback根K线内快慢EMA的交叉（有多次交叉时返回最近一次）
+// K线不足以计算慢EMA和lookback+1个交叉比较点时返回空
+func detectEMACross(klines []Kline, fast, slow, lookback int) string {
+	if len(klines) < slow+lookback {
+		return ""
+	}
+	fastSeq := calculateEMASequence(klines, fast)
+	slowSeq := calculateEMASequence(klines, slow)
+
+	// 两个序列的最后一个值都对应最新K线，从末尾对齐
+	for i := 0; i < lookback; i++ {
+		curFast, curSlow := fastSeq[len(fastSeq)-1-i], slowSeq[len(slowSeq)-1-i]
+		prevFast, prevSlow := fastSeq[len(fastSeq)-2-i], slowSeq[len(slowSeq)-2-i]
+		if prevFast <= prevSlow && curFast > curSlow {
+			return EMACrossGolden
+		}
+		if prevFast >= prevSlow && curFast < curSlow {
+			return EMACrossDeath
+		}
+	}
+	return ""
+}
+
 // calculateEMASequenceFromValues 从值序列计算EMA序列（用于DIF序列计算DEA）
 func calculateEMASequenceFromValues(values []float64, period int) []float64 {
 	if len(values) < period {
@@ -459,53 +680,41 @@ func calculateEMASequenceFromValues(values []float64, period int) []float64 {
 }
 
 // calculateMACD 计算MACD（返回MACD柱状图，即HIST = DIF - DEA）
-// 标准MACD指标包括：
-// - DIF（MACD线）= EMA12 - EMA26
-// - DEA（信号线）= DIF的9期EMA
+// 标准MACD指标（默认参数12/26/9）包括：
+// - DIF（MACD线）= EMA(fast) - EMA(slow)
+// - DEA（信号线）= DIF的signal期EMA
 // - HIST（柱状图）= DIF - DEA（这是最常用的MACD值，与Python版本的MACD_HIST一致）
 // 使用优化版本计算，数据不足时返回NaN
-func calculateMACD(klines []Kline) float64 {
-	// MACD需要至少35根K线：
-	// - 26根用于计算EMA26（DIF）
-	// - 从第26根开始计算DIF序列，需要至少9根DIF值才能计算DEA
-	if len(klines) < 35 {
+func calculateMACD(klines []Kline, fast, slow, signal int) float64 {
+	// MACD需要至少slow+signal根K线（默认35根）：
+	// - slow根用于计算慢线EMA（DIF）
+	// - 从第slow根开始计算DIF序列，需要至少signal根DIF值才能计算DEA
+	if len(klines) < slow+signal {
 		// 如果数据不足，尝试返回DIF（虽然不完整，但比返回NaN好）
-		if len(klines) >= 26 {
-			ema12 := calculateEMA(klines, 12)
-			ema26 := calculateEMA(klines, 26)
-			if math.IsNaN(ema12) || math.IsNaN(ema26) {
+		if len(klines) >= slow {
+			emaFast := calculateEMA(klines, fast)
+			emaSlow := calculateEMA(klines, slow)
+			if math.IsNaN(emaFast) || math.IsNaN(emaSlow) {
 				return math.NaN()
 			}
-			return ema12 - ema26
+			return emaFast - emaSlow
 		}
 		return math.NaN()
 	}
 
 	// 第一步：使用增量计算EMA序列（O(n)时间复杂度）
-	ema12Seq := calculateEMASequence(klines, 12)
-	ema26Seq := calculateEMASequence(klines, 26)
+	emaFastSeq := calculateEMASequence(klines, fast)
+	emaSlowSeq := calculateEMASequence(klines, slow)
 
-	// 计算DIF序列（从第26根K线开始，因为EMA26需要26根K线）
-	if len(ema12Seq) == 0 || len(ema26Seq) == 0 {
+	// 计算DIF序列（从第slow根K线开始，因为慢线EMA需要slow根K线）
+	if len(emaFastSeq) == 0 || len(emaSlowSeq) == 0 {
 		return math.NaN()
 	}
 
-	// EMA12序列长度 = len(klines) - 12 + 1
-	// EMA26序列长度 = len(klines) - 26 + 1
-	// DIF序列应该从EMA26序列开始的位置对应
-	difValues := make([]float64, 0, len(ema26Seq))
-	ema12StartIdx := len(ema12Seq) - len(ema26Seq)
-	
-	for i := 0; i < len(ema26Seq); i++ {
-		ema12Idx := ema12StartIdx + i
-		if ema12Idx >= 0 && ema12Idx < len(ema12Seq) {
-			difAtI := ema12Seq[ema12Idx] - ema26Seq[i]
-			difValues = append(difValues, difAtI)
-		}
-	}
+	difValues := macdDIFValues(emaFastSeq, emaSlowSeq)
 
-	// 如果DIF序列长度不足9，无法计算DEA
-	if len(difValues) < 9 {
+	// 如果DIF序列长度不足signal，无法计算DEA
+	if len(difValues) < signal {
 		// 降级：返回最后一个DIF值（如果存在）
 		if len(difValues) > 0 {
 			return difValues[len(difValues)-1]
@@ -513,8 +722,8 @@ func calculateMACD(klines []Kline) float64 {
 		return math.NaN()
 	}
 
-	// 第二步：计算信号线（DEA）= 对DIF序列计算9期EMA（使用优化版本）
-	deaSeq := calculateEMASequenceFromValues(difValues, 9)
+	// 第二步：计算信号线（DEA）= 对DIF序列计算signal期EMA（使用优化版本）
+	deaSeq := calculateEMASequenceFromValues(difValues, signal)
 	if len(deaSeq) == 0 {
 		// 如果无法计算DEA，返回最后一个DIF值
 		return difValues[len(difValues)-1]
@@ -531,41 +740,27 @@ func calculateMACD(klines []Kline) float64 {
 
 // calculateMACDWithComponents 计算MACD并返回DIF、DEA、HIST三个组件（优化版本，O(n)时间复杂度）
 // 返回值：(DIF, DEA, HIST)
-// - DIF = EMA12 - EMA26
-// - DEA = DIF的9期EMA
+// - DIF = EMA(fast) - EMA(slow)
+// - DEA = DIF的signal期EMA
 // - HIST = DIF - DEA
 // 数据不足时返回NaN
-func calculateMACDWithComponents(klines []Kline) (float64, float64, float64) {
-	if len(klines) < 26 {
+func calculateMACDWithComponents(klines []Kline, fast, slow, signal int) (float64, float64, float64) {
+	if len(klines) < slow {
 		return math.NaN(), math.NaN(), math.NaN()
 	}
 
 	// 第一步：使用增量计算EMA序列（O(n)时间复杂度）
-	ema12Seq := calculateEMASequence(klines, 12)
-	ema26Seq := calculateEMASequence(klines, 26)
+	emaFastSeq := calculateEMASequence(klines, fast)
+	emaSlowSeq := calculateEMASequence(klines, slow)
 
-	// 计算DIF序列（从第26根K线开始，因为EMA26需要26根K线）
-	// EMA12序列从第12根开始，EMA26序列从第26根开始
-	// 所以DIF序列从第26根开始（取两个序列的交集）
-	if len(ema12Seq) == 0 || len(ema26Seq) == 0 {
+	// 计算DIF序列（从第slow根K线开始，因为慢线EMA需要slow根K线）
+	// 快线序列从第fast根开始，慢线序列从第slow根开始
+	// 所以DIF序列从第slow根开始（取两个序列的交集）
+	if len(emaFastSeq) == 0 || len(emaSlowSeq) == 0 {
 		return 0, 0, 0
 	}
 
-	// EMA12序列长度 = len(klines) - 12 + 1
-	// EMA26序列长度 = len(klines) - 26 + 1
-	// DIF序列应该从EMA26序列开始的位置对应
-	// 即：ema12Seq的索引从 len(klines) - len(ema26Seq) 开始
-	difValues := make([]float64, 0, len(ema26Seq))
-	ema12StartIdx := len(ema12Seq) - len(ema26Seq)
-	
-	for i := 0; i < len(ema26Seq); i++ {
-		ema12Idx := ema12StartIdx + i
-		if ema12Idx >= 0 && ema12Idx < len(ema12Seq) {
-			difAtI := ema12Seq[ema12Idx] - ema26Seq[i]
-			difValues = append(difValues, difAtI)
-		}
-	}
-
+	difValues := macdDIFValues(emaFastSeq, emaSlowSeq)
 	if len(difValues) == 0 {
 		return math.NaN(), math.NaN(), math.NaN()
 	}
@@ -573,14 +768,14 @@ func calculateMACDWithComponents(klines []Kline) (float64, float64, float64) {
 	// 获取当前DIF值
 	currentDif := difValues[len(difValues)-1]
 
-	// 如果DIF序列长度不足9，无法计算DEA
-	if len(difValues) < 9 {
+	// 如果DIF序列长度不足signal，无法计算DEA
+	if len(difValues) < signal {
 		// 降级：只返回DIF，DEA和HIST为NaN
 		return currentDif, math.NaN(), math.NaN()
 	}
 
-	// 第二步：计算信号线（DEA）= 对DIF序列计算9期EMA（使用优化的序列计算）
-	deaSeq := calculateEMASequenceFromValues(difValues, 9)
+	// 第二步：计算信号线（DEA）= 对DIF序列计算signal期EMA（使用优化的序列计算）
+	deaSeq := calculateEMASequenceFromValues(difValues, signal)
 	if len(deaSeq) == 0 {
 		return currentDif, math.NaN(), math.NaN()
 	}
@@ -594,37 +789,27 @@ func calculateMACDWithComponents(klines []Kline) (float64, float64, float64) {
 
 // calculateMACDSequence 计算MACD序列（返回DIF、DEA、HIST三个序列）
 // 返回值：(DIF序列, DEA序列, HIST序列)
-func calculateMACDSequence(klines []Kline) ([]float64, []float64, []float64) {
-	if len(klines) < 26 {
+func calculateMACDSequence(klines []Kline, fast, slow, signal int) ([]float64, []float64, []float64) {
+	if len(klines) < slow {
 		return nil, nil, nil
 	}
 
 	// 第一步：使用增量计算EMA序列（O(n)时间复杂度）
-	ema12Seq := calculateEMASequence(klines, 12)
-	ema26Seq := calculateEMASequence(klines, 26)
+	emaFastSeq := calculateEMASequence(klines, fast)
+	emaSlowSeq := calculateEMASequence(klines, slow)
 
-	if len(ema12Seq) == 0 || len(ema26Seq) == 0 {
+	if len(emaFastSeq) == 0 || len(emaSlowSeq) == 0 {
 		return nil, nil, nil
 	}
 
-	// 计算DIF序列（从第26根K线开始，因为EMA26需要26根K线）
-	difValues := make([]float64, 0, len(ema26Seq))
-	ema12StartIdx := len(ema12Seq) - len(ema26Seq)
-	
-	for i := 0; i < len(ema26Seq); i++ {
-		ema12Idx := ema12StartIdx + i
-		if ema12Idx >= 0 && ema12Idx < len(ema12Seq) {
-			difAtI := ema12Seq[ema12Idx] - ema26Seq[i]
-			difValues = append(difValues, difAtI)
-		}
-	}
-
+	// 计算DIF序列（从第slow根K线开始，因为慢线EMA需要slow根K线）
+	difValues := macdDIFValues(emaFastSeq, emaSlowSeq)
 	if len(difValues) == 0 {
 		return nil, nil, nil
 	}
 
-	// 第二步：计算信号线（DEA）= 对DIF序列计算9期EMA
-	deaSeq := calculateEMASequenceFromValues(difValues, 9)
+	// 第二步：计算信号线（DEA）= 对DIF序列计算signal期EMA
+	deaSeq := calculateEMASequenceFromValues(difValues, signal)
 	if len(deaSeq) == 0 {
 		// 如果无法计算DEA，返回DIF序列，DEA和HIST为nil
 		return difValues, nil, nil
@@ -647,6 +832,22 @@ func calculateMACDSequence(klines []Kline) ([]float64, []float64, []float64) {
 	return difValues, deaSeq, histValues
 }
 
+// macdDIFValues 按K线对齐快线和慢线EMA序列，计算DIF序列（快线 - 慢线）
+// 快线序列长度 = len(klines) - fast + 1，慢线序列长度 = len(klines) - slow + 1，
+// DIF序列从慢线序列开始的位置对应，即快线序列的索引从 len(emaFastSeq) - len(emaSlowSeq) 开始
+func macdDIFValues(emaFastSeq, emaSlowSeq []float64) []float64 {
+	difValues := make([]float64, 0, len(emaSlowSeq))
+	fastStartIdx := len(emaFastSeq) - len(emaSlowSeq)
+
+	for i := 0; i < len(emaSlowSeq); i++ {
+		fastIdx := fastStartIdx + i
+		if fastIdx >= 0 && fastIdx < len(emaFastSeq) {
+			difValues = append(difValues, emaFastSeq[fastIdx]-emaSlowSeq[i])
+		}
+	}
+	return difValues
+}
+
 // calculateRSISequence 计算RSI序列（增量计算，O(n)时间复杂度）
 // 返回每个时间点的RSI值序列
 func calculateRSISequence(klines []Kline, period int) []float64 {
@@ -783,6 +984,234 @@ func calculateATR(klines []Kline, period int) float64 {
 	return atr
 }
 
+// calculateBollinger 计算布林带（上轨、中轨、下轨）
+// 中轨为最近period根K线收盘价的SMA，标准差使用同一窗口的收盘价（总体标准差）
+// 数据不足时返回NaN，调用方需要检查
+func calculateBollinger(klines []Kline, period int, stdDevMultiplier float64) (float64, float64, float64) {
+	if period <= 0 || len(klines) < period {
+		return math.NaN(), math.NaN(), math.NaN()
+	}
+
+	window := klines[len(klines)-period:]
+
+	sum := 0.0
+	for _, k := range window {
+		sum += k.Close
+	}
+	middle := sum / float64(period)
+
+	variance := 0.0
+	for _, k := range window {
+		diff := k.Close - middle
+		variance += diff * diff
+	}
+	stdDev := math.Sqrt(variance / float64(period))
+
+	return middle + stdDevMultiplier*stdDev, middle, middle - stdDevMultiplier*stdDev
+}
+
+// calculateADX 计算ADX（平均趋向指数，衡量趋势强度，不区分方向）
+// 使用Wilder平滑：先平滑TR/+DM/-DM得到+DI/-DI，再对DX做period周期平滑
+// 至少需要2*period+1根K线，数据不足时返回NaN，调用方需要检查
+func calculateADX(klines []Kline, period int) float64 {
+	if period <= 0 || len(klines) < 2*period+1 {
+		return math.NaN()
+	}
+
+	var smoothedTR, smoothedPlusDM, smoothedMinusDM float64
+	var dxSum, adx float64
+
+	for i := 1; i < len(klines); i++ {
+		high := klines[i].High
+		low := klines[i].Low
+		prevHigh := klines[i-1].High
+		prevLow := klines[i-1].Low
+		prevClose := klines[i-1].Close
+
+		tr := math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+
+		upMove := high - prevHigh
+		downMove := prevLow - low
+		plusDM, minusDM := 0.0, 0.0
+		if upMove > downMove && upMove > 0 {
+			plusDM = upMove
+		}
+		if downMove > upMove && downMove > 0 {
+			minusDM = downMove
+		}
+
+		if i <= period {
+			// 初始值：前period根的累加
+			smoothedTR += tr
+			smoothedPlusDM += plusDM
+			smoothedMinusDM += minusDM
+			if i < period {
+				continue
+			}
+		} else {
+			smoothedTR = smoothedTR - smoothedTR/float64(period) + tr
+			smoothedPlusDM = smoothedPlusDM - smoothedPlusDM/float64(period) + plusDM
+			smoothedMinusDM = smoothedMinusDM - smoothedMinusDM/float64(period) + minusDM
+		}
+
+		dx := 0.0
+		if smoothedTR > 0 {
+			plusDI := 100 * smoothedPlusDM / smoothedTR
+			minusDI := 100 * smoothedMinusDM / smoothedTR
+			if diSum := plusDI + minusDI; diSum > 0 {
+				dx = 100 * math.Abs(plusDI-minusDI) / diSum
+			}
+		}
+
+		// 第一个ADX为前period个DX的平均值，之后Wilder平滑
+		dxCount := i - period + 1
+		switch {
+		case dxCount < period:
+			dxSum += dx
+		case dxCount == period:
+			dxSum += dx
+			adx = dxSum / float64(period)
+		default:
+			adx = (adx*float64(period-1) + dx) / float64(period)
+		}
+	}
+
+	return adx
+}
+
+// calculateStochRSI 计算随机RSI（StochRSI），返回%K和%D（0-100）
+// StochRSI = (RSI - stochPeriod内RSI最低值) / (最高值 - 最低值)，%K为其k周期均线，%D为%K的d周期均线
+// 至少需要 rsiPeriod+stochPeriod+k+d-2 根K线，数据不足时返回NaN，调用方需要检查
+func calculateStochRSI(klines []Kline, rsiPeriod, stochPeriod, k, d int) (float64, float64) {
+	if rsiPeriod <= 0 || stochPeriod <= 0 || k <= 0 || d <= 0 {
+		return math.NaN(), math.NaN()
+	}
+
+	rsiSeq := calculateRSISequence(klines, rsiPeriod)
+	if len(rsiSeq) < stochPeriod+k+d-2 {
+		return math.NaN(), math.NaN()
+	}
+
+	// 原始StochRSI序列
+	stochSeq := make([]float64, 0, len(rsiSeq)-stochPeriod+1)
+	for i := stochPeriod - 1; i < len(rsiSeq); i++ {
+		lowest, highest := rsiSeq[i], rsiSeq[i]
+		for _, v := range rsiSeq[i-stochPeriod+1 : i] {
+			lowest = math.Min(lowest, v)
+			highest = math.Max(highest, v)
+		}
+		if highest == lowest {
+			stochSeq = append(stochSeq, 50) // RSI在区间内没有波动，视为中性
+		} else {
+			stochSeq = append(stochSeq, (rsiSeq[i]-lowest)/(highest-lowest)*100)
+		}
+	}
+
+	kSeq := simpleMovingAverageSequence(stochSeq, k)
+	dSeq := simpleMovingAverageSequence(kSeq, d)
+	if len(dSeq) == 0 {
+		return math.NaN(), math.NaN()
+	}
+
+	return kSeq[len(kSeq)-1], dSeq[len(dSeq)-1]
+}
+
+// calculateVWAP 计算K线窗口内的成交量加权均价（典型价 = (最高+最低+收盘)/3）
+// 窗口内没有成交量时返回0
+func calculateVWAP(klines []Kline) float64 {
+	var pv, volume float64
+	for _, k := range klines {
+		typicalPrice := (k.High + k.Low + k.Close) / 3
+		pv += typicalPrice * k.Volume
+		volume += k.Volume
+	}
+	if volume <= 0 {
+		return 0
+	}
+	return pv / volume
+}
+
+// PriceVsVWAPPct 返回当前价格相对VWAP的偏离百分比（>0在VWAP上方），VWAP无效时返回0
+func PriceVsVWAPPct(data *Data) float64 {
+	if data == nil || data.CurrentVWAP <= 0 {
+		return 0
+	}
+	return (data.CurrentPrice - data.CurrentVWAP) / data.CurrentVWAP * 100
+}
+
+// calculateOBV 计算能量潮（On-Balance Volume），返回最新OBV和最近slopePeriod根K线的OBV斜率
+// 收盘价上涨时累加成交量，下跌时减去成交量，收盘价不变（平盘K线）时OBV不变
+// 斜率为OBV序列的线性回归斜率除以同期平均成交量（即每根K线平均净流入多少根K线的成交量），
+// 不同币种之间可比；K线不足时斜率返回NaN，期间没有成交量时斜率为0
+func calculateOBV(klines []Kline, slopePeriod int) (float64, float64) {
+	if len(klines) == 0 {
+		return 0, math.NaN()
+	}
+
+	obvSeq := make([]float64, len(klines))
+	for i := 1; i < len(klines); i++ {
+		obvSeq[i] = obvSeq[i-1]
+		switch {
+		case klines[i].Close > klines[i-1].Close:
+			obvSeq[i] += klines[i].Volume
+		case klines[i].Close < klines[i-1].Close:
+			obvSeq[i] -= klines[i].Volume
+		}
+	}
+	obv := obvSeq[len(obvSeq)-1]
+
+	if slopePeriod < 2 || len(klines) < slopePeriod+1 {
+		return obv, math.NaN()
+	}
+
+	window := obvSeq[len(obvSeq)-slopePeriod:]
+	avgVolume := 0.0
+	for _, k := range klines[len(klines)-slopePeriod:] {
+		avgVolume += k.Volume
+	}
+	avgVolume /= float64(slopePeriod)
+	if avgVolume <= 0 {
+		return obv, 0
+	}
+
+	// 最小二乘法：x为0..n-1
+	n := float64(len(window))
+	meanX := (n - 1) / 2
+	meanY := 0.0
+	for _, v := range window {
+		meanY += v
+	}
+	meanY /= n
+	var num, den float64
+	for i, v := range window {
+		dx := float64(i) - meanX
+		num += dx * (v - meanY)
+		den += dx * dx
+	}
+
+	return obv, num / den / avgVolume
+}
+
+// simpleMovingAverageSequence 计算序列的简单移动平均序列（长度为 len(values)-period+1）
+func simpleMovingAverageSequence(values []float64, period int) []float64 {
+	if period <= 0 || len(values) < period {
+		return nil
+	}
+
+	sequence := make([]float64, 0, len(values)-period+1)
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		if i >= period {
+			sum -= values[i-period]
+		}
+		if i >= period-1 {
+			sequence = append(sequence, sum/float64(period))
+		}
+	}
+	return sequence
+}
+
 // getOpenInterestData 获取OI数据（支持多平台）
 func getOpenInterestData(symbol string) (*OIData, error) {
 	exchangeMutex.RLock()
@@ -791,13 +1220,7 @@ func getOpenInterestData(symbol string) (*OIData, error) {
 	
 	url := fmt.Sprintf("%s/fapi/v1/openInterest?symbol=%s", apiURL, symbol)
 
-	resp, err := http.Get(url)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
+	body, err := doGet(url)
 	if err != nil {
 		return nil, err
 	}
@@ -817,60 +1240,123 @@ func getOpenInterestData(symbol string) (*OIData, error) {
 		return nil, fmt.Errorf("解析OpenInterest失败: %w", err)
 	}
 
-	// 注意：目前只返回最新值，平均值需要历史数据计算
-	// 如果后续需要，应该维护历史OI数据来计算平均值
+	// 平均值取近24小时OI历史的均值，拉取失败时回退为最新值
+	average, err := getOpenInterestAverage(symbol)
+	if err != nil {
+		log.Printf("⚠️  获取 %s OI历史失败，平均值使用最新值: %v", symbol, err)
+		average = oi
+	}
+
 	return &OIData{
 		Latest:  oi,
-		Average: oi, // 暂时使用最新值作为平均值（需要历史数据才能准确计算）
+		Average: average,
 	}, nil
 }
 
-// getFundingRate 获取资金费率（支持多平台）
-func getFundingRate(symbol string) (float64, error) {
+// GetFundingRate 获取币种当前资金费率（用于开仓前检查，不拉取K线）
+func GetFundingRate(symbol string) (float64, error) {
+	funding, err := getFundingRate(Normalize(symbol))
+	if err != nil {
+		return 0, err
+	}
+	return funding.Rate, nil
+}
+
+// getFundingRate 获取资金费率、下次结算时间和标记/指数价格（支持多平台）
+func getFundingRate(symbol string) (*FundingInfo, error) {
 	exchangeMutex.RLock()
 	apiURL := baseAPIURL
 	exchangeMutex.RUnlock()
 	
 	url := fmt.Sprintf("%s/fapi/v1/premiumIndex?symbol=%s", apiURL, symbol)
 
-	resp, err := http.Get(url)
+	body, err := doGet(url)
 	if err != nil {
-		return 0, err
-	}
-	defer resp.Body.Close()
-
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return 0, err
-	}
-
-	var result struct {
-		Symbol          string `json:"symbol"`
-		MarkPrice       string `json:"markPrice"`
-		IndexPrice      string `json:"indexPrice"`
-		LastFundingRate string `json:"lastFundingRate"`
-		NextFundingTime int64  `json:"nextFundingTime"`
-		InterestRate    string `json:"interestRate"`
-		Time            int64  `json:"time"`
+		return nil, err
 	}
 
+	var result premiumIndex
 	if err := json.Unmarshal(body, &result); err != nil {
-		return 0, err
+		return nil, err
 	}
+	return result.toFundingInfo()
+}
 
-	rate, err := strconv.ParseFloat(result.LastFundingRate, 64)
+// premiumIndex 资金费率接口（/fapi/v1/premiumIndex）的响应
+type premiumIndex struct {
+	Symbol          string `json:"symbol"`
+	MarkPrice       string `json:"markPrice"`
+	IndexPrice      string `json:"indexPrice"`
+	LastFundingRate string `json:"lastFundingRate"`
+	NextFundingTime int64  `json:"nextFundingTime"`
+	InterestRate    string `json:"interestRate"`
+	Time            int64  `json:"time"`
+}
+
+// toFundingInfo 解析资金费率和标记/指数价格（价格解析失败时为0，不影响资金费率）
+func (p *premiumIndex) toFundingInfo() (*FundingInfo, error) {
+	rate, err := strconv.ParseFloat(p.LastFundingRate, 64)
 	if err != nil {
-		return 0, fmt.Errorf("解析LastFundingRate失败: %w", err)
-	}
-	return rate, nil
+		return nil, fmt.Errorf("解析LastFundingRate失败: %w", err)
+	}
+	markPrice, _ := strconv.ParseFloat(p.MarkPrice, 64)
+	indexPrice, _ := strconv.ParseFloat(p.IndexPrice, 64)
+	return &FundingInfo{
+		Symbol:          p.Symbol,
+		Rate:            rate,
+		NextFundingTime: p.NextFundingTime,
+		MarkPrice:       markPrice,
+		IndexPrice:      indexPrice,
+	}, nil
 }
 
 // Format 格式化输出市场数据
 func Format(data *Data) string {
 	var sb strings.Builder
 
-	sb.WriteString(fmt.Sprintf("current_price = %.2f, current_ema20 = %.3f, current_macd = %.3f, current_rsi (7 period) = %.3f\n\n",
-		data.CurrentPrice, data.CurrentEMA20, data.CurrentMACD, data.CurrentRSI7))
+	sb.WriteString(fmt.Sprintf("current_price = %.2f, current_ema20 = %.3f, current_macd = %.3f, current_rsi (7 period) = %.3f, current_atr (14 period) = %.4f\n\n",
+		data.CurrentPrice, data.CurrentEMA20, data.CurrentMACD, data.CurrentRSI7, data.CurrentATR14))
+
+	if data.CurrentEMA200 > 0 {
+		position := "below"
+		if data.CurrentPrice > data.CurrentEMA200 {
+			position = "above"
+		}
+		sb.WriteString(fmt.Sprintf("current_ema50 = %.3f, current_ema200 = %.3f (price %s EMA200)\n\n",
+			data.CurrentEMA50, data.CurrentEMA200, position))
+	} else if data.CurrentEMA50 > 0 {
+		sb.WriteString(fmt.Sprintf("current_ema50 = %.3f (not enough candles for EMA200)\n\n", data.CurrentEMA50))
+	}
+
+	switch data.EMACross {
+	case EMACrossGolden:
+		sb.WriteString(fmt.Sprintf("EMA50/EMA200 golden cross (EMA50 crossed above EMA200) within the last %d bars\n\n", EMACrossLookbackBars))
+	case EMACrossDeath:
+		sb.WriteString(fmt.Sprintf("EMA50/EMA200 death cross (EMA50 crossed below EMA200) within the last %d bars\n\n", EMACrossLookbackBars))
+	}
+
+	if data.BollingerMiddle > 0 {
+		sb.WriteString(fmt.Sprintf("Bollinger Bands (20, 2σ): upper = %.4f, middle = %.4f, lower = %.4f\n\n",
+			data.BollingerUpper, data.BollingerMiddle, data.BollingerLower))
+	}
+
+	if data.CurrentADX > 0 {
+		sb.WriteString(fmt.Sprintf("ADX (14 period, trend strength) = %.2f (<20 choppy/ranging, >25 trending)\n\n", data.CurrentADX))
+	}
+
+	if data.StochK > 0 || data.StochD > 0 {
+		sb.WriteString(fmt.Sprintf("StochRSI (14, 14, 3, 3): %%K = %.2f, %%D = %.2f (<20 oversold, >80 overbought)\n\n", data.StochK, data.StochD))
+	}
+
+	if data.OBVSlope != 0 {
+		sb.WriteString(fmt.Sprintf("OBV = %.2f, OBV slope (last %d bars, in average-volume units per bar) = %.3f (>0 accumulation/buying volume, <0 distribution/selling volume)\n\n",
+			data.CurrentOBV, OBVSlopePeriod, data.OBVSlope))
+	}
+
+	if data.CurrentVWAP > 0 {
+		sb.WriteString(fmt.Sprintf("VWAP (fetched window) = %.4f, price vs VWAP = %+.2f%% (far above/below VWAP suggests stretched price, mean reversion risk)\n\n",
+			data.CurrentVWAP, PriceVsVWAPPct(data)))
+	}
 
 	sb.WriteString(fmt.Sprintf("In addition, here is the latest %s open interest and funding rate for perps:\n\n",
 		data.Symbol))
@@ -880,7 +1366,17 @@ func Format(data *Data) string {
 			data.OpenInterest.Latest, data.OpenInterest.Average))
 	}
 
-	sb.WriteString(fmt.Sprintf("Funding Rate: %.2e\n\n", data.FundingRate))
+	if data.NextFundingTime > 0 {
+		untilSettlement := time.Until(time.UnixMilli(data.NextFundingTime))
+		sb.WriteString(fmt.Sprintf("Funding Rate: %.2e (time until next funding: %.0f min)\n\n", data.FundingRate, math.Max(untilSettlement.Minutes(), 0)))
+	} else {
+		sb.WriteString(fmt.Sprintf("Funding Rate: %.2e\n\n", data.FundingRate))
+	}
+
+	if data.MarkPrice > 0 && data.IndexPrice > 0 {
+		sb.WriteString(fmt.Sprintf("Mark Price: %.4f, Index Price: %.4f (premium %+.3f%%)\n\n",
+			data.MarkPrice, data.IndexPrice, (data.MarkPrice-data.IndexPrice)/data.IndexPrice*100))
+	}
 
 	if data.IntradaySeries != nil {
 		sb.WriteString("Intraday series (oldest → latest):\n\n")
@@ -0,0 +1,42 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// FundingInfo 币种的资金费率、下次结算时间和标记/指数价格（来自premiumIndex接口）
+type FundingInfo struct {
+	Symbol          string
+	Rate            float64 // 最近的资金费率（小数，正数表示多头支付给空头）
+	NextFundingTime int64   // 下次结算时间（毫秒时间戳）
+	MarkPrice       float64 // 标记价格
+	IndexPrice      float64 // 指数价格
+}
+
+// GetAllFundingRates 一次请求获取所有币种的资金费率（用于筛选资金费率捕获机会，避免逐个币种请求）
+func GetAllFundingRates() ([]FundingInfo, error) {
+	exchangeMutex.RLock()
+	apiURL := baseAPIURL
+	exchangeMutex.RUnlock()
+
+	body, err := doGet(fmt.Sprintf("%s/fapi/v1/premiumIndex", apiURL))
+	if err != nil {
+		return nil, err
+	}
+
+	var results []premiumIndex
+	if err := json.Unmarshal(body, &results); err != nil {
+		return nil, fmt.Errorf("解析资金费率列表失败: %w", err)
+	}
+
+	infos := make([]FundingInfo, 0, len(results))
+	for _, result := range results {
+		info, err := result.toFundingInfo()
+		if err != nil || info.NextFundingTime <= 0 {
+			continue // 已下架或未开始收取资金费的币种
+		}
+		infos = append(infos, *info)
+	}
+	return infos, nil
+}
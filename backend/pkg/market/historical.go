@@ -0,0 +1,172 @@
+package market
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Source 市场数据来源（实盘从交易所API获取，回测从历史K线构建）
+type Source interface {
+	GetWithTimeframe(symbol, timeframe string, limit int) (*Data, error)
+}
+
+// GetFrom 从指定数据来源获取市场数据（source为nil时使用实盘API）
+func GetFrom(source Source, symbol, timeframe string, limit int) (*Data, error) {
+	if source == nil {
+		return GetWithTimeframe(symbol, timeframe, limit)
+	}
+	return source.GetWithTimeframe(symbol, timeframe, limit)
+}
+
+// klineIntervals 各时间框架K线的时长
+var klineIntervals = map[string]time.Duration{
+	"1m":  time.Minute,
+	"3m":  3 * time.Minute,
+	"5m":  5 * time.Minute,
+	"15m": 15 * time.Minute,
+	"30m": 30 * time.Minute,
+	"1h":  time.Hour,
+	"4h":  4 * time.Hour,
+	"1d":  24 * time.Hour,
+}
+
+// IntervalDuration 获取时间框架对应的K线时长（不支持的时间框架返回0）
+func IntervalDuration(interval string) time.Duration {
+	return klineIntervals[interval]
+}
+
+// klinesRangePageSize 按时间范围拉取K线时每页数量（交易所单次最多1500根）
+const klinesRangePageSize = 1500
+
+// FetchKlinesRange 分页拉取指定时间范围内的K线（旧→新，不使用缓存）
+func FetchKlinesRange(symbol, interval string, start, end time.Time) ([]Kline, error) {
+	symbol = Normalize(symbol)
+	if IntervalDuration(interval) == 0 {
+		return nil, fmt.Errorf("不支持的时间框架: %s", interval)
+	}
+
+	exchangeMutex.RLock()
+	apiURL := baseAPIURL
+	exchangeMutex.RUnlock()
+
+	var all []Kline
+	cursor := start.UnixMilli()
+	endMs := end.UnixMilli()
+	for cursor < endMs {
+		url := fmt.Sprintf("%s/fapi/v1/klines?symbol=%s&interval=%s&startTime=%d&endTime=%d&limit=%d",
+			apiURL, symbol, interval, cursor, endMs, klinesRangePageSize)
+
+		page, err := requestKlines(url)
+		if err != nil {
+			// 时间范围内没有K线（如币种尚未上市）时接口返回空数组
+			if len(all) > 0 {
+				break
+			}
+			return nil, fmt.Errorf("获取%s %s K线失败: %w", symbol, interval, err)
+		}
+
+		all = append(all, page...)
+		next := page[len(page)-1].OpenTime + 1
+		if len(page) < klinesRangePageSize || next <= cursor {
+			break
+		}
+		cursor = next
+	}
+
+	return all, nil
+}
+
+// HistoricalSource 历史K线数据来源（用于回测）
+// 预先加载完整的历史K线，按当前回放时间截取已收盘的K线计算指标，不会请求交易所API
+// 没有历史OI和资金费率数据，对应字段为空/0
+type HistoricalSource struct {
+	klines map[string]map[string][]Kline // symbol -> interval -> K线（旧→新）
+	now    int64                         // 当前回放时间（毫秒），只有收盘时间早于此时间的K线可见
+	mu     sync.RWMutex
+}
+
+// NewHistoricalSource 创建历史K线数据来源
+func NewHistoricalSource() *HistoricalSource {
+	return &HistoricalSource{
+		klines: make(map[string]map[string][]Kline),
+	}
+}
+
+// Load 拉取[start-warmupBars根, end]范围内的K线（warmupBars用于保证回放开始时指标已成熟）
+func (h *HistoricalSource) Load(symbol, interval string, start, end time.Time, warmupBars int) error {
+	from := start.Add(-time.Duration(warmupBars) * IntervalDuration(interval))
+	klines, err := FetchKlinesRange(symbol, interval, from, end)
+	if err != nil {
+		return err
+	}
+	h.SetKlines(symbol, interval, klines)
+	return nil
+}
+
+// SetKlines 设置币种某个时间框架的完整历史K线（需按时间顺序排列）
+func (h *HistoricalSource) SetKlines(symbol, interval string, klines []Kline) {
+	symbol = Normalize(symbol)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.klines[symbol] == nil {
+		h.klines[symbol] = make(map[string][]Kline)
+	}
+	h.klines[symbol][interval] = klines
+}
+
+// Klines 获取币种某个时间框架的完整历史K线（不受回放时间限制）
+func (h *HistoricalSource) Klines(symbol, interval string) []Kline {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.klines[Normalize(symbol)][interval]
+}
+
+// SetTime 设置当前回放时间
+func (h *HistoricalSource) SetTime(t time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.now = t.UnixMilli()
+}
+
+// closedKlines 获取回放时间之前已收盘的最近limit根K线（调用方需持有读锁）
+func (h *HistoricalSource) closedKlines(symbol, interval string, limit int) []Kline {
+	all := h.klines[symbol][interval]
+	n := sort.Search(len(all), func(i int) bool { return all[i].CloseTime >= h.now })
+	if limit > 0 && n > limit {
+		return all[n-limit : n]
+	}
+	return all[:n]
+}
+
+// LatestKline 获取回放时间之前最后一根已收盘的K线
+func (h *HistoricalSource) LatestKline(symbol, interval string) (Kline, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	klines := h.closedKlines(Normalize(symbol), interval, 1)
+	if len(klines) == 0 {
+		return Kline{}, false
+	}
+	return klines[0], true
+}
+
+// GetWithTimeframe 按当前回放时间构建市场数据（实现Source接口）
+func (h *HistoricalSource) GetWithTimeframe(symbol, timeframe string, limit int) (*Data, error) {
+	symbol = Normalize(symbol)
+
+	h.mu.RLock()
+	klines := h.closedKlines(symbol, timeframe, limit)
+	h.mu.RUnlock()
+
+	if len(klines) == 0 {
+		return nil, fmt.Errorf("%s %s 在回放时间之前没有历史K线", symbol, timeframe)
+	}
+
+	// 复制一份，避免指标计算时与其他goroutine共享底层数组
+	window := make([]Kline, len(klines))
+	copy(window, klines)
+	return BuildData(symbol, timeframe, window), nil
+}
@@ -0,0 +1,140 @@
+package market
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// 市场数据HTTP请求配置
+const (
+	defaultHTTPTimeout    = 10 * time.Second // 默认请求超时
+	httpMaxAttempts       = 3                // 最多请求次数（含首次）
+	httpRetryBaseDelay    = 500 * time.Millisecond
+	defaultMaxConcurrency = 10 // 默认最大并发请求数
+)
+
+// 全局变量：所有市场数据请求共用的HTTP客户端和并发限制信号量
+var (
+	httpClient      = &http.Client{Timeout: defaultHTTPTimeout}
+	requestSem      = make(chan struct{}, defaultMaxConcurrency)
+	httpClientMutex sync.RWMutex
+)
+
+// SetHTTPTimeout 设置市场数据请求的超时时间（≤0时使用默认10秒）
+func SetHTTPTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultHTTPTimeout
+	}
+
+	httpClientMutex.Lock()
+	defer httpClientMutex.Unlock()
+	httpClient = &http.Client{Timeout: timeout}
+	log.Printf("📊 市场数据API: 请求超时设置为 %v", timeout)
+}
+
+// SetMaxConcurrency 设置市场数据请求的最大并发数（≤0时使用默认10）
+// 多个币种×多个时间框架并发拉取时，限制同时在途的请求数，避免触发交易所限流（429/418）
+func SetMaxConcurrency(n int) {
+	if n <= 0 {
+		n = defaultMaxConcurrency
+	}
+
+	httpClientMutex.Lock()
+	defer httpClientMutex.Unlock()
+	// 已在途的请求仍释放到旧信号量，不受影响
+	requestSem = make(chan struct{}, n)
+	log.Printf("📊 市场数据API: 最大并发请求数设置为 %d", n)
+}
+
+// getHTTPClient 获取当前的HTTP客户端
+func getHTTPClient() *http.Client {
+	httpClientMutex.RLock()
+	defer httpClientMutex.RUnlock()
+	return httpClient
+}
+
+// acquireRequestSlot 获取一个并发请求名额，返回释放函数
+func acquireRequestSlot() func() {
+	httpClientMutex.RLock()
+	sem := requestSem
+	httpClientMutex.RUnlock()
+
+	sem <- struct{}{}
+	return func() { <-sem }
+}
+
+// httpStatusError 非200状态码错误（保留响应体，便于调用方解析交易所错误信息）
+type httpStatusError struct {
+	StatusCode int
+	Body       []byte
+}
+
+func (e *httpStatusError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, string(e.Body))
+}
+
+// isRetryableError 判断错误是否值得重试（超时、网络错误、5xx）
+func isRetryableError(err error) bool {
+	var statusErr *httpStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return true
+	}
+	return errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// doGet 发送GET请求并返回响应体（超时、网络错误、5xx时指数退避重试）
+// 非200状态码返回*httpStatusError，4xx不重试
+func doGet(url string) ([]byte, error) {
+	client := getHTTPClient()
+
+	var lastErr error
+	for attempt := 1; attempt <= httpMaxAttempts; attempt++ {
+		body, err := getOnce(client, url)
+		if err == nil {
+			return body, nil
+		}
+
+		lastErr = err
+		if !isRetryableError(err) || attempt == httpMaxAttempts {
+			break
+		}
+
+		delay := httpRetryBaseDelay * time.Duration(1<<(attempt-1))
+		log.Printf("⚠️  市场数据请求失败（第%d次），%v后重试: %v", attempt, delay, err)
+		time.Sleep(delay)
+	}
+
+	return nil, lastErr
+}
+
+// getOnce 发送一次GET请求（占用一个并发名额，重试等待期间不占用）
+func getOnce(client *http.Client, url string) ([]byte, error) {
+	release := acquireRequestSlot()
+	defer release()
+
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &httpStatusError{StatusCode: resp.StatusCode, Body: body}
+	}
+	return body, nil
+}
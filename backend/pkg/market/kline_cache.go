@@ -0,0 +1,92 @@
+package market
+
+import (
+	"sync"
+	"time"
+)
+
+// klineCacheTTLs 各时间框架K线的缓存时长（周期越长，最新K线变化越慢，可缓存越久）
+var klineCacheTTLs = map[string]time.Duration{
+	"1m":  10 * time.Second,
+	"3m":  30 * time.Second,
+	"5m":  1 * time.Minute,
+	"15m": 2 * time.Minute,
+	"30m": 5 * time.Minute,
+	"1h":  10 * time.Minute,
+	"4h":  30 * time.Minute,
+	"1d":  1 * time.Hour,
+}
+
+// cachedKlines 缓存的K线数据
+type cachedKlines struct {
+	klines    []Kline
+	limit     int // 拉取时请求的数量（用于判断能否满足更大的limit）
+	fetchedAt time.Time
+}
+
+// 全局变量：K线缓存（key为 symbol:interval）
+var (
+	klineCache      = make(map[string]cachedKlines)
+	klineCacheMutex sync.RWMutex
+)
+
+// klineCacheKey 生成K线缓存键
+func klineCacheKey(symbol, interval string) string {
+	return symbol + ":" + interval
+}
+
+// getCachedKlines 从缓存获取K线（未命中、已过期或缓存数量不足时返回false）
+// 返回最近limit根K线的副本
+func getCachedKlines(symbol, interval string, limit int) ([]Kline, bool) {
+	ttl, ok := klineCacheTTLs[interval]
+	if !ok {
+		return nil, false
+	}
+
+	klineCacheMutex.RLock()
+	cached, exists := klineCache[klineCacheKey(symbol, interval)]
+	klineCacheMutex.RUnlock()
+
+	if !exists || time.Since(cached.fetchedAt) > ttl {
+		return nil, false
+	}
+	// 缓存拉取时的limit小于本次请求的limit，无法满足（新币种K线不足时两者都按实际数量返回，不受影响）
+	if cached.limit < limit {
+		return nil, false
+	}
+
+	klines := cached.klines
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	result := make([]Kline, len(klines))
+	copy(result, klines)
+	return result, true
+}
+
+// setCachedKlines 写入K线缓存（不支持的时间框架不缓存）
+func setCachedKlines(symbol, interval string, limit int, klines []Kline) {
+	if _, ok := klineCacheTTLs[interval]; !ok {
+		return
+	}
+
+	stored := make([]Kline, len(klines))
+	copy(stored, klines)
+
+	klineCacheMutex.Lock()
+	defer klineCacheMutex.Unlock()
+	klineCache[klineCacheKey(symbol, interval)] = cachedKlines{
+		klines:    stored,
+		limit:     limit,
+		fetchedAt: time.Now(),
+	}
+}
+
+// ClearCache 清空K线缓存和OI历史均值缓存（用于测试或切换交易所后强制重新拉取）
+func ClearCache() {
+	klineCacheMutex.Lock()
+	klineCache = make(map[string]cachedKlines)
+	klineCacheMutex.Unlock()
+
+	clearOIAverageCache()
+}
@@ -0,0 +1,51 @@
+package market
+
+import (
+	"log"
+	"sync"
+)
+
+// MACDParams MACD指标周期参数
+type MACDParams struct {
+	Fast   int // 快线EMA周期
+	Slow   int // 慢线EMA周期
+	Signal int // 信号线（DEA）周期
+}
+
+// DefaultMACDParams 标准MACD参数（12/26/9）
+var DefaultMACDParams = MACDParams{Fast: 12, Slow: 26, Signal: 9}
+
+// 全局变量：计算MACD使用的周期参数（实盘和回测共用）
+var (
+	macdParams   = DefaultMACDParams
+	macdParamsMu sync.RWMutex
+)
+
+// SetMACDParams 设置计算MACD使用的周期参数（未设置的周期使用默认值，快线周期不小于慢线周期时整体使用默认值）
+func SetMACDParams(params MACDParams) {
+	if params.Fast <= 0 {
+		params.Fast = DefaultMACDParams.Fast
+	}
+	if params.Slow <= 0 {
+		params.Slow = DefaultMACDParams.Slow
+	}
+	if params.Signal <= 0 {
+		params.Signal = DefaultMACDParams.Signal
+	}
+	if params.Fast >= params.Slow {
+		log.Printf("⚠️  MACD快线周期(%d)必须小于慢线周期(%d)，使用默认参数", params.Fast, params.Slow)
+		params = DefaultMACDParams
+	}
+
+	macdParamsMu.Lock()
+	defer macdParamsMu.Unlock()
+	macdParams = params
+	log.Printf("📊 MACD参数: %d/%d/%d", params.Fast, params.Slow, params.Signal)
+}
+
+// getMACDParams 获取当前的MACD周期参数
+func getMACDParams() MACDParams {
+	macdParamsMu.RLock()
+	defer macdParamsMu.RUnlock()
+	return macdParams
+}
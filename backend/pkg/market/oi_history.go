@@ -0,0 +1,84 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// OI历史均值参数：取最近24根1小时OI统计，即近24小时的平均持仓量
+const (
+	oiHistoryPeriod   = "1h"
+	oiHistoryLimit    = 24
+	oiHistoryCacheTTL = 5 * time.Minute
+)
+
+// cachedOIAverage 缓存的OI历史均值
+type cachedOIAverage struct {
+	average   float64
+	fetchedAt time.Time
+}
+
+// 全局变量：OI历史均值缓存（key为symbol）
+var (
+	oiAverageCache      = make(map[string]cachedOIAverage)
+	oiAverageCacheMutex sync.RWMutex
+)
+
+// getOpenInterestAverage 获取币种近期OI的滚动平均值（带短时缓存，避免每个周期重复拉取历史）
+func getOpenInterestAverage(symbol string) (float64, error) {
+	oiAverageCacheMutex.RLock()
+	cached, exists := oiAverageCache[symbol]
+	oiAverageCacheMutex.RUnlock()
+	if exists && time.Since(cached.fetchedAt) <= oiHistoryCacheTTL {
+		return cached.average, nil
+	}
+
+	exchangeMutex.RLock()
+	apiURL := baseAPIURL
+	exchangeMutex.RUnlock()
+
+	url := fmt.Sprintf("%s/futures/data/openInterestHist?symbol=%s&period=%s&limit=%d",
+		apiURL, symbol, oiHistoryPeriod, oiHistoryLimit)
+
+	body, err := doGet(url)
+	if err != nil {
+		return 0, err
+	}
+
+	var history []struct {
+		SumOpenInterest string `json:"sumOpenInterest"`
+		Timestamp       int64  `json:"timestamp"`
+	}
+	if err := json.Unmarshal(body, &history); err != nil {
+		return 0, fmt.Errorf("解析OI历史失败: %w", err)
+	}
+	if len(history) == 0 {
+		return 0, fmt.Errorf("OI历史为空")
+	}
+
+	sum := 0.0
+	for _, h := range history {
+		v, err := strconv.ParseFloat(h.SumOpenInterest, 64)
+		if err != nil {
+			return 0, fmt.Errorf("解析sumOpenInterest失败: %w", err)
+		}
+		sum += v
+	}
+	average := sum / float64(len(history))
+
+	oiAverageCacheMutex.Lock()
+	oiAverageCache[symbol] = cachedOIAverage{average: average, fetchedAt: time.Now()}
+	oiAverageCacheMutex.Unlock()
+
+	return average, nil
+}
+
+// clearOIAverageCache 清空OI历史均值缓存
+func clearOIAverageCache() {
+	oiAverageCacheMutex.Lock()
+	defer oiAverageCacheMutex.Unlock()
+	oiAverageCache = make(map[string]cachedOIAverage)
+}
@@ -0,0 +1,134 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 衍生品短期历史配置
+const (
+	trendSampleMinInterval = 1 * time.Minute // 同一币种两次采样的最小间隔（多个时间框架同周期获取时避免重复采样）
+	trendHistoryRetention  = 24 * time.Hour  // 历史保留时长
+	trendHistoryMaxSamples = 1500            // 每个币种最多保留的采样点数量
+	trendWindow            = 4 * time.Hour   // 趋势计算窗口
+)
+
+// HistoryPoint 短期历史采样点
+type HistoryPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+// 全局变量：OI和资金费率的短期滚动历史（symbol -> 采样序列，旧→新）
+var (
+	oiHistoryBySymbol      = make(map[string][]HistoryPoint)
+	fundingHistoryBySymbol = make(map[string][]HistoryPoint)
+	historyMutex           sync.Mutex
+)
+
+// recordHistory 记录一个采样点并返回该币种历史的副本
+func recordHistory(history map[string][]HistoryPoint, symbol string, value float64) []HistoryPoint {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+
+	now := time.Now()
+	points := history[symbol]
+	if len(points) == 0 || now.Sub(points[len(points)-1].Time) >= trendSampleMinInterval {
+		points = append(points, HistoryPoint{Time: now, Value: value})
+	} else {
+		// 间隔过短，用最新值覆盖最后一个采样点
+		points[len(points)-1] = HistoryPoint{Time: now, Value: value}
+	}
+
+	// 清理过期采样点
+	start := 0
+	for start < len(points) && now.Sub(points[start].Time) > trendHistoryRetention {
+		start++
+	}
+	if len(points)-start > trendHistoryMaxSamples {
+		start = len(points) - trendHistoryMaxSamples
+	}
+	points = points[start:]
+	history[symbol] = points
+
+	result := make([]HistoryPoint, len(points))
+	copy(result, points)
+	return result
+}
+
+// snapshotHistory 返回该币种历史的副本（获取最新值失败时使用）
+func snapshotHistory(history map[string][]HistoryPoint, symbol string) []HistoryPoint {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+
+	points := history[symbol]
+	result := make([]HistoryPoint, len(points))
+	copy(result, points)
+	return result
+}
+
+// trendBase 返回趋势窗口内最早的采样点和最新采样点
+// 历史不足2个采样点时返回ok=false
+func trendBase(points []HistoryPoint) (base, latest HistoryPoint, ok bool) {
+	if len(points) < 2 {
+		return HistoryPoint{}, HistoryPoint{}, false
+	}
+	latest = points[len(points)-1]
+	for _, p := range points {
+		if latest.Time.Sub(p.Time) <= trendWindow {
+			base = p
+			break
+		}
+	}
+	if !latest.Time.After(base.Time) {
+		return HistoryPoint{}, HistoryPoint{}, false
+	}
+	return base, latest, true
+}
+
+// formatSpan 格式化时间跨度
+func formatSpan(d time.Duration) string {
+	if d >= time.Hour {
+		return fmt.Sprintf("%.1fh", d.Hours())
+	}
+	return fmt.Sprintf("%.0fm", d.Minutes())
+}
+
+// FormatDerivativesTrend 格式化OI和资金费率的短期趋势（用于prompt）
+// 历史数据不足时返回空字符串
+func FormatDerivativesTrend(data *Data) string {
+	if data == nil {
+		return ""
+	}
+
+	var parts []string
+
+	if base, latest, ok := trendBase(data.OIHistory); ok && base.Value > 0 {
+		changePct := (latest.Value - base.Value) / base.Value * 100
+		direction := "持平"
+		if changePct > 0.5 {
+			direction = "上升"
+		} else if changePct < -0.5 {
+			direction = "下降"
+		}
+		parts = append(parts, fmt.Sprintf("OI %s %+.2f%%（近%s）", direction, changePct, formatSpan(latest.Time.Sub(base.Time))))
+	}
+
+	if base, latest, ok := trendBase(data.FundingHistory); ok {
+		direction := "持平"
+		diff := latest.Value - base.Value
+		if math.Abs(diff) >= 0.00001 {
+			if diff > 0 {
+				direction = "上升"
+			} else {
+				direction = "下降"
+			}
+		}
+		parts = append(parts, fmt.Sprintf("资金费率 %s %.4f%% → %.4f%%（近%s）", direction, base.Value*100, latest.Value*100, formatSpan(latest.Time.Sub(base.Time))))
+	}
+
+	return strings.Join(parts, " | ")
+}
@@ -5,6 +5,7 @@ import (
 	"compress/gzip"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -28,21 +29,91 @@ type Client struct {
 	SecretKey  string // 阿里云需要
 	BaseURL    string
 	Model      string
-	Timeout    time.Duration
+	Timeout    time.Duration // 单次请求超时（每次重试单独计时）
 	UseFullURL bool // 是否使用完整URL（不添加/chat/completions）
+
+	MaxRetries     int           // 临时性失败（超时、网络错误、5xx、429）的最大重试次数
+	RetryBaseDelay time.Duration // 重试退避基准时间（第n次重试前等待 RetryBaseDelay × 2^(n-1)）
+
+	Temperature float64 // 采样温度（越低输出越稳定，便于复现和排查决策）
+	TopP        float64 // 核采样概率（0表示不在请求中设置，使用服务端默认值）
+	MaxTokens   int     // 最大输出token数
+
+	stub ResponseFunc // 非nil时不请求AI API，直接由该函数生成响应（回测等离线场景）
 }
 
+// ResponseFunc 根据 system + user prompt 生成AI响应
+type ResponseFunc func(systemPrompt, userPrompt string) (string, error)
+
+// 默认重试配置
+const (
+	DefaultMaxRetries     = 2
+	DefaultRetryBaseDelay = 2 * time.Second
+)
+
+// 默认采样参数
+const (
+	DefaultTemperature = 0.5  // 降低temperature以提高JSON格式稳定性
+	DefaultMaxTokens   = 4000 // 提示词较长且需要完整JSON响应
+)
+
 func New() *Client {
 	// 默认配置
 	var defaultClient = Client{
-		Provider: ProviderDeepSeek,
-		BaseURL:  "https://api.deepseek.com/v1",
-		Model:    "deepseek-chat",
-		Timeout:  300 * time.Second, // 增加到300秒（5分钟），因为AI需要分析大量数据和生成完整JSON响应
+		Provider:       ProviderDeepSeek,
+		BaseURL:        "https://api.deepseek.com/v1",
+		Model:          "deepseek-chat",
+		Timeout:        300 * time.Second, // 增加到300秒（5分钟），因为AI需要分析大量数据和生成完整JSON响应
+		MaxRetries:     DefaultMaxRetries,
+		RetryBaseDelay: DefaultRetryBaseDelay,
+		Temperature:    DefaultTemperature,
+		MaxTokens:      DefaultMaxTokens,
 	}
 	return &defaultClient
 }
 
+// SetSamplingParams 设置采样参数（temperature<0、topP≤0、maxTokens≤0时保持当前值）
+func (cfg *Client) SetSamplingParams(temperature, topP float64, maxTokens int) {
+	if temperature >= 0 {
+		cfg.Temperature = temperature
+	}
+	if topP > 0 {
+		cfg.TopP = topP
+	}
+	if maxTokens > 0 {
+		cfg.MaxTokens = maxTokens
+	}
+}
+
+// SetRetryPolicy 设置单次请求超时和重试次数（timeout≤0时保持当前超时，maxRetries<0时保持当前重试次数）
+func (cfg *Client) SetRetryPolicy(timeout time.Duration, maxRetries int) {
+	if timeout > 0 {
+		cfg.Timeout = timeout
+	}
+	if maxRetries >= 0 {
+		cfg.MaxRetries = maxRetries
+	}
+}
+
+// apiStatusError AI API返回的非200状态码错误（用于判断是否可重试）
+type apiStatusError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *apiStatusError) Error() string {
+	return fmt.Sprintf("API返回错误 (status %d): %s", e.StatusCode, e.Message)
+}
+
+// NewStub 创建不请求AI API的客户端，所有调用都由respond生成响应（用于回测等离线场景）
+func NewStub(respond ResponseFunc) *Client {
+	client := New()
+	client.Provider = ProviderCustom
+	client.Model = "stub"
+	client.stub = respond
+	return client
+}
+
 // SetDeepSeekAPIKey 设置DeepSeek API密钥
 func (cfg *Client) SetDeepSeekAPIKey(apiKey string) {
 	cfg.Provider = ProviderDeepSeek
@@ -86,22 +157,68 @@ func (cfg *Client) SetClient(Client Client) {
 	cfg = &Client
 }
 
+// Ping 轻量探测AI API是否可达（请求模型列表接口，不消耗token；用于健康检查）
+// 收到响应即视为可达，仅密钥无效（401/403）和服务端错误（5xx）返回错误；离线客户端直接返回nil
+func (cfg *Client) Ping(timeout time.Duration) error {
+	if cfg.stub != nil {
+		return nil
+	}
+
+	url := cfg.BaseURL
+	if !cfg.UseFullURL {
+		url = fmt.Sprintf("%s/models", strings.TrimSuffix(cfg.BaseURL, "/"))
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", cfg.APIKey))
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("AI API不可达: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("AI API密钥无效 (status %d)", resp.StatusCode)
+	case resp.StatusCode >= 500:
+		return fmt.Errorf("AI API服务端错误 (status %d)", resp.StatusCode)
+	}
+	return nil
+}
+
 // CallWithMessages 使用 system + user prompt 调用AI API（推荐）
 func (cfg *Client) CallWithMessages(systemPrompt, userPrompt string) (string, error) {
+	if cfg.stub != nil {
+		return cfg.stub(systemPrompt, userPrompt)
+	}
+	return cfg.callWithRetry(systemPrompt, userPrompt, cfg.callOnce)
+}
+
+// callWithRetry 调用AI API，临时性失败按退避策略重试（call为单次调用的实现：普通请求或流式请求）
+func (cfg *Client) callWithRetry(systemPrompt, userPrompt string, call func(systemPrompt, userPrompt string) (string, error)) (string, error) {
 	if cfg.APIKey == "" {
 		return "", fmt.Errorf("AI API密钥未设置，请先调用 SetDeepSeekAPIKey() 或 SetQwenAPIKey()")
 	}
 
-	// 重试配置
-	maxRetries := 3
+	// 重试配置（首次调用 + MaxRetries 次重试）
+	maxAttempts := cfg.MaxRetries + 1
+	if maxAttempts < 1 {
+		maxAttempts = 1
+	}
 	var lastErr error
 
-	for attempt := 1; attempt <= maxRetries; attempt++ {
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
 		if attempt > 1 {
-			fmt.Printf("⚠️  AI API调用失败，正在重试 (%d/%d)...\n", attempt, maxRetries)
+			fmt.Printf("⚠️  AI API调用失败，正在重试 (%d/%d)...\n", attempt-1, cfg.MaxRetries)
 		}
 
-		result, err := cfg.callOnce(systemPrompt, userPrompt)
+		result, err := call(systemPrompt, userPrompt)
 		if err == nil {
 			if attempt > 1 {
 				fmt.Printf("✓ AI API重试成功\n")
@@ -110,26 +227,29 @@ func (cfg *Client) CallWithMessages(systemPrompt, userPrompt string) (string, er
 		}
 
 		lastErr = err
-		// 如果不是网络错误，不重试
+		// 如果不是临时性错误（如密钥错误、请求格式错误），不重试
 		if !isRetryableError(err) {
 			return "", err
 		}
 
-		// 重试前等待
-		if attempt < maxRetries {
-			waitTime := time.Duration(attempt) * 2 * time.Second
+		// 重试前指数退避等待
+		if attempt < maxAttempts {
+			waitTime := cfg.RetryBaseDelay * time.Duration(1<<(attempt-1))
 			fmt.Printf("⏳ 等待%v后重试...\n", waitTime)
 			time.Sleep(waitTime)
 		}
 	}
 
-	return "", fmt.Errorf("重试%d次后仍然失败: %w", maxRetries, lastErr)
+	if maxAttempts == 1 {
+		return "", lastErr
+	}
+	return "", fmt.Errorf("AI API调用%d次（重试%d次）后仍然失败: %w", maxAttempts, maxAttempts-1, lastErr)
 }
 
 // callOnce 单次调用AI API（重构版：简化逻辑）
 func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
 	// 1. 构建请求
-	req, err := cfg.buildRequest(systemPrompt, userPrompt)
+	req, err := cfg.buildRequest(systemPrompt, userPrompt, false)
 	if err != nil {
 		return "", err
 	}
@@ -164,6 +284,12 @@ func (cfg *Client) callOnce(systemPrompt, userPrompt string) (string, error) {
 
 // isRetryableError 判断错误是否可重试
 func isRetryableError(err error) bool {
+	// 服务端错误（5xx）和限流（429）可以重试，其余HTTP错误（如401密钥错误、400请求错误）重试无意义
+	var statusErr *apiStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500 || statusErr.StatusCode == http.StatusTooManyRequests
+	}
+
 	errStr := err.Error()
 	// 网络错误、超时、EOF、空响应等可以重试
 	retryableErrors := []string{
@@ -186,8 +312,8 @@ func isRetryableError(err error) bool {
 	return false
 }
 
-// buildRequest 构建HTTP请求
-func (cfg *Client) buildRequest(systemPrompt, userPrompt string) (*http.Request, error) {
+// buildRequest 构建HTTP请求（stream为true时请求SSE流式响应）
+func (cfg *Client) buildRequest(systemPrompt, userPrompt string, stream bool) (*http.Request, error) {
 	// 构建 messages 数组
 	messages := []map[string]string{}
 
@@ -209,8 +335,15 @@ func (cfg *Client) buildRequest(systemPrompt, userPrompt string) (*http.Request,
 	requestBody := map[string]interface{}{
 		"model":       cfg.Model,
 		"messages":    messages,
-		"temperature": 0.5, // 降低temperature以提高JSON格式稳定性
-		"max_tokens":  4000, // 增加到4000，因为提示词较长且需要完整JSON响应
+		"temperature": cfg.Temperature,
+		"max_tokens":  cfg.MaxTokens,
+	}
+	if cfg.TopP > 0 {
+		requestBody["top_p"] = cfg.TopP
+	}
+	if stream {
+		requestBody["stream"] = true
+		requestBody["stream_options"] = map[string]bool{"include_usage": true} // 最后一个数据块返回token使用情况
 	}
 
 	jsonData, err := json.Marshal(requestBody)
@@ -358,10 +491,12 @@ func (cfg *Client) parseResponse(body []byte, statusCode int) (string, error) {
 			} `json:"error"`
 		}
 		if err := json.Unmarshal(body, &errorResp); err == nil && errorResp.Error.Message != "" {
-			return "", fmt.Errorf("API返回错误 (status %d): %s (类型: %s, 代码: %s)", 
-				statusCode, errorResp.Error.Message, errorResp.Error.Type, errorResp.Error.Code)
+			return "", &apiStatusError{
+				StatusCode: statusCode,
+				Message:    fmt.Sprintf("%s (类型: %s, 代码: %s)", errorResp.Error.Message, errorResp.Error.Type, errorResp.Error.Code),
+			}
 		}
-		return "", fmt.Errorf("API返回错误 (status %d): %s", statusCode, string(body))
+		return "", &apiStatusError{StatusCode: statusCode, Message: string(body)}
 	}
 
 	// 解析响应
@@ -401,16 +536,7 @@ func (cfg *Client) parseResponse(body []byte, statusCode int) (string, error) {
 		return "", fmt.Errorf("API返回空响应 (没有choices)，完整响应: %s", responseStr)
 	}
 
-	// 检查是否被截断
-	if result.Choices[0].FinishReason == "length" {
-		fmt.Printf("⚠️  AI响应可能被截断 (finish_reason: length)，当前max_tokens可能不足\n")
-	}
-	
-	// 记录token使用情况（用于调试）
-	if result.Usage.TotalTokens > 0 {
-		fmt.Printf("📊 AI Token使用: prompt=%d, completion=%d, total=%d\n", 
-			result.Usage.PromptTokens, result.Usage.CompletionTokens, result.Usage.TotalTokens)
-	}
+	reportCompletion(result.Choices[0].FinishReason, result.Usage.PromptTokens, result.Usage.CompletionTokens, result.Usage.TotalTokens)
 
 	content := result.Choices[0].Message.Content
 	if content == "" {
@@ -420,6 +546,19 @@ func (cfg *Client) parseResponse(body []byte, statusCode int) (string, error) {
 	return content, nil
 }
 
+// reportCompletion 输出响应截断警告和token使用情况（用于调试）
+func reportCompletion(finishReason string, promptTokens, completionTokens, totalTokens int) {
+	// 检查是否被截断
+	if finishReason == "length" {
+		fmt.Printf("⚠️  AI响应可能被截断 (finish_reason: length)，当前max_tokens可能不足\n")
+	}
+
+	// 记录token使用情况
+	if totalTokens > 0 {
+		fmt.Printf("📊 AI Token使用: prompt=%d, completion=%d, total=%d\n", promptTokens, completionTokens, totalTokens)
+	}
+}
+
 // handleRequestError 处理请求错误
 func (cfg *Client) handleRequestError(err error, elapsed time.Duration) error {
 	if strings.Contains(err.Error(), "timeout") || strings.Contains(err.Error(), "deadline exceeded") {
@@ -0,0 +1,144 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// streamProgressInterval 流式响应进度日志的输出间隔
+const streamProgressInterval = 15 * time.Second
+
+// streamChunk OpenAI兼容接口的SSE数据块
+type streamChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+		FinishReason string `json:"finish_reason"`
+	} `json:"choices"`
+	Usage *struct {
+		PromptTokens     int `json:"prompt_tokens"`
+		CompletionTokens int `json:"completion_tokens"`
+		TotalTokens      int `json:"total_tokens"`
+	} `json:"usage"`
+	Error *struct {
+		Message string `json:"message"`
+		Type    string `json:"type"`
+	} `json:"error"`
+}
+
+// CallWithMessagesStream 与CallWithMessages相同，但以SSE流式接收响应并拼接为完整内容
+// 返回的内容与非流式调用一致；接收过程中定期输出已接收的字节数，便于观察慢速模型的长思维链进度。
+// 服务端不支持流式（返回普通JSON）时按非流式响应解析；超时和重试策略与CallWithMessages相同
+func (cfg *Client) CallWithMessagesStream(systemPrompt, userPrompt string) (string, error) {
+	if cfg.stub != nil {
+		return cfg.stub(systemPrompt, userPrompt)
+	}
+	return cfg.callWithRetry(systemPrompt, userPrompt, cfg.callOnceStream)
+}
+
+// callOnceStream 单次流式调用AI API
+func (cfg *Client) callOnceStream(systemPrompt, userPrompt string) (string, error) {
+	req, err := cfg.buildRequest(systemPrompt, userPrompt, true)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+	req = req.WithContext(ctx)
+	client := &http.Client{Timeout: cfg.Timeout}
+
+	startTime := time.Now()
+	fmt.Printf("📡 正在调用AI API (流式，超时设置: %v)...\n", cfg.Timeout)
+	resp, err := client.Do(req)
+	elapsed := time.Since(startTime)
+	if err != nil {
+		return "", cfg.handleRequestError(err, elapsed)
+	}
+	defer resp.Body.Close()
+
+	fmt.Printf("✓ AI API响应头接收完成 (耗时: %v)\n", elapsed)
+
+	// 错误响应和不支持流式的服务端返回普通JSON，按非流式响应解析
+	if resp.StatusCode != http.StatusOK || !strings.Contains(resp.Header.Get("Content-Type"), "text/event-stream") {
+		body, err := cfg.readResponseBody(ctx, resp, startTime)
+		if err != nil {
+			return "", err
+		}
+		return cfg.parseResponse(body, resp.StatusCode)
+	}
+
+	content, err := readStream(resp.Body, startTime)
+	if err != nil {
+		if ctx.Err() != nil {
+			return "", fmt.Errorf("读取流式响应超时 (总耗时: %v，超时设置: %v): %w", time.Since(startTime), cfg.Timeout, ctx.Err())
+		}
+		return "", err
+	}
+	return content, nil
+}
+
+// readStream 读取SSE流，拼接各数据块的增量内容（遇到 [DONE] 或流结束时返回）
+func readStream(body io.Reader, startTime time.Time) (string, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	var sb strings.Builder
+	var finishReason string
+	var promptTokens, completionTokens, totalTokens int
+	chunks := 0
+	lastProgress := time.Now()
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "data:") {
+			continue // 空行、注释（: keep-alive）和event字段
+		}
+		data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		if data == "[DONE]" {
+			break
+		}
+
+		var chunk streamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return "", fmt.Errorf("解析流式数据块失败: %w, 数据: %s", err, data)
+		}
+		if chunk.Error != nil && chunk.Error.Message != "" {
+			return "", fmt.Errorf("API返回错误: %s (类型: %s)", chunk.Error.Message, chunk.Error.Type)
+		}
+		if chunk.Usage != nil {
+			promptTokens, completionTokens, totalTokens = chunk.Usage.PromptTokens, chunk.Usage.CompletionTokens, chunk.Usage.TotalTokens
+		}
+		if len(chunk.Choices) > 0 {
+			sb.WriteString(chunk.Choices[0].Delta.Content)
+			if chunk.Choices[0].FinishReason != "" {
+				finishReason = chunk.Choices[0].FinishReason
+			}
+		}
+		chunks++
+
+		if time.Since(lastProgress) >= streamProgressInterval {
+			fmt.Printf("  📥 流式响应接收中: 已接收 %d 字节 (耗时: %v)\n", sb.Len(), time.Since(startTime).Round(time.Second))
+			lastProgress = time.Now()
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("读取响应体失败（已接收 %d 字节）: %w", sb.Len(), err)
+	}
+
+	fmt.Printf("✓ 流式响应接收完成 (数据块: %d, 总耗时: %v, 大小: %d 字节)\n", chunks, time.Since(startTime), sb.Len())
+	reportCompletion(finishReason, promptTokens, completionTokens, totalTokens)
+
+	if sb.Len() == 0 {
+		return "", fmt.Errorf("响应体为空（流式响应没有返回内容）")
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,131 @@
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 指标前缀
+const namespace = "nofx"
+
+// 账户状态（每个决策周期更新）
+var (
+	equity = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "equity_usdt",
+		Help:      "账户净值（USDT）",
+	}, []string{"trader_id"})
+
+	openPositions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "open_positions",
+		Help:      "当前持仓数量",
+	}, []string{"trader_id"})
+
+	marginUsedPct = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "margin_used_pct",
+		Help:      "保证金使用率（百分比）",
+	}, []string{"trader_id"})
+
+	dailyPnL = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "daily_pnl_usdt",
+		Help:      "日盈亏（USDT，相对今日开盘净值）",
+	}, []string{"trader_id"})
+)
+
+// 决策周期
+var (
+	cyclesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cycles_total",
+		Help:      "AI决策周期数（result=success/failure）",
+	}, []string{"trader_id", "result"})
+
+	cycleDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "cycle_duration_seconds",
+		Help:      "AI决策周期耗时（秒）",
+		Buckets:   []float64{1, 5, 10, 20, 30, 60, 90, 120, 180, 300},
+	}, []string{"trader_id"})
+)
+
+// AI调用
+var (
+	aiCallFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ai_call_failures_total",
+		Help:      "AI调用失败次数（包括响应解析失败）",
+	}, []string{"trader_id"})
+
+	aiCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "ai_call_duration_seconds",
+		Help:      "AI调用耗时（秒）",
+		Buckets:   []float64{1, 2, 5, 10, 20, 30, 60, 90, 120},
+	}, []string{"trader_id"})
+)
+
+// 强制平仓
+var forcedClosesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Namespace: namespace,
+	Name:      "forced_closes_total",
+	Help:      "强制平仓次数（止损/止盈/风控/手动紧急平仓，result=success/failure）",
+}, []string{"trader_id", "result"})
+
+func init() {
+	prometheus.MustRegister(
+		equity,
+		openPositions,
+		marginUsedPct,
+		dailyPnL,
+		cyclesTotal,
+		cycleDuration,
+		aiCallFailures,
+		aiCallDuration,
+		forcedClosesTotal,
+	)
+}
+
+// Handler Prometheus抓取接口
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// SetAccountState 更新账户状态指标
+func SetAccountState(traderID string, totalEquity float64, positionCount int, marginUsed, dailyPnLUSDT float64) {
+	equity.WithLabelValues(traderID).Set(totalEquity)
+	openPositions.WithLabelValues(traderID).Set(float64(positionCount))
+	marginUsedPct.WithLabelValues(traderID).Set(marginUsed)
+	dailyPnL.WithLabelValues(traderID).Set(dailyPnLUSDT)
+}
+
+// ObserveCycle 记录一个决策周期的耗时和结果
+func ObserveCycle(traderID string, duration time.Duration, success bool) {
+	cyclesTotal.WithLabelValues(traderID, resultLabel(success)).Inc()
+	cycleDuration.WithLabelValues(traderID).Observe(duration.Seconds())
+}
+
+// ObserveAICall 记录一次AI调用的耗时，失败时累加失败次数
+func ObserveAICall(traderID string, duration time.Duration, err error) {
+	aiCallDuration.WithLabelValues(traderID).Observe(duration.Seconds())
+	if err != nil {
+		aiCallFailures.WithLabelValues(traderID).Inc()
+	}
+}
+
+// IncForcedClose 累加强制平仓次数
+func IncForcedClose(traderID string, success bool) {
+	forcedClosesTotal.WithLabelValues(traderID, resultLabel(success)).Inc()
+}
+
+func resultLabel(success bool) string {
+	if success {
+		return "success"
+	}
+	return "failure"
+}
@@ -0,0 +1,177 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/pkg/logger"
+)
+
+const (
+	telegramAPIURL       = "https://api.telegram.org/bot%s/sendMessage"
+	telegramQueueSize    = 100              // 待发送消息队列长度（队列满时丢弃新消息）
+	telegramRequestLimit = 10 * time.Second // 单条消息发送超时
+)
+
+// TelegramNotifier Telegram通知器
+// 消息放入队列后由后台goroutine发送，调用方不会因为Telegram不可用而阻塞
+// nil通知器的所有方法均为空操作
+type TelegramNotifier struct {
+	botToken   string
+	chatID     string
+	traderName string // 消息前缀（区分多个trader）
+	client     *http.Client
+	queue      chan string
+}
+
+// NewTelegramNotifier 创建Telegram通知器（botToken或chatID为空时返回nil，即不发送通知）
+func NewTelegramNotifier(botToken, chatID, traderName string) *TelegramNotifier {
+	if botToken == "" || chatID == "" {
+		return nil
+	}
+
+	n := &TelegramNotifier{
+		botToken:   botToken,
+		chatID:     chatID,
+		traderName: traderName,
+		client:     &http.Client{Timeout: telegramRequestLimit},
+		queue:      make(chan string, telegramQueueSize),
+	}
+	go n.run()
+	return n
+}
+
+// NotifyTrade 通知开仓/平仓执行结果
+func (n *TelegramNotifier) NotifyTrade(action logger.DecisionAction) {
+	if n == nil {
+		return
+	}
+
+	var title string
+	switch action.Action {
+	case "open_long":
+		title = "📈 开多仓"
+	case "open_short":
+		title = "📉 开空仓"
+	case "close_long":
+		title = "✅ 平多仓"
+	case "close_short":
+		title = "✅ 平空仓"
+	default:
+		title = "ℹ️ " + action.Action
+	}
+	if action.PartialClose {
+		title += "（部分平仓）"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s\n", title, action.Symbol))
+	if action.Quantity > 0 {
+		sb.WriteString(fmt.Sprintf("数量: %.8g\n", action.Quantity))
+	}
+	if action.Price > 0 {
+		sb.WriteString(fmt.Sprintf("价格: %.4f\n", action.Price))
+	}
+	if action.Leverage > 0 {
+		sb.WriteString(fmt.Sprintf("杠杆: %dx\n", action.Leverage))
+	}
+	if action.OrderID > 0 {
+		sb.WriteString(fmt.Sprintf("订单ID: %d\n", action.OrderID))
+	}
+	sb.WriteString(fmt.Sprintf("时间: %s", action.Timestamp.Format("2006-01-02 15:04:05")))
+
+	n.send(sb.String())
+}
+
+// NotifyForcedClose 通知强制平仓（止损/止盈/风控触发），平仓失败时由调用方在reason中注明
+func (n *TelegramNotifier) NotifyForcedClose(symbol, side, reason string, pnl float64) {
+	if n == nil {
+		return
+	}
+	n.send(fmt.Sprintf("🛑 强制平仓 %s %s\n原因: %s\n盈亏: %+.2f USDT", symbol, strings.ToUpper(side), reason, pnl))
+}
+
+// NotifyRiskTrigger 通知账户级风控触发（回撤/日亏损超限，暂停交易并平掉所有持仓）
+func (n *TelegramNotifier) NotifyRiskTrigger(reason, detail string) {
+	if n == nil {
+		return
+	}
+	n.send(fmt.Sprintf("🛑 触发%s\n%s", reason, detail))
+}
+
+// NotifyDailyReport 推送每日交易汇总
+func (n *TelegramNotifier) NotifyDailyReport(report *logger.DailyReport) {
+	if n == nil || report == nil {
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("📊 每日汇总 %s (UTC)\n", report.Date))
+	if report.StartEquity > 0 {
+		sb.WriteString(fmt.Sprintf("净值: %.2f → %.2f USDT (%+.2f%%)\n", report.StartEquity, report.EndEquity, report.EquityChangePct))
+	}
+	sb.WriteString(fmt.Sprintf("已实现盈亏: %+.2f USDT\n", report.RealizedPnL))
+	sb.WriteString(fmt.Sprintf("交易: %d笔（盈%d/亏%d，胜率%.1f%%）\n", report.TotalTrades, report.WinningTrades, report.LosingTrades, report.WinRate))
+	if report.ForcedCloses > 0 {
+		sb.WriteString(fmt.Sprintf("强制平仓: %d次\n", report.ForcedCloses))
+	}
+	if report.BestTrade != nil {
+		sb.WriteString(fmt.Sprintf("最佳: %s %s %+.2f USDT\n", report.BestTrade.Symbol, strings.ToUpper(report.BestTrade.Side), report.BestTrade.PnL))
+	}
+	if report.WorstTrade != nil {
+		sb.WriteString(fmt.Sprintf("最差: %s %s %+.2f USDT", report.WorstTrade.Symbol, strings.ToUpper(report.WorstTrade.Side), report.WorstTrade.PnL))
+	}
+
+	n.send(strings.TrimRight(sb.String(), "\n"))
+}
+
+// send 将消息放入发送队列（队列已满时直接丢弃，不阻塞调用方）
+func (n *TelegramNotifier) send(text string) {
+	if n.traderName != "" {
+		text = fmt.Sprintf("[%s] %s", n.traderName, text)
+	}
+
+	select {
+	case n.queue <- text:
+	default:
+		log.Printf("⚠️  Telegram通知队列已满，丢弃消息")
+	}
+}
+
+// run 后台发送队列中的消息
+func (n *TelegramNotifier) run() {
+	for text := range n.queue {
+		if err := n.post(text); err != nil {
+			log.Printf("⚠️  发送Telegram通知失败: %v", err)
+		}
+	}
+}
+
+// post 调用Telegram Bot API发送一条消息
+func (n *TelegramNotifier) post(text string) error {
+	payload, err := json.Marshal(map[string]string{
+		"chat_id": n.chatID,
+		"text":    text,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化消息失败: %w", err)
+	}
+
+	resp, err := n.client.Post(fmt.Sprintf(telegramAPIURL, n.botToken), "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
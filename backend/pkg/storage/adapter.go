@@ -13,6 +13,8 @@ type StorageAdapter struct {
 	cycleSnapshot      *CycleSnapshotStorage
 	decisionLogs       *DecisionStorage
 	cache              *CacheStorage
+	riskState          *RiskStateStorage
+	forcedCloses       *ForcedCloseStorage
 	initOnce           sync.Once
 	initErr            error
 }
@@ -77,6 +79,20 @@ func (sa *StorageAdapter) initStorages() error {
 	}
 	sa.cache = cache
 
+	// 初始化风控状态存储
+	riskState, err := NewRiskStateStorage(sa.dbManager)
+	if err != nil {
+		return err
+	}
+	sa.riskState = riskState
+
+	// 初始化强制平仓记录存储
+	forcedCloses, err := NewForcedCloseStorage(sa.dbManager)
+	if err != nil {
+		return err
+	}
+	sa.forcedCloses = forcedCloses
+
 	return nil
 }
 
@@ -105,6 +121,21 @@ func (sa *StorageAdapter) GetCacheStorage() *CacheStorage {
 	return sa.cache
 }
 
+// GetRiskStateStorage 获取风控状态存储
+func (sa *StorageAdapter) GetRiskStateStorage() *RiskStateStorage {
+	return sa.riskState
+}
+
+// GetForcedCloseStorage 获取强制平仓记录存储
+func (sa *StorageAdapter) GetForcedCloseStorage() *ForcedCloseStorage {
+	return sa.forcedCloses
+}
+
+// CheckWritable 检查数据库是否可写
+func (sa *StorageAdapter) CheckWritable() error {
+	return sa.dbManager.CheckWritable()
+}
+
 // Close 关闭所有存储连接
 func (sa *StorageAdapter) Close() error {
 	return sa.dbManager.Close()
@@ -6,9 +6,13 @@ import (
 	"fmt"
 	"log"
 	"backend/pkg/db"
+	"strings"
 	"time"
 )
 
+// decisionPruneKeepLatest 清理决策记录时每个trader始终保留的最近记录数
+const decisionPruneKeepLatest = 1000
+
 // DecisionStorage 决策记录存储（使用SQLite）
 type DecisionStorage struct {
 	dbManager *db.DBManager
@@ -61,8 +65,18 @@ func (s *DecisionStorage) initTable() error {
 	CREATE INDEX IF NOT EXISTS idx_timestamp ON decisions(timestamp);
 	`
 
-	_, err := s.db.Exec(createTableSQL)
-	return err
+	if _, err := s.db.Exec(createTableSQL); err != nil {
+		return err
+	}
+
+	// 迁移现有数据库：添加多模型输出字段（如果不存在）
+	migrationSQL := `ALTER TABLE decisions ADD COLUMN model_traces TEXT;`
+	// SQLite的ALTER TABLE ADD COLUMN如果列已存在会报错，忽略该错误
+	if _, err := s.db.Exec(migrationSQL); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+		log.Printf("⚠️  数据库迁移警告: %v (SQL: %s)", err, migrationSQL)
+	}
+
+	return nil
 }
 
 // DecisionRecord 决策记录（与logger.DecisionRecord兼容）
@@ -79,6 +93,7 @@ type DecisionRecord struct {
 	ExecutionLog   json.RawMessage `json:"execution_log"`
 	Success        bool            `json:"success"`
 	ErrorMessage   string          `json:"error_message"`
+	ModelTraces    json.RawMessage `json:"model_traces,omitempty"` // 多模型投票时每个模型的原始输出
 }
 
 // LogDecision 记录决策
@@ -89,6 +104,10 @@ func (s *DecisionStorage) LogDecision(traderID string, record *DecisionRecord) e
 	candidateCoinsJSON, _ := json.Marshal(record.CandidateCoins)
 	decisionsJSON, _ := json.Marshal(record.Decisions)
 	executionLogJSON, _ := json.Marshal(record.ExecutionLog)
+	modelTraces := ""
+	if len(record.ModelTraces) > 0 {
+		modelTraces = string(record.ModelTraces)
+	}
 
 	success := 0
 	if record.Success {
@@ -99,8 +118,8 @@ func (s *DecisionStorage) LogDecision(traderID string, record *DecisionRecord) e
 		INSERT INTO decisions (
 			trader_id, cycle_number, timestamp, input_prompt, cot_trace,
 			decision_json, account_state, positions, candidate_coins,
-			decisions, execution_log, success, error_message
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			decisions, execution_log, success, error_message, model_traces
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	_, err := s.db.Exec(query,
@@ -108,7 +127,7 @@ func (s *DecisionStorage) LogDecision(traderID string, record *DecisionRecord) e
 		record.InputPrompt, record.CoTTrace, record.DecisionJSON,
 		string(accountStateJSON), string(positionsJSON),
 		string(candidateCoinsJSON), string(decisionsJSON),
-		string(executionLogJSON), success, record.ErrorMessage,
+		string(executionLogJSON), success, record.ErrorMessage, modelTraces,
 	)
 
 	if err != nil {
@@ -121,9 +140,7 @@ func (s *DecisionStorage) LogDecision(traderID string, record *DecisionRecord) e
 // GetLatestRecords 获取最近N条记录（按时间逆序：从新到旧）
 func (s *DecisionStorage) GetLatestRecords(traderID string, n int) ([]*DecisionRecord, error) {
 	query := `
-		SELECT cycle_number, timestamp, input_prompt, cot_trace, decision_json,
-		       account_state, positions, candidate_coins, decisions, execution_log,
-		       success, error_message
+		SELECT ` + decisionRecordColumns + `
 		FROM decisions
 		WHERE trader_id = ?
 		ORDER BY timestamp DESC
@@ -138,41 +155,111 @@ func (s *DecisionStorage) GetLatestRecords(traderID string, n int) ([]*DecisionR
 
 	var records []*DecisionRecord
 	for rows.Next() {
-		record := &DecisionRecord{}
-		var success int
-		var accountStateJSON, positionsJSON, candidateCoinsJSON, decisionsJSON, executionLogJSON string
-
-		err := rows.Scan(
-			&record.CycleNumber, &record.Timestamp, &record.InputPrompt,
-			&record.CoTTrace, &record.DecisionJSON,
-			&accountStateJSON, &positionsJSON, &candidateCoinsJSON,
-			&decisionsJSON, &executionLogJSON,
-			&success, &record.ErrorMessage,
-		)
-
+		record, err := scanDecisionRecord(rows.Scan)
 		if err != nil {
 			log.Printf("⚠️  扫描决策记录失败: %v", err)
 			continue
 		}
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		log.Printf("⚠️  查询决策记录时出现行扫描错误: %v", err)
+		return records, nil // 返回已收集的记录而不是错误
+	}
 
-		record.Success = success == 1
-		record.AccountState = json.RawMessage(accountStateJSON)
-		record.Positions = json.RawMessage(positionsJSON)
-		record.CandidateCoins = json.RawMessage(candidateCoinsJSON)
-		record.Decisions = json.RawMessage(decisionsJSON)
-		record.ExecutionLog = json.RawMessage(executionLogJSON)
+	return records, nil
+}
 
+// GetRecordsSince 获取指定时间之后的全部决策记录（按时间倒序，与GetLatestRecords一致）
+// 用于按时间范围绘制曲线，不受条数上限截断
+func (s *DecisionStorage) GetRecordsSince(traderID string, since time.Time) ([]*DecisionRecord, error) {
+	query := `
+		SELECT ` + decisionRecordColumns + `
+		FROM decisions
+		WHERE trader_id = ? AND timestamp >= ?
+		ORDER BY timestamp DESC
+	`
+
+	rows, err := s.db.Query(query, traderID, since)
+	if err != nil {
+		return nil, fmt.Errorf("查询决策记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*DecisionRecord
+	for rows.Next() {
+		record, err := scanDecisionRecord(rows.Scan)
+		if err != nil {
+			log.Printf("⚠️  扫描决策记录失败: %v", err)
+			continue
+		}
 		records = append(records, record)
 	}
 
 	if err := rows.Err(); err != nil {
 		log.Printf("⚠️  查询决策记录时出现行扫描错误: %v", err)
-		return records, nil // 返回已收集的记录而不是错误
+		return records, nil
 	}
 
 	return records, nil
 }
 
+// GetByCycle 获取指定周期的决策记录（未找到时返回nil, nil）
+// 重启后周期编号可能重复，此时返回该周期最新的一条
+func (s *DecisionStorage) GetByCycle(traderID string, cycle int) (*DecisionRecord, error) {
+	query := `
+		SELECT ` + decisionRecordColumns + `
+		FROM decisions
+		WHERE trader_id = ? AND cycle_number = ?
+		ORDER BY timestamp DESC
+		LIMIT 1
+	`
+
+	record, err := scanDecisionRecord(s.db.QueryRow(query, traderID, cycle).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询周期 #%d 的决策记录失败: %w", cycle, err)
+	}
+	return record, nil
+}
+
+// decisionRecordColumns 查询决策记录时的列（与scanDecisionRecord的扫描顺序一致）
+const decisionRecordColumns = `cycle_number, timestamp, input_prompt, cot_trace, decision_json,
+		       account_state, positions, candidate_coins, decisions, execution_log,
+		       success, error_message, COALESCE(model_traces, '')`
+
+// scanDecisionRecord 扫描一行决策记录（scan为sql.Row或sql.Rows的Scan方法）
+func scanDecisionRecord(scan func(dest ...interface{}) error) (*DecisionRecord, error) {
+	record := &DecisionRecord{}
+	var success int
+	var accountStateJSON, positionsJSON, candidateCoinsJSON, decisionsJSON, executionLogJSON, modelTracesJSON string
+
+	err := scan(
+		&record.CycleNumber, &record.Timestamp, &record.InputPrompt,
+		&record.CoTTrace, &record.DecisionJSON,
+		&accountStateJSON, &positionsJSON, &candidateCoinsJSON,
+		&decisionsJSON, &executionLogJSON,
+		&success, &record.ErrorMessage, &modelTracesJSON,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	record.Success = success == 1
+	record.AccountState = json.RawMessage(accountStateJSON)
+	record.Positions = json.RawMessage(positionsJSON)
+	record.CandidateCoins = json.RawMessage(candidateCoinsJSON)
+	record.Decisions = json.RawMessage(decisionsJSON)
+	record.ExecutionLog = json.RawMessage(executionLogJSON)
+	if modelTracesJSON != "" {
+		record.ModelTraces = json.RawMessage(modelTracesJSON)
+	}
+	return record, nil
+}
+
 // GetForcedCloses 获取最近的强制平仓记录
 func (s *DecisionStorage) GetForcedCloses(traderID string, maxCycles int) ([]string, error) {
 	records, err := s.GetLatestRecords(traderID, maxCycles)
@@ -224,3 +311,54 @@ func (s *DecisionStorage) GetForcedCloses(traderID string, maxCycles int) ([]str
 	return forcedCloses, nil
 }
 
+
+// PruneOlderThan 删除指定trader早于cutoff的决策记录，返回删除的行数
+// 无论时间多早，始终保留最近的 decisionPruneKeepLatest 条记录（表现分析、强制平仓记录等依赖最近的记录）
+func (s *DecisionStorage) PruneOlderThan(traderID string, cutoff time.Time) (int, error) {
+	query := `
+		DELETE FROM decisions
+		WHERE trader_id = ? AND timestamp < ?
+		AND id NOT IN (
+			SELECT id FROM decisions
+			WHERE trader_id = ?
+			ORDER BY timestamp DESC
+			LIMIT ?
+		)
+	`
+
+	result, err := s.db.Exec(query, traderID, cutoff, traderID, decisionPruneKeepLatest)
+	if err != nil {
+		return 0, fmt.Errorf("清理决策记录失败: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("获取清理行数失败: %w", err)
+	}
+	return int(deleted), nil
+}
+
+// GetAccountStatesBetween 获取时间范围内第一条和最后一条决策记录的账户状态（JSON，[start, end)）
+// 范围内没有记录时两者都返回nil
+func (s *DecisionStorage) GetAccountStatesBetween(traderID string, start, end time.Time) (json.RawMessage, json.RawMessage, error) {
+	query := `
+		SELECT account_state FROM decisions
+		WHERE trader_id = ? AND timestamp >= ? AND timestamp < ?
+		ORDER BY timestamp %s
+		LIMIT 1
+	`
+
+	var first, last string
+	err := s.db.QueryRow(fmt.Sprintf(query, "ASC"), traderID, start, end).Scan(&first)
+	if err == sql.ErrNoRows {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("查询账户状态失败: %w", err)
+	}
+	if err := s.db.QueryRow(fmt.Sprintf(query, "DESC"), traderID, start, end).Scan(&last); err != nil {
+		return nil, nil, fmt.Errorf("查询账户状态失败: %w", err)
+	}
+
+	return json.RawMessage(first), json.RawMessage(last), nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"backend/pkg/db"
+)
+
+// ForcedCloseRecord 强制平仓记录（风控触发的平仓，按触发类型区分）
+type ForcedCloseRecord struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Symbol      string    `json:"symbol"`
+	Side        string    `json:"side"`
+	Reason      string    `json:"reason"`
+	PnL         float64   `json:"pnl"`          // 平仓前的未实现盈亏（USDT）
+	TriggerType string    `json:"trigger_type"` // 触发类型（账户回撤/日亏损/单仓位止损/止盈等）
+}
+
+// ForcedCloseStorage 强制平仓记录存储（按trader存储，使用SQLite）
+type ForcedCloseStorage struct {
+	dbManager *db.DBManager
+	db        *sql.DB
+}
+
+// NewForcedCloseStorage 创建强制平仓记录存储
+func NewForcedCloseStorage(dbManager *db.DBManager) (*ForcedCloseStorage, error) {
+	storage := &ForcedCloseStorage{
+		dbManager: dbManager,
+	}
+
+	// 获取数据库连接
+	database, err := dbManager.GetDB("forced_closes")
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	storage.db = database
+
+	// 初始化表结构
+	if err := storage.initTable(); err != nil {
+		return nil, fmt.Errorf("初始化表结构失败: %w", err)
+	}
+
+	return storage, nil
+}
+
+// initTable 初始化表结构
+func (s *ForcedCloseStorage) initTable() error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS forced_closes (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		trader_id TEXT NOT NULL,
+		timestamp DATETIME NOT NULL,
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		pnl REAL NOT NULL DEFAULT 0,
+		trigger_type TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_forced_closes_trader_time ON forced_closes(trader_id, timestamp);
+	`
+
+	_, err := s.db.Exec(createTableSQL)
+	return err
+}
+
+// LogForcedClose 记录一次强制平仓
+func (s *ForcedCloseStorage) LogForcedClose(traderID string, record *ForcedCloseRecord) error {
+	query := `
+		INSERT INTO forced_closes (trader_id, timestamp, symbol, side, reason, pnl, trigger_type)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	if _, err := s.db.Exec(query, traderID, record.Timestamp, record.Symbol, record.Side,
+		record.Reason, record.PnL, record.TriggerType); err != nil {
+		return fmt.Errorf("保存强制平仓记录失败: %w", err)
+	}
+	return nil
+}
+
+// GetRecentForcedCloses 获取trader最近N条强制平仓记录（按时间降序）
+func (s *ForcedCloseStorage) GetRecentForcedCloses(traderID string, limit int) ([]*ForcedCloseRecord, error) {
+	query := `
+		SELECT timestamp, symbol, side, reason, pnl, trigger_type FROM forced_closes
+		WHERE trader_id = ?
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := s.db.Query(query, traderID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("查询强制平仓记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	records := make([]*ForcedCloseRecord, 0)
+	for rows.Next() {
+		record := &ForcedCloseRecord{}
+		if err := rows.Scan(&record.Timestamp, &record.Symbol, &record.Side, &record.Reason,
+			&record.PnL, &record.TriggerType); err != nil {
+			return nil, fmt.Errorf("解析强制平仓记录失败: %w", err)
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
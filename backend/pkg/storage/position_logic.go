@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"backend/pkg/db"
+	"strings"
 	"time"
 )
 
@@ -48,16 +49,43 @@ func (s *PositionLogicStorage) initTable() error {
 		stop_loss REAL DEFAULT 0,
 		take_profit REAL DEFAULT 0,
 		first_seen_time INTEGER DEFAULT 0,
+		trailing_distance_pct REAL DEFAULT 0,
+		trailing_best_price REAL DEFAULT 0,
+		take_profit_levels TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		UNIQUE(symbol, side)
 	);
 	
 	CREATE INDEX IF NOT EXISTS idx_symbol_side ON position_logic(symbol, side);
+
+	-- 最近平仓时间（平仓时position_logic记录会被删除，单独保存用于再入场冷却）
+	CREATE TABLE IF NOT EXISTS position_close_times (
+		symbol TEXT NOT NULL,
+		side TEXT NOT NULL,
+		close_time INTEGER NOT NULL DEFAULT 0,
+		PRIMARY KEY (symbol, side)
+	);
 	`
 
-	_, err := s.db.Exec(createTableSQL)
-	return err
+	if _, err := s.db.Exec(createTableSQL); err != nil {
+		return err
+	}
+
+	// 迁移现有数据库：添加移动止损字段（如果不存在）
+	migrationSQL := []string{
+		`ALTER TABLE position_logic ADD COLUMN trailing_distance_pct REAL DEFAULT 0;`,
+		`ALTER TABLE position_logic ADD COLUMN trailing_best_price REAL DEFAULT 0;`,
+		`ALTER TABLE position_logic ADD COLUMN take_profit_levels TEXT;`,
+	}
+	for _, sql := range migrationSQL {
+		// SQLite的ALTER TABLE ADD COLUMN如果列已存在会报错，忽略该错误
+		if _, err := s.db.Exec(sql); err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			log.Printf("⚠️  数据库迁移警告: %v (SQL: %s)", err, sql)
+		}
+	}
+
+	return nil
 }
 
 // PositionLogic 持仓逻辑结构
@@ -67,6 +95,15 @@ type PositionLogic struct {
 	StopLoss      float64     `json:"stop_loss,omitempty"`
 	TakeProfit    float64     `json:"take_profit,omitempty"`
 	FirstSeenTime int64       `json:"first_seen_time,omitempty"` // 持仓首次出现时间（Unix毫秒时间戳）
+	TrailingDistancePct float64 `json:"trailing_distance_pct,omitempty"` // 移动止损距离百分比（0表示未启用）
+	TrailingBestPrice   float64 `json:"trailing_best_price,omitempty"`   // 移动止损跟踪的最优价格（做多为最高价，做空为最低价）
+	TakeProfitLevels    []TakeProfitLevel `json:"take_profit_levels,omitempty"` // 阶梯止盈（为空表示单一止盈）
+}
+
+// TakeProfitLevel 阶梯止盈的一档
+type TakeProfitLevel struct {
+	Price float64 `json:"price"`
+	Pct   float64 `json:"pct"` // 平仓比例（占设置时持仓数量的百分比）
 }
 
 // EntryLogic 进场逻辑
@@ -150,17 +187,18 @@ func (s *PositionLogicStorage) SaveExitLogic(symbol, side string, exitLogic *Exi
 // GetLogic 获取持仓逻辑
 func (s *PositionLogicStorage) GetLogic(symbol, side string) (*PositionLogic, error) {
 	query := `
-		SELECT entry_logic, exit_logic, stop_loss, take_profit, first_seen_time
+		SELECT entry_logic, exit_logic, stop_loss, take_profit, first_seen_time, trailing_distance_pct, trailing_best_price, take_profit_levels
 		FROM position_logic
 		WHERE symbol = ? AND side = ?
 	`
 
-	var entryLogicJSON, exitLogicJSON sql.NullString
+	var entryLogicJSON, exitLogicJSON, takeProfitLevelsJSON sql.NullString
 	var stopLoss, takeProfit sql.NullFloat64
 	var firstSeenTime sql.NullInt64
+	var trailingDistancePct, trailingBestPrice sql.NullFloat64
 
 	err := s.db.QueryRow(query, symbol, side).Scan(
-		&entryLogicJSON, &exitLogicJSON, &stopLoss, &takeProfit, &firstSeenTime,
+		&entryLogicJSON, &exitLogicJSON, &stopLoss, &takeProfit, &firstSeenTime, &trailingDistancePct, &trailingBestPrice, &takeProfitLevelsJSON,
 	)
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -201,6 +239,20 @@ func (s *PositionLogicStorage) GetLogic(symbol, side string) (*PositionLogic, er
 		logic.FirstSeenTime = firstSeenTime.Int64
 	}
 
+	if trailingDistancePct.Valid {
+		logic.TrailingDistancePct = trailingDistancePct.Float64
+	}
+
+	if trailingBestPrice.Valid {
+		logic.TrailingBestPrice = trailingBestPrice.Float64
+	}
+
+	if takeProfitLevelsJSON.Valid && takeProfitLevelsJSON.String != "" {
+		if err := json.Unmarshal([]byte(takeProfitLevelsJSON.String), &logic.TakeProfitLevels); err != nil {
+			log.Printf("⚠️  解析阶梯止盈失败: %v", err)
+		}
+	}
+
 	return logic, nil
 }
 
@@ -308,6 +360,52 @@ func (s *PositionLogicStorage) SaveFirstSeenTime(symbol, side string, firstSeenT
 	return nil
 }
 
+// SaveTrailingStop 保存移动止损状态（distancePct为0表示关闭移动止损）
+func (s *PositionLogicStorage) SaveTrailingStop(symbol, side string, distancePct, bestPrice float64) error {
+	query := `
+		INSERT INTO position_logic (symbol, side, trailing_distance_pct, trailing_best_price, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(symbol, side) DO UPDATE SET
+			trailing_distance_pct = excluded.trailing_distance_pct,
+			trailing_best_price = excluded.trailing_best_price,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.Exec(query, symbol, side, distancePct, bestPrice, time.Now())
+	if err != nil {
+		return fmt.Errorf("保存移动止损状态失败: %w", err)
+	}
+
+	return nil
+}
+
+// SaveTakeProfitLevels 保存阶梯止盈（levels为空表示清除阶梯止盈，恢复为单一止盈）
+func (s *PositionLogicStorage) SaveTakeProfitLevels(symbol, side string, levels []TakeProfitLevel) error {
+	var levelsJSON sql.NullString
+	if len(levels) > 0 {
+		data, err := json.Marshal(levels)
+		if err != nil {
+			return fmt.Errorf("序列化阶梯止盈失败: %w", err)
+		}
+		levelsJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
+	query := `
+		INSERT INTO position_logic (symbol, side, take_profit_levels, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(symbol, side) DO UPDATE SET
+			take_profit_levels = excluded.take_profit_levels,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.Exec(query, symbol, side, levelsJSON, time.Now())
+	if err != nil {
+		return fmt.Errorf("保存阶梯止盈失败: %w", err)
+	}
+
+	return nil
+}
+
 // GetAllFirstSeenTimes 获取所有持仓的首次出现时间（用于迁移）
 func (s *PositionLogicStorage) GetAllFirstSeenTimes() (map[string]int64, error) {
 	query := `SELECT symbol, side, first_seen_time FROM position_logic WHERE first_seen_time > 0`
@@ -333,3 +431,42 @@ func (s *PositionLogicStorage) GetAllFirstSeenTimes() (map[string]int64, error)
 	return result, nil
 }
 
+
+// SaveLastCloseTime 保存持仓最近平仓时间（Unix毫秒时间戳，用于再入场冷却）
+func (s *PositionLogicStorage) SaveLastCloseTime(symbol, side string, closeTime int64) error {
+	query := `
+		INSERT INTO position_close_times (symbol, side, close_time)
+		VALUES (?, ?, ?)
+		ON CONFLICT(symbol, side) DO UPDATE SET
+			close_time = excluded.close_time
+	`
+
+	_, err := s.db.Exec(query, symbol, side, closeTime)
+	if err != nil {
+		return fmt.Errorf("保存平仓时间失败: %w", err)
+	}
+
+	return nil
+}
+
+// GetAllLastCloseTimes 获取所有持仓的最近平仓时间（symbol_side -> Unix毫秒时间戳）
+func (s *PositionLogicStorage) GetAllLastCloseTimes() (map[string]int64, error) {
+	rows, err := s.db.Query(`SELECT symbol, side, close_time FROM position_close_times WHERE close_time > 0`)
+	if err != nil {
+		return nil, fmt.Errorf("查询平仓时间失败: %w", err)
+	}
+	defer rows.Close()
+
+	result := make(map[string]int64)
+	for rows.Next() {
+		var symbol, side string
+		var closeTime int64
+		if err := rows.Scan(&symbol, &side, &closeTime); err != nil {
+			log.Printf("⚠️  扫描平仓时间失败: %v", err)
+			continue
+		}
+		result[symbol+"_"+side] = closeTime
+	}
+
+	return result, nil
+}
@@ -116,8 +116,11 @@ func (w *PositionLogicWrapper) GetLogic(symbol, side string) *decision.PositionL
 
 	// 转换为旧格式
 	logic := &decision.PositionLogic{
-		StopLoss:   dbLogic.StopLoss,
-		TakeProfit: dbLogic.TakeProfit,
+		StopLoss:            dbLogic.StopLoss,
+		TakeProfit:          dbLogic.TakeProfit,
+		TrailingDistancePct: dbLogic.TrailingDistancePct,
+		TrailingBestPrice:   dbLogic.TrailingBestPrice,
+		TakeProfitLevels:    convertTakeProfitLevelsFromNew(dbLogic.TakeProfitLevels),
 	}
 
 	if dbLogic.EntryLogic != nil {
@@ -215,6 +218,9 @@ func (w *PositionLogicWrapper) SaveStopLossAndTakeProfit(symbol, side string, st
 		// 从数据库加载的值更新缓存（确保完整同步）
 		logic.StopLoss = dbLogic.StopLoss
 		logic.TakeProfit = dbLogic.TakeProfit
+		logic.TrailingDistancePct = dbLogic.TrailingDistancePct
+		logic.TrailingBestPrice = dbLogic.TrailingBestPrice
+		logic.TakeProfitLevels = convertTakeProfitLevelsFromNew(dbLogic.TakeProfitLevels)
 		
 		// 更新逻辑字段（如果数据库中有）
 		if dbLogic.EntryLogic != nil {
@@ -278,6 +284,66 @@ func (w *PositionLogicWrapper) SaveFirstSeenTime(symbol, side string, firstSeenT
 	return nil
 }
 
+// SaveTrailingStop 保存移动止损状态（distancePct为0表示关闭移动止损）
+func (w *PositionLogicWrapper) SaveTrailingStop(symbol, side string, distancePct, bestPrice float64) error {
+	err := w.storage.SaveTrailingStop(symbol, side, distancePct, bestPrice)
+	if err != nil {
+		return err
+	}
+
+	// 更新缓存
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	posKey := symbol + "_" + side
+	logic, exists := w.cache[posKey]
+	if !exists {
+		logic = &decision.PositionLogic{}
+		w.cache[posKey] = logic
+	}
+	logic.TrailingDistancePct = distancePct
+	logic.TrailingBestPrice = bestPrice
+
+	return nil
+}
+
+// SaveTakeProfitLevels 保存阶梯止盈（levels为空表示清除阶梯止盈）
+func (w *PositionLogicWrapper) SaveTakeProfitLevels(symbol, side string, levels []decision.TakeProfitLevel) error {
+	newLevels := make([]TakeProfitLevel, len(levels))
+	for i, level := range levels {
+		newLevels[i] = TakeProfitLevel{Price: level.Price, Pct: level.Pct}
+	}
+
+	err := w.storage.SaveTakeProfitLevels(symbol, side, newLevels)
+	if err != nil {
+		return err
+	}
+
+	// 更新缓存
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	posKey := symbol + "_" + side
+	logic, exists := w.cache[posKey]
+	if !exists {
+		logic = &decision.PositionLogic{}
+		w.cache[posKey] = logic
+	}
+	logic.TakeProfitLevels = levels
+
+	return nil
+}
+
+// SaveLastCloseTime 保存持仓最近平仓时间（用于再入场冷却）
+func (w *PositionLogicWrapper) SaveLastCloseTime(symbol, side string, closeTime int64) error {
+	return w.storage.SaveLastCloseTime(symbol, side, closeTime)
+}
+
+// GetAllLastCloseTimes 获取所有持仓的最近平仓时间（symbol_side -> Unix毫秒时间戳）
+func (w *PositionLogicWrapper) GetAllLastCloseTimes() (map[string]int64, error) {
+	return w.storage.GetAllLastCloseTimes()
+}
+
 // GetFirstSeenTime 获取持仓首次出现时间
 func (w *PositionLogicWrapper) GetFirstSeenTime(symbol, side string) (int64, bool) {
 	// 从数据库加载
@@ -340,6 +406,17 @@ func convertLogicConditionsFromNew(conditions []LogicCondition) []decision.Logic
 	return result
 }
 
+func convertTakeProfitLevelsFromNew(levels []TakeProfitLevel) []decision.TakeProfitLevel {
+	if len(levels) == 0 {
+		return nil
+	}
+	result := make([]decision.TakeProfitLevel, len(levels))
+	for i, level := range levels {
+		result[i] = decision.TakeProfitLevel{Price: level.Price, Pct: level.Pct}
+	}
+	return result
+}
+
 func convertMultiTimeframeLogicFromNew(mtf *MultiTimeframeLogic) *decision.MultiTimeframeLogic {
 	if mtf == nil {
 		return nil
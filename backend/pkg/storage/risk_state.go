@@ -0,0 +1,141 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+
+	"backend/pkg/db"
+)
+
+// 风控状态的键
+const (
+	riskStateKeyPeakEquity       = "peak_equity"
+	riskStateKeyDailyStartEquity = "daily_start_equity"
+	riskStateKeyLastResetTime    = "last_reset_time"
+	riskStateKeyMaxDrawdownPct   = "max_drawdown_pct"
+	riskStateKeyInitialBalance   = "initial_balance"
+)
+
+// RiskState 账户级风控状态（重启后恢复，保证回撤/日亏损风控不会因重启而失忆）
+type RiskState struct {
+	PeakEquity       float64   `json:"peak_equity"`        // 峰值净值
+	DailyStartEquity float64   `json:"daily_start_equity"` // 今日开盘净值
+	LastResetTime    time.Time `json:"last_reset_time"`    // 上次日盈亏重置时间
+	MaxDrawdownPct   float64   `json:"max_drawdown_pct"`   // 历史最大回撤百分比（相对峰值净值）
+	InitialBalance   float64   `json:"initial_balance"`    // 初始余额（自动检测的初始余额重启后沿用）
+}
+
+// RiskStateStorage 风控状态存储（按trader存储的键值表，使用SQLite）
+type RiskStateStorage struct {
+	dbManager *db.DBManager
+	db        *sql.DB
+}
+
+// NewRiskStateStorage 创建风控状态存储
+func NewRiskStateStorage(dbManager *db.DBManager) (*RiskStateStorage, error) {
+	storage := &RiskStateStorage{
+		dbManager: dbManager,
+	}
+
+	// 获取数据库连接
+	database, err := dbManager.GetDB("risk_state")
+	if err != nil {
+		return nil, fmt.Errorf("获取数据库连接失败: %w", err)
+	}
+	storage.db = database
+
+	// 初始化表结构
+	if err := storage.initTable(); err != nil {
+		return nil, fmt.Errorf("初始化表结构失败: %w", err)
+	}
+
+	return storage, nil
+}
+
+// initTable 初始化表结构
+func (s *RiskStateStorage) initTable() error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS risk_state (
+		trader_id TEXT NOT NULL,
+		state_key TEXT NOT NULL,
+		state_value TEXT NOT NULL,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (trader_id, state_key)
+	);
+	`
+
+	_, err := s.db.Exec(createTableSQL)
+	return err
+}
+
+// LoadRiskState 加载trader的风控状态（没有保存过时返回nil）
+func (s *RiskStateStorage) LoadRiskState(traderID string) (*RiskState, error) {
+	rows, err := s.db.Query(`SELECT state_key, state_value FROM risk_state WHERE trader_id = ?`, traderID)
+	if err != nil {
+		return nil, fmt.Errorf("查询风控状态失败: %w", err)
+	}
+	defer rows.Close()
+
+	values := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("解析风控状态失败: %w", err)
+		}
+		values[key] = value
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("查询风控状态失败: %w", err)
+	}
+	if len(values) == 0 {
+		return nil, nil
+	}
+
+	state := &RiskState{}
+	state.PeakEquity, _ = strconv.ParseFloat(values[riskStateKeyPeakEquity], 64)
+	state.DailyStartEquity, _ = strconv.ParseFloat(values[riskStateKeyDailyStartEquity], 64)
+	state.MaxDrawdownPct, _ = strconv.ParseFloat(values[riskStateKeyMaxDrawdownPct], 64)
+	state.InitialBalance, _ = strconv.ParseFloat(values[riskStateKeyInitialBalance], 64)
+	if ms, err := strconv.ParseInt(values[riskStateKeyLastResetTime], 10, 64); err == nil && ms > 0 {
+		state.LastResetTime = time.UnixMilli(ms)
+	}
+	return state, nil
+}
+
+// SaveRiskState 保存trader的风控状态（覆盖已有值）
+func (s *RiskStateStorage) SaveRiskState(traderID string, state *RiskState) error {
+	values := map[string]string{
+		riskStateKeyPeakEquity:       strconv.FormatFloat(state.PeakEquity, 'f', -1, 64),
+		riskStateKeyDailyStartEquity: strconv.FormatFloat(state.DailyStartEquity, 'f', -1, 64),
+		riskStateKeyLastResetTime:    strconv.FormatInt(state.LastResetTime.UnixMilli(), 10),
+		riskStateKeyMaxDrawdownPct:   strconv.FormatFloat(state.MaxDrawdownPct, 'f', -1, 64),
+		riskStateKeyInitialBalance:   strconv.FormatFloat(state.InitialBalance, 'f', -1, 64),
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("开始事务失败: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO risk_state (trader_id, state_key, state_value, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(trader_id, state_key) DO UPDATE SET
+			state_value = excluded.state_value,
+			updated_at = excluded.updated_at
+	`
+	now := time.Now()
+	for key, value := range values {
+		if _, err := tx.Exec(query, traderID, key, value, now); err != nil {
+			return fmt.Errorf("保存风控状态失败: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("提交风控状态失败: %w", err)
+	}
+	return nil
+}
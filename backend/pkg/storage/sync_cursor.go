@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// SyncCursorAllSymbols 全币种拉取使用的游标键（交易所成交按全币种一次拉取时使用）
+const SyncCursorAllSymbols = "*"
+
+// SyncCursor 交易所成交同步游标（记录已同步到的最后一笔成交）
+type SyncCursor struct {
+	Symbol        string    `json:"symbol"`
+	LastTradeTime int64     `json:"last_trade_time"` // 最后一笔已同步成交的时间（Unix毫秒时间戳）
+	LastTradeID   int64     `json:"last_trade_id"`   // 最后一笔已同步成交的成交ID（同一毫秒内去重）
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// IsAfter 判断成交是否在游标之后（尚未同步）
+func (c SyncCursor) IsAfter(tradeTimeMs, tradeID int64) bool {
+	if tradeTimeMs != c.LastTradeTime {
+		return tradeTimeMs > c.LastTradeTime
+	}
+	return tradeID > c.LastTradeID
+}
+
+// initSyncCursorTable 初始化同步游标表
+func (s *TradeStorage) initSyncCursorTable() error {
+	createTableSQL := `
+	CREATE TABLE IF NOT EXISTS trade_sync_cursors (
+		symbol TEXT PRIMARY KEY,
+		last_trade_time INTEGER NOT NULL DEFAULT 0,
+		last_trade_id INTEGER NOT NULL DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+	`
+
+	_, err := s.db.Exec(createTableSQL)
+	return err
+}
+
+// GetSyncCursors 获取所有币种的同步游标（symbol -> 游标）
+func (s *TradeStorage) GetSyncCursors() (map[string]SyncCursor, error) {
+	rows, err := s.db.Query(`SELECT symbol, last_trade_time, last_trade_id, updated_at FROM trade_sync_cursors`)
+	if err != nil {
+		return nil, fmt.Errorf("查询同步游标失败: %w", err)
+	}
+	defer rows.Close()
+
+	cursors := make(map[string]SyncCursor)
+	for rows.Next() {
+		var c SyncCursor
+		if err := rows.Scan(&c.Symbol, &c.LastTradeTime, &c.LastTradeID, &c.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("解析同步游标失败: %w", err)
+		}
+		cursors[c.Symbol] = c
+	}
+	return cursors, rows.Err()
+}
+
+// SaveSyncCursor 保存同步游标（只前进不后退）
+func (s *TradeStorage) SaveSyncCursor(cursor SyncCursor) error {
+	query := `
+		INSERT INTO trade_sync_cursors (symbol, last_trade_time, last_trade_id, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(symbol) DO UPDATE SET
+			last_trade_time = excluded.last_trade_time,
+			last_trade_id = excluded.last_trade_id,
+			updated_at = excluded.updated_at
+		WHERE excluded.last_trade_time > trade_sync_cursors.last_trade_time
+			OR (excluded.last_trade_time = trade_sync_cursors.last_trade_time
+				AND excluded.last_trade_id > trade_sync_cursors.last_trade_id)
+	`
+
+	_, err := s.db.Exec(query, cursor.Symbol, cursor.LastTradeTime, cursor.LastTradeID, time.Now())
+	if err != nil {
+		return fmt.Errorf("保存同步游标失败: %w", err)
+	}
+	return nil
+}
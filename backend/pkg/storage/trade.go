@@ -74,7 +74,8 @@ func (s *TradeStorage) initTable() error {
 		close_logic TEXT,
 		forced_close_logic TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
-		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		strategy TEXT
 	);
 	
 	CREATE INDEX IF NOT EXISTS idx_symbol ON trades(symbol);
@@ -104,6 +105,8 @@ func (s *TradeStorage) initTable() error {
 		`ALTER TABLE trades ADD COLUMN forced_close_logic TEXT;`,
 		// 检查并添加updated_at字段
 		`ALTER TABLE trades ADD COLUMN updated_at DATETIME DEFAULT CURRENT_TIMESTAMP;`,
+		// 检查并添加strategy字段（产生该交易的策略名称，用于多策略对比）
+		`ALTER TABLE trades ADD COLUMN strategy TEXT;`,
 		// 修改close_time等字段允许NULL（已开仓但未平仓的记录）
 		// SQLite不支持直接修改列，这里只处理新增列的情况
 	}
@@ -123,6 +126,11 @@ func (s *TradeStorage) initTable() error {
 		}
 	}
 
+	// 初始化交易所成交同步游标表
+	if err := s.initSyncCursorTable(); err != nil {
+		return fmt.Errorf("初始化同步游标表失败: %w", err)
+	}
+
 	return nil
 }
 
@@ -160,6 +168,7 @@ type TradeRecord struct {
 	UpdateTPLogic    string     `json:"update_tp_logic"`    // 更新止盈逻辑
 	CloseLogic       string     `json:"close_logic"`        // 平仓逻辑（直接平仓的理由）
 	ForcedCloseLogic string     `json:"forced_close_logic"` // 强制平仓逻辑
+	Strategy         string     `json:"strategy"`           // 产生该交易的策略名称（系统外开仓和旧记录为空）
 }
 
 // LogTrade 记录一笔完整交易（向后兼容，用于平仓时一次性写入）
@@ -172,8 +181,8 @@ func (s *TradeStorage) LogTrade(trade *TradeRecord) error {
 			close_reason, close_cycle_num, is_forced, forced_reason,
 			duration, position_value, margin_used, pnl, pnl_pct,
 			was_stop_loss, success, error, entry_logic, exit_logic,
-			update_sl_logic, update_tp_logic, close_logic, forced_close_logic
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			update_sl_logic, update_tp_logic, close_logic, forced_close_logic, strategy
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	isForced := 0
@@ -205,7 +214,7 @@ func (s *TradeStorage) LogTrade(trade *TradeRecord) error {
 		trade.PnL, trade.PnLPct,
 		wasStopLoss, success, trade.Error,
 		trade.EntryLogic, trade.ExitLogic,
-		trade.UpdateSLLogic, trade.UpdateTPLogic, trade.CloseLogic, trade.ForcedCloseLogic,
+		trade.UpdateSLLogic, trade.UpdateTPLogic, trade.CloseLogic, trade.ForcedCloseLogic, trade.Strategy,
 	)
 
 	if err != nil {
@@ -249,9 +258,9 @@ func (s *TradeStorage) CreateTrade(trade *TradeRecord) error {
 		INSERT INTO trades (
 			trade_id, symbol, side, open_time, open_price, open_quantity,
 			open_leverage, open_order_id, open_reason, open_cycle_num,
-			position_value, margin_used, entry_logic, exit_logic,
+			position_value, margin_used, entry_logic, exit_logic, strategy,
 			created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)
 	`
 
 	_, err := s.db.Exec(query,
@@ -259,7 +268,7 @@ func (s *TradeStorage) CreateTrade(trade *TradeRecord) error {
 		trade.OpenTime, trade.OpenPrice, trade.OpenQuantity,
 		trade.OpenLeverage, trade.OpenOrderID, trade.OpenReason, trade.OpenCycleNum,
 		trade.PositionValue, trade.MarginUsed,
-		trade.EntryLogic, trade.ExitLogic,
+		trade.EntryLogic, trade.ExitLogic, trade.Strategy,
 	)
 
 	if err != nil {
@@ -476,6 +485,17 @@ func (s *TradeStorage) GetTradesByDate(date time.Time) ([]*TradeRecord, error) {
 	return s.scanTrades(rows)
 }
 
+// CountOpenedTradesSince 统计指定时间之后开仓的交易笔数（包括未平仓的交易）
+// 部分平仓记录（TradeID带_partial_后缀）与原开仓属于同一次开仓，不重复计数
+func (s *TradeStorage) CountOpenedTradesSince(since time.Time) (int, error) {
+	var count int
+	query := `SELECT COUNT(*) FROM trades WHERE open_time >= ? AND trade_id NOT LIKE '%\_partial\_%' ESCAPE '\'`
+	if err := s.db.QueryRow(query, since).Scan(&count); err != nil {
+		return 0, fmt.Errorf("统计开仓次数失败: %w", err)
+	}
+	return count, nil
+}
+
 // GetLatestTrades 获取最近N笔已平仓的交易
 func (s *TradeStorage) GetLatestTrades(n int) ([]*TradeRecord, error) {
 	query := `
@@ -504,6 +524,51 @@ func (s *TradeStorage) GetLatestTrades(n int) ([]*TradeRecord, error) {
 	return trades, nil
 }
 
+// GetTradesPaginated 分页获取已平仓的交易（按平仓时间倒序，最新的在前）
+// 同时返回已平仓交易总数（用于分页）
+func (s *TradeStorage) GetTradesPaginated(limit, offset int) ([]*TradeRecord, int, error) {
+	var total int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM trades WHERE close_time IS NOT NULL`).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("查询交易总数失败: %w", err)
+	}
+
+	query := `
+		SELECT * FROM trades
+		WHERE close_time IS NOT NULL
+		ORDER BY close_time DESC
+		LIMIT ? OFFSET ?
+	`
+
+	rows, err := s.db.Query(query, limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("查询交易记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	trades, err := s.scanTrades(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+	return trades, total, nil
+}
+
+// GetClosedTradesSince 获取指定时间之后平仓的所有交易（按平仓时间升序，用于导出）
+func (s *TradeStorage) GetClosedTradesSince(since time.Time) ([]*TradeRecord, error) {
+	query := `
+		SELECT * FROM trades
+		WHERE close_time IS NOT NULL AND close_time >= ?
+		ORDER BY close_time ASC
+	`
+
+	rows, err := s.db.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("查询交易记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanTrades(rows)
+}
+
 // GetTradesBySymbol 获取指定币种的所有已平仓交易
 func (s *TradeStorage) GetTradesBySymbol(symbol string, days int) ([]*TradeRecord, error) {
 	cutoffDate := time.Now().AddDate(0, 0, -days)
@@ -523,6 +588,42 @@ func (s *TradeStorage) GetTradesBySymbol(symbol string, days int) ([]*TradeRecor
 	return s.scanTrades(rows)
 }
 
+// GetClosedTradesBySymbolSince 获取指定币种在指定时间之后平仓的交易（按平仓时间降序，最近的在前）
+func (s *TradeStorage) GetClosedTradesBySymbolSince(symbol string, since time.Time) ([]*TradeRecord, error) {
+	query := `
+		SELECT * FROM trades
+		WHERE symbol = ? AND close_time IS NOT NULL AND close_time >= ?
+		ORDER BY close_time DESC
+	`
+
+	rows, err := s.db.Query(query, symbol, since)
+	if err != nil {
+		return nil, fmt.Errorf("查询交易记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanTrades(rows)
+}
+
+// GetTradesByStrategy 获取指定策略最近N天的所有已平仓交易（按平仓时间升序，用于多策略表现对比）
+func (s *TradeStorage) GetTradesByStrategy(name string, days int) ([]*TradeRecord, error) {
+	cutoffDate := time.Now().AddDate(0, 0, -days)
+
+	query := `
+		SELECT * FROM trades
+		WHERE strategy = ? AND close_time IS NOT NULL AND close_time >= ?
+		ORDER BY close_time ASC
+	`
+
+	rows, err := s.db.Query(query, name, cutoffDate)
+	if err != nil {
+		return nil, fmt.Errorf("查询交易记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	return s.scanTrades(rows)
+}
+
 // scanTrades 扫描查询结果
 func (s *TradeStorage) scanTrades(rows *sql.Rows) ([]*TradeRecord, error) {
 	var trades []*TradeRecord
@@ -547,7 +648,7 @@ func (s *TradeStorage) scanTrade(row *sql.Row) (*TradeRecord, error) {
 	var createdAt, updatedAt sql.NullTime
 	// 使用 sql.NullString 处理可能为 NULL 的字段
 	var entryLogic, exitLogic, updateSLLogic, updateTPLogic, closeLogic, forcedCloseLogic sql.NullString
-	var openReason, closeReason, forcedReason, duration, errorMsg sql.NullString
+	var openReason, closeReason, forcedReason, duration, errorMsg, strategy sql.NullString
 
 	err := row.Scan(
 		&trade.TradeID, &trade.Symbol, &trade.Side,
@@ -562,7 +663,7 @@ func (s *TradeStorage) scanTrade(row *sql.Row) (*TradeRecord, error) {
 		&entryLogic, &exitLogic,
 		&updateSLLogic, &updateTPLogic,
 		&closeLogic, &forcedCloseLogic,
-		&createdAt, &updatedAt,
+		&createdAt, &updatedAt, &strategy,
 	)
 
 	if err != nil {
@@ -611,6 +712,9 @@ func (s *TradeStorage) scanTrade(row *sql.Row) (*TradeRecord, error) {
 	if forcedCloseLogic.Valid {
 		trade.ForcedCloseLogic = forcedCloseLogic.String
 	}
+	if strategy.Valid {
+		trade.Strategy = strategy.String
+	}
 
 	return trade, nil
 }
@@ -623,7 +727,7 @@ func (s *TradeStorage) scanTradeRow(rows *sql.Rows) (*TradeRecord, error) {
 	var createdAt, updatedAt sql.NullTime
 	// 使用 sql.NullString 处理可能为 NULL 的字段
 	var entryLogic, exitLogic, updateSLLogic, updateTPLogic, closeLogic, forcedCloseLogic sql.NullString
-	var openReason, closeReason, forcedReason, duration, errorMsg sql.NullString
+	var openReason, closeReason, forcedReason, duration, errorMsg, strategy sql.NullString
 
 	err := rows.Scan(
 		&trade.TradeID, &trade.Symbol, &trade.Side,
@@ -638,7 +742,7 @@ func (s *TradeStorage) scanTradeRow(rows *sql.Rows) (*TradeRecord, error) {
 		&entryLogic, &exitLogic,
 		&updateSLLogic, &updateTPLogic,
 		&closeLogic, &forcedCloseLogic,
-		&createdAt, &updatedAt,
+		&createdAt, &updatedAt, &strategy,
 	)
 
 	if err != nil {
@@ -687,6 +791,9 @@ func (s *TradeStorage) scanTradeRow(rows *sql.Rows) (*TradeRecord, error) {
 	if forcedCloseLogic.Valid {
 		trade.ForcedCloseLogic = forcedCloseLogic.String
 	}
+	if strategy.Valid {
+		trade.Strategy = strategy.String
+	}
 
 	return trade, nil
 }
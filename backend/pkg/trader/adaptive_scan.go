@@ -0,0 +1,71 @@
+package trader
+
+import (
+	"log"
+	"time"
+
+	"backend/pkg/market"
+)
+
+// 自适应扫描间隔的波动率区间（参考币种15分钟K线的ATR14占价格的百分比）
+const (
+	adaptiveScanTimeframe  = "15m"
+	adaptiveScanLowATRPct  = 0.3 // ATR% ≤ 此值视为低波动，使用最大扫描间隔
+	adaptiveScanHighATRPct = 1.0 // ATR% ≥ 此值视为高波动，使用最小扫描间隔
+	defaultScanReference   = "BTCUSDT"
+)
+
+// resetCycleTimer 按下一次的扫描间隔重新设置AI决策周期定时器
+// 间隔从本周期开始时计算（与固定间隔的Ticker一致），周期耗时超过间隔时立即开始下一周期
+func (at *AutoTrader) resetCycleTimer(timer *time.Timer, cycleStart time.Time) {
+	wait := at.nextScanInterval() - time.Since(cycleStart)
+	if wait < 0 {
+		wait = 0
+	}
+
+	// 周期执行期间定时器可能已经触发，先清空再重设，避免立即多执行一个周期
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+	timer.Reset(wait)
+}
+
+// nextScanInterval 计算下一次AI决策周期的间隔
+// 未启用自适应模式或获取行情失败时使用固定的扫描间隔
+func (at *AutoTrader) nextScanInterval() time.Duration {
+	if !at.getConfig().AdaptiveScanInterval {
+		return at.getConfig().ScanInterval
+	}
+
+	symbol := at.getConfig().ScanReferenceSymbol
+	if symbol == "" {
+		symbol = defaultScanReference
+	}
+	data, err := market.GetWithTimeframe(symbol, adaptiveScanTimeframe, 100)
+	if err != nil || data.CurrentATR14 <= 0 || data.CurrentPrice <= 0 {
+		log.Printf("⚠️  获取 %s 波动率失败，使用默认扫描间隔 %v: %v", symbol, at.getConfig().ScanInterval, err)
+		return at.getConfig().ScanInterval
+	}
+
+	atrPct := data.CurrentATR14 / data.CurrentPrice * 100
+	interval := scanIntervalForVolatility(atrPct, at.getConfig().MinScanInterval, at.getConfig().MaxScanInterval)
+	log.Printf("⏱️  %s %s ATR%%=%.3f%%，下次扫描间隔 %v", symbol, adaptiveScanTimeframe, atrPct, interval)
+	return interval
+}
+
+// scanIntervalForVolatility 按波动率在最大和最小间隔之间线性插值（波动越大间隔越短，精确到秒）
+func scanIntervalForVolatility(atrPct float64, minInterval, maxInterval time.Duration) time.Duration {
+	switch {
+	case atrPct <= adaptiveScanLowATRPct:
+		return maxInterval
+	case atrPct >= adaptiveScanHighATRPct:
+		return minInterval
+	}
+
+	ratio := (atrPct - adaptiveScanLowATRPct) / (adaptiveScanHighATRPct - adaptiveScanLowATRPct)
+	interval := maxInterval - time.Duration(ratio*float64(maxInterval-minInterval))
+	return interval.Round(time.Second)
+}
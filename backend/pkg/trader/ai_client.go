@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+
+	"backend/pkg/decision"
+	"backend/pkg/mcp"
+)
+
+// newAIClient 按模型名称创建AI客户端（使用trader配置中对应的密钥），并应用超时、重试和采样参数配置
+func newAIClient(model string, config AutoTraderConfig) (*mcp.Client, error) {
+	client := mcp.New()
+
+	switch model {
+	case "custom":
+		// 使用自定义API
+		if config.CustomAPIURL == "" {
+			return nil, fmt.Errorf("使用自定义AI时必须配置custom_api_url")
+		}
+		if config.CustomAPIKey == "" {
+			return nil, fmt.Errorf("使用自定义AI时必须配置custom_api_key")
+		}
+		if config.CustomModelName == "" {
+			return nil, fmt.Errorf("使用自定义AI时必须配置custom_model_name")
+		}
+		client.SetCustomAPI(config.CustomAPIURL, config.CustomAPIKey, config.CustomModelName)
+		log.Printf("🤖 [%s] 使用自定义AI API: %s (模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
+	case "qwen":
+		// 使用Qwen
+		if config.QwenKey == "" {
+			return nil, fmt.Errorf("使用Qwen时必须配置qwen_key")
+		}
+		client.SetQwenAPIKey(config.QwenKey, "")
+		log.Printf("🤖 [%s] 使用阿里云Qwen AI", config.Name)
+	case "deepseek":
+		// 使用DeepSeek
+		if config.DeepSeekKey == "" {
+			return nil, fmt.Errorf("使用DeepSeek时必须配置deepseek_key")
+		}
+		client.SetDeepSeekAPIKey(config.DeepSeekKey)
+		log.Printf("🤖 [%s] 使用DeepSeek AI", config.Name)
+	default:
+		return nil, fmt.Errorf("不支持的AI模型: %s，当前支持deepseek、qwen和custom", model)
+	}
+
+	client.SetRetryPolicy(config.AITimeout, config.AIMaxRetries)
+	client.SetSamplingParams(config.AITemperature, config.AITopP, config.AIMaxTokens)
+	return client, nil
+}
+
+// newEnsembleMembers 创建多模型投票的AI客户端（配置的模型少于2个时返回nil，使用单模型决策）
+func newEnsembleMembers(config AutoTraderConfig) ([]decision.EnsembleMember, error) {
+	if len(config.EnsembleModels) < 2 {
+		return nil, nil
+	}
+
+	members := make([]decision.EnsembleMember, 0, len(config.EnsembleModels))
+	for _, model := range config.EnsembleModels {
+		client, err := newAIClient(model, config)
+		if err != nil {
+			return nil, fmt.Errorf("初始化投票模型 %s 失败: %w", model, err)
+		}
+		members = append(members, decision.EnsembleMember{Model: model, Client: client})
+	}
+
+	minAgree := config.EnsembleMinAgree
+	if minAgree <= 0 {
+		minAgree = len(members)/2 + 1
+	}
+	log.Printf("🗳️  [%s] 启用多模型投票: %v，开平仓至少需要%d/%d票", config.Name, config.EnsembleModels, minAgree, len(members))
+	return members, nil
+}
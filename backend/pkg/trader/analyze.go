@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+
+	"backend/pkg/decision"
+)
+
+// requestDecision 调用AI获取完整决策（决策周期和分析预览共用）
+func (at *AutoTrader) requestDecision(ctx *decision.Context) (*decision.FullDecision, error) {
+	log.Println("🤖 正在请求AI分析并决策...")
+	return decision.GetFullDecision(ctx, at.mcpClient)
+}
+
+// Analyze 预览当前行情下AI的决策：构建交易上下文并调用AI，但不执行任何决策
+// 不计入周期数、不保存决策记录、不执行强制止损检查；AI请求失败时仍返回已有的思维链（用于debug）
+func (at *AutoTrader) Analyze() (*decision.FullDecision, error) {
+	log.Printf("🔍 [%s] 分析预览：只请求AI决策，不执行", at.name)
+
+	ctx, err := at.buildTradingContext()
+	if err != nil {
+		return nil, fmt.Errorf("构建交易上下文失败: %w", err)
+	}
+
+	fullDecision, err := at.requestDecision(ctx)
+	if err != nil {
+		return fullDecision, fmt.Errorf("获取AI决策失败: %w", err)
+	}
+	return fullDecision, nil
+}
@@ -0,0 +1,74 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Aster限频重试参数
+const (
+	asterRateLimitInitialDelay = 1 * time.Second  // 首次退避时长（之后每次翻倍）
+	asterRateLimitMaxDelay     = 10 * time.Second // 单次退避上限（Retry-After更长时以Retry-After为准）
+	asterRateLimitMaxWait      = 30 * time.Second // 单个请求累计等待上限（超过后不再重试，直接返回限频错误）
+)
+
+// asterSleep 重试前等待（测试中替换，避免真实等待）
+var asterSleep = time.Sleep
+
+// asterHTTPError Aster接口返回的非200响应（错误信息格式与原来的"HTTP 状态码: 响应体"一致）
+type asterHTTPError struct {
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration // 响应头Retry-After（秒），没有时为0
+}
+
+func (e *asterHTTPError) Error() string {
+	return fmt.Sprintf("HTTP %d: %s", e.StatusCode, e.Body)
+}
+
+// isRateLimited 是否为限频响应（429：请求过多；418：持续超限后IP被临时封禁）
+func (e *asterHTTPError) isRateLimited() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode == http.StatusTeapot
+}
+
+// newAsterHTTPError 根据响应构建错误（解析Retry-After）
+func newAsterHTTPError(resp *http.Response, body []byte) *asterHTTPError {
+	err := &asterHTTPError{StatusCode: resp.StatusCode, Body: string(body)}
+	if secs, parseErr := strconv.Atoi(strings.TrimSpace(resp.Header.Get("Retry-After"))); parseErr == nil && secs > 0 {
+		err.RetryAfter = time.Duration(secs) * time.Second
+	}
+	return err
+}
+
+// isIdempotentAsterRequest 限频或网络错误时是否可以安全重试
+// 只重试查询（GET）和撤单（DELETE，重复撤单不会产生副作用）；下单、设置杠杆等POST请求不重试，
+// 避免请求实际已被交易所处理（限频响应、超时或连接在响应前断开）时重复下单
+func isIdempotentAsterRequest(method string) bool {
+	method = strings.ToUpper(method)
+	return method == "GET" || method == "DELETE"
+}
+
+// asterRateLimitDelay 计算第attempt次（从1开始）限频重试前的等待时长：指数退避，Retry-After更长时按Retry-After等待
+func asterRateLimitDelay(attempt int, retryAfter time.Duration) time.Duration {
+	delay := asterRateLimitInitialDelay << uint(attempt-1)
+	if delay > asterRateLimitMaxDelay {
+		delay = asterRateLimitMaxDelay
+	}
+	if retryAfter > delay {
+		delay = retryAfter
+	}
+	return delay
+}
+
+// asterRateLimitError 从错误中取出限频响应（不是限频错误时返回nil）
+func asterRateLimitError(err error) *asterHTTPError {
+	var httpErr *asterHTTPError
+	if errors.As(err, &httpErr) && httpErr.isRateLimited() {
+		return httpErr
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package trader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testAsterPrivateKey 仅用于测试签名的私钥
+const testAsterPrivateKey = "4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318"
+
+// newTestAsterTrader 创建指向测试服务器的AsterTrader
+func newTestAsterTrader(t *testing.T, server *httptest.Server) *AsterTrader {
+	t.Helper()
+	trader, err := NewAsterTrader("0x0000000000000000000000000000000000000001", "0x0000000000000000000000000000000000000002", testAsterPrivateKey)
+	if err != nil {
+		t.Fatalf("NewAsterTrader() error = %v", err)
+	}
+	trader.baseURL = server.URL
+	trader.client = server.Client()
+	return trader
+}
+
+// recordAsterSleeps 替换asterSleep，记录每次等待时长而不真实等待
+func recordAsterSleeps(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var sleeps []time.Duration
+	original := asterSleep
+	asterSleep = func(d time.Duration) { sleeps = append(sleeps, d) }
+	t.Cleanup(func() { asterSleep = original })
+	return &sleeps
+}
+
+func TestAsterRequestRetriesGetHonouringRetryAfter(t *testing.T) {
+	sleeps := recordAsterSleeps(t)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.Header().Set("Retry-After", "3")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"code":-1003,"msg":"Too many requests"}`))
+			return
+		}
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	body, err := newTestAsterTrader(t, server).request("GET", "/fapi/v3/positionRisk", map[string]interface{}{})
+	if err != nil {
+		t.Fatalf("request() error = %v", err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("body = %s", body)
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("requests = %d, want 2", got)
+	}
+	// Retry-After（3秒）长于首次退避（1秒），应按Retry-After等待
+	if len(*sleeps) != 1 || (*sleeps)[0] != 3*time.Second {
+		t.Errorf("sleeps = %v, want [3s]", *sleeps)
+	}
+}
+
+func TestAsterRequestDoesNotRetryPostOnRateLimit(t *testing.T) {
+	sleeps := recordAsterSleeps(t)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	_, err := newTestAsterTrader(t, server).request("POST", "/fapi/v3/order", map[string]interface{}{"symbol": "BTCUSDT"})
+	if err == nil {
+		t.Fatal("request() expected rate limit error")
+	}
+	if asterRateLimitError(err) == nil {
+		t.Errorf("error should wrap the rate limit response, got %v", err)
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("requests = %d, want 1 (POST must not be retried)", got)
+	}
+	if len(*sleeps) != 0 {
+		t.Errorf("sleeps = %v, want none", *sleeps)
+	}
+}
+
+func TestAsterRequestRateLimitTotalWaitCap(t *testing.T) {
+	sleeps := recordAsterSleeps(t)
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.Header().Set("Retry-After", "20")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	defer server.Close()
+
+	_, err := newTestAsterTrader(t, server).request("GET", "/fapi/v3/openOrders", map[string]interface{}{})
+	if err == nil {
+		t.Fatal("request() expected error after exceeding the wait cap")
+	}
+	if !strings.Contains(err.Error(), "超过上限") {
+		t.Errorf("error = %v, want wait cap error", err)
+	}
+
+	var total time.Duration
+	for _, d := range *sleeps {
+		total += d
+	}
+	if total > asterRateLimitMaxWait {
+		t.Errorf("total wait = %v, exceeds cap %v", total, asterRateLimitMaxWait)
+	}
+	// 第一次等待20秒，第二次再等20秒会超过30秒上限，因此只请求两次
+	if len(*sleeps) != 1 || atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("sleeps = %v, requests = %d, want [20s] and 2", *sleeps, atomic.LoadInt32(&requests))
+	}
+}
+
+func TestAsterRequestNetworkErrorRetriesOnlyIdempotent(t *testing.T) {
+	recordAsterSleeps(t)
+
+	tests := []struct {
+		method       string
+		wantRequests int32
+	}{
+		{"GET", 3},
+		{"DELETE", 3},
+		{"POST", 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method, func(t *testing.T) {
+			var requests int32
+			// 收到请求后直接断开连接（客户端得到EOF），模拟请求可能已被处理但响应丢失
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					t.Errorf("hijack: %v", err)
+					return
+				}
+				conn.Close()
+			}))
+			defer server.Close()
+
+			if _, err := newTestAsterTrader(t, server).request(tt.method, "/fapi/v3/order", map[string]interface{}{}); err == nil {
+				t.Fatal("request() expected error")
+			}
+			if got := atomic.LoadInt32(&requests); got != tt.wantRequests {
+				t.Errorf("requests = %d, want %d", got, tt.wantRequests)
+			}
+		})
+	}
+}
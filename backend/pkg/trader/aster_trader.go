@@ -47,9 +47,67 @@ type SymbolPrecision struct {
 	QuantityPrecision int
 	TickSize          float64 // 价格步进值
 	StepSize          float64 // 数量步进值
+	MinQty            float64 // 最小下单数量（LOT_SIZE，0表示未知）
+	MinNotional       float64 // 最小下单金额（MIN_NOTIONAL，USDT，0表示未知）
 	LastUpdated       time.Time // 最后更新时间，用于缓存过期
 }
 
+// fetchSymbolStatus 实时查询exchangeInfo中交易对的交易状态（Aster与Binance格式相同），交易所已没有该交易对时返回""
+func fetchSymbolStatus(client *http.Client, exchangeInfoURL, symbol string) (string, error) {
+	resp, err := client.Get(exchangeInfoURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var info struct {
+		Symbols []struct {
+			Symbol string `json:"symbol"`
+			Status string `json:"status"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return "", err
+	}
+	for _, s := range info.Symbols {
+		if s.Symbol == symbol {
+			return s.Status, nil
+		}
+	}
+	return "", nil
+}
+
+// parseSymbolFilters 从exchangeInfo的filters中解析价格/数量步进值和最小下单量（Aster与Binance格式相同）
+func parseSymbolFilters(prec *SymbolPrecision, filters []map[string]interface{}) {
+	parse := func(filter map[string]interface{}, key string) float64 {
+		str, _ := filter[key].(string)
+		value, _ := strconv.ParseFloat(str, 64)
+		return value
+	}
+
+	for _, filter := range filters {
+		filterType, _ := filter["filterType"].(string)
+		switch filterType {
+		case "PRICE_FILTER":
+			prec.TickSize = parse(filter, "tickSize")
+		case "LOT_SIZE":
+			prec.StepSize = parse(filter, "stepSize")
+			prec.MinQty = parse(filter, "minQty")
+		case "MIN_NOTIONAL":
+			// 合约接口字段名为notional，部分接口为minNotional
+			prec.MinNotional = parse(filter, "notional")
+			if prec.MinNotional == 0 {
+				prec.MinNotional = parse(filter, "minNotional")
+			}
+		}
+	}
+}
+
 // NewAsterTrader 创建Aster交易器
 // user: 主钱包地址 (登录地址)
 // signer: API钱包地址 (从 https://www.asterdex.com/en/api-wallet 获取)
@@ -129,20 +187,8 @@ func (t *AsterTrader) getPrecision(symbol string) (SymbolPrecision, error) {
 			LastUpdated:       now, // 记录更新时间
 		}
 
-		// 解析filters获取tickSize和stepSize
-		for _, filter := range s.Filters {
-			filterType, _ := filter["filterType"].(string)
-			switch filterType {
-			case "PRICE_FILTER":
-				if tickSizeStr, ok := filter["tickSize"].(string); ok {
-					prec.TickSize, _ = strconv.ParseFloat(tickSizeStr, 64)
-				}
-			case "LOT_SIZE":
-				if stepSizeStr, ok := filter["stepSize"].(string); ok {
-					prec.StepSize, _ = strconv.ParseFloat(stepSizeStr, 64)
-				}
-			}
-		}
+		// 解析filters获取tickSize、stepSize和最小下单量
+		parseSymbolFilters(&prec, s.Filters)
 
 		t.symbolPrecision[s.Symbol] = prec
 	}
@@ -185,6 +231,19 @@ func (t *AsterTrader) formatPrice(symbol string, price float64) (float64, error)
 	return math.Round(price*multiplier) / multiplier, nil
 }
 
+// priceDecimals 价格字符串的小数位数：有tick size时按tick size的小数位（避免pricePrecision与tick size不一致时
+// 格式化后的价格不再是tick size的整数倍），否则使用pricePrecision
+func priceDecimals(prec SymbolPrecision) int {
+	if prec.TickSize <= 0 {
+		return prec.PricePrecision
+	}
+	tick := strconv.FormatFloat(prec.TickSize, 'f', -1, 64)
+	if dot := strings.IndexByte(tick, '.'); dot >= 0 {
+		return len(tick) - dot - 1
+	}
+	return 0
+}
+
 // formatQuantity 格式化数量到正确精度和step size
 func (t *AsterTrader) formatQuantity(symbol string, quantity float64) (float64, error) {
 	prec, err := t.getPrecision(symbol)
@@ -333,9 +392,12 @@ func (t *AsterTrader) sign(params map[string]interface{}, nonce uint64) error {
 }
 
 // request 发送HTTP请求（带重试机制）
+// 只对查询和撤单重试：网络超时等临时错误最多重试3次，限频响应（429/418）按指数退避重试（遵守Retry-After，累计等待不超过asterRateLimitMaxWait）
 func (t *AsterTrader) request(method, endpoint string, params map[string]interface{}) ([]byte, error) {
 	const maxRetries = 3
 	var lastErr error
+	rateLimitAttempts := 0
+	var rateLimitWaited time.Duration
 
 	for attempt := 1; attempt <= maxRetries; attempt++ {
 		// 每次重试都生成新的nonce和签名
@@ -357,13 +419,32 @@ func (t *AsterTrader) request(method, endpoint string, params map[string]interfa
 
 		lastErr = err
 
-		// 如果是网络超时或临时错误，重试
-		if strings.Contains(err.Error(), "timeout") ||
-			strings.Contains(err.Error(), "connection reset") ||
-			strings.Contains(err.Error(), "EOF") {
+		// 限频：只重试幂等请求，不计入网络错误的重试次数
+		if rateLimitErr := asterRateLimitError(err); rateLimitErr != nil {
+			if !isIdempotentAsterRequest(method) {
+				return nil, fmt.Errorf("触发Aster限频（%s %s 非幂等请求，不自动重试）: %w", method, endpoint, err)
+			}
+			rateLimitAttempts++
+			delay := asterRateLimitDelay(rateLimitAttempts, rateLimitErr.RetryAfter)
+			if rateLimitWaited+delay > asterRateLimitMaxWait {
+				return nil, fmt.Errorf("触发Aster限频（%s %s 已等待%v，再等待%v将超过上限%v）: %w",
+					method, endpoint, rateLimitWaited, delay, asterRateLimitMaxWait, err)
+			}
+			log.Printf("  ⏳ Aster限频（HTTP %d）: %s %s，%v后重试（第%d次）", rateLimitErr.StatusCode, method, endpoint, delay, rateLimitAttempts)
+			asterSleep(delay)
+			rateLimitWaited += delay
+			attempt--
+			continue
+		}
+
+		// 如果是网络超时或临时错误，只重试幂等请求（POST可能已被交易所处理，重试会重复下单）
+		if isIdempotentAsterRequest(method) &&
+			(strings.Contains(err.Error(), "timeout") ||
+				strings.Contains(err.Error(), "connection reset") ||
+				strings.Contains(err.Error(), "EOF")) {
 			if attempt < maxRetries {
 				waitTime := time.Duration(attempt) * time.Second
-				time.Sleep(waitTime)
+				asterSleep(waitTime)
 				continue
 			}
 		}
@@ -401,7 +482,7 @@ func (t *AsterTrader) doRequest(method, endpoint string, params map[string]inter
 
 		body, _ := io.ReadAll(resp.Body)
 		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+			return nil, newAsterHTTPError(resp, body)
 		}
 		return body, nil
 
@@ -427,7 +508,7 @@ func (t *AsterTrader) doRequest(method, endpoint string, params map[string]inter
 
 		body, _ := io.ReadAll(resp.Body)
 		if resp.StatusCode != http.StatusOK {
-			return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+			return nil, newAsterHTTPError(resp, body)
 		}
 		return body, nil
 
@@ -492,21 +573,17 @@ func (t *AsterTrader) GetPositions() ([]map[string]interface{}, error) {
 
 	result := []map[string]interface{}{}
 	for _, pos := range positions {
-		posAmtStr, ok := pos["positionAmt"].(string)
-		if !ok {
-			continue
+		posAmt, ok := getFloat(pos, "positionAmt")
+		if !ok || posAmt == 0 {
+			continue // 跳过空仓位和无法解析的持仓
 		}
 
-		posAmt, _ := strconv.ParseFloat(posAmtStr, 64)
-		if posAmt == 0 {
-			continue // 跳过空仓位
-		}
-
-		entryPrice, _ := strconv.ParseFloat(pos["entryPrice"].(string), 64)
-		markPrice, _ := strconv.ParseFloat(pos["markPrice"].(string), 64)
-		unRealizedProfit, _ := strconv.ParseFloat(pos["unRealizedProfit"].(string), 64)
-		leverageVal, _ := strconv.ParseFloat(pos["leverage"].(string), 64)
-		liquidationPrice, _ := strconv.ParseFloat(pos["liquidationPrice"].(string), 64)
+		// 字段缺失、为null或类型变化时为0（由parsePosition在使用时校验）
+		entryPrice, _ := getFloat(pos, "entryPrice")
+		markPrice, _ := getFloat(pos, "markPrice")
+		unRealizedProfit, _ := getFloat(pos, "unRealizedProfit")
+		leverageVal, _ := getFloat(pos, "leverage")
+		liquidationPrice, _ := getFloat(pos, "liquidationPrice")
 
 		// 判断方向
 		side := "long"
@@ -557,7 +634,7 @@ func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (m
 	}
 
 	// 格式化价格和数量到正确精度
-	formattedPrice, err := t.formatPrice(symbol, limitPrice)
+	priceStr, err := t.FormatPrice(symbol, limitPrice)
 	if err != nil {
 		return nil, err
 	}
@@ -573,7 +650,6 @@ func (t *AsterTrader) OpenLong(symbol string, quantity float64, leverage int) (m
 	}
 
 	// 转换为字符串，使用正确的精度格式
-	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
 	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
@@ -628,7 +704,7 @@ func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (
 	}
 
 	// 格式化价格和数量到正确精度
-	formattedPrice, err := t.formatPrice(symbol, limitPrice)
+	priceStr, err := t.FormatPrice(symbol, limitPrice)
 	if err != nil {
 		return nil, err
 	}
@@ -644,7 +720,6 @@ func (t *AsterTrader) OpenShort(symbol string, quantity float64, leverage int) (
 	}
 
 	// 转换为字符串，使用正确的精度格式
-	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
 	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
@@ -684,7 +759,7 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 
 		for _, pos := range positions {
 			if pos["symbol"] == symbol && pos["side"] == "long" {
-				quantity = pos["positionAmt"].(float64)
+				quantity, _ = getFloat(pos, "positionAmt")
 				break
 			}
 		}
@@ -696,7 +771,7 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 	}
 
 	// 获取最新价格（在平仓前再次获取，减少时间窗口）
-	price, err := t.GetMarketPrice(symbol)
+	price, err := t.closeReferencePrice(symbol, "long")
 	if err != nil {
 		return nil, err
 	}
@@ -708,7 +783,7 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 	}
 
 	// 格式化价格和数量到正确精度
-	formattedPrice, err := t.formatPrice(symbol, limitPrice)
+	priceStr, err := t.FormatPrice(symbol, limitPrice)
 	if err != nil {
 		return nil, err
 	}
@@ -724,7 +799,6 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 	}
 
 	// 转换为字符串，使用正确的精度格式
-	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
 	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
@@ -738,6 +812,7 @@ func (t *AsterTrader) CloseLong(symbol string, quantity float64) (map[string]int
 		"timeInForce":  "GTC",
 		"quantity":     qtyStr,
 		"price":        priceStr,
+		"reduceOnly":   "true", // 只减仓：数量超过持仓时不会反向开仓
 	}
 
 	body, err := t.request("POST", "/fapi/v3/order", params)
@@ -772,7 +847,7 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		for _, pos := range positions {
 			if pos["symbol"] == symbol && pos["side"] == "short" {
 				// Aster的GetPositions已经将空仓数量转换为正数，直接使用
-				quantity = pos["positionAmt"].(float64)
+				quantity, _ = getFloat(pos, "positionAmt")
 				break
 			}
 		}
@@ -784,7 +859,7 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 	}
 
 	// 获取最新价格（在平仓前再次获取，减少时间窗口）
-	price, err := t.GetMarketPrice(symbol)
+	price, err := t.closeReferencePrice(symbol, "short")
 	if err != nil {
 		return nil, err
 	}
@@ -796,7 +871,7 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 	}
 
 	// 格式化价格和数量到正确精度
-	formattedPrice, err := t.formatPrice(symbol, limitPrice)
+	priceStr, err := t.FormatPrice(symbol, limitPrice)
 	if err != nil {
 		return nil, err
 	}
@@ -812,7 +887,6 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 	}
 
 	// 转换为字符串，使用正确的精度格式
-	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
 	log.Printf("  📏 精度处理: 价格 %.8f -> %s (精度=%d), 数量 %.8f -> %s (精度=%d)",
@@ -826,6 +900,7 @@ func (t *AsterTrader) CloseShort(symbol string, quantity float64) (map[string]in
 		"timeInForce":  "GTC",
 		"quantity":     qtyStr,
 		"price":        priceStr,
+		"reduceOnly":   "true", // 只减仓：数量超过持仓时不会反向开仓
 	}
 
 	body, err := t.request("POST", "/fapi/v3/order", params)
@@ -886,6 +961,29 @@ func (t *AsterTrader) GetMarketPrice(symbol string) (float64, error) {
 	return strconv.ParseFloat(priceStr, 64)
 }
 
+// closeReferencePrice 获取平仓限价的参考价格
+// 优先使用ticker最新价；币种停牌/下架时ticker可能获取失败，改用持仓的标记价格，保证仍然可以挂单平仓
+func (t *AsterTrader) closeReferencePrice(symbol, side string) (float64, error) {
+	price, err := t.GetMarketPrice(symbol)
+	if err == nil {
+		return price, nil
+	}
+
+	positions, posErr := t.GetPositions()
+	if posErr != nil {
+		return 0, err
+	}
+	for _, pos := range positions {
+		if pos["symbol"] == symbol && pos["side"] == side {
+			if markPrice, ok := getFloat(pos, "markPrice"); ok && markPrice > 0 {
+				log.Printf("  ⚠️  获取 %s 最新价格失败: %v，使用持仓标记价格 %.8f", symbol, err, markPrice)
+				return markPrice, nil
+			}
+		}
+	}
+	return 0, err
+}
+
 // SetStopLoss 设置止损
 func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
 	side := "SELL"
@@ -894,7 +992,7 @@ func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity,
 	}
 
 	// 格式化价格和数量到正确精度
-	formattedPrice, err := t.formatPrice(symbol, stopPrice)
+	priceStr, err := t.FormatPrice(symbol, stopPrice)
 	if err != nil {
 		return err
 	}
@@ -910,7 +1008,6 @@ func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity,
 	}
 
 	// 转换为字符串，使用正确的精度格式
-	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
 	params := map[string]interface{}{
@@ -921,6 +1018,7 @@ func (t *AsterTrader) SetStopLoss(symbol string, positionSide string, quantity,
 		"stopPrice":    priceStr,
 		"quantity":     qtyStr,
 		"timeInForce":  "GTC",
+		"reduceOnly":   "true", // 只减仓：持仓已平掉后触发也不会反向开仓
 	}
 
 	_, err = t.request("POST", "/fapi/v3/order", params)
@@ -935,7 +1033,7 @@ func (t *AsterTrader) SetTakeProfit(symbol string, positionSide string, quantity
 	}
 
 	// 格式化价格和数量到正确精度
-	formattedPrice, err := t.formatPrice(symbol, takeProfitPrice)
+	priceStr, err := t.FormatPrice(symbol, takeProfitPrice)
 	if err != nil {
 		return err
 	}
@@ -951,7 +1049,6 @@ func (t *AsterTrader) SetTakeProfit(symbol string, positionSide string, quantity
 	}
 
 	// 转换为字符串，使用正确的精度格式
-	priceStr := t.formatFloatWithPrecision(formattedPrice, prec.PricePrecision)
 	qtyStr := t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision)
 
 	params := map[string]interface{}{
@@ -962,12 +1059,76 @@ func (t *AsterTrader) SetTakeProfit(symbol string, positionSide string, quantity
 		"stopPrice":    priceStr,
 		"quantity":     qtyStr,
 		"timeInForce":  "GTC",
+		"reduceOnly":   "true", // 只减仓：持仓已平掉后触发也不会反向开仓
 	}
 
 	_, err = t.request("POST", "/fapi/v3/order", params)
 	return err
 }
 
+// placeOpenLimitOrder 下限价开仓单（GTC，按交易对精度格式化价格和数量）
+func (t *AsterTrader) placeOpenLimitOrder(symbol, side string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
+	}
+
+	// 先设置杠杆
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	priceStr, err := t.FormatPrice(symbol, price)
+	if err != nil {
+		return nil, err
+	}
+	formattedQty, err := t.formatQuantity(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	params := map[string]interface{}{
+		"symbol":       symbol,
+		"positionSide": "BOTH",
+		"type":         "LIMIT",
+		"side":         side,
+		"timeInForce":  "GTC",
+		"quantity":     t.formatFloatWithPrecision(formattedQty, prec.QuantityPrecision),
+		"price":        priceStr,
+	}
+
+	body, err := t.request("POST", "/fapi/v3/order", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// OpenLongLimit 限价开多单
+func (t *AsterTrader) OpenLongLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	return t.placeOpenLimitOrder(symbol, "BUY", quantity, price, leverage)
+}
+
+// OpenShortLimit 限价开空单
+func (t *AsterTrader) OpenShortLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	return t.placeOpenLimitOrder(symbol, "SELL", quantity, price, leverage)
+}
+
+// SetBracket 设置止损止盈（实现Trader接口）
+// Aster暂不支持OCO联动订单，退化为两个独立订单（止损优先）
+func (t *AsterTrader) SetBracket(symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error {
+	return setBracketWithSeparateOrders(t, symbol, positionSide, quantity, stopPrice, takeProfitPrice)
+}
+
 // CancelAllOrders 取消所有订单
 func (t *AsterTrader) CancelAllOrders(symbol string) error {
 	params := map[string]interface{}{
@@ -978,6 +1139,93 @@ func (t *AsterTrader) CancelAllOrders(symbol string) error {
 	return err
 }
 
+// GetOpenOrders 获取当前挂单（symbol为""时获取所有交易对）
+func (t *AsterTrader) GetOpenOrders(symbol string) ([]map[string]interface{}, error) {
+	params := make(map[string]interface{})
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+
+	body, err := t.request("GET", "/fapi/v3/openOrders", params)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	var orders []map[string]interface{}
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("解析挂单失败: %w", err)
+	}
+	return orders, nil
+}
+
+// CancelOrder 取消指定订单
+func (t *AsterTrader) CancelOrder(symbol string, orderID int64) error {
+	params := map[string]interface{}{
+		"symbol":  symbol,
+		"orderId": orderID,
+	}
+
+	_, err := t.request("DELETE", "/fapi/v3/order", params)
+	return err
+}
+
+// GetOrder 查询指定订单
+func (t *AsterTrader) GetOrder(symbol string, orderID int64) (map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"symbol":  symbol,
+		"orderId": orderID,
+	}
+
+	body, err := t.request("GET", "/fapi/v3/order", params)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+
+	var order map[string]interface{}
+	if err := json.Unmarshal(body, &order); err != nil {
+		return nil, fmt.Errorf("解析订单失败: %w", err)
+	}
+	return order, nil
+}
+
+// GetForceOrders 获取强平订单历史（symbol为""时获取所有交易对，startTime为零值时由交易所按默认范围返回）
+func (t *AsterTrader) GetForceOrders(symbol string, startTime time.Time) ([]map[string]interface{}, error) {
+	params := map[string]interface{}{
+		"limit": 100,
+	}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	if !startTime.IsZero() {
+		params["startTime"] = startTime.UnixMilli()
+	}
+
+	body, err := t.request("GET", "/fapi/v3/forceOrders", params)
+	if err != nil {
+		return nil, fmt.Errorf("获取强平订单失败: %w", err)
+	}
+
+	var orders []map[string]interface{}
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("解析强平订单失败: %w", err)
+	}
+	return orders, nil
+}
+
+// GetSymbolStatus 获取交易对当前的交易状态（实现Trader接口）
+func (t *AsterTrader) GetSymbolStatus(symbol string) (string, error) {
+	return fetchSymbolStatus(t.client, t.baseURL+"/fapi/v3/exchangeInfo", symbol)
+}
+
+// GetSymbolFilters 获取交易对的最小下单金额、最小数量、数量和价格步进值（实现Trader接口，exchangeInfo缓存24小时）
+func (t *AsterTrader) GetSymbolFilters(symbol string) (minNotional, minQty, stepSize, tickSize float64, err error) {
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return prec.MinNotional, prec.MinQty, prec.StepSize, prec.TickSize, nil
+}
+
 // FormatQuantity 格式化数量（实现Trader接口）
 func (t *AsterTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
 	formatted, err := t.formatQuantity(symbol, quantity)
@@ -987,6 +1235,19 @@ func (t *AsterTrader) FormatQuantity(symbol string, quantity float64) (string, e
 	return fmt.Sprintf("%v", formatted), nil
 }
 
+// FormatPrice 格式化价格到tick size并转换为下单用的字符串（实现Trader接口）
+func (t *AsterTrader) FormatPrice(symbol string, price float64) (string, error) {
+	formatted, err := t.formatPrice(symbol, price)
+	if err != nil {
+		return "", err
+	}
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return "", err
+	}
+	return t.formatFloatWithPrecision(formatted, priceDecimals(prec)), nil
+}
+
 // GetAccountTrades 获取账户交易历史
 // symbol: 交易对 (可选，为""时获取所有交易对)
 // startTime: 开始时间戳 (可选，为0时不限制开始时间)
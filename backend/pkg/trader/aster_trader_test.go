@@ -0,0 +1,150 @@
+package trader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeAsterExchange 模拟Aster接口：返回预置的精度、价格和持仓，记录下单请求的表单参数
+type fakeAsterExchange struct {
+	mu          sync.Mutex
+	positionAmt string
+	orders      []url.Values
+}
+
+func (f *fakeAsterExchange) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/fapi/v3/exchangeInfo", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"symbols":[{"symbol":"BTCUSDT","pricePrecision":1,"quantityPrecision":3,"filters":[
+			{"filterType":"PRICE_FILTER","tickSize":"0.1"},
+			{"filterType":"LOT_SIZE","stepSize":"0.001","minQty":"0.001"}]}]}`))
+	})
+	mux.HandleFunc("/fapi/v3/ticker/price", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"symbol":"BTCUSDT","price":"50000.0"}`))
+	})
+	mux.HandleFunc("/fapi/v3/positionRisk", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		w.Write([]byte(`[{"symbol":"BTCUSDT","positionAmt":"` + f.positionAmt + `","entryPrice":"49000","markPrice":"50000",` +
+			`"unRealizedProfit":"0","leverage":"5","liquidationPrice":"0"}]`))
+	})
+	mux.HandleFunc("/fapi/v3/order", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("order request method = %s, want POST", r.Method)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse order form: %v", err)
+		}
+		f.mu.Lock()
+		f.orders = append(f.orders, r.PostForm)
+		f.mu.Unlock()
+		w.Write([]byte(`{"orderId":123,"status":"NEW"}`))
+	})
+	mux.HandleFunc("/fapi/v3/allOpenOrders", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"code":200,"msg":"success"}`))
+	})
+	return mux
+}
+
+func TestAsterClosePositionSendsReduceOnly(t *testing.T) {
+	tests := []struct {
+		name        string
+		side        string
+		positionAmt string
+		quantity    float64
+		wantSide    string
+		wantQty     float64
+	}{
+		{"close long full position", "long", "0.5", 0, "SELL", 0.5},
+		{"close long partial", "long", "0.5", 0.2, "SELL", 0.2},
+		{"close short full position", "short", "-0.3", 0, "BUY", 0.3},
+		{"close short partial", "short", "-0.3", 0.1, "BUY", 0.1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exchange := &fakeAsterExchange{positionAmt: tt.positionAmt}
+			server := httptest.NewServer(exchange.handler(t))
+			defer server.Close()
+			trader := newTestAsterTrader(t, server)
+
+			var err error
+			if tt.side == "long" {
+				_, err = trader.CloseLong("BTCUSDT", tt.quantity)
+			} else {
+				_, err = trader.CloseShort("BTCUSDT", tt.quantity)
+			}
+			if err != nil {
+				t.Fatalf("close %s error = %v", tt.side, err)
+			}
+
+			if len(exchange.orders) != 1 {
+				t.Fatalf("order requests = %d, want 1", len(exchange.orders))
+			}
+			form := exchange.orders[0]
+			if got := form.Get("reduceOnly"); got != "true" {
+				t.Errorf("reduceOnly = %q, want true", got)
+			}
+			if got := form.Get("positionSide"); got != "BOTH" {
+				t.Errorf("positionSide = %q, want BOTH", got)
+			}
+			if got := form.Get("side"); got != tt.wantSide {
+				t.Errorf("side = %q, want %s", got, tt.wantSide)
+			}
+			if got := form.Get("symbol"); got != "BTCUSDT" {
+				t.Errorf("symbol = %q, want BTCUSDT", got)
+			}
+			qty, err := strconv.ParseFloat(form.Get("quantity"), 64)
+			if err != nil || qty != tt.wantQty {
+				t.Errorf("quantity = %q, want %v", form.Get("quantity"), tt.wantQty)
+			}
+		})
+	}
+}
+
+// formatPriceCase FormatPrice测试用例（Aster去掉末尾的0，Binance保留tick size的小数位）
+type formatPriceCase struct {
+	name        string
+	tickSize    float64
+	price       float64
+	wantAster   string
+	wantBinance string
+}
+
+// formatPriceCases pricePrecision统一设为8，验证有tick size时按tick size取整和确定小数位
+var formatPriceCases = []formatPriceCase{
+	{"tick 0.1 rounds up", 0.1, 50000.06, "50000.1", "50000.1"},
+	{"tick 0.1 rounds down to integer", 0.1, 50000.04, "50000", "50000.0"},
+	{"tick 0.01", 0.01, 3000.456, "3000.46", "3000.46"},
+	{"tick 0.01 keeps exact price", 0.01, 1.1, "1.1", "1.10"},
+	{"tick 0.0001", 0.0001, 0.123456, "0.1235", "0.1235"},
+	{"tick 0.0001 small price", 0.0001, 0.00004, "0", "0.0000"},
+	{"tick 0.5 rounds to half", 0.5, 123.26, "123.5", "123.5"},
+	{"tick 0.5 rounds down", 0.5, 123.74, "123.5", "123.5"},
+	{"tick 0.5 rounds up to integer", 0.5, 123.76, "124", "124.0"},
+}
+
+func TestAsterFormatPrice(t *testing.T) {
+	for _, tt := range formatPriceCases {
+		t.Run(tt.name, func(t *testing.T) {
+			trader := &AsterTrader{
+				symbolPrecision: map[string]SymbolPrecision{
+					"BTCUSDT": {PricePrecision: 8, TickSize: tt.tickSize, LastUpdated: time.Now()},
+				},
+				precisionCacheTTL: time.Hour,
+			}
+			got, err := trader.FormatPrice("BTCUSDT", tt.price)
+			if err != nil {
+				t.Fatalf("FormatPrice() error = %v", err)
+			}
+			if got != tt.wantAster {
+				t.Errorf("FormatPrice(%v) with tick %v = %q, want %q", tt.price, tt.tickSize, got, tt.wantAster)
+			}
+		})
+	}
+}
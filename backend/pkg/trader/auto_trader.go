@@ -11,6 +11,8 @@ import (
 	"backend/pkg/logger"
 	"backend/pkg/market"
 	"backend/pkg/mcp"
+	"backend/pkg/metrics"
+	"backend/pkg/notify"
 	"backend/pkg/pool"
 	"backend/pkg/storage"
 	"strings"
@@ -27,13 +29,17 @@ type AutoTraderConfig struct {
 	AIModel string // AI模型: "qwen" 或 "deepseek"
 
 	// 交易平台选择
-	Exchange string // "aster"
+	Exchange string // "aster" 或 "binance"
 
 	// Aster配置
 	AsterUser       string // Aster主钱包地址
 	AsterSigner     string // Aster API钱包地址
 	AsterPrivateKey string // Aster API钱包私钥
 
+	// Binance配置
+	BinanceAPIKey    string // Binance API Key
+	BinanceSecretKey string // Binance Secret Key
+
 	// AI配置
 	UseQwen     bool
 	DeepSeekKey string
@@ -44,25 +50,107 @@ type AutoTraderConfig struct {
 	CustomAPIKey    string
 	CustomModelName string
 
+	// AI请求配置
+	AITimeout    time.Duration // 单次AI请求超时（0表示使用默认值）
+	AIMaxRetries int           // AI请求临时性失败的重试次数（<0表示使用默认值）
+
+	// AI采样参数
+	AITemperature float64 // 采样温度（<0表示使用默认值）
+	AITopP        float64 // 核采样概率（0表示不设置）
+	AIMaxTokens   int     // 最大输出token数（0表示使用默认值）
+
+	// 以SSE流式接收AI响应（慢速模型输出长思维链时可以看到接收进度）
+	StreamResponses bool
+
+	// 多模型投票配置（模型少于2个时使用单模型决策）
+	EnsembleModels   []string // 参与投票的模型（deepseek/qwen/custom，使用上面配置的密钥）
+	EnsembleMinAgree int      // 开平仓决策至少需要的同意票数（0表示过半数）
+
 	// 扫描配置
 	ScanInterval time.Duration // 扫描间隔（建议3分钟）
 
+	// 自适应扫描间隔（按参考币种的波动率在最小和最大间隔之间调整，未启用时固定使用ScanInterval）
+	AdaptiveScanInterval bool
+	MinScanInterval      time.Duration // 高波动时的扫描间隔
+	MaxScanInterval      time.Duration // 低波动时的扫描间隔
+	ScanReferenceSymbol  string        // 计算波动率的参考币种（为空时使用BTCUSDT）
+
 	// 账户配置
-	InitialBalance float64 // 初始金额（用于计算盈亏，需手动设置）
+	InitialBalance           float64 // 初始金额（用于计算盈亏，<=0时需开启AutoDetectInitialBalance）
+	AutoDetectInitialBalance bool    // 未设置初始金额时以启动时的钱包余额作为初始金额（保存到数据库，重启后沿用）
+	TakerFeeBps    float64 // 吃单手续费（基点），交易记录盈亏扣除开仓和平仓手续费（0表示不扣除）
+
+	// 候选币种数量（每个周期从币种池取评分最高的前N个币种，<=0时使用20）
+	CandidatePoolSize int
+
+	// 限价开仓单超时时间（超时未完全成交则撤单）
+	LimitOrderTimeout time.Duration
+
+	// 单仓位止损检查间隔（同时确认限价开仓单成交，<=0时使用10秒）
+	StopLossCheckInterval time.Duration
+
+	// 行情数据最大时长（最新K线收盘早于该时长则跳过开仓，<=0时使用2倍K线周期）
+	MaxDataAge time.Duration
+
+	// 资金费率捕获模式（在prompt中突出展示资金费率绝对值≥FundingCaptureMinRate且即将结算的币种）
+	FundingCaptureMode    bool
+	FundingCaptureMinRate float64 // 小数形式，如0.0005=0.05%
+
+	// 币种黑白名单（黑名单优先；白名单非空时只开仓白名单中的币种，已有持仓不受影响）
+	SymbolAllowlist []string
+	SymbolDenylist  []string
+
+	// 同方向持仓收益率相关系数上限（超过时跳过开仓，<=0表示不检查）
+	MaxCorrelation float64
+
+	// 单币种连续亏损冷却（窗口期内最近连续亏损达到SymbolLossStreak笔时暂停该币种开仓，<=0表示不启用）
+	SymbolLossStreak   int
+	SymbolLossWindow   time.Duration // 统计连续亏损的窗口
+	SymbolLossCooldown time.Duration // 从最后一笔亏损平仓开始的冷却时长
+
+	// 每日最多开仓次数（与日盈亏同时重置，按交易记录统计，<=0表示不限制）
+	MaxDailyTrades int
+
+	// 会话边界平仓：启动时（第一个决策周期之前）/优雅停止时平掉所有持仓
+	FlattenOnStartup  bool
+	FlattenOnShutdown bool
+
+	// 决策记录保留时长（每天清理一次更早的记录）
+	DecisionRetention time.Duration
+
+	// 决策记录详细程度："full" / "summary"（只保存有操作或失败的周期） / "minimal"（无操作周期不保存prompt和思维链）
+	LogVerbosity string
+
+	// 熔断配置（连续失败周期数达到阈值后暂停交易）
+	CircuitBreakerFailures int
+	CircuitBreakerBackoff  time.Duration
+
+	// 停牌/下架处理：只有该币种行情数据失败的周期数达到阈值后告警，"close"时按交易所标记价格平仓
+	HaltedSymbolFailures int
+	HaltedSymbolAction   string
 
 	// 杠杆配置
 	BTCETHLeverage  int // BTC和ETH的杠杆倍数
 	AltcoinLeverage int // 山寨币的杠杆倍数
+	SymbolLeverageOverrides map[string]int // 单币种杠杆上限（优先于分组杠杆）
 
 	// 风险控制（强制止损止盈）
 	MaxDailyLoss         float64       // 最大日亏损百分比（账户级别风控）
 	MaxDrawdown          float64       // 最大回撤百分比（账户级别风控）
+	DeRiskDrawdown       float64       // 回撤减仓百分比（低于MaxDrawdown，超过时平掉表现最差的一半持仓，不暂停交易；0表示不启用）
 	PositionStopLossPct  float64       // 单仓位止损百分比（单仓位亏损超过此值时强制平仓，默认10%）
 	PositionTakeProfitPct float64      // 单仓位止盈百分比（可选，>0时强制止盈，≤0时由AI自行判断）
+	PositionMaxLossUSD   float64       // 单仓位最大亏损金额（USDT，未实现亏损超过时强制平仓，与百分比止损同时生效；0表示不启用）
+	MaxOpenPositions     int           // 最大同时持仓数量（0表示不限制）
+	ReentryCooldown      time.Duration // 平仓后同一币种的再入场冷却时长（0表示不限制，不区分方向）
+	MaxHoldingTime       time.Duration // 最长持仓时间（从持仓首次出现开始计算，超过后强制平仓；0表示不限制）
+	MaxFundingRate       float64       // 开仓方向需支付的最大资金费率（小数，如0.0005=0.05%；0表示不限制）
+	RiskPerTradePct      float64       // 单笔交易风险预算（占账户净值百分比，按止损距离限制仓位；0表示不限制）
 	StopTradingTime      time.Duration // 触发风控后暂停时长
 	
 	// 流动性过滤配置
 	SkipLiquidityCheck  bool           // 是否跳过流动性检查（默认false，开启后可以交易流动性差的币种）
+	MinOpenInterestValueUSD float64    // 流动性检查的最低持仓价值（USD，≤0时使用默认15M）
 	
 	// 分析模式配置
 	AnalysisMode        string         // 分析模式："standard" 或 "multi_timeframe"
@@ -70,6 +158,39 @@ type AutoTraderConfig struct {
 	
 	// 策略配置
 	StrategyName string // 策略名称（从配置读取）
+
+	// 开仓确认配置
+	OpenConfirmationEnabled bool          // 是否启用开仓确认（新开仓需在下一周期再次提出才执行）
+	OpenConfirmationWindow  time.Duration // 确认窗口，超过此时间未确认则作废（0表示使用2倍扫描间隔）
+
+	// Prompt内容配置
+	IncludeDerivativesTrend bool    // 是否在prompt中包含OI和资金费率的短期趋势
+	IncludeConcentration    bool    // 是否在prompt中包含持仓集中度
+	ConcentrationWarnHHI    float64 // 集中度（HHI）超过此值时提示AI分散或减仓
+
+	// 运行上限配置（0表示不限制）
+	MaxCycles     int           // 最大AI决策周期数
+	MaxRuntime    time.Duration // 最大运行时长
+	FlattenOnStop bool          // 达到上限停止时是否平掉所有持仓
+
+	// K线历史不足处理配置
+	MinKlineBars            int    // 每个时间框架所需的最少K线数量
+	InsufficientHistoryMode string // "flag"（在prompt中标注）或 "exclude"（排除候选币种）
+
+	// AI决策验证模式："strict"（整批拒绝）或 "lenient"（只丢弃无效决策）
+	ValidationMode         string
+	MinRiskReward          float64 // 开仓最低风险回报比（0表示不检查）
+	MinStopATRMultiple     float64 // 开仓止损距离的最小ATR倍数（0表示不检查）
+	MaxPositionNotionalPct float64 // 单笔开仓仓位价值上限（占账户净值百分比，0表示不限制）
+
+	// 决策记录Webhook（为空时不推送）
+	WebhookURL string
+
+	// Telegram通知配置（都为空时不发送通知）
+	TelegramBotToken   string
+	TelegramChatID     string
+	DailyReport        bool // 是否每天推送前一天的交易汇总（需要配置Telegram）
+	DailyReportHourUTC int  // 推送每日汇总的UTC整点（0-23）
 }
 
 // AutoTrader 自动交易器
@@ -79,27 +200,62 @@ type AutoTrader struct {
 	aiModel               string // AI模型名称
 	exchange              string // 交易平台名称
 	config                AutoTraderConfig
+	configMu              sync.RWMutex     // 保护config的并发访问（运行时可通过UpdateConfig修改风控和扫描参数）
+	configUpdated         chan struct{}    // 扫描间隔被修改时通知主循环重设定时器
 	trader                Trader // 使用Trader接口（支持多平台）
 	mcpClient             *mcp.Client
+	ensembleMembers       []decision.EnsembleMember // 多模型投票的AI模型（为空时使用mcpClient单模型决策）
 	positionLogicManager  *storage.PositionLogicWrapper // 持仓逻辑管理器（使用数据库存储）
 	storageAdapter        *storage.StorageAdapter // 数据库存储适配器
 	initialBalance        float64
 	dailyPnL              float64          // 日盈亏（需要并发保护）
 	dailyStartEquity      float64          // 每日开始时的净值（用于计算日盈亏）
-	lastResetTime         time.Time
-	stopUntil             time.Time
+	lastResetTime         time.Time        // 上次日盈亏重置时间（受riskMu保护）
+	stopUntil             time.Time        // 风控暂停交易截止时间（受riskMu保护）
+	consecutiveFailures   int              // 连续失败的决策周期数（只在Run循环中访问），用于熔断
+	deRiskTriggered       bool             // 回撤减仓已触发（回撤回落到阈值以下后重置，只在决策周期中访问，重启后重置）
 	isRunning             int32            // 运行状态（使用atomic保护，1=运行中，0=已停止）
+	paused                int32            // 暂停AI决策（使用atomic保护，1=已暂停），暂停期间单仓位止损检查照常执行
 	startTime             time.Time        // 系统启动时间
 	callCount             int64            // AI调用次数（使用atomic保护）
 	positionFirstSeenTime map[string]int64 // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
 	positionTimeMu        sync.RWMutex     // 保护positionFirstSeenTime的并发访问
-	peakEquity            float64          // 峰值净值（用于计算回撤）
-	riskMu                sync.RWMutex     // 保护peakEquity和dailyPnL的并发访问
+	peakEquity            float64          // 峰值净值（用于计算回撤，持久化到数据库）
+	maxDrawdownPct        float64          // 历史最大回撤百分比（相对峰值净值，持久化到数据库）
+	riskMu                sync.RWMutex     // 保护peakEquity、maxDrawdownPct和dailyPnL的并发访问
 	forcedClosedPositions map[string]time.Time // 已强制平仓的持仓（symbol_side -> 标记时间），失败时记录失败时间，5分钟后可重试
 	forcedCloseMu         sync.RWMutex          // 保护forcedClosedPositions的并发访问
 	closingPositions      map[string]*sync.Mutex // 正在执行平仓的持仓锁（symbol_side -> Mutex），防止并发平仓
 	closingPositionsMu    sync.Mutex       // 保护closingPositions的并发访问
 	savePositionTimeMu    sync.Mutex       // 保护savePositionFirstSeenTime的并发调用
+	pendingOpens          map[string]pendingOpen // 待确认的开仓提议（symbol_action -> 提议信息）
+	pendingOpensMu        sync.Mutex       // 保护pendingOpens的并发访问
+	pendingLimits         map[string]*pendingLimitOrder // 未成交的限价开仓单（symbol_side -> 挂单信息），只保存在内存中
+	pendingLimitsMu       sync.Mutex       // 保护pendingLimits的并发访问
+	stopReason            string           // 自动停止原因（达到运行上限等）
+	stopReasonMu          sync.RWMutex     // 保护stopReason的并发访问
+	trailingStops         map[string]trailingStopState // 移动止损状态（symbol_side -> 状态），持久化到PositionLogicManager
+	trailingStopsMu       sync.Mutex       // 保护trailingStops的并发访问
+	lastCloseTime         map[string]int64 // 最近平仓时间（symbol_side -> timestamp毫秒），用于再入场冷却，持久化到PositionLogicManager
+	lastCloseTimeMu       sync.Mutex       // 保护lastCloseTime的并发访问
+	liquidationChecked    map[string]int64 // 已查询过强平记录的消失持仓（symbol_side -> 首次出现时间），每个持仓只查询一次
+	liquidationCheckedMu  sync.Mutex       // 保护liquidationChecked的并发访问
+	marketDataFailures    map[string]int   // 持仓币种行情数据连续获取失败的周期数（symbol_side -> 次数），用于判定停牌/下架
+	marketDataFailuresMu  sync.Mutex       // 保护marketDataFailures的并发访问
+	cycleClosePrices      map[string][]float64 // 本周期决策上下文中的1小时K线收盘价（symbol -> 收盘价），相关性检查复用
+	cycleClosePricesMu    sync.RWMutex     // 保护cycleClosePrices的并发访问
+	notifier              *notify.TelegramNotifier // Telegram通知器（nil表示不发送通知）
+	eventLog              *logger.Logger   // 关键事件的结构化日志（开仓/平仓/强制平仓/风控触发）
+	lastDailyReport       string           // 最近一次推送的每日汇总日期（YYYY-MM-DD，只在Run循环中访问）
+	stopCh                chan struct{}    // Stop时关闭，让主循环立即退出（不等待下一次定时器）
+	stopOnce              sync.Once        // 保证stopCh只关闭一次
+	inFlight              sync.WaitGroup   // 进行中的主循环和平仓操作（Shutdown时等待完成）
+}
+
+// pendingOpen 待确认的开仓提议
+type pendingOpen struct {
+	cycleNum   int64     // 首次提出时的周期编号
+	proposedAt time.Time // 首次提出时间
 }
 
 // NewAutoTrader 创建自动交易器
@@ -119,36 +275,21 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		}
 	}
 
-	mcpClient := mcp.New()
-
 	// 初始化AI并验证密钥（在初始化时验证，避免运行时才发现配置错误）
-	if config.AIModel == "custom" {
-		// 使用自定义API
-		if config.CustomAPIURL == "" {
-			return nil, fmt.Errorf("使用自定义AI时必须配置custom_api_url")
-		}
-		if config.CustomAPIKey == "" {
-			return nil, fmt.Errorf("使用自定义AI时必须配置custom_api_key")
-		}
-		if config.CustomModelName == "" {
-			return nil, fmt.Errorf("使用自定义AI时必须配置custom_model_name")
-		}
-		mcpClient.SetCustomAPI(config.CustomAPIURL, config.CustomAPIKey, config.CustomModelName)
-		log.Printf("🤖 [%s] 使用自定义AI API: %s (模型: %s)", config.Name, config.CustomAPIURL, config.CustomModelName)
-	} else if config.UseQwen || config.AIModel == "qwen" {
-		// 使用Qwen
-		if config.QwenKey == "" {
-			return nil, fmt.Errorf("使用Qwen时必须配置qwen_key")
-		}
-		mcpClient.SetQwenAPIKey(config.QwenKey, "")
-		log.Printf("🤖 [%s] 使用阿里云Qwen AI", config.Name)
-	} else {
-		// 默认使用DeepSeek
-		if config.DeepSeekKey == "" {
-			return nil, fmt.Errorf("使用DeepSeek时必须配置deepseek_key")
-		}
-		mcpClient.SetDeepSeekAPIKey(config.DeepSeekKey)
-		log.Printf("🤖 [%s] 使用DeepSeek AI", config.Name)
+	primaryModel := config.AIModel
+	if primaryModel != "custom" && config.UseQwen {
+		primaryModel = "qwen"
+	}
+	mcpClient, err := newAIClient(primaryModel, config)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🤖 [%s] AI请求超时: %v，失败重试: %d次（指数退避）", config.Name, mcpClient.Timeout, mcpClient.MaxRetries)
+
+	// 多模型投票（配置了2个及以上模型时）
+	ensembleMembers, err := newEnsembleMembers(config)
+	if err != nil {
+		return nil, err
 	}
 
 	// 设置默认交易平台
@@ -158,23 +299,29 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 
 	// 根据配置创建对应的交易器
 	var trader Trader
-	var err error
 
-	if config.Exchange != "aster" {
-		return nil, fmt.Errorf("不支持的交易平台: %s，当前仅支持aster", config.Exchange)
-	}
-
-	log.Printf("🏦 [%s] 使用Aster交易", config.Name)
-	trader, err = NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
-	if err != nil {
-		return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
+	switch config.Exchange {
+	case "aster":
+		log.Printf("🏦 [%s] 使用Aster交易", config.Name)
+		trader, err = NewAsterTrader(config.AsterUser, config.AsterSigner, config.AsterPrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Aster交易器失败: %w", err)
+		}
+	case "binance":
+		log.Printf("🏦 [%s] 使用Binance合约交易", config.Name)
+		trader, err = NewBinanceTrader(config.BinanceAPIKey, config.BinanceSecretKey)
+		if err != nil {
+			return nil, fmt.Errorf("初始化Binance交易器失败: %w", err)
+		}
+	default:
+		return nil, fmt.Errorf("不支持的交易平台: %s，当前支持aster和binance", config.Exchange)
 	}
-	// 设置市场数据API使用Aster
-	market.SetExchange("aster")
+	// 设置市场数据API使用对应交易所
+	market.SetExchange(config.Exchange)
 
 	// 验证初始金额配置
-	if config.InitialBalance <= 0 {
-		return nil, fmt.Errorf("初始金额必须大于0，请在配置中设置InitialBalance")
+	if config.InitialBalance <= 0 && !config.AutoDetectInitialBalance {
+		return nil, fmt.Errorf("初始金额必须大于0，请在配置中设置InitialBalance或开启AutoDetectInitialBalance")
 	}
 
 	// 初始化数据库存储适配器
@@ -198,7 +345,15 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		log.Printf("📅 已从数据库加载 %d 个持仓的开仓时间", len(allTimes))
 	}
 
-	return &AutoTrader{
+	// 从数据库加载最近平仓时间（重启后继续执行再入场冷却）
+	lastCloseTime := make(map[string]int64)
+	if closeTimes, err := logicManager.GetAllLastCloseTimes(); err == nil {
+		lastCloseTime = closeTimes
+	} else {
+		log.Printf("⚠️  加载平仓时间失败: %v", err)
+	}
+
+	at := &AutoTrader{
 		id:                    config.ID,
 		name:                  config.Name,
 		aiModel:               config.AIModel,
@@ -206,6 +361,7 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		config:                config,
 		trader:                trader,
 		mcpClient:             mcpClient,
+		ensembleMembers:       ensembleMembers,
 		positionLogicManager:   logicManager,
 		storageAdapter:        storageAdapter,
 		initialBalance:        config.InitialBalance,
@@ -218,8 +374,30 @@ func NewAutoTrader(config AutoTraderConfig) (*AutoTrader, error) {
 		peakEquity:            config.InitialBalance, // 初始峰值 = 初始余额
 		forcedClosedPositions: make(map[string]time.Time),
 		closingPositions:      make(map[string]*sync.Mutex),
+		pendingOpens:          make(map[string]pendingOpen),
+		pendingLimits:         make(map[string]*pendingLimitOrder),
+		trailingStops:         make(map[string]trailingStopState),
+		lastCloseTime:         lastCloseTime,
+		liquidationChecked:    make(map[string]int64),
+		marketDataFailures:    make(map[string]int),
+		notifier:              notify.NewTelegramNotifier(config.TelegramBotToken, config.TelegramChatID, config.Name),
+		eventLog:              logger.NewLogger(config.ID),
 		stopUntil:             time.Time{}, // 初始化为零值，表示未设置暂停状态（重启后重置）
-	}, nil
+		stopCh:                make(chan struct{}),
+		configUpdated:         make(chan struct{}, 1),
+	}
+
+	// 从数据库恢复峰值净值等风控状态（重启后回撤风控不会失忆）
+	at.restoreRiskState()
+
+	// 未设置初始金额时（且数据库中没有之前检测到的值）以当前钱包余额作为初始金额
+	if at.initialBalance <= 0 {
+		if err := at.detectInitialBalance(); err != nil {
+			return nil, err
+		}
+	}
+
+	return at, nil
 }
 
 // savePositionFirstSeenTime 保存持仓首次出现时间到数据库（已废弃，现在直接保存）
@@ -230,60 +408,219 @@ func (at *AutoTrader) savePositionFirstSeenTime() {
 
 // Run 运行自动交易主循环
 func (at *AutoTrader) Run() error {
+	at.inFlight.Add(1)
+	defer at.inFlight.Done()
+
 	atomic.StoreInt32(&at.isRunning, 1)
 	log.Println("🚀 AI驱动自动交易系统启动")
 	log.Printf("💰 初始余额: %.2f USDT", at.initialBalance)
-	log.Printf("⚙️  扫描间隔: %v", at.config.ScanInterval)
+	if at.getConfig().AdaptiveScanInterval {
+		log.Printf("⚙️  扫描间隔: 按波动率自适应（%v ~ %v）", at.getConfig().MinScanInterval, at.getConfig().MaxScanInterval)
+	} else {
+		log.Printf("⚙️  扫描间隔: %v", at.getConfig().ScanInterval)
+	}
 	log.Println("🤖 AI将全权决定杠杆、仓位大小、止损止盈等参数")
-	log.Println("🛡️  单仓位止损检查：每10秒执行一次（独立于AI决策周期，快速响应插针行情）")
+	stopLossInterval := at.getConfig().StopLossCheckInterval
+	if stopLossInterval <= 0 {
+		stopLossInterval = 10 * time.Second
+	}
+	log.Printf("🛡️  单仓位止损检查：每%v执行一次（独立于AI决策周期，快速响应插针行情）", stopLossInterval)
 
-	// 主循环定时器（AI决策周期）
-	ticker := time.NewTicker(at.config.ScanInterval)
-	defer ticker.Stop()
+	// 主循环定时器（AI决策周期）：每个周期结束后按下一次的扫描间隔重新设置（自适应模式下间隔随波动率变化）
+	cycleTimer := time.NewTimer(at.getConfig().ScanInterval)
+	defer cycleTimer.Stop()
 
-	// 单仓位止损检查定时器（每10秒执行，快速响应插针行情）
-	stopLossTicker := time.NewTicker(10 * time.Second)
+	// 单仓位止损检查定时器（默认每10秒执行，快速响应插针行情）
+	stopLossTicker := time.NewTicker(stopLossInterval)
 	defer stopLossTicker.Stop()
 
-	// 首次立即执行AI决策周期
-	if err := at.runCycle(); err != nil {
+	// 每日汇总推送检查（每分钟检查一次是否到达推送时间，未启用时channel为nil，永远不会触发）
+	var dailyReportC <-chan time.Time
+	if at.getConfig().DailyReport && at.notifier != nil {
+		dailyReportTicker := time.NewTicker(time.Minute)
+		defer dailyReportTicker.Stop()
+		dailyReportC = dailyReportTicker.C
+		log.Printf("📊 每日汇总：每天UTC %02d:00 推送前一天的交易汇总到Telegram", at.getConfig().DailyReportHourUTC)
+	}
+
+	// 决策记录清理（启动时和之后每天执行一次）
+	pruneTicker := time.NewTicker(24 * time.Hour)
+	defer pruneTicker.Stop()
+	at.pruneDecisionRecords()
+
+	// 孤立止损止盈单检查（对应持仓已不存在的挂单，手动平仓或止损触发后可能残留）
+	orphanOrderTicker := time.NewTicker(OrphanOrderCheckInterval)
+	defer orphanOrderTicker.Stop()
+
+	// 启动时平掉已有持仓（在第一个决策周期之前，从空仓开始）
+	if at.getConfig().FlattenOnStartup {
+		at.flattenForSessionBoundary("启动时平仓（flatten_on_startup）", ForcedCloseTriggerStartupFlatten)
+	}
+
+	// 首次立即执行AI决策周期（与定时周期一样计入连续失败熔断）
+	cycleStart := time.Now()
+	err := at.runCycle()
+	if err != nil {
 		log.Printf("❌ 执行失败: %v", err)
 	}
+	at.trackCycleResult(err)
+	at.resetCycleTimer(cycleTimer, cycleStart)
 
 	// 首次立即执行单仓位止损检查
 	at.checkPositionStopLossOnly()
 
 	for atomic.LoadInt32(&at.isRunning) == 1 {
+		// 检查运行上限（在周期之间检查，确保最后一个周期完整执行）
+		if reason := at.checkRunLimit(); reason != "" {
+			at.stopForRunLimit(reason)
+			break
+		}
+
 		select {
-		case <-ticker.C:
+		case <-cycleTimer.C:
 			// AI决策周期
-			if err := at.runCycle(); err != nil {
+			cycleStart = time.Now()
+			err := at.runCycle()
+			if err != nil {
 				log.Printf("❌ 执行失败: %v", err)
 			}
+			at.trackCycleResult(err)
+			at.resetCycleTimer(cycleTimer, cycleStart)
 		case <-stopLossTicker.C:
-			// 单仓位止损检查（每10秒执行，快速响应插针行情）
+			// 单仓位止损检查（按stop_loss_check_interval_seconds执行，快速响应插针行情）
 			at.checkPositionStopLossOnly()
+			// 限价开仓单成交确认和超时撤单
+			at.checkPendingLimitOrders()
+		case now := <-dailyReportC:
+			// 每日汇总推送
+			at.checkDailyReport(now)
+		case <-pruneTicker.C:
+			// 清理过期的决策记录
+			at.pruneDecisionRecords()
+		case <-orphanOrderTicker.C:
+			// 撤销孤立的止损止盈单
+			at.reconcileOrphanOrders()
+		case <-at.configUpdated:
+			// 扫描间隔已修改，按新间隔从上一周期开始时间重新计时
+			at.resetCycleTimer(cycleTimer, cycleStart)
+		case <-at.stopCh:
+			// 已停止，退出主循环
 		}
 	}
 
 	return nil
 }
 
+// checkRunLimit 检查是否达到运行上限（周期数或运行时长）
+// 返回停止原因，未达到上限时返回空字符串
+func (at *AutoTrader) checkRunLimit() string {
+	if at.getConfig().MaxCycles > 0 {
+		if cycles := atomic.LoadInt64(&at.callCount); cycles >= int64(at.getConfig().MaxCycles) {
+			return fmt.Sprintf("已达到最大周期数上限（%d/%d）", cycles, at.getConfig().MaxCycles)
+		}
+	}
+	if at.getConfig().MaxRuntime > 0 {
+		if runtime := time.Since(at.startTime); runtime >= at.getConfig().MaxRuntime {
+			return fmt.Sprintf("已达到最大运行时长上限（%.0f/%.0f分钟）", runtime.Minutes(), at.getConfig().MaxRuntime.Minutes())
+		}
+	}
+	return ""
+}
+
+// stopForRunLimit 因达到运行上限而停止交易（可选平掉所有持仓），记录停止原因并发送通知
+func (at *AutoTrader) stopForRunLimit(reason string) {
+	log.Printf("🏁 [%s] %s，自动停止交易", at.name, reason)
+
+	if at.getConfig().FlattenOnStop {
+		log.Printf("🧹 [%s] 配置了flatten_on_stop，正在平掉所有持仓...", at.name)
+		// 只获取持仓列表（不构建完整交易上下文），候选币种或行情获取失败时也能平仓
+		at.cancelAllPendingLimits()
+		ctx, err := at.flattenContext()
+		if err != nil {
+			log.Printf("⚠️  [%s] 获取持仓失败，无法平仓: %v", at.name, err)
+			reason += "（平仓失败：无法获取持仓）"
+		} else {
+			actions, _ := at.forceCloseAllPositions("运行上限: "+reason, ForcedCloseTriggerRunLimit, ctx)
+			log.Printf("✓ [%s] 已平仓 %d/%d 个持仓", at.name, len(actions), len(ctx.Positions))
+			if len(actions) < len(ctx.Positions) {
+				reason += fmt.Sprintf("（%d个持仓平仓失败，请手动检查）", len(ctx.Positions)-len(actions))
+			}
+		}
+	}
+
+	at.stopReasonMu.Lock()
+	at.stopReason = reason
+	at.stopReasonMu.Unlock()
+	at.notifier.NotifyRiskTrigger("运行上限自动停止", reason)
+
+	at.Stop()
+}
+
 // Stop 停止自动交易
 func (at *AutoTrader) Stop() {
 	atomic.StoreInt32(&at.isRunning, 0)
+	at.stopOnce.Do(func() { close(at.stopCh) })
 	log.Println("⏹ 自动交易系统停止")
 }
 
+// Pause 暂停AI决策（主循环和每10秒的单仓位止损/止盈检查继续运行，用于重大消息期间避免开仓）
+func (at *AutoTrader) Pause() {
+	if atomic.CompareAndSwapInt32(&at.paused, 0, 1) {
+		log.Printf("⏸ [%s] AI决策已暂停（止损保护继续运行）", at.name)
+	}
+}
+
+// Resume 恢复AI决策（从下一个决策周期开始生效）
+func (at *AutoTrader) Resume() {
+	if atomic.CompareAndSwapInt32(&at.paused, 1, 0) {
+		log.Printf("▶️  [%s] AI决策已恢复", at.name)
+	}
+}
+
+// IsPaused AI决策是否已暂停
+func (at *AutoTrader) IsPaused() bool {
+	return atomic.LoadInt32(&at.paused) == 1
+}
+
+// checkForcedStopLossWhilePaused 暂停期间仍执行账户级别强制止损（回撤/日亏损），避免暂停时失去账户风控
+func (at *AutoTrader) checkForcedStopLossWhilePaused() {
+	ctx, err := at.buildTradingContext()
+	if err != nil {
+		log.Printf("⚠️  [%s] 暂停期间构建交易上下文失败，跳过强制止损检查: %v", at.name, err)
+		return
+	}
+
+	forcedActions, err := at.checkAndExecuteForcedStopLoss(ctx)
+	if err != nil {
+		log.Printf("⚠️  [%s] 暂停期间强制止损检查失败: %v", at.name, err)
+	}
+	for _, action := range forcedActions {
+		log.Printf("🛑 [%s] 暂停期间强制平仓: %s %s - %s", at.name, action.Symbol, action.Action, action.ForcedReason)
+
+		// 清理已强制平仓的持仓时间记录（与runCycle一致）
+		posKey := action.Symbol + "_" + strings.ToLower(strings.TrimPrefix(action.Action, "close_"))
+		at.positionTimeMu.Lock()
+		delete(at.positionFirstSeenTime, posKey)
+		at.positionTimeMu.Unlock()
+	}
+}
+
 // runCycle 运行一个交易周期（使用AI全权决策）
 func (at *AutoTrader) runCycle() error {
+	// 0. 手动暂停时跳过AI决策（不计入周期数），账户级别强制止损在此继续检查，单仓位止损检查由Run中独立的定时器继续执行
+	if at.IsPaused() {
+		log.Printf("⏸ [%s] AI决策已手动暂停，跳过本周期（止损保护继续运行）", at.name)
+		at.checkForcedStopLossWhilePaused()
+		return nil
+	}
+
 	atomic.AddInt64(&at.callCount, 1)
 
 	cycleNum := atomic.LoadInt64(&at.callCount)
 	now := time.Now()
-	log.Printf("\n" + strings.Repeat("=", 70))
+	log.Print("\n" + strings.Repeat("=", 70))
 	log.Printf("⏰ %s - AI决策周期 #%d", now.Format("2006-01-02 15:04:05"), cycleNum)
-	log.Printf(strings.Repeat("=", 70))
+	log.Print(strings.Repeat("=", 70))
 
 	// 创建决策记录
 	record := &logger.DecisionRecord{
@@ -295,12 +632,18 @@ func (at *AutoTrader) runCycle() error {
 		CandidateCoins: []string{},
 		Success:        true,
 	}
+	defer func() {
+		metrics.ObserveCycle(at.id, time.Since(now), record.Success)
+	}()
 
 	// 1. 检查是否需要停止交易
 	// 注意：stopUntil 只在本次运行期间有效，重启后应该重置
 	// 使用 IsZero() 检查是否为未设置状态（重启后的情况）
-	if !at.stopUntil.IsZero() && time.Now().Before(at.stopUntil) {
-		remaining := at.stopUntil.Sub(time.Now())
+	at.riskMu.RLock()
+	stopUntil := at.stopUntil
+	at.riskMu.RUnlock()
+	if !stopUntil.IsZero() && time.Now().Before(stopUntil) {
+		remaining := stopUntil.Sub(time.Now())
 		log.Printf("⏸ 风险控制：暂停交易中，剩余 %.0f 分钟", remaining.Minutes())
 		
 		// 尝试获取账户状态（即使暂停交易也要显示账户信息）
@@ -321,7 +664,9 @@ func (at *AutoTrader) runCycle() error {
 	}
 
 	// 2. 检查日盈亏重置（在构建上下文之前，避免构建失败时无法重置）
+	at.riskMu.RLock()
 	needResetDailyPnL := time.Since(at.lastResetTime) > 24*time.Hour
+	at.riskMu.RUnlock()
 	
 	// 2.5. 收集交易上下文（先获取持仓数据用于强制止损检查）
 	ctx, err := at.buildTradingContext()
@@ -336,8 +681,9 @@ func (at *AutoTrader) runCycle() error {
 			at.dailyStartEquity = at.initialBalance
 			at.dailyPnL = 0
 			at.peakEquity = at.initialBalance
-			at.riskMu.Unlock()
 			at.lastResetTime = time.Now()
+			at.riskMu.Unlock()
+			at.saveRiskState()
 			log.Printf("📅 日盈亏已重置（构建上下文失败，使用初始余额作为fallback）: %.2f USDT", at.initialBalance)
 		}
 		
@@ -373,8 +719,9 @@ func (at *AutoTrader) runCycle() error {
 		}
 		peakEquitySnapshot := at.peakEquity
 		dailyStartEquitySnapshot := at.dailyStartEquity
-		at.riskMu.Unlock()
 		at.lastResetTime = time.Now()
+		at.riskMu.Unlock()
+		at.saveRiskState()
 		log.Printf("📅 日盈亏已重置，今日开盘净值: %.2f USDT (峰值净值: %.2f USDT)", 
 			dailyStartEquitySnapshot, peakEquitySnapshot)
 	}
@@ -412,6 +759,9 @@ func (at *AutoTrader) runCycle() error {
 		// 不影响主流程，继续执行AI决策
 	}
 
+	// 4.1 检查持仓币种是否停牌/下架（只有该币种的行情数据连续获取失败），达到阈值后告警，halted_symbol_action为close时按交易所标记价格平仓
+	forcedActions = append(forcedActions, at.checkHaltedSymbols(ctx)...)
+
 	// 记录强制平仓的操作
 	for _, action := range forcedActions {
 		record.Decisions = append(record.Decisions, action)
@@ -470,21 +820,16 @@ func (at *AutoTrader) runCycle() error {
 				currentPositionKeys := make(map[string]bool)
 				
 				for _, pos := range positions {
-				symbol := pos["symbol"].(string)
-				side := pos["side"].(string)
-				entryPrice := pos["entryPrice"].(float64)
-				markPrice := pos["markPrice"].(float64)
-				quantity := pos["positionAmt"].(float64)
-				if quantity < 0 {
-					quantity = -quantity
+				p, parseErr := parsePosition(pos)
+				if parseErr != nil {
+					log.Printf("⚠️  跳过格式异常的持仓: %v", parseErr)
+					continue
 				}
-				unrealizedPnl := pos["unRealizedProfit"].(float64)
-				liquidationPrice := pos["liquidationPrice"].(float64)
+				symbol, side := p.Symbol, p.Side
+				entryPrice, markPrice, quantity := p.EntryPrice, p.MarkPrice, p.Quantity
+				unrealizedPnl, liquidationPrice := p.UnrealizedPnl, p.LiquidationPrice
 				
-				leverage := 10
-				if lev, ok := pos["leverage"].(float64); ok {
-					leverage = int(lev)
-				}
+				leverage := p.Leverage
 				marginUsed := (quantity * markPrice) / float64(leverage)
 				totalMarginUsed += marginUsed
 				
@@ -547,7 +892,26 @@ func (at *AutoTrader) runCycle() error {
 			ctx.Account.MarginUsed = totalMarginUsed
 			ctx.Account.MarginUsedPct = marginUsedPct
 			
-			// 检测并处理已平仓的持仓（包括手动平仓），记录到交易历史
+			// 更新净敞口和总敞口
+			netExposure, grossExposure := calculateExposure(positionInfos)
+			ctx.Account.NetExposureUSD = netExposure
+			ctx.Account.GrossExposure = grossExposure
+			ctx.Account.NetExposurePct = 0
+			ctx.Account.GrossExposurePct = 0
+			if ctx.Account.TotalEquity > 0 {
+				ctx.Account.NetExposurePct = (netExposure / ctx.Account.TotalEquity) * 100
+				ctx.Account.GrossExposurePct = (grossExposure / ctx.Account.TotalEquity) * 100
+			}
+			
+			// 更新持仓集中度
+			notionals := make(map[string]float64)
+			for _, pos := range positionInfos {
+				notionals[pos.Symbol+"_"+pos.Side] = pos.Quantity * pos.MarkPrice
+			}
+			ctx.Account.ConcentrationHHI, ctx.Account.LargestPositionPct, ctx.Account.LargestPosition = calculateConcentration(notionals)
+			
+			// 检测并处理已平仓的持仓（包括手动平仓），记录到交易历史；被交易所强平的先按强平处理（不再记为手动平仓）
+			at.detectLiquidations(currentPositionKeys)
 			at.positionTimeMu.Lock()
 			var closedPositions []string
 			for key := range at.positionFirstSeenTime {
@@ -707,6 +1071,7 @@ func (at *AutoTrader) runCycle() error {
 							WasStopLoss:    trade.WasStopLoss,
 							Success:        trade.Success,
 							Error:          trade.Error,
+							Strategy:       trade.Strategy,
 						}
 						
 						if err := tradeStorage.LogTrade(dbTrade); err != nil {
@@ -728,6 +1093,7 @@ func (at *AutoTrader) runCycle() error {
 						log.Printf("⚠️  删除持仓逻辑失败 %s: %v", posKey, err)
 					}
 				}
+				at.recordPositionClose(symbol, side)
 			}
 			}
 		} else {
@@ -767,13 +1133,14 @@ func (at *AutoTrader) runCycle() error {
 		ctx.Account.TotalEquity, ctx.Account.AvailableBalance, ctx.Account.PositionCount)
 
 	// 4. 调用AI获取完整决策
-	log.Println("🤖 正在请求AI分析并决策...")
-	decision, err := decision.GetFullDecision(ctx, at.mcpClient)
+	decision, err := at.requestDecision(ctx)
+	at.setCycleClosePrices(ctx.HourlyClosePrices)
 
 	// 即使有错误，也保存思维链、决策和输入prompt（用于debug）
 	if decision != nil {
 		record.InputPrompt = decision.UserPrompt
 		record.CoTTrace = decision.CoTTrace
+		record.ModelTraces = decision.ModelTraces
 		if len(decision.Decisions) > 0 {
 			decisionJSON, _ := json.MarshalIndent(decision.Decisions, "", "  ")
 			record.DecisionJSON = string(decisionJSON)
@@ -786,22 +1153,22 @@ func (at *AutoTrader) runCycle() error {
 
 		// 打印AI思维链（即使有错误）
 		if decision != nil && decision.CoTTrace != "" {
-			log.Printf("\n" + strings.Repeat("-", 70))
+			log.Print("\n" + strings.Repeat("-", 70))
 			log.Println("💭 AI思维链分析（错误情况）:")
 			log.Println(strings.Repeat("-", 70))
 			log.Println(decision.CoTTrace)
-			log.Printf(strings.Repeat("-", 70) + "\n")
+			log.Print(strings.Repeat("-", 70) + "\n")
 		}
 
 		return fmt.Errorf("获取AI决策失败: %w", err)
 	}
 
 	// 5. 打印AI思维链
-	log.Printf("\n" + strings.Repeat("-", 70))
+	log.Print("\n" + strings.Repeat("-", 70))
 	log.Println("💭 AI思维链分析:")
 	log.Println(strings.Repeat("-", 70))
 	log.Println(decision.CoTTrace)
-	log.Printf(strings.Repeat("-", 70) + "\n")
+	log.Print(strings.Repeat("-", 70) + "\n")
 
 	// 6. 打印AI决策
 	log.Printf("📋 AI决策列表 (%d 个):\n", len(decision.Decisions))
@@ -814,6 +1181,20 @@ func (at *AutoTrader) runCycle() error {
 	}
 	log.Println()
 
+	// 6.5. 记录宽松验证模式下被丢弃的无效决策（其余有效决策照常执行）
+	for _, dropped := range decision.DroppedDecisions {
+		log.Printf("🚫 %s %s 验证失败已丢弃: %s", dropped.Decision.Symbol, dropped.Decision.Action, dropped.Defect)
+		record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("🚫 %s %s 验证失败已丢弃: %s", dropped.Decision.Symbol, dropped.Decision.Action, dropped.Defect))
+		record.Decisions = append(record.Decisions, logger.DecisionAction{
+			Action:    dropped.Decision.Action,
+			Symbol:    dropped.Decision.Symbol,
+			Leverage:  dropped.Decision.Leverage,
+			Timestamp: time.Now(),
+			Success:   false,
+			Error:     "INVALID: " + dropped.Defect,
+		})
+	}
+
 	// 7. 对决策排序：确保先平仓后开仓（防止仓位叠加超限）
 	sortedDecisions := sortDecisionsByPriority(decision.Decisions)
 
@@ -865,6 +1246,16 @@ func (at *AutoTrader) runCycle() error {
 			ForcedReason: "",
 		}
 
+		// 开仓确认：新提出的开仓需在下一周期再次提出才执行（平仓和止损止盈更新立即执行）
+		if (d.Action == "open_long" || d.Action == "open_short") && !at.confirmOpenProposal(d.Symbol, d.Action, cycleNum) {
+			actionRecord.Success = true
+			actionRecord.Error = "SKIPPED: 等待下一周期确认开仓"
+			log.Printf("⏳ %s %s 为新提议，等待下一周期确认后再执行", d.Symbol, d.Action)
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏭️  %s %s 已跳过：等待下一周期确认开仓", d.Symbol, d.Action))
+			record.Decisions = append(record.Decisions, actionRecord)
+			continue
+		}
+
 		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
 			log.Printf("❌ 执行决策失败 (%s %s): %v", d.Symbol, d.Action, err)
 			actionRecord.Error = err.Error()
@@ -883,6 +1274,9 @@ func (at *AutoTrader) runCycle() error {
 			if actionRecord.Error != "" && strings.HasPrefix(actionRecord.Error, "SKIPPED:") {
 				skipMsg := strings.TrimPrefix(actionRecord.Error, "SKIPPED: ")
 				record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏭️  %s %s 已跳过：%s", d.Symbol, d.Action, skipMsg))
+			} else if strings.HasPrefix(actionRecord.Error, pendingLimitPrefix) {
+				pendingMsg := strings.TrimPrefix(actionRecord.Error, pendingLimitPrefix)
+				record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("⏳ %s %s %s", d.Symbol, d.Action, pendingMsg))
 			} else {
 				record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功", d.Symbol, d.Action))
 				// 成功执行后短暂延迟
@@ -893,6 +1287,9 @@ func (at *AutoTrader) runCycle() error {
 		record.Decisions = append(record.Decisions, actionRecord)
 	}
 
+	// 清理本周期未再次提出的开仓提议（确认只在紧邻的下一周期有效）
+	at.expirePendingOpens(cycleNum)
+
 	// 8. 保存决策记录到数据库
 	if at.storageAdapter != nil {
 		decisionStorage := at.storageAdapter.GetDecisionStorage()
@@ -903,6 +1300,10 @@ func (at *AutoTrader) runCycle() error {
 			candidateCoinsJSON, _ := json.Marshal(record.CandidateCoins)
 			decisionsJSON, _ := json.Marshal(record.Decisions)
 			executionLogJSON, _ := json.Marshal(record.ExecutionLog)
+			var modelTracesJSON json.RawMessage
+			if len(record.ModelTraces) > 0 {
+				modelTracesJSON, _ = json.Marshal(record.ModelTraces)
+			}
 
 			dbRecord := &storage.DecisionRecord{
 				Timestamp:      record.Timestamp,
@@ -917,13 +1318,18 @@ func (at *AutoTrader) runCycle() error {
 				ExecutionLog:   executionLogJSON,
 				Success:        record.Success,
 				ErrorMessage:   record.ErrorMessage,
+				ModelTraces:    modelTracesJSON,
 			}
 
-			if err := decisionStorage.LogDecision(at.id, dbRecord); err != nil {
-				log.Printf("⚠️  保存决策记录到数据库失败: %v", err)
+			// 按log_verbosity过滤或精简记录（summary模式下不保存无操作的周期）
+			if at.applyLogVerbosity(dbRecord, record.Decisions) {
+				if err := decisionStorage.LogDecision(at.id, dbRecord); err != nil {
+					log.Printf("⚠️  保存决策记录到数据库失败: %v", err)
+				}
 			}
 		}
 	}
+	at.postDecisionWebhook(record)
 
 	// 9. 记录周期快照（用于自检式review）
 	if err := at.logCycleSnapshot(ctx, decision, record, cycleNum); err != nil {
@@ -984,22 +1390,17 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	currentPositionKeys := make(map[string]bool)
 
 	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity // 空仓数量为负，转为正数
+		p, parseErr := parsePosition(pos)
+		if parseErr != nil {
+			log.Printf("⚠️  跳过格式异常的持仓: %v", parseErr)
+			continue
 		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		liquidationPrice := pos["liquidationPrice"].(float64)
+		symbol, side := p.Symbol, p.Side
+		entryPrice, markPrice, quantity := p.EntryPrice, p.MarkPrice, p.Quantity
+		unrealizedPnl, liquidationPrice := p.UnrealizedPnl, p.LiquidationPrice
 
 		// 计算占用保证金（估算）
-		leverage := 10 // 默认值，实际应该从持仓信息获取
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
-		}
+		leverage := p.Leverage
 		marginUsed := (quantity * markPrice) / float64(leverage)
 		totalMarginUsed += marginUsed
 
@@ -1056,9 +1457,9 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 			if marketData, err := market.Get(symbol); err == nil {
 				// 构建完整的上下文，确保逻辑检查有足够的数据
 				ctx := &decision.Context{
-					MultiTimeframeConfig: at.config.MultiTimeframeConfig,
+					MultiTimeframeConfig: at.getConfig().MultiTimeframeConfig,
 					MarketDataMap:        make(map[string]*market.Data),
-					StrategyName:         at.config.StrategyName,
+					StrategyName:         at.getConfig().StrategyName,
 				}
 				// 将市场数据放入上下文，以便逻辑检查可以访问
 				ctx.MarketDataMap[symbol] = marketData
@@ -1097,6 +1498,9 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		if logic != nil {
 			positionInfo.EntryLogic = logic.EntryLogic
 			positionInfo.ExitLogic = logic.ExitLogic
+			positionInfo.TrailingDistancePct = logic.TrailingDistancePct
+			positionInfo.TrailingBestPrice = logic.TrailingBestPrice
+			positionInfo.TakeProfitLevels = logic.TakeProfitLevels
 		}
 		positionInfo.LogicInvalid = logicInvalid
 		positionInfo.InvalidReasons = invalidReasons
@@ -1104,7 +1508,8 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		positionInfos = append(positionInfos, positionInfo)
 	}
 
-	// 清理已平仓的持仓记录（包括时间和止损/止盈价格）
+	// 清理已平仓的持仓记录（包括时间和止损/止盈价格），清理前先确认是否被交易所强平
+	at.detectLiquidations(currentPositionKeys)
 	at.positionTimeMu.Lock()
 	for key := range at.positionFirstSeenTime {
 		if !currentPositionKeys[key] {
@@ -1119,7 +1524,10 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// 3. 获取候选币种池
 	// 无论有没有持仓，都分析相同数量的币种（让AI看到所有好机会）
 	// AI会根据保证金使用率和现有持仓情况，自己决定是否要换仓
-	const coinLimit = 20 // 取前20个评分最高的币种
+	coinLimit := at.getConfig().CandidatePoolSize // 取前N个评分最高的币种
+	if coinLimit <= 0 {
+		coinLimit = DefaultCandidatePoolSize
+	}
 
 	// 获取币种池
 	mergedPool, err := pool.GetMergedCoinPool(coinLimit)
@@ -1137,6 +1545,11 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		})
 	}
 
+	candidateCoins = at.filterCandidateCoins(candidateCoins)
+
+	// 资金费率捕获模式：加入资金费率极端且即将结算的币种
+	candidateCoins, fundingOpportunities := at.addFundingCaptureCandidates(candidateCoins)
+
 	log.Printf("📋 候选币种池: 总计%d个候选币种", len(candidateCoins))
 
 	// 4. 计算总盈亏
@@ -1151,6 +1564,21 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		marginUsedPct = (totalMarginUsed / totalEquity) * 100
 	}
 
+	// 计算净敞口和总敞口
+	netExposure, grossExposure := calculateExposure(positionInfos)
+	netExposurePct, grossExposurePct := 0.0, 0.0
+	if totalEquity > 0 {
+		netExposurePct = (netExposure / totalEquity) * 100
+		grossExposurePct = (grossExposure / totalEquity) * 100
+	}
+
+	// 计算持仓集中度
+	notionals := make(map[string]float64)
+	for _, pos := range positionInfos {
+		notionals[pos.Symbol+"_"+pos.Side] = pos.Quantity * pos.MarkPrice
+	}
+	concentrationHHI, largestPositionPct, largestPosition := calculateConcentration(notionals)
+
 	// 5. 分析历史表现（从数据库获取）
 	var performance interface{} = nil
 	if at.storageAdapter != nil {
@@ -1179,12 +1607,15 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	recentForcedCloses := at.getRecentForcedCloses(3) // 最近3个周期的强制平仓记录
 
 	// 6. 构建上下文
+	now := time.Now()
 	ctx := &decision.Context{
-		CurrentTime:     time.Now().Format("2006-01-02 15:04:05"),
+		CurrentTime:     now.Format("2006-01-02 15:04:05"),
+		Now:             now,
 		RuntimeMinutes:  int(time.Since(at.startTime).Minutes()),
 		CallCount:       int(atomic.LoadInt64(&at.callCount)),
-		BTCETHLeverage:  at.config.BTCETHLeverage,  // 使用配置的杠杆倍数
-		AltcoinLeverage: at.config.AltcoinLeverage, // 使用配置的杠杆倍数
+		BTCETHLeverage:  at.getConfig().BTCETHLeverage,  // 使用配置的杠杆倍数
+		AltcoinLeverage: at.getConfig().AltcoinLeverage, // 使用配置的杠杆倍数
+		SymbolLeverageOverrides: at.getConfig().SymbolLeverageOverrides, // 单币种杠杆上限
 		Account: decision.AccountInfo{
 			TotalEquity:      totalEquity,
 			AvailableBalance: availableBalance,
@@ -1193,15 +1624,38 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 			MarginUsed:       totalMarginUsed,
 			MarginUsedPct:    marginUsedPct,
 			PositionCount:    len(positionInfos),
+			NetExposureUSD:   netExposure,
+			NetExposurePct:   netExposurePct,
+			GrossExposure:    grossExposure,
+			GrossExposurePct: grossExposurePct,
+			ConcentrationHHI:   concentrationHHI,
+			LargestPositionPct: largestPositionPct,
+			LargestPosition:    largestPosition,
 		},
 		Positions:      positionInfos,
 		CandidateCoins: candidateCoins,
 		Performance:    performance, // 添加历史表现分析
 		RecentForcedCloses: recentForcedCloses, // 最近的强制平仓记录
-		SkipLiquidityCheck: at.config.SkipLiquidityCheck, // 是否跳过流动性检查
-		AnalysisMode:    at.config.AnalysisMode, // 分析模式
-		MultiTimeframeConfig: at.config.MultiTimeframeConfig, // 多时间框架配置
-		StrategyName:    at.config.StrategyName, // 策略名称
+		SkipLiquidityCheck: at.getConfig().SkipLiquidityCheck, // 是否跳过流动性检查
+		MinOpenInterestValueUSD: at.getConfig().MinOpenInterestValueUSD, // 流动性检查的最低持仓价值
+		AnalysisMode:    at.getConfig().AnalysisMode, // 分析模式
+		MultiTimeframeConfig: at.getConfig().MultiTimeframeConfig, // 多时间框架配置
+		StrategyName:    at.getConfig().StrategyName, // 策略名称
+		IncludeDerivativesTrend: at.getConfig().IncludeDerivativesTrend, // 是否包含OI/资金费率趋势
+		IncludeConcentration:    at.getConfig().IncludeConcentration,    // 是否包含持仓集中度
+		ConcentrationWarnHHI:    at.getConfig().ConcentrationWarnHHI,    // 集中度提示阈值
+		MinKlineBars:            at.getConfig().MinKlineBars,            // 每个时间框架最少K线数量
+		InsufficientHistoryMode: at.getConfig().InsufficientHistoryMode, // K线不足时的处理方式
+		ValidationMode:          at.getConfig().ValidationMode,          // 决策验证模式
+		TraderID:                at.id,                             // 监控指标标签
+		EnsembleMembers:         at.ensembleMembers,                // 多模型投票
+		EnsembleMinAgree:        at.getConfig().EnsembleMinAgree,        // 开平仓最少同意票数
+		RiskPerTradePct:         at.getConfig().RiskPerTradePct,         // 单笔交易风险预算
+		MinRiskReward:           at.getConfig().MinRiskReward,           // 开仓最低风险回报比
+		MinStopATRMultiple:      at.getConfig().MinStopATRMultiple,      // 开仓止损距离的最小ATR倍数
+		MaxPositionNotionalPct:  at.getConfig().MaxPositionNotionalPct,  // 单笔仓位价值上限（占净值百分比）
+		StreamResponses:         at.getConfig().StreamResponses,         // 流式接收AI响应
+		FundingOpportunities:    fundingOpportunities,              // 资金费率捕获机会
 	}
 
 	return ctx, nil
@@ -1257,10 +1711,18 @@ func (at *AutoTrader) findPositionOpenTimeFromLogs(symbol, side string) (int64,
 func (at *AutoTrader) checkAndExecuteForcedStopLoss(ctx *decision.Context) ([]logger.DecisionAction, error) {
 	var forcedActions []logger.DecisionAction
 
-	// 更新峰值净值和日盈亏（使用锁保护）
+	// 更新峰值净值、历史最大回撤和日盈亏（使用锁保护）
 	at.riskMu.Lock()
+	riskStateChanged := false
 	if ctx.Account.TotalEquity > at.peakEquity {
 		at.peakEquity = ctx.Account.TotalEquity
+		riskStateChanged = true
+	}
+	if at.peakEquity > 0 {
+		if drawdown := (at.peakEquity - ctx.Account.TotalEquity) / at.peakEquity * 100; drawdown > at.maxDrawdownPct {
+			at.maxDrawdownPct = drawdown
+			riskStateChanged = true
+		}
 	}
 
 	// 更新日盈亏（每天重置后的累计盈亏）
@@ -1276,22 +1738,33 @@ func (at *AutoTrader) checkAndExecuteForcedStopLoss(ctx *decision.Context) ([]lo
 	currentDailyStartEquity := at.dailyStartEquity
 	at.riskMu.Unlock()
 
+	if riskStateChanged {
+		at.saveRiskState()
+	}
+	metrics.SetAccountState(at.id, ctx.Account.TotalEquity, ctx.Account.PositionCount, ctx.Account.MarginUsedPct, currentDailyPnL)
+
 	// 1. 检查账户级别风控（优先级最高）
 	// 检查最大回撤
-	if at.config.MaxDrawdown > 0 && currentPeakEquity > 0 {
+	if at.getConfig().MaxDrawdown > 0 && currentPeakEquity > 0 {
 		currentDrawdown := ((currentPeakEquity - ctx.Account.TotalEquity) / currentPeakEquity) * 100
-		if currentDrawdown > at.config.MaxDrawdown {
+		if currentDrawdown > at.getConfig().MaxDrawdown {
 			// 计算账户总盈亏百分比（相对初始余额）
 			totalPnLPct := ctx.Account.TotalPnLPct
-			log.Printf("🛑 触发账户回撤风控: 当前回撤%.2f%% > 最大回撤%.2f%%，账户总盈亏%.2f%% (%.2f USDT)，暂停交易%.0f分钟",
-				currentDrawdown, at.config.MaxDrawdown, totalPnLPct, ctx.Account.TotalPnL, at.config.StopTradingTime.Minutes())
+			at.eventLog.Warn(atomic.LoadInt64(&at.callCount), "", "risk_trigger",
+				fmt.Sprintf("🛑 触发账户回撤风控: 当前回撤%.2f%% > 最大回撤%.2f%%，账户总盈亏%.2f%% (%.2f USDT)，暂停交易%.0f分钟",
+					currentDrawdown, at.getConfig().MaxDrawdown, totalPnLPct, ctx.Account.TotalPnL, at.getConfig().StopTradingTime.Minutes()),
+				"reason", "max_drawdown", "drawdown_pct", currentDrawdown, "total_pnl", ctx.Account.TotalPnL)
 			
 			// 设置暂停交易时间
-			at.stopUntil = time.Now().Add(at.config.StopTradingTime)
+			at.riskMu.Lock()
+			at.stopUntil = time.Now().Add(at.getConfig().StopTradingTime)
+			at.riskMu.Unlock()
+			at.notifier.NotifyRiskTrigger("账户回撤风控", fmt.Sprintf("当前回撤%.2f%% > 最大回撤%.2f%%，账户总盈亏%.2f USDT\n暂停交易%.0f分钟并强制平掉所有持仓",
+				currentDrawdown, at.getConfig().MaxDrawdown, ctx.Account.TotalPnL, at.getConfig().StopTradingTime.Minutes()))
 			
 			// 强制平掉所有持仓
 			log.Printf("🛑 回撤风控触发：强制平掉所有持仓")
-			allForced, err := at.forceCloseAllPositions("账户回撤风控", ctx)
+			allForced, err := at.forceCloseAllPositions("账户回撤风控", ForcedCloseTriggerAccountDrawdown, ctx)
 			if err != nil {
 				return forcedActions, fmt.Errorf("强制平掉所有持仓失败: %w", err)
 			}
@@ -1303,20 +1776,26 @@ func (at *AutoTrader) checkAndExecuteForcedStopLoss(ctx *decision.Context) ([]lo
 
 	// 检查最大日亏损
 	// 使用当日开盘净值作为分母，更符合"当日亏损百分比"的定义
-	if at.config.MaxDailyLoss > 0 && currentDailyStartEquity > 0 {
+	if at.getConfig().MaxDailyLoss > 0 && currentDailyStartEquity > 0 {
 		dailyLossPct := (currentDailyPnL / currentDailyStartEquity) * 100
-		if dailyLossPct < -at.config.MaxDailyLoss {
+		if dailyLossPct < -at.getConfig().MaxDailyLoss {
 			// 计算账户总盈亏百分比（相对初始余额）
 			totalPnLPct := ctx.Account.TotalPnLPct
-			log.Printf("🛑 触发账户日亏损风控: 日亏损%.2f%% > 最大日亏损%.2f%%，账户总盈亏%.2f%% (%.2f USDT)，暂停交易%.0f分钟",
-				-dailyLossPct, at.config.MaxDailyLoss, totalPnLPct, ctx.Account.TotalPnL, at.config.StopTradingTime.Minutes())
+			at.eventLog.Warn(atomic.LoadInt64(&at.callCount), "", "risk_trigger",
+				fmt.Sprintf("🛑 触发账户日亏损风控: 日亏损%.2f%% > 最大日亏损%.2f%%，账户总盈亏%.2f%% (%.2f USDT)，暂停交易%.0f分钟",
+					-dailyLossPct, at.getConfig().MaxDailyLoss, totalPnLPct, ctx.Account.TotalPnL, at.getConfig().StopTradingTime.Minutes()),
+				"reason", "max_daily_loss", "daily_loss_pct", -dailyLossPct, "total_pnl", ctx.Account.TotalPnL)
 			
 			// 设置暂停交易时间
-			at.stopUntil = time.Now().Add(at.config.StopTradingTime)
+			at.riskMu.Lock()
+			at.stopUntil = time.Now().Add(at.getConfig().StopTradingTime)
+			at.riskMu.Unlock()
+			at.notifier.NotifyRiskTrigger("账户日亏损风控", fmt.Sprintf("日亏损%.2f%% > 最大日亏损%.2f%%，账户总盈亏%.2f USDT\n暂停交易%.0f分钟并强制平掉所有持仓",
+				-dailyLossPct, at.getConfig().MaxDailyLoss, ctx.Account.TotalPnL, at.getConfig().StopTradingTime.Minutes()))
 			
 			// 强制平掉所有持仓
 			log.Printf("🛑 日亏损风控触发：强制平掉所有持仓")
-			allForced, err := at.forceCloseAllPositions("账户日亏损风控", ctx)
+			allForced, err := at.forceCloseAllPositions("账户日亏损风控", ForcedCloseTriggerDailyLoss, ctx)
 			if err != nil {
 				return forcedActions, fmt.Errorf("强制平掉所有持仓失败: %w", err)
 			}
@@ -1326,6 +1805,12 @@ func (at *AutoTrader) checkAndExecuteForcedStopLoss(ctx *decision.Context) ([]lo
 		}
 	}
 
+	// 检查回撤减仓（软风控：回撤超过de_risk_drawdown但未达到max_drawdown时只平掉表现最差的一半持仓）
+	if currentPeakEquity > 0 {
+		currentDrawdown := ((currentPeakEquity - ctx.Account.TotalEquity) / currentPeakEquity) * 100
+		forcedActions = append(forcedActions, at.checkDeRiskDrawdown(ctx, currentDrawdown)...)
+	}
+
 	// 注意：单仓位止损检查已移至独立的每分钟检查循环（checkPositionStopLossOnly）
 	// 这里只保留账户级别的风控检查
 
@@ -1336,7 +1821,15 @@ func (at *AutoTrader) checkAndExecuteForcedStopLoss(ctx *decision.Context) ([]lo
 	return forcedActions, nil
 }
 
-// checkPositionStopLossOnly 检查单仓位止损和止盈（每10秒执行，不依赖scan_interval_minutes）
+// PositionPnLPct 持仓盈亏百分比（按保证金计算，即价格变化百分比×杠杆），单仓位止损/止盈按此判断
+func PositionPnLPct(side string, entryPrice, markPrice float64, leverage int) float64 {
+	if side == "long" {
+		return ((markPrice - entryPrice) / entryPrice) * float64(leverage) * 100
+	}
+	return ((entryPrice - markPrice) / entryPrice) * float64(leverage) * 100
+}
+
+// checkPositionStopLossOnly 检查单仓位止损和止盈（按止损检查间隔执行，默认10秒，不依赖scan_interval_minutes）
 // 这个函数独立运行，不需要调用AI，专门用于快速响应市场变化（包括插针行情）
 // 如果配置了position_take_profit_pct > 0，也会检查强制止盈
 // 使用市价单全平，确保快速执行
@@ -1346,36 +1839,42 @@ func (at *AutoTrader) checkPositionStopLossOnly() {
 		return
 	}
 
-	// 获取账户信息和持仓信息（用于构建日志记录）
-	balance, err := at.trader.GetBalance()
-	if err != nil {
-		log.Printf("⚠️  单仓位止损检查：获取账户信息失败: %v", err)
-		// 继续执行，即使账户信息获取失败
-	}
-
 	// 获取持仓信息（轻量级检查，不需要构建完整上下文）
+	// 每次检查固定消耗一次持仓查询的API权重，有持仓时再加一次余额查询，间隔越短消耗越多
 	positions, err := at.trader.GetPositions()
 	if err != nil {
 		log.Printf("⚠️  单仓位止损检查：获取持仓失败: %v", err)
 		return
 	}
 
-	// 如果没有任何持仓，直接返回
+	// 如果没有任何持仓，直接返回（不再查询余额）
 	if len(positions) == 0 {
+		at.pruneTrailingStops(nil)
+		at.detectLiquidations(nil)
 		return
 	}
 
+	// 获取账户信息（用于构建日志记录）
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		log.Printf("⚠️  单仓位止损检查：获取账户信息失败: %v", err)
+		// 继续执行，即使账户信息获取失败
+	}
+
 	// 构建当前持仓的key集合（用于后续记录）
 	currentPositionKeys := make(map[string]bool)
 	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
+		symbol, _ := getString(pos, "symbol")
+		side, _ := getString(pos, "side")
 		posKey := symbol + "_" + side
 		currentPositionKeys[posKey] = true
 	}
+	at.pruneTrailingStops(currentPositionKeys)
+	// 已消失的持仓：确认是否被交易所强平（强平后及时告警，不等下一个决策周期）
+	at.detectLiquidations(currentPositionKeys)
 
 	// 获取单仓位止损配置
-	positionStopLossPct := at.config.PositionStopLossPct
+	positionStopLossPct := at.getConfig().PositionStopLossPct
 	
 	// 检查是否使用默认值：如果配置为0，可能是未设置或设为0
 	// 需要区分：未设置(0) vs 明确设为0(禁用止损) vs 设为其他值
@@ -1389,40 +1888,56 @@ func (at *AutoTrader) checkPositionStopLossOnly() {
 	var forcedActions []logger.DecisionAction
 	forcedCount := 0
 	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity
+		p, parseErr := parsePosition(pos)
+		if parseErr != nil {
+			log.Printf("⚠️  跳过格式异常的持仓: %v", parseErr)
+			continue
 		}
+		symbol, side := p.Symbol, p.Side
+		entryPrice, markPrice, quantity := p.EntryPrice, p.MarkPrice, p.Quantity
 
 		// 计算盈亏百分比
-		leverage := 10
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
-		}
+		leverage := p.Leverage
 
-		var pnlPct float64
-		if side == "long" {
-			pnlPct = ((markPrice - entryPrice) / entryPrice) * float64(leverage) * 100
-		} else {
-			pnlPct = ((entryPrice - markPrice) / entryPrice) * float64(leverage) * 100
+		pnlPct := PositionPnLPct(side, entryPrice, markPrice, leverage)
+
+		// 检查移动止损（价格创新高/新低时收紧止损，越过止损时市价全平）
+		if breached, trailingStop := at.checkTrailingStop(symbol, side, markPrice, quantity); breached {
+			log.Printf("🛑 [止损检查] 触发移动止损: %s %s 当前价%.4f 越过移动止损价%.4f，市价全平",
+				symbol, side, markPrice, trailingStop)
+
+			action, err := at.forceClosePosition(symbol, side, fmt.Sprintf("触发移动止损（当前价%.4f，移动止损价%.4f）", markPrice, trailingStop), ForcedCloseTriggerTrailingStop)
+			if err != nil {
+				log.Printf("⚠️  强制平仓失败 (%s %s): %v", symbol, side, err)
+				forcedActions = append(forcedActions, action)
+				continue
+			}
+
+			forcedCount++
+			forcedActions = append(forcedActions, action)
+
+			posKey := symbol + "_" + side
+			at.positionTimeMu.Lock()
+			delete(at.positionFirstSeenTime, posKey)
+			at.positionTimeMu.Unlock()
+			at.clearTrailingStop(symbol, side)
+
+			log.Printf("  ✓ 强制平仓成功（移动止损）: %s %s", symbol, side)
+			continue
 		}
 
 		// 检查止损（只检查亏损的持仓）
 		if pnlPct < 0 {
 			lossPct := -pnlPct // 转为正数
 			if lossPct >= positionStopLossPct {
-				log.Printf("🛑 [每10秒检查] 触发单仓位强制止损: %s %s 亏损%.2f%% > %.2f%%，市价全平",
+				log.Printf("🛑 [止损检查] 触发单仓位强制止损: %s %s 亏损%.2f%% > %.2f%%，市价全平",
 					symbol, side, lossPct, positionStopLossPct)
 
 				// 执行强制平仓，记录触发的止损百分比
 				// 格式：触发了X%的止损强制平仓（实际亏损Y%，止损阈值Z%）
 				forcedReason := fmt.Sprintf("触发了%.2f%%的止损强制平仓（实际亏损%.2f%%，止损阈值%.2f%%）", 
 					positionStopLossPct, lossPct, positionStopLossPct)
-				action, err := at.forceClosePosition(symbol, side, forcedReason)
+				action, err := at.forceClosePosition(symbol, side, forcedReason, ForcedCloseTriggerPositionStopLoss)
 				if err != nil {
 					log.Printf("⚠️  强制平仓失败 (%s %s): %v", symbol, side, err)
 					// 失败时也记录到日志中
@@ -1445,16 +1960,41 @@ func (at *AutoTrader) checkPositionStopLossOnly() {
 			}
 		}
 
+		// 检查单仓位最大亏损金额（与百分比止损同时生效，先触发的生效）
+		if maxLossUSD := at.getConfig().PositionMaxLossUSD; maxLossUSD > 0 && p.UnrealizedPnl <= -maxLossUSD {
+			log.Printf("🛑 [止损检查] 触发单仓位最大亏损金额: %s %s 未实现亏损%.2f USDT > %.2f USDT，市价全平",
+				symbol, side, -p.UnrealizedPnl, maxLossUSD)
+
+			forcedReason := fmt.Sprintf("单仓位亏损%.2f USDT达到%.2f USDT最大亏损金额（亏损%.2f%%）", -p.UnrealizedPnl, maxLossUSD, -pnlPct)
+			action, err := at.forceClosePosition(symbol, side, forcedReason, ForcedCloseTriggerPositionMaxLoss)
+			if err != nil {
+				log.Printf("⚠️  强制平仓失败 (%s %s): %v", symbol, side, err)
+				forcedActions = append(forcedActions, action)
+				continue
+			}
+
+			forcedCount++
+			forcedActions = append(forcedActions, action)
+
+			posKey := symbol + "_" + side
+			at.positionTimeMu.Lock()
+			delete(at.positionFirstSeenTime, posKey)
+			at.positionTimeMu.Unlock()
+
+			log.Printf("  ✓ 强制平仓成功: %s %s - 单仓位亏损%.2f USDT", symbol, side, -p.UnrealizedPnl)
+			continue
+		}
+
 		// 检查止盈（如果配置了止盈百分比，且持仓盈利）
-		positionTakeProfitPct := at.config.PositionTakeProfitPct
+		positionTakeProfitPct := at.getConfig().PositionTakeProfitPct
 		if positionTakeProfitPct > 0 && pnlPct > 0 {
 			profitPct := pnlPct // 已经是正数
 			if profitPct >= positionTakeProfitPct {
-				log.Printf("🎯 [每10秒检查] 触发单仓位强制止盈: %s %s 盈利%.2f%% >= %.2f%%，市价全平",
+				log.Printf("🎯 [止损检查] 触发单仓位强制止盈: %s %s 盈利%.2f%% >= %.2f%%，市价全平",
 					symbol, side, profitPct, positionTakeProfitPct)
 
 				// 执行强制平仓（止盈）
-				action, err := at.forceClosePosition(symbol, side, fmt.Sprintf("单仓位盈利%.2f%%达到%.2f%%止盈目标", profitPct, positionTakeProfitPct))
+				action, err := at.forceClosePosition(symbol, side, fmt.Sprintf("单仓位盈利%.2f%%达到%.2f%%止盈目标", profitPct, positionTakeProfitPct), ForcedCloseTriggerPositionTakeProfit)
 				if err != nil {
 					log.Printf("⚠️  强制平仓失败 (%s %s): %v", symbol, side, err)
 					// 失败时也记录到日志中
@@ -1472,6 +2012,38 @@ func (at *AutoTrader) checkPositionStopLossOnly() {
 				at.positionTimeMu.Unlock()
 
 				log.Printf("  ✓ 强制平仓成功（止盈）: %s %s - 单仓位盈利%.2f%%", symbol, side, profitPct)
+				continue
+			}
+		}
+
+		// 检查最长持仓时间（持仓时间从持仓首次出现开始计算）
+		if at.getConfig().MaxHoldingTime > 0 {
+			firstSeen, err := at.findPositionOpenTimeFromLogs(symbol, side)
+			if err != nil {
+				continue // 未记录开仓时间（如刚开仓尚未同步），下次检查再判断
+			}
+			holdingTime := time.Since(time.UnixMilli(firstSeen))
+			if holdingTime >= at.getConfig().MaxHoldingTime {
+				log.Printf("⏰ [止损检查] 超过最长持仓时间: %s %s 已持仓%v >= %v，市价全平",
+					symbol, side, holdingTime.Round(time.Minute), at.getConfig().MaxHoldingTime)
+
+				action, err := at.forceClosePosition(symbol, side, fmt.Sprintf("max holding time exceeded（已持仓%v，上限%v）",
+					holdingTime.Round(time.Minute), at.getConfig().MaxHoldingTime), ForcedCloseTriggerMaxHoldingTime)
+				if err != nil {
+					log.Printf("⚠️  强制平仓失败 (%s %s): %v", symbol, side, err)
+					forcedActions = append(forcedActions, action)
+					continue
+				}
+
+				forcedCount++
+				forcedActions = append(forcedActions, action)
+
+				posKey := symbol + "_" + side
+				at.positionTimeMu.Lock()
+				delete(at.positionFirstSeenTime, posKey)
+				at.positionTimeMu.Unlock()
+
+				log.Printf("  ✓ 强制平仓成功（超过最长持仓时间）: %s %s - 持仓盈亏%.2f%%", symbol, side, pnlPct)
 			}
 		}
 	}
@@ -1497,7 +2069,7 @@ func (at *AutoTrader) checkPositionStopLossOnly() {
 			}
 		}
 		
-		log.Printf("🛑 [每10秒检查] 本周期强制平仓 %d 个持仓（市价全平），当前账户总盈亏: %.2f%% (%.2f USDT)",
+		log.Printf("🛑 [止损检查] 本周期强制平仓 %d 个持仓（市价全平），当前账户总盈亏: %.2f%% (%.2f USDT)",
 			forcedCount, totalPnLPct, totalPnL)
 		
 		// 构建账户状态快照（用于日志记录）
@@ -1529,31 +2101,21 @@ func (at *AutoTrader) checkPositionStopLossOnly() {
 		// 构建持仓快照
 		var positionSnapshots []logger.PositionSnapshot
 		for _, pos := range positions {
-			symbol := pos["symbol"].(string)
-			side := pos["side"].(string)
-			entryPrice := pos["entryPrice"].(float64)
-			markPrice := pos["markPrice"].(float64)
-			quantity := pos["positionAmt"].(float64)
-			if quantity < 0 {
-				quantity = -quantity
-			}
-			unrealizedPnl := pos["unRealizedProfit"].(float64)
-			liquidationPrice := pos["liquidationPrice"].(float64)
-			
-			leverage := 10.0
-			if lev, ok := pos["leverage"].(float64); ok {
-				leverage = lev
+			p, parseErr := parsePosition(pos)
+			if parseErr != nil {
+				log.Printf("⚠️  跳过格式异常的持仓: %v", parseErr)
+				continue
 			}
 
 			positionSnapshots = append(positionSnapshots, logger.PositionSnapshot{
-				Symbol:           symbol,
-				Side:             side,
-				PositionAmt:      quantity,
-				EntryPrice:       entryPrice,
-				MarkPrice:        markPrice,
-				UnrealizedProfit: unrealizedPnl,
-				Leverage:         leverage,
-				LiquidationPrice: liquidationPrice,
+				Symbol:           p.Symbol,
+				Side:             p.Side,
+				PositionAmt:      p.Quantity,
+				EntryPrice:       p.EntryPrice,
+				MarkPrice:        p.MarkPrice,
+				UnrealizedProfit: p.UnrealizedPnl,
+				Leverage:         float64(p.Leverage),
+				LiquidationPrice: p.LiquidationPrice,
 			})
 		}
 
@@ -1580,7 +2142,7 @@ func (at *AutoTrader) checkPositionStopLossOnly() {
 				dbRecord := &storage.DecisionRecord{
 					Timestamp:      time.Now(),
 					CycleNumber:    0, // 止损检查不计算周期
-					InputPrompt:    "[单仓位止损检查] 每10秒执行的止损检查，快速响应插针行情，使用市价全平",
+					InputPrompt:    "[单仓位止损检查] 独立于AI决策周期的定时止损检查，快速响应插针行情，使用市价全平",
 					CoTTrace:       "",
 					DecisionJSON:   "",
 					AccountState:   accountStateJSON,
@@ -1592,11 +2154,27 @@ func (at *AutoTrader) checkPositionStopLossOnly() {
 					ErrorMessage:   "",
 				}
 
-				if err := decisionStorage.LogDecision(at.id, dbRecord); err != nil {
-					log.Printf("⚠️  保存止损检查日志到数据库失败: %v", err)
+				// 与决策周期使用同一log_verbosity规则（止损检查只在有强制平仓时写入，不会被过滤）
+				if at.applyLogVerbosity(dbRecord, forcedActions) {
+					if err := decisionStorage.LogDecision(at.id, dbRecord); err != nil {
+						log.Printf("⚠️  保存止损检查日志到数据库失败: %v", err)
+					}
 				}
 			}
 		}
+
+		if len(forcedActions) > 0 {
+			at.postDecisionWebhook(&logger.DecisionRecord{
+				Timestamp:      time.Now(),
+				InputPrompt:    "[单仓位止损检查] 独立于AI决策周期的定时止损检查，快速响应插针行情，使用市价全平",
+				AccountState:   accountState,
+				Positions:      positionSnapshots,
+				CandidateCoins: []string{},
+				Decisions:      forcedActions,
+				ExecutionLog:   executionLog,
+				Success:        true,
+			})
+		}
 	}
 }
 
@@ -1622,8 +2200,15 @@ func (at *AutoTrader) cleanupClosingLock(posKey string) {
 	delete(at.closingPositions, posKey)
 }
 
+// forceCloseMarketData 强制平仓时获取行情数据（测试中替换以模拟行情接口故障）
+var forceCloseMarketData = market.Get
+
 // forceClosePosition 强制平掉单个持仓（带并发保护）
-func (at *AutoTrader) forceClosePosition(symbol, side, reason string) (logger.DecisionAction, error) {
+// triggerType 为风控触发类型（ForcedCloseTrigger*），平仓成功后随强制平仓记录一起保存
+func (at *AutoTrader) forceClosePosition(symbol, side, reason, triggerType string) (logger.DecisionAction, error) {
+	at.inFlight.Add(1)
+	defer at.inFlight.Done()
+
 	posKey := symbol + "_" + side
 	
 	// 先检查是否已被标记为强制平仓（快速检查，避免不必要的锁定）
@@ -1678,14 +2263,31 @@ func (at *AutoTrader) forceClosePosition(symbol, side, reason string) (logger.De
 		IsForced:     true,
 		ForcedReason: reason,
 	}
+	defer func() {
+		metrics.IncForcedClose(at.id, actionRecord.Success)
+	}()
 
-	// 获取当前价格
-	marketData, err := market.Get(symbol)
+	// 平仓前记录未实现盈亏（用于通知）
+	pnl := at.positionUnrealizedPnL(symbol, side)
+
+	// 记录平仓前的实际持仓数量：持仓可能已被阶梯止盈或部分平仓减少，交易记录只按剩余数量计算盈亏
+	markPrice := 0.0
+	if pos, posSide, err := at.findPositionBySymbol(symbol); err == nil && posSide == side {
+		if amt, ok := getFloat(pos, "positionAmt"); ok {
+			actionRecord.Quantity = math.Abs(amt)
+		}
+		markPrice, _ = getFloat(pos, "markPrice")
+	}
+
+	// 获取当前价格（只用于平仓记录，平仓本身按持仓数量下单，不依赖行情数据）
+	// 行情数据获取失败（行情接口故障、币种停牌/下架）时改用交易所持仓的标记价格（没有时为0），不阻塞紧急平仓
+	marketData, err := forceCloseMarketData(symbol)
 	if err != nil {
-		actionRecord.Error = fmt.Sprintf("获取市场数据失败: %v", err)
-		return actionRecord, err
+		log.Printf("⚠️  获取 %s 市场数据失败: %v，使用持仓标记价格 %.4f 记录平仓价格", symbol, err, markPrice)
+		actionRecord.Price = markPrice
+	} else {
+		actionRecord.Price = marketData.CurrentPrice
 	}
-	actionRecord.Price = marketData.CurrentPrice
 
 	// 根据方向执行平仓
 	var order map[string]interface{}
@@ -1708,6 +2310,7 @@ func (at *AutoTrader) forceClosePosition(symbol, side, reason string) (logger.De
 		log.Printf("🚨 [严重告警] 强制平仓失败 (%s %s): %v", symbol, side, err)
 		log.Printf("🚨 [严重告警] 失败标记已设置（%.0f分钟后可重试），但建议立即手动检查持仓状态", PositionStopLossRetryTimeout.Minutes())
 		log.Printf("🚨 [严重告警] 如果持仓仍存在且亏损继续扩大，请立即手动平仓以避免更大损失")
+		at.notifier.NotifyForcedClose(symbol, side, fmt.Sprintf("%s（🚨 平仓失败: %v，请立即手动检查持仓）", reason, err), pnl)
 		
 		return actionRecord, err
 	}
@@ -1717,14 +2320,33 @@ func (at *AutoTrader) forceClosePosition(symbol, side, reason string) (logger.De
 	}
 
 	actionRecord.Success = true
+
+	// 平仓前既没有行情数据也没有标记价格时，用交易所的成交价补全平仓价格（避免交易记录按0价格计算盈亏）
+	if actionRecord.Price == 0 {
+		closePrice, err := at.getLatestClosePrice(symbol, side)
+		switch {
+		case err != nil:
+			log.Printf("⚠️  无法获取 %s %s 的平仓成交价格，交易记录的平仓价格为0: %v", symbol, side, err)
+		case closePrice <= 0:
+			log.Printf("⚠️  交易所没有 %s %s 的平仓成交记录，交易记录的平仓价格为0", symbol, side)
+		default:
+			actionRecord.Price = closePrice
+		}
+	}
 	
 	// 标记为已强制平仓（在锁保护下，确保原子性）
 	at.forcedCloseMu.Lock()
 	at.forcedClosedPositions[posKey] = time.Now()
 	at.forcedCloseMu.Unlock()
 	
-	log.Printf("  ✓ 强制平仓成功: %s %s - %s", symbol, side, reason)
+	at.eventLog.Warn(atomic.LoadInt64(&at.callCount), symbol, "forced_close_"+side,
+		fmt.Sprintf("  ✓ 强制平仓成功: %s %s - %s", symbol, side, reason),
+		"reason", reason, "trigger_type", triggerType, "quantity", actionRecord.Quantity, "pnl", pnl)
+	at.notifier.NotifyForcedClose(symbol, side, reason, pnl)
+	at.recordForcedClose(symbol, side, reason, triggerType, pnl)
 	
+	at.recordPositionClose(symbol, side)
+
 	// 清理持仓逻辑（强制平仓后应删除逻辑）
 	if err := at.positionLogicManager.DeleteLogic(symbol, side); err != nil {
 		log.Printf("  ⚠️  清理持仓逻辑失败: %v", err)
@@ -1739,16 +2361,31 @@ func (at *AutoTrader) forceClosePosition(symbol, side, reason string) (logger.De
 }
 
 // forceCloseAllPositions 强制平掉所有持仓
-func (at *AutoTrader) forceCloseAllPositions(reason string, ctx *decision.Context) ([]logger.DecisionAction, error) {
+func (at *AutoTrader) forceCloseAllPositions(reason, triggerType string, ctx *decision.Context) ([]logger.DecisionAction, error) {
+	actions, _ := at.forceCloseAllPositionsWithResults(reason, triggerType, ctx)
+	return actions, nil
+}
+
+// forceCloseAllPositionsWithResults 强制平掉所有持仓，并返回每个持仓的平仓结果（包括失败的）
+func (at *AutoTrader) forceCloseAllPositionsWithResults(reason, triggerType string, ctx *decision.Context) ([]logger.DecisionAction, []FlattenResult) {
 	var actions []logger.DecisionAction
+	results := make([]FlattenResult, 0, len(ctx.Positions))
 
 	for _, pos := range ctx.Positions {
-		action, err := at.forceClosePosition(pos.Symbol, pos.Side, reason)
+		action, err := at.forceClosePosition(pos.Symbol, pos.Side, reason, triggerType)
 		if err != nil {
 			log.Printf("⚠️  强制平仓失败 (%s %s): %v", pos.Symbol, pos.Side, err)
+			results = append(results, FlattenResult{Symbol: pos.Symbol, Side: pos.Side, Error: err.Error()})
 			continue
 		}
 		actions = append(actions, action)
+		results = append(results, FlattenResult{
+			Symbol:  pos.Symbol,
+			Side:    pos.Side,
+			Success: true,
+			OrderID: action.OrderID,
+			Price:   action.Price,
+		})
 		
 		// 记录已强制平仓的持仓
 		posKey := pos.Symbol + "_" + pos.Side
@@ -1757,30 +2394,53 @@ func (at *AutoTrader) forceCloseAllPositions(reason string, ctx *decision.Contex
 		at.forcedCloseMu.Unlock()
 	}
 
-	return actions, nil
+	return actions, results
 }
 
 // executeDecisionWithRecord 执行AI决策并记录详细信息
 func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	var err error
 	switch decision.Action {
 	case "open_long":
-		return at.executeOpenLongWithRecord(decision, actionRecord)
+		err = at.executeOpenLongWithRecord(decision, actionRecord)
 	case "open_short":
-		return at.executeOpenShortWithRecord(decision, actionRecord)
+		err = at.executeOpenShortWithRecord(decision, actionRecord)
 	case "close_long":
-		return at.executeCloseLongWithRecord(decision, actionRecord)
+		err = at.executeCloseLongWithRecord(decision, actionRecord)
 	case "close_short":
-		return at.executeCloseShortWithRecord(decision, actionRecord)
+		err = at.executeCloseShortWithRecord(decision, actionRecord)
 	case "update_tp":
 		return at.executeUpdateTakeProfit(decision, actionRecord)
 	case "update_sl":
 		return at.executeUpdateStopLoss(decision, actionRecord)
+	case "update_sl_trailing":
+		return at.executeTrailingStopLoss(decision, actionRecord)
+	case "cancel_orders":
+		return at.executeCancelOrders(decision, actionRecord)
 	case "hold", "wait":
 		// 无需执行，仅记录
 		return nil
 	default:
 		return fmt.Errorf("未知的action: %s", decision.Action)
 	}
+
+	// 开仓/平仓成功后发送通知（被跳过的决策不通知，限价开仓在成交后通知）
+	if err == nil && !strings.HasPrefix(actionRecord.Error, "SKIPPED: ") && !strings.HasPrefix(actionRecord.Error, pendingLimitPrefix) {
+		notified := *actionRecord
+		notified.Success = true
+		at.notifier.NotifyTrade(notified)
+	}
+	return err
+}
+
+// positionUnrealizedPnL 获取持仓的未实现盈亏（获取失败时返回0）
+func (at *AutoTrader) positionUnrealizedPnL(symbol, side string) float64 {
+	pos, posSide, err := at.findPositionBySymbol(symbol)
+	if err != nil || posSide != side {
+		return 0
+	}
+	pnl, _ := getFloat(pos, "unRealizedProfit")
+	return pnl
 }
 
 // executeOpenLongWithRecord 执行开多仓并记录详细信息
@@ -1797,6 +2457,66 @@ func (at *AutoTrader) executeOpenLongWithRecord(dec *decision.Decision, actionRe
 		}
 	}
 
+	// 持仓数量上限：已达上限时跳过开仓（记录为SKIPPED，在决策记录中可见）
+	if err == nil {
+		if skipReason := at.maxOpenPositionsSkipReason(positions); skipReason != "" {
+			log.Printf("  ⏭️  跳过开仓：%s %s", dec.Symbol, skipReason)
+			actionRecord.Error = "SKIPPED: " + skipReason
+			return nil
+		}
+	}
+
+	// 每日开仓次数上限：当天开仓次数达到上限后不再开新仓
+	if skipReason := at.dailyTradeLimitSkipReason(); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 再入场冷却：同一币种刚平仓时跳过开仓（不区分方向）
+	if skipReason := at.reentryCooldownSkipReason(dec.Symbol); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 币种黑白名单：黑名单或不在白名单中的币种不开仓（已有持仓照常管理）
+	if skipReason := at.symbolFilterSkipReason(dec.Symbol); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 单币种连续亏损冷却：该币种最近连续亏损时暂停该币种开仓（其他币种不受影响）
+	if skipReason := at.symbolLossStreakSkipReason(dec.Symbol); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 已有未成交的限价开仓单时不重复开仓
+	if skipReason := at.pendingLimitSkipReason(dec.Symbol, "long"); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 资金费率检查：本方向需支付的资金费率过高时跳过开仓
+	if skipReason := at.fundingRateSkipReason(dec.Symbol, "long"); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 相关性过滤：与已有同方向持仓高度相关时跳过开仓（避免集中押注同一方向）
+	if err == nil {
+		if skipReason := at.correlationSkipReason(dec.Symbol, "long", positions); skipReason != "" {
+			log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+			actionRecord.Error = "SKIPPED: " + skipReason
+			return nil
+		}
+	}
+
 	// 构建交易上下文用于保证金检查
 	ctx, err := at.buildTradingContext()
 	if err != nil {
@@ -1829,8 +2549,19 @@ func (at *AutoTrader) executeOpenLongWithRecord(dec *decision.Decision, actionRe
 		return fmt.Errorf("当前价格无效或为0: %.4f", marketData.CurrentPrice)
 	}
 
-	// 计算数量（使用最新价格）
-	quantity := dec.PositionSizeUSD / marketData.CurrentPrice
+	// 行情新鲜度检查：最新K线过旧时跳过开仓（记录为SKIPPED）
+	if skipReason := at.staleDataSkipReason(marketData, "3m"); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 计算数量（使用最新价格，限价开仓时使用限价）
+	entryPrice := marketData.CurrentPrice
+	if dec.IsLimitOrder() {
+		entryPrice = dec.LimitPrice
+	}
+	quantity := dec.PositionSizeUSD / entryPrice
 	
 	// 立即格式化数量到正确精度（避免精度损失）
 	formattedQuantityStr, err := at.trader.FormatQuantity(dec.Symbol, quantity)
@@ -1842,149 +2573,110 @@ func (at *AutoTrader) executeOpenLongWithRecord(dec *decision.Decision, actionRe
 		return fmt.Errorf("解析格式化后的数量失败: %w", err)
 	}
 	
-	// 检查最小数量（使用格式化后的数量）
-	minQuantity := MinPositionSizeUSD / marketData.CurrentPrice
-	if formattedQuantity < minQuantity {
-		return fmt.Errorf("计算出的数量过小(%.8f)，小于最小要求(%.8f)。可能因为仓位大小过小或价格过高", formattedQuantity, minQuantity)
+	// 检查交易所的最小下单数量和金额（使用格式化后的数量）
+	if err := at.checkSymbolMinimums(dec.Symbol, formattedQuantity, entryPrice); err != nil {
+		return err
 	}
 
 	actionRecord.Quantity = formattedQuantity
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = entryPrice
 
-	// 开仓（使用格式化后的数量）
-	order, err := at.trader.OpenLong(dec.Symbol, actionRecord.Quantity, dec.Leverage)
+	// 限价开仓：挂单后由每10秒的检查确认成交，成交后再设置止损止盈和保存逻辑
+	if dec.IsLimitOrder() {
+		return at.placeLimitOpen(dec, "long", actionRecord, marketData)
+	}
+
+	// 开仓并验证持仓确实出现（使用格式化后的数量，原订单结束后仍不足时补单一次，部分成交按实际数量处理）
+	order, filledQuantity, err := at.openAndVerify(dec.Symbol, "long", actionRecord.Quantity, dec.Leverage)
 	if err != nil {
 		return err
 	}
+	actionRecord.Quantity = filledQuantity
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], actionRecord.Quantity)
+	at.eventLog.Info(atomic.LoadInt64(&at.callCount), dec.Symbol, "open_long",
+		fmt.Sprintf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], actionRecord.Quantity),
+		"order_id", actionRecord.OrderID, "quantity", actionRecord.Quantity, "price", actionRecord.Price, "leverage", dec.Leverage)
 
-	// 记录开仓时间
-	posKey := dec.Symbol + "_long"
-	firstSeenTime := time.Now().UnixMilli()
-	at.positionTimeMu.Lock()
-	at.positionFirstSeenTime[posKey] = firstSeenTime
-	at.positionTimeMu.Unlock()
-	// 保存到数据库
-	if at.positionLogicManager != nil {
-		if err := at.positionLogicManager.SaveFirstSeenTime(dec.Symbol, "long", firstSeenTime); err != nil {
-			log.Printf("⚠️  保存持仓首次出现时间失败: %v", err)
-		}
-	}
+	at.finishOpenPosition(dec, "long", actionRecord, marketData)
+	return nil
+}
 
-	// 设置止损止盈并保存到PositionLogicManager（与逻辑一起持久化）
-	if dec.StopLoss > 0 || dec.TakeProfit > 0 {
-		// 先保存到PositionLogicManager（无论设置是否成功，都保存AI决策中的价格）
-		if err := at.positionLogicManager.SaveStopLossAndTakeProfit(dec.Symbol, "long", dec.StopLoss, dec.TakeProfit); err != nil {
-			log.Printf("  ⚠ 保存止损/止盈价格失败: %v", err)
-		} else {
-			log.Printf("  ✓ 已保存止损/止盈价格到逻辑管理器: 止损=%.4f, 止盈=%.4f", dec.StopLoss, dec.TakeProfit)
-		}
-		
-		// 然后设置到交易所（如果失败不影响已保存的价格）
-		if dec.StopLoss > 0 {
-			if err := at.trader.SetStopLoss(dec.Symbol, "LONG", quantity, dec.StopLoss); err != nil {
-				log.Printf("  ⚠ 设置止损失败: %v (价格已保存到逻辑管理器)", err)
-			} else {
-				log.Printf("  ✓ 止损设置成功: %.4f", dec.StopLoss)
-			}
-		}
-		if dec.TakeProfit > 0 {
-			if err := at.trader.SetTakeProfit(dec.Symbol, "LONG", quantity, dec.TakeProfit); err != nil {
-				log.Printf("  ⚠ 设置止盈失败: %v (价格已保存到逻辑管理器)", err)
-			} else {
-				log.Printf("  ✓ 止盈设置成功: %.4f", dec.TakeProfit)
+// executeOpenShortWithRecord 执行开空仓并记录详细信息
+func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionRecord *logger.DecisionAction) error {
+	log.Printf("  📉 开空仓: %s", dec.Symbol)
+
+	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
+	positions, err := at.trader.GetPositions()
+	if err == nil {
+		for _, pos := range positions {
+			if pos["symbol"] == dec.Symbol && pos["side"] == "short" {
+				return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", dec.Symbol)
 			}
 		}
 	}
 
-	// 保存进场逻辑和出场逻辑（复用已获取的市场数据）
-	var entryLogicText, exitLogicText string
-	if dec.Reasoning != "" {
-		// 构建简化的上下文（只包含必要的市场数据）
-		ctx := &decision.Context{
-			MultiTimeframeConfig: at.config.MultiTimeframeConfig,
-			MarketDataMap:        make(map[string]*market.Data),
-		}
-		// 复用前面已获取的市场数据，避免重复API调用
-		ctx.MarketDataMap[dec.Symbol] = marketData
-		
-		// 保存进场逻辑
-		entryLogic := decision.ExtractEntryLogicFromReasoning(dec.Reasoning, ctx, dec.Symbol)
-		entryLogicText = entryLogic.Reasoning
-		if err := at.positionLogicManager.SaveEntryLogic(dec.Symbol, "long", entryLogic); err != nil {
-			log.Printf("  ⚠ 保存进场逻辑失败: %v", err)
-		} else {
-			log.Printf("  ✓ 已保存进场逻辑")
-		}
-		
-		// 保存出场逻辑（如果提供）
-		if dec.ExitReasoning != "" {
-			exitLogic := decision.ExtractExitLogicFromReasoning(dec.ExitReasoning, ctx, dec.Symbol)
-			exitLogicText = exitLogic.Reasoning
-			if err := at.positionLogicManager.SaveExitLogic(dec.Symbol, "long", exitLogic); err != nil {
-				log.Printf("  ⚠ 保存出场逻辑失败: %v", err)
-			} else {
-				log.Printf("  ✓ 已保存出场逻辑")
-			}
-		} else {
-			log.Printf("  ⚠ 警告：开仓时未提供出场逻辑（exit_reasoning），建议在开仓时规划好出场逻辑")
+	// 持仓数量上限：已达上限时跳过开仓（记录为SKIPPED，在决策记录中可见）
+	if err == nil {
+		if skipReason := at.maxOpenPositionsSkipReason(positions); skipReason != "" {
+			log.Printf("  ⏭️  跳过开仓：%s %s", dec.Symbol, skipReason)
+			actionRecord.Error = "SKIPPED: " + skipReason
+			return nil
 		}
 	}
 
-	// 创建交易记录（建仓时创建，后续操作更新）
-	if at.storageAdapter != nil {
-		tradeStorage := at.storageAdapter.GetTradeStorage()
-		if tradeStorage != nil {
-			openTime := actionRecord.Timestamp
-			tradeID := fmt.Sprintf("%s_%s_%d", dec.Symbol, "long", openTime.Unix())
-			positionValue := actionRecord.Quantity * actionRecord.Price
-			marginUsed := positionValue / float64(actionRecord.Leverage)
+	// 每日开仓次数上限：当天开仓次数达到上限后不再开新仓
+	if skipReason := at.dailyTradeLimitSkipReason(); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
 
-			dbTrade := &storage.TradeRecord{
-				TradeID:       tradeID,
-				Symbol:        dec.Symbol,
-				Side:          "long",
-				OpenTime:      openTime,
-				OpenPrice:     actionRecord.Price,
-				OpenQuantity: actionRecord.Quantity,
-				OpenLeverage:  actionRecord.Leverage,
-			OpenOrderID:   actionRecord.OrderID,
-			OpenReason:    dec.Reasoning,
-			OpenCycleNum:  int(atomic.LoadInt64(&at.callCount)),
-			PositionValue: positionValue,
-			MarginUsed:    marginUsed,
-			EntryLogic:    entryLogicText,
-			ExitLogic:     exitLogicText,
-		}
+	// 再入场冷却：同一币种刚平仓时跳过开仓（不区分方向）
+	if skipReason := at.reentryCooldownSkipReason(dec.Symbol); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
 
-		if err := tradeStorage.CreateTrade(dbTrade); err != nil {
-			log.Printf("  ⚠ 创建交易记录失败: %v", err)
-		} else {
-			log.Printf("  ✓ 已创建交易记录")
-		}
+	// 币种黑白名单：黑名单或不在白名单中的币种不开仓（已有持仓照常管理）
+	if skipReason := at.symbolFilterSkipReason(dec.Symbol); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
 	}
-}
 
-return nil
-}
+	// 单币种连续亏损冷却：该币种最近连续亏损时暂停该币种开仓（其他币种不受影响）
+	if skipReason := at.symbolLossStreakSkipReason(dec.Symbol); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
 
-// executeOpenShortWithRecord 执行开空仓并记录详细信息
-func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionRecord *logger.DecisionAction) error {
-	log.Printf("  📉 开空仓: %s", dec.Symbol)
+	// 已有未成交的限价开仓单时不重复开仓
+	if skipReason := at.pendingLimitSkipReason(dec.Symbol, "short"); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
 
-	// ⚠️ 关键：检查是否已有同币种同方向持仓，如果有则拒绝开仓（防止仓位叠加超限）
-	positions, err := at.trader.GetPositions()
+	// 资金费率检查：本方向需支付的资金费率过高时跳过开仓
+	if skipReason := at.fundingRateSkipReason(dec.Symbol, "short"); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 相关性过滤：与已有同方向持仓高度相关时跳过开仓（避免集中押注同一方向）
 	if err == nil {
-		for _, pos := range positions {
-			if pos["symbol"] == dec.Symbol && pos["side"] == "short" {
-				return fmt.Errorf("❌ %s 已有空仓，拒绝开仓以防止仓位叠加超限。如需换仓，请先给出 close_short 决策", dec.Symbol)
-			}
+		if skipReason := at.correlationSkipReason(dec.Symbol, "short", positions); skipReason != "" {
+			log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+			actionRecord.Error = "SKIPPED: " + skipReason
+			return nil
 		}
 	}
 
@@ -2020,8 +2712,19 @@ func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionR
 		return fmt.Errorf("当前价格无效或为0: %.4f", marketData.CurrentPrice)
 	}
 
-	// 计算数量（使用最新价格）
-	quantity := dec.PositionSizeUSD / marketData.CurrentPrice
+	// 行情新鲜度检查：最新K线过旧时跳过开仓（记录为SKIPPED）
+	if skipReason := at.staleDataSkipReason(marketData, "3m"); skipReason != "" {
+		log.Printf("  ⏭️  跳过开仓：%s", skipReason)
+		actionRecord.Error = "SKIPPED: " + skipReason
+		return nil
+	}
+
+	// 计算数量（使用最新价格，限价开仓时使用限价）
+	entryPrice := marketData.CurrentPrice
+	if dec.IsLimitOrder() {
+		entryPrice = dec.LimitPrice
+	}
+	quantity := dec.PositionSizeUSD / entryPrice
 	
 	// 立即格式化数量到正确精度（避免精度损失）
 	formattedQuantityStr, err := at.trader.FormatQuantity(dec.Symbol, quantity)
@@ -2033,64 +2736,76 @@ func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionR
 		return fmt.Errorf("解析格式化后的数量失败: %w", err)
 	}
 	
-	// 检查最小数量（使用格式化后的数量）
-	minQuantity := MinPositionSizeUSD / marketData.CurrentPrice
-	if formattedQuantity < minQuantity {
-		return fmt.Errorf("计算出的数量过小(%.8f)，小于最小要求(%.8f)。可能因为仓位大小过小或价格过高", formattedQuantity, minQuantity)
+	// 检查交易所的最小下单数量和金额（使用格式化后的数量）
+	if err := at.checkSymbolMinimums(dec.Symbol, formattedQuantity, entryPrice); err != nil {
+		return err
 	}
 
 	actionRecord.Quantity = formattedQuantity
-	actionRecord.Price = marketData.CurrentPrice
+	actionRecord.Price = entryPrice
+
+	// 限价开仓：挂单后由每10秒的检查确认成交，成交后再设置止损止盈和保存逻辑
+	if dec.IsLimitOrder() {
+		return at.placeLimitOpen(dec, "short", actionRecord, marketData)
+	}
 
-	// 开仓（使用格式化后的数量）
-	order, err := at.trader.OpenShort(dec.Symbol, actionRecord.Quantity, dec.Leverage)
+	// 开仓并验证持仓确实出现（使用格式化后的数量，原订单结束后仍不足时补单一次，部分成交按实际数量处理）
+	order, filledQuantity, err := at.openAndVerify(dec.Symbol, "short", actionRecord.Quantity, dec.Leverage)
 	if err != nil {
 		return err
 	}
+	actionRecord.Quantity = filledQuantity
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
 		actionRecord.OrderID = orderID
 	}
 
-	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], actionRecord.Quantity)
+	at.eventLog.Info(atomic.LoadInt64(&at.callCount), dec.Symbol, "open_short",
+		fmt.Sprintf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], actionRecord.Quantity),
+		"order_id", actionRecord.OrderID, "quantity", actionRecord.Quantity, "price", actionRecord.Price, "leverage", dec.Leverage)
+
+	at.finishOpenPosition(dec, "short", actionRecord, marketData)
+	return nil
+}
 
+// finishOpenPosition 开仓成交后的处理：记录开仓时间、设置止损止盈、保存进场/出场逻辑并创建交易记录
+// 市价开仓成交验证后立即调用；限价开仓在检测到成交（或超时撤单时已部分成交）后调用
+func (at *AutoTrader) finishOpenPosition(dec *decision.Decision, side string, actionRecord *logger.DecisionAction, marketData *market.Data) {
 	// 记录开仓时间
-	posKey := dec.Symbol + "_short"
+	posKey := dec.Symbol + "_" + side
 	firstSeenTime := time.Now().UnixMilli()
 	at.positionTimeMu.Lock()
 	at.positionFirstSeenTime[posKey] = firstSeenTime
 	at.positionTimeMu.Unlock()
 	// 保存到数据库
 	if at.positionLogicManager != nil {
-		if err := at.positionLogicManager.SaveFirstSeenTime(dec.Symbol, "short", firstSeenTime); err != nil {
+		if err := at.positionLogicManager.SaveFirstSeenTime(dec.Symbol, side, firstSeenTime); err != nil {
 			log.Printf("⚠️  保存持仓首次出现时间失败: %v", err)
 		}
 	}
 
+	// 新开仓使用AI给出的固定止损，关闭上一笔持仓遗留的移动止损
+	at.clearTrailingStop(dec.Symbol, side)
+
 	// 设置止损止盈并保存到PositionLogicManager（与逻辑一起持久化）
 	if dec.StopLoss > 0 || dec.TakeProfit > 0 {
 		// 先保存到PositionLogicManager（无论设置是否成功，都保存AI决策中的价格）
-		if err := at.positionLogicManager.SaveStopLossAndTakeProfit(dec.Symbol, "short", dec.StopLoss, dec.TakeProfit); err != nil {
+		if err := at.positionLogicManager.SaveStopLossAndTakeProfit(dec.Symbol, side, dec.StopLoss, dec.TakeProfit); err != nil {
 			log.Printf("  ⚠ 保存止损/止盈价格失败: %v", err)
 		} else {
 			log.Printf("  ✓ 已保存止损/止盈价格到逻辑管理器: 止损=%.4f, 止盈=%.4f", dec.StopLoss, dec.TakeProfit)
 		}
 		
-		// 然后设置到交易所（如果失败不影响已保存的价格）
-		if dec.StopLoss > 0 {
-			if err := at.trader.SetStopLoss(dec.Symbol, "SHORT", quantity, dec.StopLoss); err != nil {
-				log.Printf("  ⚠ 设置止损失败: %v (价格已保存到逻辑管理器)", err)
-			} else {
-				log.Printf("  ✓ 止损设置成功: %.4f", dec.StopLoss)
-			}
-		}
-		if dec.TakeProfit > 0 {
-			if err := at.trader.SetTakeProfit(dec.Symbol, "SHORT", quantity, dec.TakeProfit); err != nil {
-				log.Printf("  ⚠ 设置止盈失败: %v (价格已保存到逻辑管理器)", err)
-			} else {
-				log.Printf("  ✓ 止盈设置成功: %.4f", dec.TakeProfit)
+		// 然后设置到交易所（如果失败不影响已保存的价格），提供了阶梯止盈时按阶梯分档挂止盈单
+		if len(dec.TakeProfitLevels) > 0 {
+			if err := at.executeSetTakeProfitLadder(dec.Symbol, side, actionRecord.Quantity, dec.StopLoss, dec.TakeProfitLevels); err != nil {
+				log.Printf("  ⚠ 设置阶梯止盈失败: %v (价格已保存到逻辑管理器)", err)
 			}
+		} else if err := at.placeProtectionOrders(dec.Symbol, strings.ToUpper(side), actionRecord.Quantity, dec.StopLoss, dec.TakeProfit); err != nil {
+			log.Printf("  ⚠ 设置止损/止盈失败: %v (价格已保存到逻辑管理器)", err)
+		} else {
+			log.Printf("  ✓ 止损/止盈设置成功: 止损=%.4f, 止盈=%.4f", dec.StopLoss, dec.TakeProfit)
 		}
 	}
 
@@ -2098,7 +2813,7 @@ func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionR
 	var entryLogicText, exitLogicText string
 	if dec.Reasoning != "" {
 		ctx := &decision.Context{
-			MultiTimeframeConfig: at.config.MultiTimeframeConfig,
+			MultiTimeframeConfig: at.getConfig().MultiTimeframeConfig,
 			MarketDataMap:        make(map[string]*market.Data),
 		}
 		// 复用前面已获取的市场数据，避免重复API调用
@@ -2107,7 +2822,7 @@ func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionR
 		// 保存进场逻辑
 		entryLogic := decision.ExtractEntryLogicFromReasoning(dec.Reasoning, ctx, dec.Symbol)
 		entryLogicText = entryLogic.Reasoning
-		if err := at.positionLogicManager.SaveEntryLogic(dec.Symbol, "short", entryLogic); err != nil {
+		if err := at.positionLogicManager.SaveEntryLogic(dec.Symbol, side, entryLogic); err != nil {
 			log.Printf("  ⚠ 保存进场逻辑失败: %v", err)
 		} else {
 			log.Printf("  ✓ 已保存进场逻辑")
@@ -2117,7 +2832,7 @@ func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionR
 		if dec.ExitReasoning != "" {
 			exitLogic := decision.ExtractExitLogicFromReasoning(dec.ExitReasoning, ctx, dec.Symbol)
 			exitLogicText = exitLogic.Reasoning
-			if err := at.positionLogicManager.SaveExitLogic(dec.Symbol, "short", exitLogic); err != nil {
+			if err := at.positionLogicManager.SaveExitLogic(dec.Symbol, side, exitLogic); err != nil {
 				log.Printf("  ⚠ 保存出场逻辑失败: %v", err)
 			} else {
 				log.Printf("  ✓ 已保存出场逻辑")
@@ -2132,14 +2847,14 @@ func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionR
 		tradeStorage := at.storageAdapter.GetTradeStorage()
 		if tradeStorage != nil {
 			openTime := actionRecord.Timestamp
-			tradeID := fmt.Sprintf("%s_%s_%d", dec.Symbol, "short", openTime.Unix())
+			tradeID := fmt.Sprintf("%s_%s_%d", dec.Symbol, side, openTime.Unix())
 			positionValue := actionRecord.Quantity * actionRecord.Price
 			marginUsed := positionValue / float64(actionRecord.Leverage)
 
 			dbTrade := &storage.TradeRecord{
 				TradeID:       tradeID,
 				Symbol:        dec.Symbol,
-				Side:          "short",
+				Side:          side,
 				OpenTime:      openTime,
 				OpenPrice:     actionRecord.Price,
 				OpenQuantity: actionRecord.Quantity,
@@ -2151,6 +2866,7 @@ func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionR
 				MarginUsed:    marginUsed,
 				EntryLogic:    entryLogicText,
 				ExitLogic:     exitLogicText,
+				Strategy:      at.getConfig().StrategyName,
 			}
 
 			if err := tradeStorage.CreateTrade(dbTrade); err != nil {
@@ -2160,12 +2876,13 @@ func (at *AutoTrader) executeOpenShortWithRecord(dec *decision.Decision, actionR
 			}
 		}
 	}
-
-	return nil
 }
 
 // executeCloseLongWithRecord 执行平多仓并记录详细信息（带并发保护）
 func (at *AutoTrader) executeCloseLongWithRecord(dec *decision.Decision, actionRecord *logger.DecisionAction) error {
+	at.inFlight.Add(1)
+	defer at.inFlight.Done()
+
 	log.Printf("  🔄 平多仓: %s", dec.Symbol)
 	
 	posKey := dec.Symbol + "_long"
@@ -2200,8 +2917,14 @@ func (at *AutoTrader) executeCloseLongWithRecord(dec *decision.Decision, actionR
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
+	// 计算平仓数量（close_percent部分平仓，0 = 全部平仓）
+	closeQuantity, err := at.resolveCloseQuantity(dec.Symbol, "long", dec.ClosePercent, actionRecord)
+	if err != nil {
+		return err
+	}
+
 	// 平仓
-	order, err := at.trader.CloseLong(dec.Symbol, 0) // 0 = 全部平仓
+	order, err := at.trader.CloseLong(dec.Symbol, closeQuantity)
 	if err != nil {
 		// 平仓失败，保留锁以便重试
 		return err
@@ -2210,15 +2933,17 @@ func (at *AutoTrader) executeCloseLongWithRecord(dec *decision.Decision, actionR
 	// 平仓成功后验证持仓是否真的被平掉（等待一小段时间让订单处理）
 	time.Sleep(500 * time.Millisecond) // 等待500ms让交易所处理订单
 	
+	var remainingQty float64
 	positions, err := at.trader.GetPositions()
 	if err == nil {
 		for _, pos := range positions {
 			if pos["symbol"] == dec.Symbol && pos["side"] == "long" {
-				quantity := pos["positionAmt"].(float64)
+				quantity, _ := getFloat(pos, "positionAmt")
 				if quantity < 0 {
 					quantity = -quantity
 				}
-				if quantity > 0.0001 { // 允许小的精度误差
+				remainingQty = quantity
+				if closeQuantity == 0 && quantity > partialCloseDustQty { // 允许小的精度误差
 					log.Printf("  ⚠️  警告：平仓后持仓仍存在，数量: %.8f", quantity)
 					log.Printf("  ⚠️  订单可能正在处理中，如果5秒后持仓仍存在，请手动检查")
 					// 记录到actionRecord以便后续监控
@@ -2237,6 +2962,17 @@ func (at *AutoTrader) executeCloseLongWithRecord(dec *decision.Decision, actionR
 		actionRecord.OrderID = orderID
 	}
 
+	// 部分平仓：保留持仓逻辑和持仓时间，为剩余持仓重新设置止损止盈
+	if closeQuantity > 0 && remainingQty > partialCloseDustQty {
+		actionRecord.PartialClose = true
+		at.restoreOrdersAfterPartialClose(dec.Symbol, "long", remainingQty)
+		at.recordTradeHistory("long", dec, actionRecord, false, "")
+		at.eventLog.Info(atomic.LoadInt64(&at.callCount), dec.Symbol, "close_long",
+			fmt.Sprintf("  ✓ 部分平仓成功，剩余持仓 %.8f", remainingQty),
+			"order_id", actionRecord.OrderID, "quantity", actionRecord.Quantity, "price", actionRecord.Price, "remaining", remainingQty)
+		return nil
+	}
+
 	// 清理持仓时间记录
 	posKeyForTime := dec.Symbol + "_long"
 	at.positionTimeMu.Lock()
@@ -2244,6 +2980,8 @@ func (at *AutoTrader) executeCloseLongWithRecord(dec *decision.Decision, actionR
 	at.positionTimeMu.Unlock()
 
 	// 删除持仓逻辑（平仓后不再需要，止损/止盈价格会一起删除）
+	at.recordPositionClose(dec.Symbol, "long")
+
 	if err := at.positionLogicManager.DeleteLogic(dec.Symbol, "long"); err != nil {
 		log.Printf("  ⚠ 删除持仓逻辑失败: %v", err)
 	} else {
@@ -2252,12 +2990,16 @@ func (at *AutoTrader) executeCloseLongWithRecord(dec *decision.Decision, actionR
 
 	at.recordTradeHistory("long", dec, actionRecord, false, "")
 
-	log.Printf("  ✓ 平仓成功")
+	at.eventLog.Info(atomic.LoadInt64(&at.callCount), dec.Symbol, "close_long", "  ✓ 平仓成功",
+		"order_id", actionRecord.OrderID, "quantity", actionRecord.Quantity, "price", actionRecord.Price)
 	return nil
 }
 
 // executeCloseShortWithRecord 执行平空仓并记录详细信息（带并发保护）
 func (at *AutoTrader) executeCloseShortWithRecord(dec *decision.Decision, actionRecord *logger.DecisionAction) error {
+	at.inFlight.Add(1)
+	defer at.inFlight.Done()
+
 	log.Printf("  🔄 平空仓: %s", dec.Symbol)
 	
 	posKey := dec.Symbol + "_short"
@@ -2292,8 +3034,14 @@ func (at *AutoTrader) executeCloseShortWithRecord(dec *decision.Decision, action
 	}
 	actionRecord.Price = marketData.CurrentPrice
 
+	// 计算平仓数量（close_percent部分平仓，0 = 全部平仓）
+	closeQuantity, err := at.resolveCloseQuantity(dec.Symbol, "short", dec.ClosePercent, actionRecord)
+	if err != nil {
+		return err
+	}
+
 	// 平仓
-	order, err := at.trader.CloseShort(dec.Symbol, 0) // 0 = 全部平仓
+	order, err := at.trader.CloseShort(dec.Symbol, closeQuantity)
 	if err != nil {
 		// 平仓失败，保留锁以便重试
 		return err
@@ -2302,15 +3050,17 @@ func (at *AutoTrader) executeCloseShortWithRecord(dec *decision.Decision, action
 	// 平仓成功后验证持仓是否真的被平掉（等待一小段时间让订单处理）
 	time.Sleep(500 * time.Millisecond) // 等待500ms让交易所处理订单
 	
+	var remainingQty float64
 	positions, err := at.trader.GetPositions()
 	if err == nil {
 		for _, pos := range positions {
 			if pos["symbol"] == dec.Symbol && pos["side"] == "short" {
-				quantity := pos["positionAmt"].(float64)
+				quantity, _ := getFloat(pos, "positionAmt")
 				if quantity < 0 {
 					quantity = -quantity
 				}
-				if quantity > 0.0001 { // 允许小的精度误差
+				remainingQty = quantity
+				if closeQuantity == 0 && quantity > partialCloseDustQty { // 允许小的精度误差
 					log.Printf("  ⚠️  警告：平仓后持仓仍存在，数量: %.8f", quantity)
 					log.Printf("  ⚠️  订单可能正在处理中，如果5秒后持仓仍存在，请手动检查")
 					// 记录到actionRecord以便后续监控
@@ -2329,6 +3079,17 @@ func (at *AutoTrader) executeCloseShortWithRecord(dec *decision.Decision, action
 		actionRecord.OrderID = orderID
 	}
 
+	// 部分平仓：保留持仓逻辑和持仓时间，为剩余持仓重新设置止损止盈
+	if closeQuantity > 0 && remainingQty > partialCloseDustQty {
+		actionRecord.PartialClose = true
+		at.restoreOrdersAfterPartialClose(dec.Symbol, "short", remainingQty)
+		at.recordTradeHistory("short", dec, actionRecord, false, "")
+		at.eventLog.Info(atomic.LoadInt64(&at.callCount), dec.Symbol, "close_short",
+			fmt.Sprintf("  ✓ 部分平仓成功，剩余持仓 %.8f", remainingQty),
+			"order_id", actionRecord.OrderID, "quantity", actionRecord.Quantity, "price", actionRecord.Price, "remaining", remainingQty)
+		return nil
+	}
+
 	// 清理持仓时间记录和止损/止盈价格（通过PositionLogicManager删除逻辑时一起清理）
 	posKeyForTime := dec.Symbol + "_short"
 	at.positionTimeMu.Lock()
@@ -2336,6 +3097,8 @@ func (at *AutoTrader) executeCloseShortWithRecord(dec *decision.Decision, action
 	at.positionTimeMu.Unlock()
 
 	// 删除持仓逻辑（平仓后不再需要，止损/止盈价格会一起删除）
+	at.recordPositionClose(dec.Symbol, "short")
+
 	if err := at.positionLogicManager.DeleteLogic(dec.Symbol, "short"); err != nil {
 		log.Printf("  ⚠ 删除持仓逻辑失败: %v", err)
 	} else {
@@ -2345,7 +3108,62 @@ func (at *AutoTrader) executeCloseShortWithRecord(dec *decision.Decision, action
 	// 记录交易历史（从持仓信息中获取开仓信息）
 	at.recordTradeHistory("short", dec, actionRecord, false, "")
 
-	log.Printf("  ✓ 平仓成功")
+	at.eventLog.Info(atomic.LoadInt64(&at.callCount), dec.Symbol, "close_short", "  ✓ 平仓成功",
+		"order_id", actionRecord.OrderID, "quantity", actionRecord.Quantity, "price", actionRecord.Price)
+	return nil
+}
+
+// executeCancelOrders 撤销持仓的全部止损止盈单（不平仓），并清除已保存的止损止盈、阶梯止盈和移动止损
+// 用于AI希望让趋势仓位不设止盈止损自由运行的场景（单仓位百分比止损等系统级风控仍然生效）
+func (at *AutoTrader) executeCancelOrders(dec *decision.Decision, actionRecord *logger.DecisionAction) error {
+	log.Printf("  📋 开始撤销止损止盈: %s", dec.Symbol)
+
+	// 步骤1: 查找持仓（没有持仓时撤单没有意义，可能是AI看错了持仓）
+	foundPosition, positionSide, err := at.findPositionBySymbol(dec.Symbol)
+	if err != nil {
+		return fmt.Errorf("未找到 %s 的持仓，无法撤销止损止盈: %w", dec.Symbol, err)
+	}
+	positionAmt, ok := getFloat(foundPosition, "positionAmt")
+	if !ok {
+		return fmt.Errorf("%s 持仓的positionAmt字段缺失或无法解析: %v", dec.Symbol, foundPosition["positionAmt"])
+	}
+	quantity := math.Abs(positionAmt)
+	actionRecord.Quantity = quantity
+	if markPrice, ok := getFloat(foundPosition, "markPrice"); ok {
+		actionRecord.Price = markPrice
+	}
+
+	// 步骤2: 撤销该币种的全部挂单（没有挂单时不视为失败）
+	if err := at.trader.CancelAllOrders(dec.Symbol); err != nil {
+		errStr := strings.ToLower(err.Error())
+		if !strings.Contains(errStr, "no orders") &&
+			!strings.Contains(errStr, "not found") &&
+			!strings.Contains(errStr, "没有订单") {
+			return fmt.Errorf("撤销 %s 挂单失败: %w", dec.Symbol, err)
+		}
+	}
+
+	// 步骤3: 清除已保存的止损止盈（持仓本身不变），避免部分平仓等操作后按旧价格重新挂单
+	var oldStopLoss, oldTakeProfit float64
+	if logic := at.positionLogicManager.GetLogic(dec.Symbol, positionSide); logic != nil {
+		oldStopLoss, oldTakeProfit = logic.StopLoss, logic.TakeProfit
+	}
+	if err := at.positionLogicManager.SaveStopLoss(dec.Symbol, positionSide, 0); err != nil {
+		log.Printf("  ⚠ 清除已保存的止损失败: %v", err)
+	}
+	if err := at.positionLogicManager.SaveTakeProfit(dec.Symbol, positionSide, 0); err != nil {
+		log.Printf("  ⚠ 清除已保存的止盈失败: %v", err)
+	}
+	if err := at.positionLogicManager.SaveTakeProfitLevels(dec.Symbol, positionSide, nil); err != nil {
+		log.Printf("  ⚠ 清除阶梯止盈失败: %v", err)
+	}
+	at.clearTrailingStop(dec.Symbol, positionSide)
+
+	log.Printf("  ✓ 已撤销止损止盈: %s %s（原止损 %.4f / 止盈 %.4f），持仓 %.8f 保持不变",
+		dec.Symbol, positionSide, oldStopLoss, oldTakeProfit, quantity)
+	at.eventLog.Warn(atomic.LoadInt64(&at.callCount), dec.Symbol, "cancel_orders",
+		fmt.Sprintf("🚫 AI撤销了 %s %s 的止损止盈，持仓当前没有交易所保护订单", dec.Symbol, positionSide),
+		"side", positionSide, "old_stop_loss", oldStopLoss, "old_take_profit", oldTakeProfit)
 	return nil
 }
 
@@ -2358,12 +3176,12 @@ func (at *AutoTrader) findPositionBySymbol(symbol string) (map[string]interface{
 
 	for _, pos := range positions {
 		if pos["symbol"] == symbol {
-			side := pos["side"].(string)
-			quantity := pos["positionAmt"].(float64)
+			side, _ := getString(pos, "side")
+			quantity, _ := getFloat(pos, "positionAmt")
 			if quantity < 0 {
 				quantity = -quantity
 			}
-			if quantity > 0 {
+			if side != "" && quantity > 0 {
 				return pos, side, nil
 			}
 		}
@@ -2377,7 +3195,7 @@ func (at *AutoTrader) executeUpdateTakeProfit(dec *decision.Decision, actionReco
 	log.Printf("  📋 开始更新止盈: %s -> %.4f", dec.Symbol, dec.TakeProfit)
 
 	// 步骤1: 验证参数
-	if dec.TakeProfit <= 0 {
+	if dec.TakeProfit <= 0 && len(dec.TakeProfitLevels) == 0 {
 		return fmt.Errorf("止盈价格必须大于0: %.4f", dec.TakeProfit)
 	}
 
@@ -2389,9 +3207,14 @@ func (at *AutoTrader) executeUpdateTakeProfit(dec *decision.Decision, actionReco
 	}
 	log.Printf("  ✓ 找到持仓: %s %s", dec.Symbol, positionSide)
 
-	// 步骤3: 检查是否已经设置过相同或非常接近的止盈价格，防止频繁小幅调整
+	// 阶梯止盈：按持仓方向检查价格单调性，take_profit取最远一档
+	if err := decision.NormalizeTakeProfitLevels(dec, positionSide); err != nil {
+		return err
+	}
+
+	// 步骤3: 检查是否已经设置过相同或非常接近的止盈价格，防止频繁小幅调整（阶梯止盈的新设或撤销不跳过）
 	existingLogic := at.positionLogicManager.GetLogic(dec.Symbol, positionSide)
-	if existingLogic != nil && existingLogic.TakeProfit > 0 {
+	if existingLogic != nil && existingLogic.TakeProfit > 0 && len(dec.TakeProfitLevels) == 0 && len(existingLogic.TakeProfitLevels) == 0 {
 		// 计算价格差异百分比
 		priceDiff := (dec.TakeProfit - existingLogic.TakeProfit) / existingLogic.TakeProfit
 		if priceDiff < 0 {
@@ -2400,11 +3223,11 @@ func (at *AutoTrader) executeUpdateTakeProfit(dec *decision.Decision, actionReco
 		// 如果价格差异小于0.5%，则认为变化太小，不值得更新，跳过执行
 		// 这样可以避免频繁的小幅调整，减少不必要的订单操作
 		if priceDiff < 0.005 {
-			skipReason := fmt.Sprintf("新止盈价格 %.4f 与当前止盈 %.4f 差异太小（%.4f%%），小于0.5%阈值，跳过更新以避免频繁调整", 
+			skipReason := fmt.Sprintf("新止盈价格 %.4f 与当前止盈 %.4f 差异太小（%.4f%%），小于0.5%%阈值，跳过更新以避免频繁调整", 
 				dec.TakeProfit, existingLogic.TakeProfit, priceDiff*100)
 			log.Printf("  ⏭️  跳过更新止盈：%s %s", dec.Symbol, skipReason)
 			actionRecord.Price = existingLogic.TakeProfit
-			actionRecord.Quantity = foundPosition["positionAmt"].(float64)
+			actionRecord.Quantity, _ = getFloat(foundPosition, "positionAmt")
 			if actionRecord.Quantity < 0 {
 				actionRecord.Quantity = -actionRecord.Quantity
 			}
@@ -2414,7 +3237,11 @@ func (at *AutoTrader) executeUpdateTakeProfit(dec *decision.Decision, actionReco
 	}
 
 	// 步骤4: 获取持仓数量和当前价格
-	quantity := foundPosition["positionAmt"].(float64)
+	positionAmt, ok := getFloat(foundPosition, "positionAmt")
+	if !ok {
+		return fmt.Errorf("%s 持仓的positionAmt字段缺失或无法解析: %v", dec.Symbol, foundPosition["positionAmt"])
+	}
+	quantity := positionAmt
 	if quantity < 0 {
 		quantity = -quantity
 	}
@@ -2423,7 +3250,7 @@ func (at *AutoTrader) executeUpdateTakeProfit(dec *decision.Decision, actionReco
 		return fmt.Errorf("持仓数量无效: %.4f", quantity)
 	}
 	// 验证quantity是否与实际的持仓数量匹配
-	actualQuantity := foundPosition["positionAmt"].(float64)
+	actualQuantity := positionAmt
 	if math.Abs(quantity-math.Abs(actualQuantity)) > 0.0001 {
 		log.Printf("  ⚠ 警告：持仓数量可能不匹配，计算值: %.4f, 实际值: %.4f，使用实际值", quantity, actualQuantity)
 		quantity = math.Abs(actualQuantity)
@@ -2460,6 +3287,14 @@ func (at *AutoTrader) executeUpdateTakeProfit(dec *decision.Decision, actionReco
 		}
 	}
 
+	// 阶梯止盈的第一档也必须在盈利方向
+	if len(dec.TakeProfitLevels) > 0 {
+		first := dec.TakeProfitLevels[0].Price
+		if (positionSide == "long" && first <= currentPrice) || (positionSide == "short" && first >= currentPrice) {
+			return fmt.Errorf("阶梯止盈第一档(%.4f)必须在当前价(%.4f)的盈利方向", first, currentPrice)
+		}
+	}
+
 	// 如果同时提供了止损，验证止损和止盈的相对位置
 	if dec.StopLoss > 0 {
 		if positionSide == "long" {
@@ -2547,9 +3382,16 @@ func (at *AutoTrader) executeUpdateTakeProfit(dec *decision.Decision, actionReco
 		sideStr = "SHORT"
 	}
 
-	// 步骤9: 设置新的止盈单
-	log.Printf("  ➕ 设置新的止盈订单: %.4f", dec.TakeProfit)
-	if err := at.trader.SetTakeProfit(dec.Symbol, sideStr, quantity, dec.TakeProfit); err != nil {
+	// 步骤9-10: 以联动订单设置新的止盈，并同步保留的止损（保持止损止盈同步）
+	// 提供了阶梯止盈时按阶梯分档挂单；单一止盈会替换已保存的阶梯止盈
+	log.Printf("  ➕ 设置新的止盈订单: %.4f（同步止损: %.4f）", dec.TakeProfit, preserveStopLoss)
+	var setErr error
+	if len(dec.TakeProfitLevels) > 0 {
+		setErr = at.executeSetTakeProfitLadder(dec.Symbol, positionSide, quantity, preserveStopLoss, dec.TakeProfitLevels)
+	} else {
+		setErr = at.placeProtectionOrders(dec.Symbol, sideStr, quantity, preserveStopLoss, dec.TakeProfit)
+	}
+	if err := setErr; err != nil {
 		// 设置新订单失败，尝试恢复旧订单（回滚）
 		log.Printf("  ⚠️  设置新止盈失败，尝试恢复旧订单...")
 		rollbackErr := at.rollbackOrders(dec.Symbol, sideStr, quantity, oldStopLossOrder, oldTakeProfitOrder)
@@ -2562,23 +3404,6 @@ func (at *AutoTrader) executeUpdateTakeProfit(dec *decision.Decision, actionReco
 	}
 	log.Printf("  ✓ 止盈订单设置成功")
 
-	// 步骤10: 如果Decision中提供了StopLoss，或者需要保留已有的止损，重新设置止损（保持止损止盈同步）
-	if preserveStopLoss > 0 {
-		log.Printf("  ➕ 同步设置止损: %.4f", preserveStopLoss)
-		if err := at.trader.SetStopLoss(dec.Symbol, sideStr, quantity, preserveStopLoss); err != nil {
-			// 设置止损失败，尝试恢复旧订单（回滚）
-			log.Printf("  ⚠️  同步设置止损失败，尝试恢复旧订单...")
-			rollbackErr := at.rollbackOrders(dec.Symbol, sideStr, quantity, oldStopLossOrder, oldTakeProfitOrder)
-			if rollbackErr != nil {
-				log.Printf("  ❌ 回滚失败: %v，旧订单已丢失，需要手动检查", rollbackErr)
-				return fmt.Errorf("同步设置止损失败且回滚失败: %w (回滚错误: %v)", err, rollbackErr)
-			}
-			log.Printf("  ✓ 已恢复旧订单")
-			return fmt.Errorf("同步设置止损失败，已恢复旧订单: %w", err)
-		}
-		log.Printf("  ✓ 止损已同步: %.4f", preserveStopLoss)
-	}
-
 	// 步骤11: 保存止盈价格到PositionLogicManager（如果保留了止损，也要保存）
 	saveStopLoss := dec.StopLoss
 	if saveStopLoss <= 0 && preserveStopLoss > 0 {
@@ -2682,11 +3507,11 @@ func (at *AutoTrader) executeUpdateStopLoss(dec *decision.Decision, actionRecord
 		// 如果价格差异小于0.5%，则认为变化太小，不值得更新，跳过执行
 		// 这样可以避免频繁的小幅调整，减少不必要的订单操作
 		if priceDiff < 0.005 {
-			skipReason := fmt.Sprintf("新止损价格 %.4f 与当前止损 %.4f 差异太小（%.4f%%），小于0.5%阈值，跳过更新以避免频繁调整", 
+			skipReason := fmt.Sprintf("新止损价格 %.4f 与当前止损 %.4f 差异太小（%.4f%%），小于0.5%%阈值，跳过更新以避免频繁调整", 
 				dec.StopLoss, existingLogic.StopLoss, priceDiff*100)
 			log.Printf("  ⏭️  跳过更新止损：%s %s", dec.Symbol, skipReason)
 			actionRecord.Price = existingLogic.StopLoss
-			actionRecord.Quantity = foundPosition["positionAmt"].(float64)
+			actionRecord.Quantity, _ = getFloat(foundPosition, "positionAmt")
 			if actionRecord.Quantity < 0 {
 				actionRecord.Quantity = -actionRecord.Quantity
 			}
@@ -2696,7 +3521,11 @@ func (at *AutoTrader) executeUpdateStopLoss(dec *decision.Decision, actionRecord
 	}
 
 	// 步骤4: 获取持仓数量和当前价格
-	quantity := foundPosition["positionAmt"].(float64)
+	positionAmt, ok := getFloat(foundPosition, "positionAmt")
+	if !ok {
+		return fmt.Errorf("%s 持仓的positionAmt字段缺失或无法解析: %v", dec.Symbol, foundPosition["positionAmt"])
+	}
+	quantity := positionAmt
 	if quantity < 0 {
 		quantity = -quantity
 	}
@@ -2705,7 +3534,7 @@ func (at *AutoTrader) executeUpdateStopLoss(dec *decision.Decision, actionRecord
 		return fmt.Errorf("持仓数量无效: %.4f", quantity)
 	}
 	// 验证quantity是否与实际的持仓数量匹配
-	actualQuantity := foundPosition["positionAmt"].(float64)
+	actualQuantity := positionAmt
 	if math.Abs(quantity-math.Abs(actualQuantity)) > 0.0001 {
 		log.Printf("  ⚠ 警告：持仓数量可能不匹配，计算值: %.4f, 实际值: %.4f，使用实际值", quantity, actualQuantity)
 		quantity = math.Abs(actualQuantity)
@@ -2845,9 +3674,9 @@ func (at *AutoTrader) executeUpdateStopLoss(dec *decision.Decision, actionRecord
 		sideStr = "SHORT"
 	}
 
-	// 步骤9: 设置新的止损单
-	log.Printf("  ➕ 设置新的止损订单: %.4f", dec.StopLoss)
-	if err := at.trader.SetStopLoss(dec.Symbol, sideStr, quantity, dec.StopLoss); err != nil {
+	// 步骤9-10: 以联动订单设置新的止损，并同步保留的止盈（保持止损止盈同步）
+	log.Printf("  ➕ 设置新的止损订单: %.4f（同步止盈: %.4f）", dec.StopLoss, preserveTakeProfit)
+	if err := at.placeProtectionOrders(dec.Symbol, sideStr, quantity, dec.StopLoss, preserveTakeProfit); err != nil {
 		// 设置新订单失败，尝试恢复旧订单（回滚）
 		log.Printf("  ⚠️  设置新止损失败，尝试恢复旧订单...")
 		rollbackErr := at.rollbackOrders(dec.Symbol, sideStr, quantity, oldStopLossOrder, oldTakeProfitOrder)
@@ -2860,23 +3689,6 @@ func (at *AutoTrader) executeUpdateStopLoss(dec *decision.Decision, actionRecord
 	}
 	log.Printf("  ✓ 止损订单设置成功")
 
-	// 步骤10: 如果Decision中提供了TakeProfit，或者需要保留已有的止盈，重新设置止盈（保持止损止盈同步）
-	if preserveTakeProfit > 0 {
-		log.Printf("  ➕ 同步设置止盈: %.4f", preserveTakeProfit)
-		if err := at.trader.SetTakeProfit(dec.Symbol, sideStr, quantity, preserveTakeProfit); err != nil {
-			// 设置止盈失败，尝试恢复旧订单（回滚）
-			log.Printf("  ⚠️  同步设置止盈失败，尝试恢复旧订单...")
-			rollbackErr := at.rollbackOrders(dec.Symbol, sideStr, quantity, oldStopLossOrder, oldTakeProfitOrder)
-			if rollbackErr != nil {
-				log.Printf("  ❌ 回滚失败: %v，旧订单已丢失，需要手动检查", rollbackErr)
-				return fmt.Errorf("同步设置止盈失败且回滚失败: %w (回滚错误: %v)", err, rollbackErr)
-			}
-			log.Printf("  ✓ 已恢复旧订单")
-			return fmt.Errorf("同步设置止盈失败，已恢复旧订单: %w", err)
-		}
-		log.Printf("  ✓ 止盈已同步: %.4f", preserveTakeProfit)
-	}
-
 	// 步骤11: 保存止损价格到PositionLogicManager（如果保留了止盈，也要保存）
 	saveTakeProfit := dec.TakeProfit
 	if saveTakeProfit <= 0 && preserveTakeProfit > 0 {
@@ -3081,7 +3893,8 @@ func (at *AutoTrader) recordTradeHistory(side string, decision *decision.Decisio
 							continue
 						}
 						for _, laterAction := range laterDecisions {
-							if !laterAction.Success {
+							// 部分平仓后仍有剩余持仓，不视为已平仓
+							if !laterAction.Success || laterAction.PartialClose {
 								continue
 							}
 							if laterAction.Symbol == decision.Symbol {
@@ -3175,6 +3988,12 @@ func (at *AutoTrader) recordTradeHistory(side string, decision *decision.Decisio
 		trade.WasStopLoss = true
 	}
 	
+	// 部分平仓：单独记录已平仓部分，开仓记录保持未平仓状态（剩余持仓平仓时再更新）
+	if closeAction.PartialClose {
+		at.logPartialCloseTrade(trade, side, closeLogic, updateSLLogic)
+		return
+	}
+
 	// 更新交易历史到数据库（使用新的方式：直接更新该币种该方向未平仓的最新记录）
 	if at.storageAdapter != nil {
 		tradeStorage := at.storageAdapter.GetTradeStorage()
@@ -3251,6 +4070,7 @@ func (at *AutoTrader) recordTradeHistory(side string, decision *decision.Decisio
 					Error:          trade.Error,
 					EntryLogic:     entryLogic, // 从数据库获取或为空
 					ExitLogic:      exitLogic,  // 从数据库获取或为空
+					Strategy:       trade.Strategy,
 				}
 				// 根据是否强制平仓，设置不同的逻辑字段
 				if trade.IsForced {
@@ -3364,16 +4184,10 @@ func (at *AutoTrader) recordTradeHistoryFromPosition(side, symbol string, closeA
 		positions, err := at.trader.GetPositions()
 		if err == nil {
 			for _, pos := range positions {
-				if pos["symbol"].(string) == symbol && pos["side"].(string) == side {
-					entryPrice = pos["entryPrice"].(float64)
-					qty := pos["positionAmt"].(float64)
-					if qty < 0 {
-						qty = -qty
-					}
-					quantity = qty
-					if lev, ok := pos["leverage"].(float64); ok {
-						leverage = lev
-					}
+				if p, parseErr := parsePosition(pos); parseErr == nil && p.Symbol == symbol && p.Side == side {
+					entryPrice = p.EntryPrice
+					quantity = p.Quantity
+					leverage = float64(p.Leverage)
 					log.Printf("ℹ️  从持仓信息获取到 %s %s 的开仓价格: %.2f, 数量: %.4f, 杠杆: %.0fx", 
 						symbol, side, entryPrice, quantity, leverage)
 					break
@@ -3545,18 +4359,22 @@ func (at *AutoTrader) recordTradeHistoryFromPosition(side, symbol string, closeA
 	// 构建交易记录用于计算盈亏等信息
 	trade := at.buildTradeRecord(symbol, side, openAction, closeAction, 0, atomic.LoadInt64(&at.callCount), isForced, forcedReason, "系统外开仓", "")
 	
-	// 如果是强制平仓，尝试从交易所获取准确的realizedPnl（已扣除手续费）
+	// 如果是强制平仓，尝试从交易所获取准确的realizedPnl（按实际成交价计算，不含手续费）
 	if isForced && closeAction.OrderID > 0 {
 		realizedPnl, err := at.getRealizedPnlFromExchange(symbol, closeAction.OrderID, closeAction.Timestamp)
 		if err == nil && realizedPnl != 0 {
-			// 使用交易所返回的realizedPnl（已扣除手续费）
-			trade.PnL = realizedPnl
+			// 使用交易所返回的realizedPnl，并与手动计算一样扣除估算手续费
+			closedQuantity := 0.0
+			if trade.OpenPrice > 0 {
+				closedQuantity = trade.PositionValue / trade.OpenPrice
+			}
+			trade.PnL = realizedPnl - at.estimateTradingFees(closedQuantity, trade.OpenPrice, trade.ClosePrice)
 			// 重新计算盈亏百分比
 			if trade.MarginUsed > 0 {
-				trade.PnLPct = (realizedPnl / trade.MarginUsed) * 100
+				trade.PnLPct = (trade.PnL / trade.MarginUsed) * 100
 			}
-			log.Printf("ℹ️  从交易所获取到 %s %s 的已实现盈亏（已扣除手续费）: %.2f USDT (%.2f%%)", 
-				symbol, side, realizedPnl, trade.PnLPct)
+			log.Printf("ℹ️  从交易所获取到 %s %s 的已实现盈亏: %.2f USDT，扣除手续费后 %.2f USDT (%.2f%%)", 
+				symbol, side, realizedPnl, trade.PnL, trade.PnLPct)
 		} else if err != nil {
 			log.Printf("⚠️  无法从交易所获取 %s %s 的已实现盈亏: %v，使用手动计算的盈亏", symbol, side, err)
 		}
@@ -3696,16 +4514,23 @@ func (at *AutoTrader) recordTradeHistoryFromPosition(side, symbol string, closeA
 
 // buildTradeRecord 构建完整的交易记录
 func (at *AutoTrader) buildTradeRecord(symbol, side string, openAction, closeAction *logger.DecisionAction, openCycleNum int, closeCycleNum int64, isForced bool, forcedReason, openReason, closeReason string) *logger.TradeRecord {
-	// 计算盈亏
+	// 本次平仓数量：部分平仓（或此前已部分平仓）时只按实际平掉的数量计算
+	closedQuantity := openAction.Quantity
+	if closeAction.Quantity > 0 && closeAction.Quantity < openAction.Quantity {
+		closedQuantity = closeAction.Quantity
+	}
+
+	// 计算盈亏（扣除开仓和平仓的估算手续费）
 	var pnl float64
 	if side == "long" {
-		pnl = openAction.Quantity * (closeAction.Price - openAction.Price)
+		pnl = closedQuantity * (closeAction.Price - openAction.Price)
 	} else {
-		pnl = openAction.Quantity * (openAction.Price - closeAction.Price)
+		pnl = closedQuantity * (openAction.Price - closeAction.Price)
 	}
+	pnl -= at.estimateTradingFees(closedQuantity, openAction.Price, closeAction.Price)
 
-	// 计算持仓价值和保证金
-	positionValue := openAction.Quantity * openAction.Price
+	// 计算持仓价值和保证金（按本次平仓部分计算）
+	positionValue := closedQuantity * openAction.Price
 	marginUsed := positionValue / float64(openAction.Leverage)
 	pnlPct := 0.0
 	if marginUsed > 0 {
@@ -3745,7 +4570,17 @@ func (at *AutoTrader) buildTradeRecord(symbol, side string, openAction, closeAct
 		WasStopLoss:   isForced && pnl < 0,
 		Success:       openAction.Success && closeAction.Success,
 		Error:         closeAction.Error,
+		Strategy:      at.getConfig().StrategyName,
+	}
+}
+
+// estimateTradingFees 估算一笔交易的开仓和平仓手续费（按吃单费率，各按对应的成交价值计算）
+// 交易所的realizedPnl不含手续费，使用交易所盈亏时也要扣除，保证与手动计算的盈亏可比
+func (at *AutoTrader) estimateTradingFees(quantity, openPrice, closePrice float64) float64 {
+	if at.getConfig().TakerFeeBps <= 0 || quantity <= 0 {
+		return 0
 	}
+	return quantity * (openPrice + closePrice) * at.getConfig().TakerFeeBps / 10000
 }
 
 // GetID 获取trader ID
@@ -3771,31 +4606,21 @@ func (at *AutoTrader) GetDecisionLogger() interface{} {
 }
 
 // rollbackOrders 回滚订单（恢复旧的止损止盈订单）
+// 先取消新订单中可能已生效的一边（退化为独立订单时可能部分成功），避免与恢复的旧订单重复
 func (at *AutoTrader) rollbackOrders(symbol, sideStr string, quantity, oldStopLoss, oldTakeProfit float64) error {
-	var rollbackErrors []string
-	
-	// 恢复止损订单
-	if oldStopLoss > 0 {
-		if err := at.trader.SetStopLoss(symbol, sideStr, quantity, oldStopLoss); err != nil {
-			rollbackErrors = append(rollbackErrors, fmt.Sprintf("恢复止损失败: %v", err))
-		} else {
-			log.Printf("  ✓ 已恢复止损订单: %.4f", oldStopLoss)
-		}
+	if err := at.trader.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠️  回滚前取消部分生效的订单失败: %v", err)
 	}
-	
-	// 恢复止盈订单
-	if oldTakeProfit > 0 {
-		if err := at.trader.SetTakeProfit(symbol, sideStr, quantity, oldTakeProfit); err != nil {
-			rollbackErrors = append(rollbackErrors, fmt.Sprintf("恢复止盈失败: %v", err))
-		} else {
-			log.Printf("  ✓ 已恢复止盈订单: %.4f", oldTakeProfit)
-		}
+
+	if oldStopLoss <= 0 && oldTakeProfit <= 0 {
+		return nil
 	}
-	
-	if len(rollbackErrors) > 0 {
-		return fmt.Errorf("回滚部分失败: %s", strings.Join(rollbackErrors, "; "))
+
+	if err := at.placeProtectionOrders(symbol, sideStr, quantity, oldStopLoss, oldTakeProfit); err != nil {
+		return fmt.Errorf("回滚部分失败: %w", err)
 	}
-	
+	log.Printf("  ✓ 已恢复止损/止盈订单: 止损=%.4f, 止盈=%.4f", oldStopLoss, oldTakeProfit)
+
 	return nil
 }
 
@@ -3815,40 +4640,72 @@ func (at *AutoTrader) GetDecisionRecordsFromDB(limit int) ([]*logger.DecisionRec
 		return nil, fmt.Errorf("从数据库获取决策记录失败: %w", err)
 	}
 
-	// 转换为logger.DecisionRecord格式
+	return toLoggerDecisionRecords(dbRecords), nil
+}
+
+// GetDecisionRecordsSince 从数据库获取指定时间之后的全部决策记录（按时间倒序，用于按时间范围绘图的API接口）
+func (at *AutoTrader) GetDecisionRecordsSince(since time.Time) ([]*logger.DecisionRecord, error) {
+	if at.storageAdapter == nil {
+		return []*logger.DecisionRecord{}, nil
+	}
+
+	decisionStorage := at.storageAdapter.GetDecisionStorage()
+	if decisionStorage == nil {
+		return []*logger.DecisionRecord{}, nil
+	}
+
+	dbRecords, err := decisionStorage.GetRecordsSince(at.id, since)
+	if err != nil {
+		return nil, fmt.Errorf("从数据库获取决策记录失败: %w", err)
+	}
+
+	return toLoggerDecisionRecords(dbRecords), nil
+}
+
+// toLoggerDecisionRecords 批量转换为logger.DecisionRecord格式
+func toLoggerDecisionRecords(dbRecords []*storage.DecisionRecord) []*logger.DecisionRecord {
 	var records []*logger.DecisionRecord
 	for _, dbRecord := range dbRecords {
-		record := &logger.DecisionRecord{
-			Timestamp:      dbRecord.Timestamp,
-			CycleNumber:    dbRecord.CycleNumber,
-			InputPrompt:    dbRecord.InputPrompt,
-			CoTTrace:       dbRecord.CoTTrace,
-			DecisionJSON:   dbRecord.DecisionJSON,
-			Success:        dbRecord.Success,
-			ErrorMessage:   dbRecord.ErrorMessage,
-		}
+		records = append(records, toLoggerDecisionRecord(dbRecord))
+	}
+	return records
+}
 
-		// 解析JSON字段
-		if err := json.Unmarshal(dbRecord.AccountState, &record.AccountState); err != nil {
-			log.Printf("⚠️  解析账户状态失败: %v", err)
-		}
-		if err := json.Unmarshal(dbRecord.Positions, &record.Positions); err != nil {
-			log.Printf("⚠️  解析持仓失败: %v", err)
-		}
-		if err := json.Unmarshal(dbRecord.CandidateCoins, &record.CandidateCoins); err != nil {
-			log.Printf("⚠️  解析候选币种失败: %v", err)
-		}
-		if err := json.Unmarshal(dbRecord.Decisions, &record.Decisions); err != nil {
-			log.Printf("⚠️  解析决策失败: %v", err)
-		}
-		if err := json.Unmarshal(dbRecord.ExecutionLog, &record.ExecutionLog); err != nil {
-			log.Printf("⚠️  解析执行日志失败: %v", err)
-		}
+// toLoggerDecisionRecord 将数据库中的决策记录转换为logger.DecisionRecord格式（解析JSON字段，解析失败时记录日志并保留零值）
+func toLoggerDecisionRecord(dbRecord *storage.DecisionRecord) *logger.DecisionRecord {
+	record := &logger.DecisionRecord{
+		Timestamp:      dbRecord.Timestamp,
+		CycleNumber:    dbRecord.CycleNumber,
+		InputPrompt:    dbRecord.InputPrompt,
+		CoTTrace:       dbRecord.CoTTrace,
+		DecisionJSON:   dbRecord.DecisionJSON,
+		Success:        dbRecord.Success,
+		ErrorMessage:   dbRecord.ErrorMessage,
+	}
 
-		records = append(records, record)
+	// 解析JSON字段
+	if err := json.Unmarshal(dbRecord.AccountState, &record.AccountState); err != nil {
+		log.Printf("⚠️  解析账户状态失败: %v", err)
+	}
+	if err := json.Unmarshal(dbRecord.Positions, &record.Positions); err != nil {
+		log.Printf("⚠️  解析持仓失败: %v", err)
+	}
+	if err := json.Unmarshal(dbRecord.CandidateCoins, &record.CandidateCoins); err != nil {
+		log.Printf("⚠️  解析候选币种失败: %v", err)
+	}
+	if err := json.Unmarshal(dbRecord.Decisions, &record.Decisions); err != nil {
+		log.Printf("⚠️  解析决策失败: %v", err)
+	}
+	if err := json.Unmarshal(dbRecord.ExecutionLog, &record.ExecutionLog); err != nil {
+		log.Printf("⚠️  解析执行日志失败: %v", err)
+	}
+	if len(dbRecord.ModelTraces) > 0 {
+		if err := json.Unmarshal(dbRecord.ModelTraces, &record.ModelTraces); err != nil {
+			log.Printf("⚠️  解析多模型输出失败: %v", err)
+		}
 	}
 
-	return records, nil
+	return record
 }
 
 // GetPerformanceFromDB 从数据库获取表现分析（用于API接口）
@@ -3877,6 +4734,58 @@ func (at *AutoTrader) GetPerformanceFromDB(lookbackCycles int) (*logger.Performa
 	return at.analyzePerformanceFromDB(records), nil
 }
 
+// GetStrategyPerformanceFromDB 从数据库获取指定策略最近N天的表现分析（用于API接口，对比共用同一账户的多个策略）
+func (at *AutoTrader) GetStrategyPerformanceFromDB(strategy string, days int) (*logger.PerformanceAnalysis, error) {
+	if at.storageAdapter == nil || at.storageAdapter.GetTradeStorage() == nil {
+		return SummarizeTradeOutcomes(nil), nil
+	}
+
+	trades, err := at.storageAdapter.GetTradeStorage().GetTradesByStrategy(strategy, days)
+	if err != nil {
+		return nil, fmt.Errorf("从数据库获取策略交易记录失败: %w", err)
+	}
+	return at.analyzePerformanceFromTrades(trades), nil
+}
+
+// GetTradesPaginatedFromDB 从数据库分页获取已平仓的交易（用于API接口），同时返回总数
+func (at *AutoTrader) GetTradesPaginatedFromDB(limit, offset int) ([]*storage.TradeRecord, int, error) {
+	if at.storageAdapter == nil {
+		return []*storage.TradeRecord{}, 0, nil
+	}
+
+	tradeStorage := at.storageAdapter.GetTradeStorage()
+	if tradeStorage == nil {
+		return []*storage.TradeRecord{}, 0, nil
+	}
+
+	trades, total, err := tradeStorage.GetTradesPaginated(limit, offset)
+	if err != nil {
+		return nil, 0, fmt.Errorf("从数据库获取交易记录失败: %w", err)
+	}
+	if trades == nil {
+		trades = []*storage.TradeRecord{}
+	}
+	return trades, total, nil
+}
+
+// GetClosedTradesFromDB 从数据库获取最近N天已平仓的交易（用于API导出）
+func (at *AutoTrader) GetClosedTradesFromDB(days int) ([]*storage.TradeRecord, error) {
+	if at.storageAdapter == nil {
+		return []*storage.TradeRecord{}, nil
+	}
+
+	tradeStorage := at.storageAdapter.GetTradeStorage()
+	if tradeStorage == nil {
+		return []*storage.TradeRecord{}, nil
+	}
+
+	trades, err := tradeStorage.GetClosedTradesSince(time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return nil, fmt.Errorf("从数据库获取交易记录失败: %w", err)
+	}
+	return trades, nil
+}
+
 // GetStatisticsFromDB 从数据库获取统计信息（用于API接口）
 func (at *AutoTrader) GetStatisticsFromDB() (*logger.Statistics, error) {
 	if at.storageAdapter == nil {
@@ -3932,7 +4841,7 @@ func (at *AutoTrader) GetStatisticsFromDB() (*logger.Statistics, error) {
 // GetStatus 获取系统状态（用于API，带并发保护）
 func (at *AutoTrader) GetStatus() map[string]interface{} {
 	aiProvider := "DeepSeek"
-	if at.config.UseQwen {
+	if at.getConfig().UseQwen {
 		aiProvider = "Qwen"
 	}
 
@@ -3946,15 +4855,57 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		"ai_model":        at.aiModel,
 		"exchange":        at.exchange,
 		"is_running":      atomic.LoadInt32(&at.isRunning) == 1,
+		"is_paused":       at.IsPaused(), // AI决策是否已手动暂停（止损保护仍运行）
 		"start_time":      at.startTime.Format(time.RFC3339),
 		"runtime_minutes": int(time.Since(at.startTime).Minutes()),
 		"call_count":      atomic.LoadInt64(&at.callCount),
 		"initial_balance": at.initialBalance,
-		"scan_interval":   at.config.ScanInterval.String(),
+		"scan_interval":   at.getConfig().ScanInterval.String(),
 		"stop_until":      at.stopUntil.Format(time.RFC3339),
 		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
+		"peak_equity":     at.peakEquity,
+		"max_drawdown_pct": at.maxDrawdownPct, // 历史最大回撤（跨重启保留）
 		"ai_provider":     aiProvider,
+		"stop_reason":     at.getStopReason(),
+		"run_limit":       at.getRunLimitStatus(),
+	}
+}
+
+// getStopReason 获取自动停止原因
+func (at *AutoTrader) getStopReason() string {
+	at.stopReasonMu.RLock()
+	defer at.stopReasonMu.RUnlock()
+	return at.stopReason
+}
+
+// getRunLimitStatus 获取运行上限的剩余额度（未配置的上限为nil）
+func (at *AutoTrader) getRunLimitStatus() map[string]interface{} {
+	status := map[string]interface{}{
+		"max_cycles":                nil,
+		"remaining_cycles":          nil,
+		"max_runtime_minutes":       nil,
+		"remaining_runtime_minutes": nil,
+		"flatten_on_stop":           at.getConfig().FlattenOnStop,
+	}
+
+	if at.getConfig().MaxCycles > 0 {
+		remaining := int64(at.getConfig().MaxCycles) - atomic.LoadInt64(&at.callCount)
+		if remaining < 0 {
+			remaining = 0
+		}
+		status["max_cycles"] = at.getConfig().MaxCycles
+		status["remaining_cycles"] = remaining
+	}
+	if at.getConfig().MaxRuntime > 0 {
+		remaining := at.getConfig().MaxRuntime - time.Since(at.startTime)
+		if remaining < 0 {
+			remaining = 0
+		}
+		status["max_runtime_minutes"] = int(at.getConfig().MaxRuntime.Minutes())
+		status["remaining_runtime_minutes"] = int(remaining.Minutes())
 	}
+
+	return status
 }
 
 // GetAccountInfo 获取账户信息（用于API）
@@ -3990,20 +4941,29 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 
 	totalMarginUsed := 0.0
 	totalUnrealizedPnL := 0.0
+	netExposure := 0.0
+	grossExposure := 0.0
+	notionals := make(map[string]float64) // symbol_side -> 名义价值（用于计算集中度）
 	for _, pos := range positions {
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity
+		p, parseErr := parsePosition(pos)
+		if parseErr != nil {
+			log.Printf("⚠️  跳过格式异常的持仓: %v", parseErr)
+			continue
 		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		totalUnrealizedPnL += unrealizedPnl
+		markPrice, quantity := p.MarkPrice, p.Quantity
+		totalUnrealizedPnL += p.UnrealizedPnl
 
-		leverage := 10
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
+		// 名义价值：多头计正，空头计负
+		notional := quantity * markPrice
+		grossExposure += notional
+		if p.Side == "short" {
+			netExposure -= notional
+		} else {
+			netExposure += notional
 		}
-		marginUsed := (quantity * markPrice) / float64(leverage)
+		notionals[p.Symbol+"_"+p.Side] = notional
+
+		marginUsed := (quantity * markPrice) / float64(p.Leverage)
 		totalMarginUsed += marginUsed
 	}
 
@@ -4020,10 +4980,16 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 	}
 
 	marginUsedPct := 0.0
+	netExposurePct := 0.0
+	grossExposurePct := 0.0
 	if totalEquity > 0 {
 		marginUsedPct = (totalMarginUsed / totalEquity) * 100
+		netExposurePct = (netExposure / totalEquity) * 100
+		grossExposurePct = (grossExposure / totalEquity) * 100
 	}
 
+	concentrationHHI, largestPositionPct, largestPosition := calculateConcentration(notionals)
+
 	return map[string]interface{}{
 		// 核心字段
 		"total_equity":      totalEquity,           // 账户净值 = wallet + unrealized
@@ -4042,6 +5008,17 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 		"position_count":  len(positions),  // 持仓数量
 		"margin_used":     totalMarginUsed, // 保证金占用
 		"margin_used_pct": marginUsedPct,   // 保证金使用率
+
+		// 敞口信息（按名义价值）
+		"net_exposure_usd":   netExposure,      // 净敞口 = 多头 - 空头
+		"net_exposure_pct":   netExposurePct,   // 净敞口占净值百分比
+		"gross_exposure":     grossExposure,    // 总敞口 = 多头 + 空头
+		"gross_exposure_pct": grossExposurePct, // 总敞口占净值百分比
+
+		// 持仓集中度
+		"concentration_hhi":    concentrationHHI,   // 赫芬达尔指数（0-1，越高越集中）
+		"largest_position_pct": largestPositionPct, // 最大持仓占总敞口百分比
+		"largest_position":     largestPosition,    // 最大持仓（symbol_side）
 	}, nil
 }
 
@@ -4054,83 +5031,131 @@ func (at *AutoTrader) GetPositions() ([]map[string]interface{}, error) {
 
 	var result []map[string]interface{}
 	for _, pos := range positions {
-		symbol := pos["symbol"].(string)
-		side := pos["side"].(string)
-		entryPrice := pos["entryPrice"].(float64)
-		markPrice := pos["markPrice"].(float64)
-		quantity := pos["positionAmt"].(float64)
-		if quantity < 0 {
-			quantity = -quantity
-		}
-		unrealizedPnl := pos["unRealizedProfit"].(float64)
-		liquidationPrice := pos["liquidationPrice"].(float64)
-
-		leverage := 10
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = int(lev)
+		if posData := at.buildPositionData(pos); posData != nil {
+			result = append(result, posData)
 		}
+	}
 
-		pnlPct := 0.0
-		if side == "long" {
-			pnlPct = ((markPrice - entryPrice) / entryPrice) * float64(leverage) * 100
-		} else {
-			pnlPct = ((entryPrice - markPrice) / entryPrice) * float64(leverage) * 100
-		}
+	return result, nil
+}
 
-		marginUsed := (quantity * markPrice) / float64(leverage)
+// GetPositionDetail 获取单个持仓的完整信息（用于API，包含持仓逻辑、止盈止损和首次出现时间）
+// 未找到该持仓时返回nil
+func (at *AutoTrader) GetPositionDetail(symbol, side string) (map[string]interface{}, error) {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
 
-		// 加载持仓逻辑并检查是否失效
-		logic := at.positionLogicManager.GetLogic(symbol, side)
-		logicInvalid := false
-		var invalidReasons []string
-		
-		if logic != nil {
-			// 获取市场数据用于检查逻辑
-			if marketData, err := market.Get(symbol); err == nil {
-				ctx := &decision.Context{
-					MultiTimeframeConfig: at.config.MultiTimeframeConfig,
-					MarketDataMap:        make(map[string]*market.Data),
-					StrategyName:         at.config.StrategyName,
-				}
-				ctx.MarketDataMap[symbol] = marketData
-				logicInvalid, invalidReasons = decision.CheckLogicValidity(logic, symbol, marketData, ctx, side)
-			}
+	for _, pos := range positions {
+		if pos["symbol"] != symbol || pos["side"] != side {
+			continue
 		}
 
-		// 构建返回的持仓数据
-		posData := map[string]interface{}{
-			"symbol":             symbol,
-			"side":               side,
-			"entry_price":        entryPrice,
-			"mark_price":         markPrice,
-			"quantity":           quantity,
-			"leverage":           leverage,
-			"unrealized_pnl":     unrealizedPnl,
-			"unrealized_pnl_pct": pnlPct,
-			"liquidation_price":  liquidationPrice,
-			"margin_used":        marginUsed,
+		posData := at.buildPositionData(pos)
+		if posData == nil {
+			return nil, fmt.Errorf("持仓数据格式异常: %s %s", symbol, side)
+		}
+		if _, ok := posData["logic_invalid"]; !ok {
+			posData["logic_invalid"] = false
 		}
 
-		// 添加逻辑信息
-		if logic != nil {
-			if logic.EntryLogic != nil {
-				posData["entry_logic"] = logic.EntryLogic
+		if logic := at.positionLogicManager.GetLogic(symbol, side); logic != nil {
+			posData["stop_loss"] = logic.StopLoss
+			posData["take_profit"] = logic.TakeProfit
+			if len(logic.TakeProfitLevels) > 0 {
+				posData["take_profit_levels"] = logic.TakeProfitLevels
 			}
-			if logic.ExitLogic != nil {
-				posData["exit_logic"] = logic.ExitLogic
+			if logic.TrailingDistancePct > 0 {
+				posData["trailing_distance_pct"] = logic.TrailingDistancePct
+				posData["trailing_best_price"] = logic.TrailingBestPrice
 			}
 		}
-		if logicInvalid {
-			posData["logic_invalid"] = true
-			if len(invalidReasons) > 0 {
-				posData["invalid_reasons"] = invalidReasons
+
+		at.positionTimeMu.RLock()
+		firstSeen, exists := at.positionFirstSeenTime[symbol+"_"+side]
+		at.positionTimeMu.RUnlock()
+		if exists {
+			posData["first_seen_time"] = firstSeen
+			posData["holding_minutes"] = int(time.Since(time.UnixMilli(firstSeen)).Minutes())
+		}
+
+		return posData, nil
+	}
+
+	return nil, nil
+}
+
+// buildPositionData 构建单个持仓的返回数据（包含持仓逻辑和逻辑是否失效，持仓字段缺失或类型异常时返回nil）
+func (at *AutoTrader) buildPositionData(pos map[string]interface{}) map[string]interface{} {
+	p, parseErr := parsePosition(pos)
+	if parseErr != nil {
+		log.Printf("⚠️  跳过格式异常的持仓: %v", parseErr)
+		return nil
+	}
+	symbol, side := p.Symbol, p.Side
+	entryPrice, markPrice, quantity := p.EntryPrice, p.MarkPrice, p.Quantity
+	unrealizedPnl, liquidationPrice := p.UnrealizedPnl, p.LiquidationPrice
+	leverage := p.Leverage
+
+	pnlPct := 0.0
+	if side == "long" {
+		pnlPct = ((markPrice - entryPrice) / entryPrice) * float64(leverage) * 100
+	} else {
+		pnlPct = ((entryPrice - markPrice) / entryPrice) * float64(leverage) * 100
+	}
+
+	marginUsed := (quantity * markPrice) / float64(leverage)
+
+	// 加载持仓逻辑并检查是否失效
+	logic := at.positionLogicManager.GetLogic(symbol, side)
+	logicInvalid := false
+	var invalidReasons []string
+	
+	if logic != nil {
+		// 获取市场数据用于检查逻辑
+		if marketData, err := market.Get(symbol); err == nil {
+			ctx := &decision.Context{
+				MultiTimeframeConfig: at.getConfig().MultiTimeframeConfig,
+				MarketDataMap:        make(map[string]*market.Data),
+				StrategyName:         at.getConfig().StrategyName,
 			}
+			ctx.MarketDataMap[symbol] = marketData
+			logicInvalid, invalidReasons = decision.CheckLogicValidity(logic, symbol, marketData, ctx, side)
 		}
+	}
 
-		result = append(result, posData)
+	// 构建返回的持仓数据
+	posData := map[string]interface{}{
+		"symbol":             symbol,
+		"side":               side,
+		"entry_price":        entryPrice,
+		"mark_price":         markPrice,
+		"quantity":           quantity,
+		"leverage":           leverage,
+		"unrealized_pnl":     unrealizedPnl,
+		"unrealized_pnl_pct": pnlPct,
+		"liquidation_price":  liquidationPrice,
+		"margin_used":        marginUsed,
 	}
 
-	return result, nil
+	// 添加逻辑信息
+	if logic != nil {
+		if logic.EntryLogic != nil {
+			posData["entry_logic"] = logic.EntryLogic
+		}
+		if logic.ExitLogic != nil {
+			posData["exit_logic"] = logic.ExitLogic
+		}
+	}
+	if logicInvalid {
+		posData["logic_invalid"] = true
+		if len(invalidReasons) > 0 {
+			posData["invalid_reasons"] = invalidReasons
+		}
+	}
+
+	return posData
 }
 
 // sortDecisionsByPriority 对决策排序：先平仓，再开仓，最后hold/wait
@@ -4170,8 +5195,92 @@ func sortDecisionsByPriority(decisions []decision.Decision) []decision.Decision
 	return sorted
 }
 
+// calculateExposure 按名义价值计算净敞口（多头 - 空头）和总敞口（多头 + 空头）
+func calculateExposure(positions []decision.PositionInfo) (float64, float64) {
+	netExposure := 0.0
+	grossExposure := 0.0
+	for _, pos := range positions {
+		notional := pos.Quantity * pos.MarkPrice
+		grossExposure += notional
+		if pos.Side == "short" {
+			netExposure -= notional
+		} else {
+			netExposure += notional
+		}
+	}
+	return netExposure, grossExposure
+}
+
+// calculateConcentration 计算持仓集中度
+// 输入为各持仓的名义价值（symbol_side -> USD），返回赫芬达尔指数（各持仓占比平方和，0-1）、
+// 最大持仓占总敞口的百分比和最大持仓的key；无持仓时全部返回零值
+func calculateConcentration(notionals map[string]float64) (float64, float64, string) {
+	total := 0.0
+	for _, notional := range notionals {
+		total += notional
+	}
+	if total <= 0 {
+		return 0, 0, ""
+	}
+
+	hhi := 0.0
+	largest := 0.0
+	largestKey := ""
+	for key, notional := range notionals {
+		share := notional / total
+		hhi += share * share
+		if notional > largest {
+			largest = notional
+			largestKey = key
+		}
+	}
+	return hhi, (largest / total) * 100, largestKey
+}
+
+// confirmOpenProposal 检查开仓提议是否已确认
+// 未启用开仓确认时直接返回true；否则仅当上一周期已提出相同币种+方向且在确认窗口内时返回true，
+// 其余情况记录为本周期的新提议并返回false
+func (at *AutoTrader) confirmOpenProposal(symbol, action string, cycleNum int64) bool {
+	if !at.getConfig().OpenConfirmationEnabled {
+		return true
+	}
+
+	window := at.getConfig().OpenConfirmationWindow
+	if window <= 0 {
+		window = 2 * at.getConfig().ScanInterval
+	}
+
+	key := symbol + "_" + action
+	at.pendingOpensMu.Lock()
+	defer at.pendingOpensMu.Unlock()
+
+	if pending, exists := at.pendingOpens[key]; exists && pending.cycleNum == cycleNum-1 && time.Since(pending.proposedAt) <= window {
+		delete(at.pendingOpens, key)
+		log.Printf("✅ %s %s 已在连续两个周期提出，确认开仓", symbol, action)
+		return true
+	}
+
+	at.pendingOpens[key] = pendingOpen{cycleNum: cycleNum, proposedAt: time.Now()}
+	return false
+}
+
+// expirePendingOpens 清理早于当前周期的开仓提议（未在下一周期再次提出即作废）
+func (at *AutoTrader) expirePendingOpens(cycleNum int64) {
+	at.pendingOpensMu.Lock()
+	defer at.pendingOpensMu.Unlock()
+
+	for key, pending := range at.pendingOpens {
+		if pending.cycleNum < cycleNum {
+			delete(at.pendingOpens, key)
+			log.Printf("🗑️  开仓提议 %s 未在下一周期确认，已作废", key)
+		}
+	}
+}
+
 // deduplicateDecisions 去重决策：合并同一币种相同类型的操作
 // 对于 update_sl 和 update_tp，只保留最后一个（按顺序）
+// 对于 open_long 和 open_short，同一币种同一方向也只保留最后一个，避免AI重复输出时同一周期重复开仓
+// （后续的持仓检查也会拦截，但依赖交易所持仓及时更新，这里提前去重更可靠）
 func deduplicateDecisions(decisions []decision.Decision) []decision.Decision {
 	if len(decisions) <= 1 {
 		return decisions
@@ -4185,6 +5294,10 @@ func deduplicateDecisions(decisions []decision.Decision) []decision.Decision {
 	dedupActions := map[string]bool{
 		"update_sl": true,
 		"update_tp": true,
+		"update_sl_trailing": true,
+		"cancel_orders": true,
+		"open_long":  true,
+		"open_short": true,
 	}
 
 	// 第一遍：找出每个币种+操作类型的最后一个索引
@@ -4215,41 +5328,114 @@ func deduplicateDecisions(decisions []decision.Decision) []decision.Decision {
 	return result
 }
 
+// parseExchangeTradeTimeMs 解析交易所成交时间（统一为Unix毫秒时间戳）
+func parseExchangeTradeTimeMs(exchangeTrade map[string]interface{}) (int64, bool) {
+	timeMs, ok := exchangeTrade["time"].(float64)
+	if !ok {
+		if t, ok := exchangeTrade["timestamp"].(float64); ok {
+			timeMs = t
+		} else {
+			return 0, false
+		}
+	}
+	// 如果时间戳小于 1e12，认为是秒；否则认为是毫秒
+	if timeMs < 1e12 {
+		return int64(timeMs) * 1000, true
+	}
+	return int64(timeMs), true
+}
+
+// parseExchangeTradeID 解析交易所成交ID（解析失败返回0）
+func parseExchangeTradeID(exchangeTrade map[string]interface{}) int64 {
+	switch id := exchangeTrade["id"].(type) {
+	case float64:
+		return int64(id)
+	case string:
+		parsed, _ := strconv.ParseInt(id, 10, 64)
+		return parsed
+	}
+	return 0
+}
+
+// findOpenFill 在成交列表中查找平仓订单对应的开仓成交
+// 条件：同币种、方向相反、realizedPnl为0、时间早于平仓时间；选择最接近平仓时间的一笔
+func findOpenFill(accountTrades []map[string]interface{}, symbol, tradeSide string, closeTime time.Time) (map[string]interface{}, time.Time) {
+	var bestOpenTrade map[string]interface{}
+	var bestOpenTime time.Time
+	for _, potentialOpenTrade := range accountTrades {
+		openTradeSymbol, ok := potentialOpenTrade["symbol"].(string)
+		if !ok || openTradeSymbol != symbol {
+			continue
+		}
+
+		openTradeSide, _ := potentialOpenTrade["side"].(string)
+		openTradeRealizedPnlStr, _ := potentialOpenTrade["realizedPnl"].(string)
+		openTradeRealizedPnlVal, _ := strconv.ParseFloat(openTradeRealizedPnlStr, 64)
+		openTradeTimeMs, ok := parseExchangeTradeTimeMs(potentialOpenTrade)
+		if !ok {
+			continue
+		}
+		openTradeTime := time.UnixMilli(openTradeTimeMs)
+
+		// 开仓交易：方向相反、realizedPnl为0、时间早于平仓时间
+		isOppositeSide := (tradeSide == "long" && strings.ToUpper(openTradeSide) == "BUY") ||
+			(tradeSide == "short" && strings.ToUpper(openTradeSide) == "SELL")
+
+		if isOppositeSide && openTradeRealizedPnlVal == 0 && openTradeTime.Before(closeTime) {
+			// 选择最接近平仓时间的开仓交易（时间最大的，但早于平仓时间）
+			if bestOpenTrade == nil || openTradeTime.After(bestOpenTime) {
+				bestOpenTrade = potentialOpenTrade
+				bestOpenTime = openTradeTime
+			}
+		}
+	}
+	return bestOpenTrade, bestOpenTime
+}
+
 // SyncManualTradesFromExchange 同步手工交易到历史记录
 // 这个方法会从交易所获取最近的交易历史，并与本地记录对比，补充缺失的交易记录
+// 同步是增量的：每个币种持久化"最后已同步成交"游标，只拉取游标之后的新成交；
+// 保存失败的币种不推进游标，下次同步会重新处理（按订单ID去重保证幂等）
 // ⚠️ 已禁用：此功能已禁用，不再从交易所历史恢复交易记录
 // 如需启用，请取消注释 runTradingCycle 中的调用
 func (at *AutoTrader) SyncManualTradesFromExchange() error {
 	log.Println("🔄 开始同步交易所交易历史到本地记录...")
 	
-	// 检查trader是否支持GetAccountTrades方法
-	asterTrader, ok := at.trader.(*AsterTrader)
-	if !ok {
-		return fmt.Errorf("当前交易器不支持获取交易历史功能")
+	// 获取本地已存储的交易记录
+	tradeStorage := at.storageAdapter.GetTradeStorage()
+	if tradeStorage == nil {
+		return fmt.Errorf("无法获取交易存储")
+	}
+	
+	// 加载同步游标：有游标时只拉取游标之后的成交，首次同步回溯最近7天
+	cursors, err := tradeStorage.GetSyncCursors()
+	if err != nil {
+		return fmt.Errorf("获取同步游标失败: %w", err)
 	}
 	
-	// 获取最近7天的交易历史
+	const syncFetchLimit = 1000
 	endTime := time.Now()
 	startTime := endTime.AddDate(0, 0, -7) // 最近7天
+	if globalCursor, exists := cursors[storage.SyncCursorAllSymbols]; exists && globalCursor.LastTradeTime > 0 {
+		// startTime包含游标所在毫秒，同一毫秒内已同步的成交通过成交ID过滤
+		startTime = time.UnixMilli(globalCursor.LastTradeTime)
+	}
 	
-	accountTrades, err := asterTrader.GetAccountTrades("", startTime, endTime, 1000)
+	accountTrades, err := at.trader.GetAccountTrades("", startTime, endTime, syncFetchLimit)
 	if err != nil {
 		return fmt.Errorf("获取交易所交易历史失败: %w", err)
 	}
 	
-	log.Printf("📊 从交易所获取到 %d 笔交易记录", len(accountTrades))
+	log.Printf("📊 从交易所获取到 %d 笔交易记录（起始时间: %s）", len(accountTrades), startTime.Format("2006-01-02 15:04:05"))
+	if len(accountTrades) >= syncFetchLimit {
+		log.Printf("ℹ️  本次拉取达到上限(%d笔)，剩余成交将在下次同步时从游标处继续", syncFetchLimit)
+	}
 	
 	if len(accountTrades) == 0 {
 		log.Println("✅ 交易所没有新的交易记录")
 		return nil
 	}
 	
-	// 获取本地已存储的交易记录
-	tradeStorage := at.storageAdapter.GetTradeStorage()
-	if tradeStorage == nil {
-		return fmt.Errorf("无法获取交易存储")
-	}
-	
 	localTrades, err := tradeStorage.GetLatestTrades(1000) // 获取最近的1000条记录
 	if err != nil {
 		return fmt.Errorf("获取本地交易记录失败: %w", err)
@@ -4280,6 +5466,10 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 	// 按订单ID聚合交易（使用orderId作为键，因为同一订单可能有多个成交）
 	orderMap := make(map[int64]*aggregatedTrade)
 	
+	// 本次拉取到的每个币种的最新成交位置（处理成功后用于推进游标）
+	latestCursors := make(map[string]storage.SyncCursor)
+	newFillCount := 0
+	
 	for _, exchangeTrade := range accountTrades {
 		// 安全解析字段，添加错误处理
 		symbol, ok := exchangeTrade["symbol"].(string)
@@ -4287,6 +5477,20 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 			continue
 		}
 		
+		// 游标过滤：跳过该币种已同步过的成交
+		fillTimeMs, ok := parseExchangeTradeTimeMs(exchangeTrade)
+		if !ok {
+			continue
+		}
+		fillID := parseExchangeTradeID(exchangeTrade)
+		if cursor, exists := cursors[symbol]; exists && !cursor.IsAfter(fillTimeMs, fillID) {
+			continue
+		}
+		if latest, exists := latestCursors[symbol]; !exists || latest.IsAfter(fillTimeMs, fillID) {
+			latestCursors[symbol] = storage.SyncCursor{Symbol: symbol, LastTradeTime: fillTimeMs, LastTradeID: fillID}
+		}
+		newFillCount++
+		
 		// 解析orderId（订单ID，不是成交ID）
 		var orderId float64
 		var orderIdOK bool
@@ -4412,6 +5616,19 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 		}
 	}
 	
+	if newFillCount == 0 {
+		log.Println("✅ 交易所没有新的交易记录")
+		return nil
+	}
+	
+	// 保存失败的币种及其最早失败成交时间（这些币种不推进游标，下次同步重试）
+	failedSymbols := make(map[string]time.Time)
+	markSyncFailed := func(symbol string, fillTime time.Time) {
+		if earliest, exists := failedSymbols[symbol]; !exists || fillTime.Before(earliest) {
+			failedSymbols[symbol] = fillTime
+		}
+	}
+	
 	// 将聚合后的订单转换为交易记录
 	var missingTrades []*storage.TradeRecord
 	for _, agg := range orderMap {
@@ -4424,45 +5641,16 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 		var openTime time.Time
 		
 		// 尝试从交易所历史中查找对应的开仓交易（优先使用交易所数据，更准确）
-		// 查找方向相反且realizedPnl为0的交易（开仓），且时间早于平仓时间
-		var bestOpenTrade map[string]interface{}
-		var bestOpenTime time.Time
-		for _, potentialOpenTrade := range accountTrades {
-			openTradeSymbol, ok := potentialOpenTrade["symbol"].(string)
-			if !ok || openTradeSymbol != agg.symbol {
-				continue
-			}
-			
-			openTradeSide, _ := potentialOpenTrade["side"].(string)
-			openTradeRealizedPnlStr, _ := potentialOpenTrade["realizedPnl"].(string)
-			openTradeRealizedPnlVal, _ := strconv.ParseFloat(openTradeRealizedPnlStr, 64)
-			openTradeTimeMs, ok := potentialOpenTrade["time"].(float64)
-			if !ok {
-				if t, ok := potentialOpenTrade["timestamp"].(float64); ok {
-					openTradeTimeMs = t
-				} else {
-					continue
-				}
-			}
-			// 自动检测时间戳是秒还是毫秒
-			var openTradeTime time.Time
-			if openTradeTimeMs < 1e12 {
-				openTradeTime = time.Unix(int64(openTradeTimeMs), 0)
+		// 查找方向相反且realizedPnl为0的交易（开仓），且时间早于平仓时间（使用lastTime作为平仓时间）
+		bestOpenTrade, bestOpenTime := findOpenFill(accountTrades, agg.symbol, agg.tradeSide, agg.lastTime)
+		
+		// 增量拉取只包含游标之后的成交，开仓成交可能更早：按币种回溯最近7天查找
+		if bestOpenTrade == nil {
+			lookbackTrades, err := at.trader.GetAccountTrades(agg.symbol, agg.lastTime.AddDate(0, 0, -7), agg.lastTime, syncFetchLimit)
+			if err != nil {
+				log.Printf("⚠️  回溯获取 %s 开仓成交失败: %v", agg.symbol, err)
 			} else {
-				openTradeTime = time.UnixMilli(int64(openTradeTimeMs))
-			}
-			
-			// 开仓交易：方向相反、realizedPnl为0、时间早于平仓时间
-			isOppositeSide := (agg.tradeSide == "long" && strings.ToUpper(openTradeSide) == "BUY") ||
-				(agg.tradeSide == "short" && strings.ToUpper(openTradeSide) == "SELL")
-			
-			// 找到符合条件的开仓交易，且时间早于平仓时间（使用lastTime作为平仓时间）
-			if isOppositeSide && openTradeRealizedPnlVal == 0 && openTradeTime.Before(agg.lastTime) {
-				// 选择最接近平仓时间的开仓交易（时间最大的，但早于平仓时间）
-				if bestOpenTrade == nil || openTradeTime.After(bestOpenTime) {
-					bestOpenTrade = potentialOpenTrade
-					bestOpenTime = openTradeTime
-				}
+				bestOpenTrade, bestOpenTime = findOpenFill(lookbackTrades, agg.symbol, agg.tradeSide, agg.lastTime)
 			}
 		}
 		
@@ -4485,9 +5673,9 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 			positions, err := at.trader.GetPositions()
 			if err == nil {
 				for _, pos := range positions {
-					if posSymbol, ok := pos["symbol"].(string); ok && posSymbol == agg.symbol {
-						if posSide, ok := pos["side"].(string); ok && posSide == agg.tradeSide {
-							if lev, ok := pos["leverage"].(float64); ok {
+					if posSymbol, ok := getString(pos, "symbol"); ok && posSymbol == agg.symbol {
+						if posSide, ok := getString(pos, "side"); ok && posSide == agg.tradeSide {
+							if lev, ok := getFloat(pos, "leverage"); ok {
 								openLeverage = int(lev)
 								break
 							}
@@ -4516,13 +5704,9 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 				}
 			}
 			
-			// 如果还是获取不到，使用配置的杠杆（根据币种类型）
+			// 如果还是获取不到，使用配置的杠杆（单币种配置优先，其次根据币种类型）
 			if openLeverage == 0 {
-				if agg.symbol == "BTCUSDT" || agg.symbol == "ETHUSDT" {
-					openLeverage = at.config.BTCETHLeverage
-				} else {
-					openLeverage = at.config.AltcoinLeverage
-				}
+				openLeverage = decision.LeverageForSymbol(agg.symbol, at.getConfig().BTCETHLeverage, at.getConfig().AltcoinLeverage, at.getConfig().SymbolLeverageOverrides)
 				log.Printf("⚠️  无法获取 %s %s 的实际杠杆，使用配置的杠杆: %dx", 
 					agg.symbol, agg.tradeSide, openLeverage)
 			}
@@ -4584,8 +5768,8 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 		// 计算持仓时长
 		duration := agg.lastTime.Sub(openTime)
 		
-		// 使用聚合后的盈亏
-		calculatedPnL := agg.totalRealizedPnl
+		// 使用聚合后的盈亏（交易所realizedPnl不含手续费，与buildTradeRecord一样扣除估算的开平仓手续费）
+		calculatedPnL := agg.totalRealizedPnl - at.estimateTradingFees(agg.totalQty, openPrice, agg.weightedPrice)
 		
 		// 计算持仓价值和保证金
 		positionValue := openQuantity * openPrice
@@ -4691,6 +5875,7 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 					
 					if err := tradeStorage.UpdateTrade(updateTrade); err != nil {
 						log.Printf("⚠️  更新交易记录失败: %v, ID: %s", err, existingTrade.TradeID)
+						markSyncFailed(agg.symbol, agg.firstTime)
 					} else {
 						log.Printf("✅ 已更新交易记录（从交易所同步平仓信息）: %s - %s, 盈亏: %.2f USDT (%.2f%%)", 
 							agg.symbol, agg.tradeSide, calculatedPnL, pnlPct)
@@ -4745,6 +5930,9 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 	for _, trade := range missingTrades {
 		if err := tradeStorage.LogTrade(trade); err != nil {
 			log.Printf("⚠️  保存缺失交易记录失败: %v, ID: %s", err, trade.TradeID)
+			if agg, exists := orderMap[trade.CloseOrderID]; exists {
+				markSyncFailed(agg.symbol, agg.firstTime)
+			}
 			continue
 		}
 		syncedCount++
@@ -4752,6 +5940,34 @@ func (at *AutoTrader) SyncManualTradesFromExchange() error {
 	}
 	
 	log.Printf("✅ 交易同步完成: 找到 %d 个缺失交易，成功同步 %d 个", len(missingTrades), syncedCount)
+	
+	// 推进同步游标：成功的币种推进到本次拉取的最新成交；失败的币种保持原游标
+	var globalCursor storage.SyncCursor
+	for symbol, latest := range latestCursors {
+		if globalCursor.Symbol == "" || globalCursor.IsAfter(latest.LastTradeTime, latest.LastTradeID) {
+			globalCursor = latest
+		}
+		if _, failed := failedSymbols[symbol]; failed {
+			continue
+		}
+		if err := tradeStorage.SaveSyncCursor(latest); err != nil {
+			log.Printf("⚠️  保存 %s 同步游标失败: %v", symbol, err)
+		}
+	}
+	// 全币种拉取游标不能越过最早的失败成交，否则下次拉取会漏掉需要重试的成交
+	for _, failedTime := range failedSymbols {
+		if failedMs := failedTime.UnixMilli() - 1; failedMs < globalCursor.LastTradeTime {
+			globalCursor.LastTradeTime = failedMs
+			globalCursor.LastTradeID = 0
+		}
+	}
+	globalCursor.Symbol = storage.SyncCursorAllSymbols
+	if err := tradeStorage.SaveSyncCursor(globalCursor); err != nil {
+		log.Printf("⚠️  保存全币种同步游标失败: %v", err)
+	}
+	if len(failedSymbols) > 0 {
+		log.Printf("⚠️  %d 个币种同步失败，游标未推进，下次同步将重试", len(failedSymbols))
+	}
 	return nil
 }
 
@@ -4824,17 +6040,12 @@ func (at *AutoTrader) getEntryInfoFromHistory(symbol, side string) (float64, flo
 // getLatestClosePrice 获取最近的平仓价格
 func (at *AutoTrader) getLatestClosePrice(symbol, side string) (float64, error) {
 	// 尝试从交易所直接获取最近的交易信息
-	// 检查trader是否支持GetAccountTrades方法
-	asterTrader, ok := at.trader.(*AsterTrader)
-	if !ok {
-		return 0, fmt.Errorf("当前交易器不支持获取交易历史功能")
-	}
 	
 	// 获取最近24小时的交易历史
 	endTime := time.Now()
 	startTime := endTime.Add(-24 * time.Hour) // 最近24小时
 	
-	accountTrades, err := asterTrader.GetAccountTrades(symbol, startTime, endTime, 100)
+	accountTrades, err := at.trader.GetAccountTrades(symbol, startTime, endTime, 100)
 	if err != nil {
 		return 0, fmt.Errorf("获取交易所交易历史失败: %w", err)
 	}
@@ -4915,12 +6126,6 @@ func (at *AutoTrader) getLatestClosePrice(symbol, side string) (float64, error)
 
 // getRealizedPnlFromExchange 从交易所获取订单的已实现盈亏（已扣除手续费）
 func (at *AutoTrader) getRealizedPnlFromExchange(symbol string, orderID int64, closeTime time.Time) (float64, error) {
-	// 检查trader是否支持GetAccountTrades方法
-	asterTrader, ok := at.trader.(*AsterTrader)
-	if !ok {
-		return 0, fmt.Errorf("当前交易器不支持获取交易历史功能")
-	}
-	
 	// 等待一小段时间，确保订单已处理完成
 	time.Sleep(2 * time.Second)
 	
@@ -4928,7 +6133,7 @@ func (at *AutoTrader) getRealizedPnlFromExchange(symbol string, orderID int64, c
 	startTime := closeTime.Add(-5 * time.Minute)
 	endTime := closeTime.Add(5 * time.Minute)
 	
-	accountTrades, err := asterTrader.GetAccountTrades(symbol, startTime, endTime, 100)
+	accountTrades, err := at.trader.GetAccountTrades(symbol, startTime, endTime, 100)
 	if err != nil {
 		return 0, fmt.Errorf("获取交易所交易历史失败: %w", err)
 	}
@@ -0,0 +1,114 @@
+package trader
+
+import (
+	"testing"
+
+	"backend/pkg/decision"
+)
+
+func TestDeduplicateDecisions(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []decision.Decision
+		want  []decision.Decision
+	}{
+		{
+			name:  "empty",
+			input: nil,
+			want:  nil,
+		},
+		{
+			name: "duplicate open keeps the last one",
+			input: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "open_long", PositionSizeUSD: 100},
+				{Symbol: "BTCUSDT", Action: "open_long", PositionSizeUSD: 200},
+			},
+			want: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "open_long", PositionSizeUSD: 200},
+			},
+		},
+		{
+			name: "duplicate update_sl and update_tp keep the last one per symbol",
+			input: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "update_sl", StopLoss: 59000},
+				{Symbol: "ETHUSDT", Action: "update_sl", StopLoss: 2900},
+				{Symbol: "BTCUSDT", Action: "update_tp", TakeProfit: 70000},
+				{Symbol: "BTCUSDT", Action: "update_sl", StopLoss: 59500},
+				{Symbol: "BTCUSDT", Action: "update_tp", TakeProfit: 71000},
+			},
+			want: []decision.Decision{
+				{Symbol: "ETHUSDT", Action: "update_sl", StopLoss: 2900},
+				{Symbol: "BTCUSDT", Action: "update_sl", StopLoss: 59500},
+				{Symbol: "BTCUSDT", Action: "update_tp", TakeProfit: 71000},
+			},
+		},
+		{
+			name: "same action on different symbols is kept",
+			input: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "open_short"},
+				{Symbol: "ETHUSDT", Action: "open_short"},
+			},
+			want: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "open_short"},
+				{Symbol: "ETHUSDT", Action: "open_short"},
+			},
+		},
+		{
+			name: "close and reopen of the same symbol are both kept in order",
+			input: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "close_long"},
+				{Symbol: "BTCUSDT", Action: "open_long"},
+				{Symbol: "BTCUSDT", Action: "open_long", Leverage: 3},
+			},
+			want: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "close_long"},
+				{Symbol: "BTCUSDT", Action: "open_long", Leverage: 3},
+			},
+		},
+		{
+			name: "opposite opens and closes of the same symbol are not merged",
+			input: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "close_short"},
+				{Symbol: "BTCUSDT", Action: "open_long"},
+				{Symbol: "BTCUSDT", Action: "open_short"},
+			},
+			want: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "close_short"},
+				{Symbol: "BTCUSDT", Action: "open_long"},
+				{Symbol: "BTCUSDT", Action: "open_short"},
+			},
+		},
+		{
+			name: "duplicate closes and holds are not merged",
+			input: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "close_long", ClosePercent: 50},
+				{Symbol: "BTCUSDT", Action: "close_long", ClosePercent: 50},
+				{Symbol: "ETHUSDT", Action: "hold"},
+				{Symbol: "ETHUSDT", Action: "hold"},
+			},
+			want: []decision.Decision{
+				{Symbol: "BTCUSDT", Action: "close_long", ClosePercent: 50},
+				{Symbol: "BTCUSDT", Action: "close_long", ClosePercent: 50},
+				{Symbol: "ETHUSDT", Action: "hold"},
+				{Symbol: "ETHUSDT", Action: "hold"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := deduplicateDecisions(tt.input)
+			if len(got) != len(tt.want) {
+				t.Fatalf("deduplicateDecisions() returned %d decisions, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range got {
+				g, w := got[i], tt.want[i]
+				if g.Symbol != w.Symbol || g.Action != w.Action || g.PositionSizeUSD != w.PositionSizeUSD ||
+					g.StopLoss != w.StopLoss || g.TakeProfit != w.TakeProfit || g.Leverage != w.Leverage ||
+					g.ClosePercent != w.ClosePercent {
+					t.Errorf("decision[%d] = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
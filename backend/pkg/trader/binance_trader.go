@@ -0,0 +1,788 @@
+package trader
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BinanceTrader Binance U本位合约交易实现（单向持仓模式）
+type BinanceTrader struct {
+	apiKey    string
+	secretKey string
+	client    *http.Client
+	baseURL   string
+
+	// 缓存交易对精度信息
+	symbolPrecision map[string]SymbolPrecision
+	mu              sync.RWMutex
+
+	// 精度缓存过期时间（24小时）
+	precisionCacheTTL time.Duration
+}
+
+// NewBinanceTrader 创建Binance合约交易器
+// apiKey/secretKey: Binance API密钥（需开启合约交易权限）
+func NewBinanceTrader(apiKey, secretKey string) (*BinanceTrader, error) {
+	if apiKey == "" || secretKey == "" {
+		return nil, fmt.Errorf("Binance API Key和Secret Key不能为空")
+	}
+
+	return &BinanceTrader{
+		apiKey:            apiKey,
+		secretKey:         secretKey,
+		symbolPrecision:   make(map[string]SymbolPrecision),
+		precisionCacheTTL: 24 * time.Hour, // 精度信息缓存24小时
+		client: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSHandshakeTimeout:   10 * time.Second,
+				ResponseHeaderTimeout: 10 * time.Second,
+				IdleConnTimeout:       90 * time.Second,
+			},
+		},
+		baseURL: "https://fapi.binance.com",
+	}, nil
+}
+
+// getPrecision 获取交易对精度信息（带缓存过期机制）
+func (t *BinanceTrader) getPrecision(symbol string) (SymbolPrecision, error) {
+	t.mu.RLock()
+	if prec, ok := t.symbolPrecision[symbol]; ok && time.Since(prec.LastUpdated) < t.precisionCacheTTL {
+		t.mu.RUnlock()
+		return prec, nil
+	}
+	t.mu.RUnlock()
+
+	// 获取交易所信息
+	resp, err := t.client.Get(t.baseURL + "/fapi/v1/exchangeInfo")
+	if err != nil {
+		return SymbolPrecision{}, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return SymbolPrecision{}, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var info struct {
+		Symbols []struct {
+			Symbol            string                   `json:"symbol"`
+			PricePrecision    int                      `json:"pricePrecision"`
+			QuantityPrecision int                      `json:"quantityPrecision"`
+			Filters           []map[string]interface{} `json:"filters"`
+		} `json:"symbols"`
+	}
+	if err := json.Unmarshal(body, &info); err != nil {
+		return SymbolPrecision{}, err
+	}
+
+	// 缓存所有交易对的精度（带时间戳）
+	now := time.Now()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, s := range info.Symbols {
+		prec := SymbolPrecision{
+			PricePrecision:    s.PricePrecision,
+			QuantityPrecision: s.QuantityPrecision,
+			LastUpdated:       now,
+		}
+
+		// 解析filters获取tickSize、stepSize和最小下单量
+		parseSymbolFilters(&prec, s.Filters)
+
+		t.symbolPrecision[s.Symbol] = prec
+	}
+
+	if prec, ok := t.symbolPrecision[symbol]; ok {
+		return prec, nil
+	}
+	return SymbolPrecision{}, fmt.Errorf("未找到交易对 %s 的精度信息", symbol)
+}
+
+// formatPriceString 格式化价格到tick size并转换为字符串
+func (t *BinanceTrader) formatPriceString(symbol string, price float64) (string, error) {
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return "", err
+	}
+
+	if prec.TickSize > 0 {
+		price = roundToTickSize(price, prec.TickSize)
+	} else {
+		multiplier := math.Pow10(prec.PricePrecision)
+		price = math.Round(price*multiplier) / multiplier
+	}
+	return strconv.FormatFloat(price, 'f', priceDecimals(prec), 64), nil
+}
+
+// formatQuantityString 格式化数量到step size并转换为字符串
+// 数量向下取整到step size，避免超过持仓数量（reduceOnly订单会被拒绝）
+func (t *BinanceTrader) formatQuantityString(symbol string, quantity float64) (string, error) {
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return "", err
+	}
+
+	if prec.StepSize > 0 {
+		// 加一个极小值避免浮点误差导致少取一个step
+		quantity = math.Floor(quantity/prec.StepSize+1e-9) * prec.StepSize
+	} else {
+		multiplier := math.Pow10(prec.QuantityPrecision)
+		quantity = math.Floor(quantity*multiplier+1e-9) / multiplier
+	}
+	return strconv.FormatFloat(quantity, 'f', prec.QuantityPrecision, 64), nil
+}
+
+// sign 对查询字符串进行HMAC-SHA256签名
+func (t *BinanceTrader) sign(query string) string {
+	mac := hmac.New(sha256.New, []byte(t.secretKey))
+	mac.Write([]byte(query))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// request 发送签名请求（带重试机制，网络超时或临时错误时重试）
+// 只重试查询（GET）和撤单（DELETE）：下单等POST请求超时后订单可能已被交易所接受，重试会重复下单
+func (t *BinanceTrader) request(method, endpoint string, params map[string]string) ([]byte, error) {
+	const maxRetries = 3
+	var lastErr error
+	idempotent := strings.EqualFold(method, "GET") || strings.EqualFold(method, "DELETE")
+
+	for attempt := 1; attempt <= maxRetries; attempt++ {
+		// 每次重试都重新生成时间戳和签名
+		body, err := t.doRequest(method, endpoint, params)
+		if err == nil {
+			return body, nil
+		}
+
+		lastErr = err
+		if idempotent && (strings.Contains(err.Error(), "timeout") ||
+			strings.Contains(err.Error(), "connection reset") ||
+			strings.Contains(err.Error(), "EOF")) {
+			if attempt < maxRetries {
+				time.Sleep(time.Duration(attempt) * time.Second)
+				continue
+			}
+		}
+
+		// 其他错误（如400/401等）和非幂等请求不重试
+		return nil, err
+	}
+
+	return nil, fmt.Errorf("请求失败（已重试%d次）: %w", maxRetries, lastErr)
+}
+
+// doRequest 执行实际的HTTP请求
+// Binance签名：对完整的查询字符串（含timestamp、recvWindow）做HMAC-SHA256，API Key放在X-MBX-APIKEY请求头
+func (t *BinanceTrader) doRequest(method, endpoint string, params map[string]string) ([]byte, error) {
+	method = strings.ToUpper(method)
+
+	values := url.Values{}
+	for k, v := range params {
+		values.Set(k, v)
+	}
+	values.Set("recvWindow", "5000")
+	values.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+	query := values.Encode()
+	signedQuery := query + "&signature=" + t.sign(query)
+
+	var req *http.Request
+	var err error
+	switch method {
+	case "POST":
+		// POST请求：参数放在表单body中
+		req, err = http.NewRequest(method, t.baseURL+endpoint, strings.NewReader(signedQuery))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	case "GET", "DELETE":
+		// GET/DELETE请求：参数放在querystring中
+		req, err = http.NewRequest(method, t.baseURL+endpoint+"?"+signedQuery, nil)
+	default:
+		return nil, fmt.Errorf("不支持的HTTP方法: %s", method)
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-MBX-APIKEY", t.apiKey)
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return body, nil
+}
+
+// GetBalance 获取账户余额
+func (t *BinanceTrader) GetBalance() (map[string]interface{}, error) {
+	body, err := t.request("GET", "/fapi/v2/balance", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var balances []map[string]interface{}
+	if err := json.Unmarshal(body, &balances); err != nil {
+		return nil, err
+	}
+
+	// 查找USDT余额
+	totalBalance := 0.0
+	availableBalance := 0.0
+	crossUnPnl := 0.0
+	for _, bal := range balances {
+		if asset, ok := bal["asset"].(string); ok && asset == "USDT" {
+			if wb, ok := bal["balance"].(string); ok {
+				totalBalance, _ = strconv.ParseFloat(wb, 64)
+			}
+			if avail, ok := bal["availableBalance"].(string); ok {
+				availableBalance, _ = strconv.ParseFloat(avail, 64)
+			}
+			if unpnl, ok := bal["crossUnPnl"].(string); ok {
+				crossUnPnl, _ = strconv.ParseFloat(unpnl, 64)
+			}
+			break
+		}
+	}
+
+	// 返回标准字段名，确保AutoTrader能正确解析
+	return map[string]interface{}{
+		"totalWalletBalance":    totalBalance,
+		"availableBalance":      availableBalance,
+		"totalUnrealizedProfit": crossUnPnl,
+	}, nil
+}
+
+// GetPositions 获取持仓信息
+func (t *BinanceTrader) GetPositions() ([]map[string]interface{}, error) {
+	body, err := t.request("GET", "/fapi/v2/positionRisk", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var positions []map[string]interface{}
+	if err := json.Unmarshal(body, &positions); err != nil {
+		return nil, err
+	}
+
+	parse := func(pos map[string]interface{}, key string) float64 {
+		s, _ := pos[key].(string)
+		v, _ := strconv.ParseFloat(s, 64)
+		return v
+	}
+
+	result := []map[string]interface{}{}
+	for _, pos := range positions {
+		posAmt := parse(pos, "positionAmt")
+		if posAmt == 0 {
+			continue // 跳过空仓位
+		}
+
+		// 判断方向
+		side := "long"
+		if posAmt < 0 {
+			side = "short"
+			posAmt = -posAmt
+		}
+
+		// 返回标准字段名（与AsterTrader一致）
+		result = append(result, map[string]interface{}{
+			"symbol":           pos["symbol"],
+			"side":             side,
+			"positionAmt":      posAmt,
+			"entryPrice":       parse(pos, "entryPrice"),
+			"markPrice":        parse(pos, "markPrice"),
+			"unRealizedProfit": parse(pos, "unRealizedProfit"),
+			"leverage":         parse(pos, "leverage"),
+			"liquidationPrice": parse(pos, "liquidationPrice"),
+		})
+	}
+
+	return result, nil
+}
+
+// placeMarketOrder 下市价单（reduceOnly为true时只减仓，用于平仓）
+func (t *BinanceTrader) placeMarketOrder(symbol, side string, quantity float64, reduceOnly bool) (map[string]interface{}, error) {
+	qtyStr, err := t.formatQuantityString(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if qty, _ := strconv.ParseFloat(qtyStr, 64); qty <= 0 {
+		return nil, fmt.Errorf("下单数量过小: %.8f -> %s", quantity, qtyStr)
+	}
+
+	params := map[string]string{
+		"symbol":   symbol,
+		"side":     side,
+		"type":     "MARKET",
+		"quantity": qtyStr,
+	}
+	if reduceOnly {
+		params["reduceOnly"] = "true"
+	}
+
+	body, err := t.request("POST", "/fapi/v1/order", params)
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// OpenLong 开多单（市价）
+func (t *BinanceTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
+	}
+
+	// 先设置杠杆
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	return t.placeMarketOrder(symbol, "BUY", quantity, false)
+}
+
+// OpenShort 开空单（市价）
+func (t *BinanceTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
+	}
+
+	// 先设置杠杆
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	return t.placeMarketOrder(symbol, "SELL", quantity, false)
+}
+
+// placeOpenLimitOrder 下限价开仓单（GTC）
+func (t *BinanceTrader) placeOpenLimitOrder(symbol, side string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	// 开仓前先取消所有挂单,防止残留挂单导致仓位叠加
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消挂单失败(继续开仓): %v", err)
+	}
+
+	// 先设置杠杆
+	if err := t.SetLeverage(symbol, leverage); err != nil {
+		return nil, fmt.Errorf("设置杠杆失败: %w", err)
+	}
+
+	qtyStr, err := t.formatQuantityString(symbol, quantity)
+	if err != nil {
+		return nil, err
+	}
+	if qty, _ := strconv.ParseFloat(qtyStr, 64); qty <= 0 {
+		return nil, fmt.Errorf("下单数量过小: %.8f -> %s", quantity, qtyStr)
+	}
+	priceStr, err := t.formatPriceString(symbol, price)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := t.request("POST", "/fapi/v1/order", map[string]string{
+		"symbol":      symbol,
+		"side":        side,
+		"type":        "LIMIT",
+		"timeInForce": "GTC",
+		"quantity":    qtyStr,
+		"price":       priceStr,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// OpenLongLimit 限价开多单
+func (t *BinanceTrader) OpenLongLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	return t.placeOpenLimitOrder(symbol, "BUY", quantity, price, leverage)
+}
+
+// OpenShortLimit 限价开空单
+func (t *BinanceTrader) OpenShortLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	return t.placeOpenLimitOrder(symbol, "SELL", quantity, price, leverage)
+}
+
+// positionQuantity 获取指定方向的持仓数量（未找到返回0）
+func (t *BinanceTrader) positionQuantity(symbol, side string) (float64, error) {
+	positions, err := t.GetPositions()
+	if err != nil {
+		return 0, err
+	}
+	for _, pos := range positions {
+		if pos["symbol"] == symbol && pos["side"] == side {
+			amt, ok := getFloat(pos, "positionAmt")
+			if !ok {
+				return 0, fmt.Errorf("%s 持仓的positionAmt字段缺失或无法解析: %v", symbol, pos["positionAmt"])
+			}
+			return amt, nil
+		}
+	}
+	return 0, nil
+}
+
+// CloseLong 平多单（市价，quantity=0表示全部平仓）
+func (t *BinanceTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	if quantity == 0 {
+		positionAmt, err := t.positionQuantity(symbol, "long")
+		if err != nil {
+			return nil, err
+		}
+		if positionAmt == 0 {
+			return nil, fmt.Errorf("没有找到 %s 的多仓", symbol)
+		}
+		quantity = positionAmt
+		log.Printf("  📊 获取到多仓数量: %.8f", quantity)
+	}
+
+	result, err := t.placeMarketOrder(symbol, "SELL", quantity, true)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("✓ 平多仓成功: %s 数量: %.8f", symbol, quantity)
+
+	// 平仓后取消该币种的所有挂单(止损止盈单)
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	}
+
+	return result, nil
+}
+
+// CloseShort 平空单（市价，quantity=0表示全部平仓）
+func (t *BinanceTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	if quantity == 0 {
+		positionAmt, err := t.positionQuantity(symbol, "short")
+		if err != nil {
+			return nil, err
+		}
+		if positionAmt == 0 {
+			return nil, fmt.Errorf("没有找到 %s 的空仓", symbol)
+		}
+		quantity = positionAmt
+		log.Printf("  📊 获取到空仓数量: %.8f", quantity)
+	}
+
+	result, err := t.placeMarketOrder(symbol, "BUY", quantity, true)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("✓ 平空仓成功: %s 数量: %.8f", symbol, quantity)
+
+	// 平仓后取消该币种的所有挂单(止损止盈单)
+	if err := t.CancelAllOrders(symbol); err != nil {
+		log.Printf("  ⚠ 取消挂单失败: %v", err)
+	}
+
+	return result, nil
+}
+
+// SetLeverage 设置杠杆倍数
+func (t *BinanceTrader) SetLeverage(symbol string, leverage int) error {
+	_, err := t.request("POST", "/fapi/v1/leverage", map[string]string{
+		"symbol":   symbol,
+		"leverage": strconv.Itoa(leverage),
+	})
+	return err
+}
+
+// GetMarketPrice 获取市场价格
+func (t *BinanceTrader) GetMarketPrice(symbol string) (float64, error) {
+	resp, err := t.client.Get(fmt.Sprintf("%s/fapi/v1/ticker/price?symbol=%s", t.baseURL, symbol))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result map[string]interface{}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return 0, err
+	}
+
+	priceStr, ok := result["price"].(string)
+	if !ok {
+		return 0, errors.New("无法获取价格")
+	}
+	return strconv.ParseFloat(priceStr, 64)
+}
+
+// placeTriggerOrder 下触发单（止损/止盈，按标记价格触发，只减仓）
+func (t *BinanceTrader) placeTriggerOrder(symbol, positionSide, orderType string, quantity, triggerPrice float64) error {
+	side := "SELL"
+	if positionSide == "SHORT" {
+		side = "BUY"
+	}
+
+	priceStr, err := t.formatPriceString(symbol, triggerPrice)
+	if err != nil {
+		return err
+	}
+	qtyStr, err := t.formatQuantityString(symbol, quantity)
+	if err != nil {
+		return err
+	}
+
+	_, err = t.request("POST", "/fapi/v1/order", map[string]string{
+		"symbol":      symbol,
+		"side":        side,
+		"type":        orderType,
+		"stopPrice":   priceStr,
+		"quantity":    qtyStr,
+		"reduceOnly":  "true",
+		"workingType": "MARK_PRICE",
+		"timeInForce": "GTC",
+	})
+	return err
+}
+
+// SetStopLoss 设置止损
+func (t *BinanceTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return t.placeTriggerOrder(symbol, positionSide, "STOP_MARKET", quantity, stopPrice)
+}
+
+// SetTakeProfit 设置止盈
+func (t *BinanceTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return t.placeTriggerOrder(symbol, positionSide, "TAKE_PROFIT_MARKET", quantity, takeProfitPrice)
+}
+
+// SetBracket 设置止损止盈（实现Trader接口）
+// Binance合约不支持OCO联动订单，退化为两个独立订单（止损优先）
+func (t *BinanceTrader) SetBracket(symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error {
+	return setBracketWithSeparateOrders(t, symbol, positionSide, quantity, stopPrice, takeProfitPrice)
+}
+
+// CancelAllOrders 取消所有订单
+func (t *BinanceTrader) CancelAllOrders(symbol string) error {
+	_, err := t.request("DELETE", "/fapi/v1/allOpenOrders", map[string]string{
+		"symbol": symbol,
+	})
+	return err
+}
+
+// GetOpenOrders 获取当前挂单（symbol为""时获取所有交易对）
+func (t *BinanceTrader) GetOpenOrders(symbol string) ([]map[string]interface{}, error) {
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+
+	body, err := t.request("GET", "/fapi/v1/openOrders", params)
+	if err != nil {
+		return nil, fmt.Errorf("获取挂单失败: %w", err)
+	}
+
+	var orders []map[string]interface{}
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("解析挂单失败: %w", err)
+	}
+	return orders, nil
+}
+
+// CancelOrder 取消指定订单
+func (t *BinanceTrader) CancelOrder(symbol string, orderID int64) error {
+	_, err := t.request("DELETE", "/fapi/v1/order", map[string]string{
+		"symbol":  symbol,
+		"orderId": strconv.FormatInt(orderID, 10),
+	})
+	return err
+}
+
+// GetOrder 查询指定订单
+func (t *BinanceTrader) GetOrder(symbol string, orderID int64) (map[string]interface{}, error) {
+	body, err := t.request("GET", "/fapi/v1/order", map[string]string{
+		"symbol":  symbol,
+		"orderId": strconv.FormatInt(orderID, 10),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+
+	var order map[string]interface{}
+	if err := json.Unmarshal(body, &order); err != nil {
+		return nil, fmt.Errorf("解析订单失败: %w", err)
+	}
+	return order, nil
+}
+
+// GetForceOrders 获取强平订单历史（symbol为""时获取所有交易对，startTime为零值时由交易所按默认范围返回）
+func (t *BinanceTrader) GetForceOrders(symbol string, startTime time.Time) ([]map[string]interface{}, error) {
+	params := map[string]string{
+		"limit": "100",
+	}
+	if symbol != "" {
+		params["symbol"] = symbol
+	}
+	if !startTime.IsZero() {
+		params["startTime"] = strconv.FormatInt(startTime.UnixMilli(), 10)
+	}
+
+	body, err := t.request("GET", "/fapi/v1/forceOrders", params)
+	if err != nil {
+		return nil, fmt.Errorf("获取强平订单失败: %w", err)
+	}
+
+	var orders []map[string]interface{}
+	if err := json.Unmarshal(body, &orders); err != nil {
+		return nil, fmt.Errorf("解析强平订单失败: %w", err)
+	}
+	return orders, nil
+}
+
+// FormatQuantity 格式化数量（实现Trader接口）
+func (t *BinanceTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return t.formatQuantityString(symbol, quantity)
+}
+
+// FormatPrice 格式化价格到tick size并转换为下单用的字符串（实现Trader接口）
+func (t *BinanceTrader) FormatPrice(symbol string, price float64) (string, error) {
+	return t.formatPriceString(symbol, price)
+}
+
+// GetSymbolStatus 获取交易对当前的交易状态（实现Trader接口）
+func (t *BinanceTrader) GetSymbolStatus(symbol string) (string, error) {
+	return fetchSymbolStatus(t.client, t.baseURL+"/fapi/v1/exchangeInfo", symbol)
+}
+
+// GetSymbolFilters 获取交易对的最小下单金额、最小数量、数量和价格步进值（实现Trader接口，exchangeInfo带缓存）
+func (t *BinanceTrader) GetSymbolFilters(symbol string) (minNotional, minQty, stepSize, tickSize float64, err error) {
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return prec.MinNotional, prec.MinQty, prec.StepSize, prec.TickSize, nil
+}
+
+// GetAccountTrades 获取账户交易历史
+// Binance的userTrades接口必须指定symbol：symbol为""时先从资金流水（手续费记录，开平仓都会产生）中找出时间范围内有成交的交易对，
+// 再逐个查询后按时间合并（超过limit时保留最早的limit笔，与单个交易对查询的顺序一致）。startTime与endTime间隔不超过7天
+func (t *BinanceTrader) GetAccountTrades(symbol string, startTime, endTime time.Time, limit int) ([]map[string]interface{}, error) {
+	if symbol != "" {
+		return t.getSymbolTrades(symbol, startTime, endTime, limit)
+	}
+
+	symbols, err := t.tradedSymbols(startTime, endTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var trades []map[string]interface{}
+	for _, s := range symbols {
+		symbolTrades, err := t.getSymbolTrades(s, startTime, endTime, limit)
+		if err != nil {
+			return nil, err
+		}
+		trades = append(trades, symbolTrades...)
+	}
+	sort.SliceStable(trades, func(i, j int) bool {
+		ti, _ := getFloat(trades[i], "time")
+		tj, _ := getFloat(trades[j], "time")
+		return ti < tj
+	})
+	if limit > 0 && len(trades) > limit {
+		trades = trades[:limit]
+	}
+	return trades, nil
+}
+
+// tradedSymbols 从手续费资金流水中找出时间范围内有成交的交易对
+func (t *BinanceTrader) tradedSymbols(startTime, endTime time.Time) ([]string, error) {
+	params := map[string]string{
+		"incomeType": "COMMISSION",
+		"limit":      "1000",
+	}
+	if !startTime.IsZero() {
+		params["startTime"] = strconv.FormatInt(startTime.UnixMilli(), 10)
+	}
+	if !endTime.IsZero() {
+		params["endTime"] = strconv.FormatInt(endTime.UnixMilli(), 10)
+	}
+
+	body, err := t.request("GET", "/fapi/v1/income", params)
+	if err != nil {
+		return nil, fmt.Errorf("获取资金流水失败: %w", err)
+	}
+
+	var incomes []map[string]interface{}
+	if err := json.Unmarshal(body, &incomes); err != nil {
+		return nil, fmt.Errorf("解析资金流水失败: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var symbols []string
+	for _, income := range incomes {
+		if s, ok := getString(income, "symbol"); ok && s != "" && !seen[s] {
+			seen[s] = true
+			symbols = append(symbols, s)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols, nil
+}
+
+// getSymbolTrades 获取单个交易对的成交历史
+func (t *BinanceTrader) getSymbolTrades(symbol string, startTime, endTime time.Time, limit int) ([]map[string]interface{}, error) {
+	params := map[string]string{
+		"symbol": symbol,
+	}
+	if !startTime.IsZero() {
+		params["startTime"] = strconv.FormatInt(startTime.UnixMilli(), 10)
+	}
+	if !endTime.IsZero() {
+		params["endTime"] = strconv.FormatInt(endTime.UnixMilli(), 10)
+	}
+	if limit > 0 {
+		if limit > 1000 {
+			limit = 1000 // API limit
+		}
+		params["limit"] = strconv.Itoa(limit)
+	}
+
+	body, err := t.request("GET", "/fapi/v1/userTrades", params)
+	if err != nil {
+		return nil, fmt.Errorf("获取账户交易历史失败: %w", err)
+	}
+
+	var trades []map[string]interface{}
+	if err := json.Unmarshal(body, &trades); err != nil {
+		return nil, fmt.Errorf("解析账户交易历史失败: %w", err)
+	}
+
+	return trades, nil
+}
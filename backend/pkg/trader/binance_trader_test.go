@@ -0,0 +1,42 @@
+package trader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBinanceFormatPrice(t *testing.T) {
+	for _, tt := range formatPriceCases {
+		t.Run(tt.name, func(t *testing.T) {
+			trader := &BinanceTrader{
+				symbolPrecision: map[string]SymbolPrecision{
+					"BTCUSDT": {PricePrecision: 8, TickSize: tt.tickSize, LastUpdated: time.Now()},
+				},
+				precisionCacheTTL: time.Hour,
+			}
+			got, err := trader.FormatPrice("BTCUSDT", tt.price)
+			if err != nil {
+				t.Fatalf("FormatPrice() error = %v", err)
+			}
+			if got != tt.wantBinance {
+				t.Errorf("FormatPrice(%v) with tick %v = %q, want %q", tt.price, tt.tickSize, got, tt.wantBinance)
+			}
+		})
+	}
+}
+
+func TestBinanceFormatPriceWithoutTickSize(t *testing.T) {
+	trader := &BinanceTrader{
+		symbolPrecision: map[string]SymbolPrecision{
+			"BTCUSDT": {PricePrecision: 2, LastUpdated: time.Now()},
+		},
+		precisionCacheTTL: time.Hour,
+	}
+	got, err := trader.FormatPrice("BTCUSDT", 1.2345)
+	if err != nil {
+		t.Fatalf("FormatPrice() error = %v", err)
+	}
+	if got != "1.23" {
+		t.Errorf("FormatPrice() without tick size = %q, want 1.23 (pricePrecision)", got)
+	}
+}
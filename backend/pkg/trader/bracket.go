@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"fmt"
+	"strings"
+)
+
+// setBracketWithSeparateOrders 以两个独立订单设置止损止盈（不支持OCO时的退化实现）
+// 先设置止损再设置止盈，一边失败不影响另一边；任一边失败时返回错误，
+// 调用方如需"全有或全无"语义，应在失败后自行取消已生效的一边
+func setBracketWithSeparateOrders(t Trader, symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error {
+	var errs []string
+
+	if stopPrice > 0 {
+		if err := t.SetStopLoss(symbol, positionSide, quantity, stopPrice); err != nil {
+			errs = append(errs, fmt.Sprintf("设置止损失败: %v", err))
+		}
+	}
+
+	if takeProfitPrice > 0 {
+		if err := t.SetTakeProfit(symbol, positionSide, quantity, takeProfitPrice); err != nil {
+			errs = append(errs, fmt.Sprintf("设置止盈失败: %v", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// trackCycleResult 统计连续失败的决策周期，达到阈值时触发熔断：暂停交易一段时间并发送告警
+// 任一周期成功即重新计数；手动暂停跳过的周期不影响计数
+func (at *AutoTrader) trackCycleResult(err error) {
+	if at.IsPaused() {
+		return
+	}
+	if err == nil {
+		if at.consecutiveFailures > 0 {
+			log.Printf("✓ [%s] 决策周期恢复正常（此前连续失败 %d 次）", at.name, at.consecutiveFailures)
+		}
+		at.consecutiveFailures = 0
+		return
+	}
+
+	at.consecutiveFailures++
+	threshold := at.getConfig().CircuitBreakerFailures
+	if threshold <= 0 || at.consecutiveFailures < threshold {
+		return
+	}
+
+	backoff := at.getConfig().CircuitBreakerBackoff
+	at.riskMu.Lock()
+	at.stopUntil = time.Now().Add(backoff)
+	at.riskMu.Unlock()
+	at.consecutiveFailures = 0
+
+	at.eventLog.Warn(atomic.LoadInt64(&at.callCount), "", "risk_trigger",
+		fmt.Sprintf("🚨🚨🚨 [%s] 熔断触发：连续 %d 个决策周期失败，暂停交易 %.0f 分钟（最近错误: %v）",
+			at.name, threshold, backoff.Minutes(), err),
+		"reason", "circuit_breaker", "consecutive_failures", threshold, "error", err.Error())
+	at.notifier.NotifyRiskTrigger("连续失败熔断", fmt.Sprintf("连续%d个决策周期失败，暂停交易%.0f分钟\n最近错误: %v",
+		threshold, backoff.Minutes(), err))
+}
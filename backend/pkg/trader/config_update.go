@@ -0,0 +1,202 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// ConfigPatch 运行时可修改的配置项（字段为nil表示不修改）
+// 只包含扫描间隔、杠杆和风控参数，API密钥、交易所、AI模型等需要重新初始化的配置不支持运行时修改；
+// 修改只保存在内存中，重启后恢复为配置文件中的值
+type ConfigPatch struct {
+	ScanIntervalMinutes    *int     `json:"scan_interval_minutes,omitempty"`
+	BTCETHLeverage         *int     `json:"btc_eth_leverage,omitempty"`
+	AltcoinLeverage        *int     `json:"altcoin_leverage,omitempty"`
+	MaxDailyLoss           *float64 `json:"max_daily_loss,omitempty"`
+	MaxDrawdown            *float64 `json:"max_drawdown,omitempty"`
+	PositionStopLossPct    *float64 `json:"position_stop_loss_pct,omitempty"`
+	PositionTakeProfitPct  *float64 `json:"position_take_profit_pct,omitempty"`
+	PositionMaxLossUSD     *float64 `json:"position_max_loss_usd,omitempty"`
+	StopTradingMinutes     *int     `json:"stop_trading_minutes,omitempty"`
+	MaxOpenPositions       *int     `json:"max_open_positions,omitempty"`
+	ReentryCooldownMinutes *int     `json:"reentry_cooldown_minutes,omitempty"`
+	MaxHoldingHours        *float64 `json:"max_holding_hours,omitempty"`
+	MaxFundingRate         *float64 `json:"max_funding_rate,omitempty"`
+	RiskPerTradePct        *float64 `json:"risk_per_trade_pct,omitempty"`
+	MinRiskReward          *float64 `json:"min_risk_reward,omitempty"`
+}
+
+// validate 校验修改的值（范围与启动时的配置校验一致）
+func (p *ConfigPatch) validate() error {
+	var errs []string
+	checkInt := func(v *int, name string, min, max int) {
+		if v != nil && (*v < min || *v > max) {
+			errs = append(errs, fmt.Sprintf("%s必须在%d-%d之间", name, min, max))
+		}
+	}
+	checkFloat := func(v *float64, name string, min, max float64) {
+		if v != nil && (*v < min || *v > max) {
+			errs = append(errs, fmt.Sprintf("%s必须在%g-%g之间", name, min, max))
+		}
+	}
+	checkNonNegative := func(v interface{}, name string) {
+		switch n := v.(type) {
+		case *int:
+			if n != nil && *n < 0 {
+				errs = append(errs, name+"不能为负数")
+			}
+		case *float64:
+			if n != nil && *n < 0 {
+				errs = append(errs, name+"不能为负数")
+			}
+		}
+	}
+
+	checkInt(p.ScanIntervalMinutes, "scan_interval_minutes", 1, 60)
+	checkInt(p.BTCETHLeverage, "btc_eth_leverage", 1, 125)
+	checkInt(p.AltcoinLeverage, "altcoin_leverage", 1, 125)
+	checkFloat(p.MaxDailyLoss, "max_daily_loss", 0, 100)
+	checkFloat(p.MaxDrawdown, "max_drawdown", 0, 100)
+	checkFloat(p.PositionStopLossPct, "position_stop_loss_pct", 0, 100)
+	checkNonNegative(p.PositionTakeProfitPct, "position_take_profit_pct")
+	checkNonNegative(p.PositionMaxLossUSD, "position_max_loss_usd")
+	checkNonNegative(p.StopTradingMinutes, "stop_trading_minutes")
+	checkNonNegative(p.MaxOpenPositions, "max_open_positions")
+	checkNonNegative(p.ReentryCooldownMinutes, "reentry_cooldown_minutes")
+	checkNonNegative(p.MaxHoldingHours, "max_holding_hours")
+	checkFloat(p.RiskPerTradePct, "risk_per_trade_pct", 0, 10)
+	checkFloat(p.MinRiskReward, "min_risk_reward", 0, 20)
+	if p.MaxFundingRate != nil && (*p.MaxFundingRate < 0 || *p.MaxFundingRate >= 0.01) {
+		errs = append(errs, "max_funding_rate必须在0-0.01之间（小数形式，0表示不限制）")
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// isEmpty 是否没有任何需要修改的配置项
+func (p *ConfigPatch) isEmpty() bool {
+	return *p == ConfigPatch{}
+}
+
+// getConfig 获取当前配置的副本（运行时可能被UpdateConfig修改，读取时需加锁）
+func (at *AutoTrader) getConfig() AutoTraderConfig {
+	at.configMu.RLock()
+	defer at.configMu.RUnlock()
+	return at.config
+}
+
+// UpdateConfig 运行时修改扫描间隔、杠杆和风控参数（校验失败时不修改任何配置）
+// 扫描间隔变化时通知主循环按新间隔重设定时器，其余参数从下一次使用时生效
+func (at *AutoTrader) UpdateConfig(patch ConfigPatch) error {
+	if patch.isEmpty() {
+		return fmt.Errorf("没有需要修改的配置项")
+	}
+	if err := patch.validate(); err != nil {
+		return err
+	}
+
+	at.configMu.Lock()
+	cfg := at.config
+	if patch.ScanIntervalMinutes != nil {
+		cfg.ScanInterval = time.Duration(*patch.ScanIntervalMinutes) * time.Minute
+	}
+	if patch.BTCETHLeverage != nil {
+		cfg.BTCETHLeverage = *patch.BTCETHLeverage
+	}
+	if patch.AltcoinLeverage != nil {
+		cfg.AltcoinLeverage = *patch.AltcoinLeverage
+	}
+	if patch.MaxDailyLoss != nil {
+		cfg.MaxDailyLoss = *patch.MaxDailyLoss
+	}
+	if patch.MaxDrawdown != nil {
+		cfg.MaxDrawdown = *patch.MaxDrawdown
+	}
+	if patch.PositionStopLossPct != nil {
+		cfg.PositionStopLossPct = *patch.PositionStopLossPct
+	}
+	if patch.PositionTakeProfitPct != nil {
+		cfg.PositionTakeProfitPct = *patch.PositionTakeProfitPct
+	}
+	if patch.PositionMaxLossUSD != nil {
+		cfg.PositionMaxLossUSD = *patch.PositionMaxLossUSD
+	}
+	if patch.StopTradingMinutes != nil {
+		cfg.StopTradingTime = time.Duration(*patch.StopTradingMinutes) * time.Minute
+	}
+	if patch.MaxOpenPositions != nil {
+		cfg.MaxOpenPositions = *patch.MaxOpenPositions
+	}
+	if patch.ReentryCooldownMinutes != nil {
+		cfg.ReentryCooldown = time.Duration(*patch.ReentryCooldownMinutes) * time.Minute
+	}
+	if patch.MaxHoldingHours != nil {
+		cfg.MaxHoldingTime = time.Duration(*patch.MaxHoldingHours * float64(time.Hour))
+	}
+	if patch.MaxFundingRate != nil {
+		cfg.MaxFundingRate = *patch.MaxFundingRate
+	}
+	if patch.RiskPerTradePct != nil {
+		cfg.RiskPerTradePct = *patch.RiskPerTradePct
+	}
+	if patch.MinRiskReward != nil {
+		cfg.MinRiskReward = *patch.MinRiskReward
+	}
+	// 跨字段校验（与启动时的配置校验一致）：回撤减仓阈值必须低于最大回撤
+	if cfg.DeRiskDrawdown > 0 && cfg.MaxDrawdown > 0 && cfg.DeRiskDrawdown >= cfg.MaxDrawdown {
+		at.configMu.Unlock()
+		return fmt.Errorf("max_drawdown（%.2f）必须大于de_risk_drawdown（%.2f）", cfg.MaxDrawdown, cfg.DeRiskDrawdown)
+	}
+	scanIntervalChanged := cfg.ScanInterval != at.config.ScanInterval
+	at.config = cfg
+	at.configMu.Unlock()
+
+	if scanIntervalChanged {
+		select {
+		case at.configUpdated <- struct{}{}:
+		default:
+			// 已有未处理的通知，主循环重设定时器时会读取最新的间隔
+		}
+	}
+
+	log.Printf("⚙️  [%s] 运行时配置已更新: %s", at.name, at.GetRuntimeConfig().describe())
+	return nil
+}
+
+// GetRuntimeConfig 获取当前运行时可修改的配置项（用于API返回）
+func (at *AutoTrader) GetRuntimeConfig() ConfigPatch {
+	cfg := at.getConfig()
+	scanMinutes := int(cfg.ScanInterval / time.Minute)
+	stopTradingMinutes := int(cfg.StopTradingTime / time.Minute)
+	cooldownMinutes := int(cfg.ReentryCooldown / time.Minute)
+	holdingHours := cfg.MaxHoldingTime.Hours()
+	return ConfigPatch{
+		ScanIntervalMinutes:    &scanMinutes,
+		BTCETHLeverage:         &cfg.BTCETHLeverage,
+		AltcoinLeverage:        &cfg.AltcoinLeverage,
+		MaxDailyLoss:           &cfg.MaxDailyLoss,
+		MaxDrawdown:            &cfg.MaxDrawdown,
+		PositionStopLossPct:    &cfg.PositionStopLossPct,
+		PositionTakeProfitPct:  &cfg.PositionTakeProfitPct,
+		PositionMaxLossUSD:     &cfg.PositionMaxLossUSD,
+		StopTradingMinutes:     &stopTradingMinutes,
+		MaxOpenPositions:       &cfg.MaxOpenPositions,
+		ReentryCooldownMinutes: &cooldownMinutes,
+		MaxHoldingHours:        &holdingHours,
+		MaxFundingRate:         &cfg.MaxFundingRate,
+		RiskPerTradePct:        &cfg.RiskPerTradePct,
+		MinRiskReward:          &cfg.MinRiskReward,
+	}
+}
+
+// describe 格式化配置项（用于日志）
+func (p ConfigPatch) describe() string {
+	return fmt.Sprintf("扫描间隔=%d分钟 杠杆=%dx/%dx 日亏损=%.1f%% 回撤=%.1f%% 单仓止损=%.1f%% 单仓止盈=%.1f%% 最大持仓数=%d 单笔风险=%.2f%%",
+		*p.ScanIntervalMinutes, *p.BTCETHLeverage, *p.AltcoinLeverage, *p.MaxDailyLoss, *p.MaxDrawdown,
+		*p.PositionStopLossPct, *p.PositionTakeProfitPct, *p.MaxOpenPositions, *p.RiskPerTradePct)
+}
@@ -0,0 +1,42 @@
+package trader
+
+import (
+	"testing"
+)
+
+func TestUpdateConfigMaxDrawdownAboveDeRisk(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxDrawdown float64
+		wantErr     bool
+	}{
+		{"below de-risk", 8, true},
+		{"equal to de-risk", 10, true},
+		{"above de-risk", 15, false},
+		{"disabled", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := &AutoTrader{
+				name:          "test",
+				config:        AutoTraderConfig{MaxDrawdown: 20, DeRiskDrawdown: 10},
+				configUpdated: make(chan struct{}, 1),
+			}
+
+			maxDrawdown := tt.maxDrawdown
+			err := at.UpdateConfig(ConfigPatch{MaxDrawdown: &maxDrawdown})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateConfig(max_drawdown=%v) error = %v, wantErr %v", tt.maxDrawdown, err, tt.wantErr)
+			}
+
+			want := tt.maxDrawdown
+			if tt.wantErr {
+				want = 20 // 校验失败时不修改任何配置
+			}
+			if got := at.getConfig().MaxDrawdown; got != want {
+				t.Errorf("MaxDrawdown = %v, want %v", got, want)
+			}
+		})
+	}
+}
@@ -14,11 +14,31 @@ const (
 
 	// PositionStopLoss 单仓位止损相关
 	PositionStopLossRetryTimeout = 5 * time.Minute // 平仓失败后重试超时时间
+
+	// TrailingStop 移动止损相关
+	TrailingStopMinStepPct = 0.2 // 移动止损收紧幅度达到此百分比才更新交易所订单（避免频繁撤单挂单）
+
+	// Correlation 持仓相关性过滤相关（用1小时K线收盘价的对数收益率计算皮尔逊相关系数）
+	CorrelationTimeframe    = "1h" // 与决策上下文中复用的HourlyClosePrices一致
+	CorrelationLookbackBars = 100  // 约4天
+	CorrelationMinSamples   = 30   // 两个币种重叠的收益率样本少于该数量时不判断（新上市币种）
+
+	// OrphanOrderCheckInterval 孤立止损止盈单检查间隔（查询全部挂单权重较高，不随10秒止损检查执行）
+	OrphanOrderCheckInterval = 5 * time.Minute
+)
+
+// 健康检查相关常量
+const (
+	// HealthProbeTimeout 单个组件探测的超时时间（交易所余额查询、AI API探测、数据库写入）
+	HealthProbeTimeout = 10 * time.Second
 )
 
 // 交易相关常量
 const (
-	// MinPositionSizeUSD 最小仓位大小（USDT）
+	// MinPositionSizeUSD 最小仓位大小（USDT），仅在获取交易所的交易对限制失败时使用
 	MinPositionSizeUSD = 0.001
+
+	// DefaultCandidatePoolSize 默认每个周期分析的候选币种数量
+	DefaultCandidatePoolSize = 20
 )
 
@@ -0,0 +1,125 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"backend/pkg/market"
+)
+
+// correlationSkipReason 检查待开仓币种与已有同方向持仓的收益率相关性（开仓前检查）
+// 与任一同方向持仓的相关系数超过MaxCorrelation时返回跳过原因；未配置、无同方向持仓或K线获取失败时返回空字符串
+func (at *AutoTrader) correlationSkipReason(symbol, side string, positions []map[string]interface{}) string {
+	maxCorrelation := at.getConfig().MaxCorrelation
+	if maxCorrelation <= 0 {
+		return ""
+	}
+
+	var held []string
+	for _, pos := range positions {
+		posSymbol, symbolOK := getString(pos, "symbol")
+		posSide, sideOK := getString(pos, "side")
+		if !symbolOK || !sideOK {
+			log.Printf("  ⚠️  持仓字段无法解析，跳过该持仓的相关性检查: symbol=%v side=%v", pos["symbol"], pos["side"])
+			continue
+		}
+		if posSide == side && posSymbol != "" && posSymbol != symbol {
+			held = append(held, posSymbol)
+		}
+	}
+	if len(held) == 0 {
+		return ""
+	}
+
+	closes, err := at.correlationClosePrices(symbol)
+	if err != nil {
+		log.Printf("  ⚠️  获取 %s K线失败，跳过相关性检查: %v", symbol, err)
+		return ""
+	}
+	for _, heldSymbol := range held {
+		heldCloses, err := at.correlationClosePrices(heldSymbol)
+		if err != nil {
+			log.Printf("  ⚠️  获取 %s K线失败，跳过与其的相关性检查: %v", heldSymbol, err)
+			continue
+		}
+		correlation, ok := returnCorrelation(closes, heldCloses, CorrelationMinSamples)
+		if !ok {
+			continue
+		}
+		if correlation > maxCorrelation {
+			sideName := "多"
+			if side == "short" {
+				sideName = "空"
+			}
+			return fmt.Sprintf("%s 与已有%s仓 %s 的%s收益率相关系数 %.2f > 上限 %.2f，拒绝开仓以避免集中押注同一方向",
+				symbol, sideName, heldSymbol, CorrelationTimeframe, correlation, maxCorrelation)
+		}
+	}
+	return ""
+}
+
+// setCycleClosePrices 保存本周期决策上下文中的1小时K线收盘价（每个决策周期替换）
+func (at *AutoTrader) setCycleClosePrices(closes map[string][]float64) {
+	at.cycleClosePricesMu.Lock()
+	at.cycleClosePrices = closes
+	at.cycleClosePricesMu.Unlock()
+}
+
+// correlationClosePrices 获取计算相关性用的最近CorrelationLookbackBars根收盘价（旧→新）
+// 优先复用本周期决策上下文已获取的K线，上下文中没有该币种时才请求K线
+func (at *AutoTrader) correlationClosePrices(symbol string) ([]float64, error) {
+	at.cycleClosePricesMu.RLock()
+	closes, ok := at.cycleClosePrices[symbol]
+	at.cycleClosePricesMu.RUnlock()
+	if !ok {
+		return market.GetClosePrices(symbol, CorrelationTimeframe, CorrelationLookbackBars)
+	}
+	if len(closes) > CorrelationLookbackBars {
+		closes = closes[len(closes)-CorrelationLookbackBars:]
+	}
+	return closes, nil
+}
+
+// returnCorrelation 计算两个收盘价序列（旧→新）的对数收益率皮尔逊相关系数
+// 两个序列按最新一根对齐，只使用重叠部分；重叠的收益率样本少于minSamples、价格无效或任一序列没有波动时返回false
+func returnCorrelation(a, b []float64, minSamples int) (float64, bool) {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	if n-1 < minSamples || n < 3 {
+		return 0, false
+	}
+	a, b = a[len(a)-n:], b[len(b)-n:]
+
+	returnsA := make([]float64, 0, n-1)
+	returnsB := make([]float64, 0, n-1)
+	for i := 1; i < n; i++ {
+		if a[i-1] <= 0 || a[i] <= 0 || b[i-1] <= 0 || b[i] <= 0 {
+			return 0, false
+		}
+		returnsA = append(returnsA, math.Log(a[i]/a[i-1]))
+		returnsB = append(returnsB, math.Log(b[i]/b[i-1]))
+	}
+
+	var meanA, meanB float64
+	for i := range returnsA {
+		meanA += returnsA[i]
+		meanB += returnsB[i]
+	}
+	meanA /= float64(len(returnsA))
+	meanB /= float64(len(returnsB))
+
+	var cov, varA, varB float64
+	for i := range returnsA {
+		da, db := returnsA[i]-meanA, returnsB[i]-meanB
+		cov += da * db
+		varA += da * da
+		varB += db * db
+	}
+	if varA == 0 || varB == 0 {
+		return 0, false
+	}
+	return cov / math.Sqrt(varA*varB), true
+}
@@ -0,0 +1,105 @@
+package trader
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+// priceSeries 根据对数收益率序列生成收盘价（旧→新）
+func priceSeries(start float64, returns []float64) []float64 {
+	prices := []float64{start}
+	for _, r := range returns {
+		prices = append(prices, prices[len(prices)-1]*math.Exp(r))
+	}
+	return prices
+}
+
+// wavyReturns 生成有波动的收益率序列
+func wavyReturns(n int) []float64 {
+	returns := make([]float64, n)
+	for i := range returns {
+		returns[i] = 0.01*math.Sin(float64(i)) + 0.002*float64(i%3)
+	}
+	return returns
+}
+
+func negate(values []float64) []float64 {
+	result := make([]float64, len(values))
+	for i, v := range values {
+		result[i] = -v
+	}
+	return result
+}
+
+func TestReturnCorrelation(t *testing.T) {
+	returns := wavyReturns(40)
+	base := priceSeries(100, returns)
+
+	tests := []struct {
+		name       string
+		a, b       []float64
+		minSamples int
+		want       float64
+		wantOK     bool
+	}{
+		{"perfectly correlated", base, priceSeries(3000, returns), 30, 1, true},
+		{"anti-correlated", base, priceSeries(50, negate(returns)), 30, -1, true},
+		{
+			// 较长的序列按最新一根对齐，只使用重叠部分
+			name:       "mismatched length aligned to latest",
+			a:          append(priceSeries(1, wavyReturns(25)), base...),
+			b:          priceSeries(3000, returns),
+			minSamples: 30,
+			want:       1,
+			wantOK:     true,
+		},
+		{"mismatched length below min samples", base, base[len(base)-20:], 30, 0, false},
+		{"no volatility", base, priceSeries(10, make([]float64, 40)), 30, 0, false},
+		{"non-positive price", base, append(append([]float64{}, base[:39]...), 0, 1), 30, 0, false},
+		{"too short", []float64{1, 2}, []float64{1, 2}, 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := returnCorrelation(tt.a, tt.b, tt.minSamples)
+			if ok != tt.wantOK {
+				t.Fatalf("returnCorrelation() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("returnCorrelation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorrelationSkipReasonUsesCycleClosePrices(t *testing.T) {
+	returns := wavyReturns(CorrelationLookbackBars + 50)
+	at := &AutoTrader{config: AutoTraderConfig{MaxCorrelation: 0.8}}
+	// 上下文中的K线比回看窗口更长时只使用最近CorrelationLookbackBars根；没有这些数据时会请求K线接口
+	at.setCycleClosePrices(map[string][]float64{
+		"BTCUSDT": priceSeries(60000, returns),
+		"ETHUSDT": priceSeries(3000, returns),
+		"SOLUSDT": priceSeries(150, negate(returns)),
+	})
+
+	positions := []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long"},
+		{"symbol": "SOLUSDT", "side": "short"},
+	}
+
+	reason := at.correlationSkipReason("ETHUSDT", "long", positions)
+	if !strings.Contains(reason, "BTCUSDT") {
+		t.Errorf("correlated long should be skipped, got %q", reason)
+	}
+
+	// 与空仓SOL负相关，且没有同方向的高相关持仓
+	if reason := at.correlationSkipReason("ETHUSDT", "short", positions); reason != "" {
+		t.Errorf("anti-correlated short should not be skipped, got %q", reason)
+	}
+
+	at.config.MaxCorrelation = 0
+	if reason := at.correlationSkipReason("ETHUSDT", "long", positions); reason != "" {
+		t.Errorf("disabled check should not skip, got %q", reason)
+	}
+}
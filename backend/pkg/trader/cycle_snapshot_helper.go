@@ -99,7 +99,7 @@ func (at *AutoTrader) logCycleSnapshot(ctx *decision.Context, decision *decision
 		TraderID:          at.id,
 		CycleNumber:       int(cycleNum),
 		Timestamp:         record.Timestamp,
-		ScanInterval:      int(at.config.ScanInterval.Minutes()),
+		ScanInterval:      int(at.getConfig().ScanInterval.Minutes()),
 		AccountState:      record.AccountState,
 		MarketEnvironment: marketEnv,
 		PositionsSnapshot: record.Positions,
@@ -0,0 +1,124 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"backend/pkg/logger"
+)
+
+// GenerateDailyReport 生成指定日期（按UTC日期）的交易汇总
+// 已实现盈亏、胜率、最佳/最差交易来自当天平仓的交易记录，起止净值来自当天第一条和最后一条决策记录
+func (at *AutoTrader) GenerateDailyReport(date time.Time) (*logger.DailyReport, error) {
+	date = date.UTC()
+	start := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	end := start.Add(24 * time.Hour)
+
+	report := &logger.DailyReport{
+		TraderID:    at.id,
+		Date:        start.Format("2006-01-02"),
+		GeneratedAt: time.Now(),
+	}
+	if at.storageAdapter == nil {
+		return report, nil
+	}
+
+	if tradeStorage := at.storageAdapter.GetTradeStorage(); tradeStorage != nil {
+		trades, err := tradeStorage.GetTradesByDate(start)
+		if err != nil {
+			return nil, fmt.Errorf("获取当天交易记录失败: %w", err)
+		}
+
+		for _, trade := range trades {
+			if trade.CloseTime == nil {
+				continue
+			}
+			report.TotalTrades++
+			report.RealizedPnL += trade.PnL
+			if trade.PnL > 0 {
+				report.WinningTrades++
+			} else if trade.PnL < 0 {
+				report.LosingTrades++
+			}
+			if trade.IsForced {
+				report.ForcedCloses++
+			}
+
+			outcome := &logger.TradeOutcome{
+				Symbol:        trade.Symbol,
+				Side:          trade.Side,
+				Quantity:      trade.OpenQuantity,
+				Leverage:      trade.OpenLeverage,
+				OpenPrice:     trade.OpenPrice,
+				ClosePrice:    trade.ClosePrice,
+				PositionValue: trade.PositionValue,
+				MarginUsed:    trade.MarginUsed,
+				PnL:           trade.PnL,
+				PnLPct:        trade.PnLPct,
+				Duration:      trade.CloseTime.Sub(trade.OpenTime).String(),
+				OpenTime:      trade.OpenTime,
+				CloseTime:     *trade.CloseTime,
+				WasStopLoss:   trade.WasStopLoss,
+				CloseReason:   trade.CloseReason,
+			}
+			if report.BestTrade == nil || outcome.PnL > report.BestTrade.PnL {
+				report.BestTrade = outcome
+			}
+			if report.WorstTrade == nil || outcome.PnL < report.WorstTrade.PnL {
+				report.WorstTrade = outcome
+			}
+		}
+		if report.TotalTrades > 0 {
+			report.WinRate = float64(report.WinningTrades) / float64(report.TotalTrades) * 100
+		}
+	}
+
+	if decisionStorage := at.storageAdapter.GetDecisionStorage(); decisionStorage != nil {
+		first, last, err := decisionStorage.GetAccountStatesBetween(at.id, start, end)
+		if err != nil {
+			return nil, fmt.Errorf("获取当天账户净值失败: %w", err)
+		}
+		if first != nil {
+			var startState, endState logger.AccountSnapshot
+			if err := json.Unmarshal(first, &startState); err != nil {
+				return nil, fmt.Errorf("解析账户状态失败: %w", err)
+			}
+			if err := json.Unmarshal(last, &endState); err != nil {
+				return nil, fmt.Errorf("解析账户状态失败: %w", err)
+			}
+			report.StartEquity = startState.TotalBalance
+			report.EndEquity = endState.TotalBalance
+			report.EquityChange = report.EndEquity - report.StartEquity
+			if report.StartEquity > 0 {
+				report.EquityChangePct = report.EquityChange / report.StartEquity * 100
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// checkDailyReport 到达配置的UTC整点后推送前一天的交易汇总（每天只推送一次）
+func (at *AutoTrader) checkDailyReport(now time.Time) {
+	now = now.UTC()
+	if now.Hour() != at.getConfig().DailyReportHourUTC {
+		return
+	}
+
+	reportDate := now.AddDate(0, 0, -1)
+	dateStr := reportDate.Format("2006-01-02")
+	if at.lastDailyReport == dateStr {
+		return
+	}
+	at.lastDailyReport = dateStr
+
+	report, err := at.GenerateDailyReport(reportDate)
+	if err != nil {
+		log.Printf("⚠️  [%s] 生成每日汇总失败（%s）: %v", at.name, dateStr, err)
+		return
+	}
+	log.Printf("📊 [%s] 推送每日汇总（%s）: %d笔交易，已实现盈亏 %+.2f USDT", at.name, dateStr, report.TotalTrades, report.RealizedPnL)
+	at.notifier.NotifyDailyReport(report)
+}
@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+)
+
+// dailyTradeLimitSkipReason 检查每日开仓次数上限（开仓前检查）
+// 从交易记录统计上次日盈亏重置以来的开仓笔数（重启后不会清零），达到上限时返回跳过原因；查询失败时不拦截开仓
+func (at *AutoTrader) dailyTradeLimitSkipReason() string {
+	maxTrades := at.getConfig().MaxDailyTrades
+	if maxTrades <= 0 || at.storageAdapter == nil || at.storageAdapter.GetTradeStorage() == nil {
+		return ""
+	}
+
+	// lastResetTime在riskMu保护下重置，这里同样加锁读取
+	at.riskMu.RLock()
+	since := at.lastResetTime
+	at.riskMu.RUnlock()
+	count, err := at.storageAdapter.GetTradeStorage().CountOpenedTradesSince(since)
+	if err != nil {
+		log.Printf("⚠️  每日开仓次数检查：%v", err)
+		return ""
+	}
+	if count < maxTrades {
+		return ""
+	}
+
+	return fmt.Sprintf("自 %s 以来已开仓%d次，达到每日开仓上限（%d次），日盈亏重置后恢复开仓",
+		since.Format("01-02 15:04"), count, maxTrades)
+}
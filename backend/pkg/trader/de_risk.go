@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"backend/pkg/decision"
+	"backend/pkg/logger"
+)
+
+// checkDeRiskDrawdown 回撤减仓（软风控）：回撤超过de_risk_drawdown（低于max_drawdown）时，
+// 按未实现盈亏平掉表现最差的一半持仓（向上取整），不暂停交易
+// 每次回撤越过阈值只触发一次，回撤回落到阈值以下后才会再次触发（避免每个周期继续减半直到平光）
+func (at *AutoTrader) checkDeRiskDrawdown(ctx *decision.Context, currentDrawdown float64) []logger.DecisionAction {
+	threshold := at.getConfig().DeRiskDrawdown
+	if threshold <= 0 {
+		return nil
+	}
+	if currentDrawdown <= threshold {
+		if at.deRiskTriggered {
+			log.Printf("✓ 回撤已回落到减仓阈值以下（%.2f%% ≤ %.2f%%），回撤减仓重新生效", currentDrawdown, threshold)
+			at.deRiskTriggered = false
+		}
+		return nil
+	}
+	if at.deRiskTriggered || len(ctx.Positions) == 0 {
+		return nil
+	}
+	at.deRiskTriggered = true
+
+	positions := make([]decision.PositionInfo, len(ctx.Positions))
+	copy(positions, ctx.Positions)
+	sort.Slice(positions, func(i, j int) bool {
+		return positions[i].UnrealizedPnL < positions[j].UnrealizedPnL
+	})
+	closeCount := (len(positions) + 1) / 2
+
+	at.eventLog.Warn(atomic.LoadInt64(&at.callCount), "", "risk_trigger",
+		fmt.Sprintf("⚠️ 触发回撤减仓: 当前回撤%.2f%% > 减仓阈值%.2f%%，平掉亏损最多的 %d/%d 个持仓（不暂停交易）",
+			currentDrawdown, threshold, closeCount, len(positions)),
+		"reason", "de_risk_drawdown", "drawdown_pct", currentDrawdown, "close_count", closeCount)
+	at.notifier.NotifyRiskTrigger("回撤减仓", fmt.Sprintf("当前回撤%.2f%% > 减仓阈值%.2f%%\n平掉亏损最多的 %d/%d 个持仓，不暂停交易",
+		currentDrawdown, threshold, closeCount, len(positions)))
+
+	reason := fmt.Sprintf("回撤减仓（回撤%.2f%% > %.2f%%）", currentDrawdown, threshold)
+	var actions []logger.DecisionAction
+	for _, pos := range positions[:closeCount] {
+		action, err := at.forceClosePosition(pos.Symbol, pos.Side, reason, ForcedCloseTriggerDeRisk)
+		if err != nil {
+			log.Printf("⚠️  回撤减仓平仓失败 (%s %s): %v", pos.Symbol, pos.Side, err)
+			continue
+		}
+		actions = append(actions, action)
+		log.Printf("  ✓ 回撤减仓已平仓: %s %s（未实现盈亏 %.2f USDT）", pos.Symbol, pos.Side, pos.UnrealizedPnL)
+
+		at.forcedCloseMu.Lock()
+		at.forcedClosedPositions[pos.Symbol+"_"+pos.Side] = time.Now()
+		at.forcedCloseMu.Unlock()
+	}
+	return actions
+}
@@ -0,0 +1,213 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/pkg/decision"
+	"backend/pkg/logger"
+)
+
+// DecisionDiff 两个周期决策记录的差异（用于排查AI为什么改变了持仓方向）
+type DecisionDiff struct {
+	CycleA     int       `json:"cycle_a"`
+	CycleB     int       `json:"cycle_b"`
+	TimestampA time.Time `json:"timestamp_a"`
+	TimestampB time.Time `json:"timestamp_b"`
+
+	PositionsOpened  []logger.PositionSnapshot `json:"positions_opened"`  // 周期B有、周期A没有的持仓
+	PositionsClosed  []logger.PositionSnapshot `json:"positions_closed"`  // 周期A有、周期B没有的持仓
+	PositionsChanged []PositionChange          `json:"positions_changed"` // 两个周期都有但数量变化的持仓
+
+	ActionChanges []ActionChange `json:"action_changes"` // AI对同一币种给出的动作不同（没有给出决策的一方为空）
+
+	CandidatesAdded   []string `json:"candidates_added"`   // 周期B新增的候选币种
+	CandidatesRemoved []string `json:"candidates_removed"` // 周期B不再分析的候选币种
+
+	Account AccountDelta `json:"account"`
+}
+
+// PositionChange 同一持仓在两个周期之间的变化
+type PositionChange struct {
+	Symbol            string  `json:"symbol"`
+	Side              string  `json:"side"`
+	PositionAmtA      float64 `json:"position_amt_a"`
+	PositionAmtB      float64 `json:"position_amt_b"`
+	UnrealizedProfitA float64 `json:"unrealized_profit_a"`
+	UnrealizedProfitB float64 `json:"unrealized_profit_b"`
+}
+
+// ActionChange 同一币种在两个周期的AI决策（同一币种有多个决策时动作以逗号连接）
+type ActionChange struct {
+	Symbol     string `json:"symbol"`
+	ActionA    string `json:"action_a"`
+	ActionB    string `json:"action_b"`
+	ReasoningA string `json:"reasoning_a,omitempty"`
+	ReasoningB string `json:"reasoning_b,omitempty"`
+}
+
+// AccountDelta 账户状态变化（B - A）
+type AccountDelta struct {
+	EquityA             float64 `json:"equity_a"`
+	EquityB             float64 `json:"equity_b"`
+	EquityChange        float64 `json:"equity_change"`
+	AvailableChange     float64 `json:"available_change"`
+	TotalPnLChange      float64 `json:"total_pnl_change"`
+	MarginUsedPctChange float64 `json:"margin_used_pct_change"`
+	PositionCountChange int     `json:"position_count_change"`
+}
+
+// GetDecisionDiff 对比两个周期的决策记录（任一周期没有记录时返回错误）
+func (at *AutoTrader) GetDecisionDiff(cycleA, cycleB int) (*DecisionDiff, error) {
+	if at.storageAdapter == nil || at.storageAdapter.GetDecisionStorage() == nil {
+		return nil, fmt.Errorf("决策记录存储未启用")
+	}
+	decisionStorage := at.storageAdapter.GetDecisionStorage()
+
+	records := make([]*logger.DecisionRecord, 2)
+	for i, cycle := range []int{cycleA, cycleB} {
+		dbRecord, err := decisionStorage.GetByCycle(at.id, cycle)
+		if err != nil {
+			return nil, err
+		}
+		if dbRecord == nil {
+			return nil, fmt.Errorf("未找到周期 #%d 的决策记录", cycle)
+		}
+		records[i] = toLoggerDecisionRecord(dbRecord)
+	}
+
+	return diffDecisionRecords(records[0], records[1]), nil
+}
+
+// diffDecisionRecords 计算两条决策记录的差异
+func diffDecisionRecords(a, b *logger.DecisionRecord) *DecisionDiff {
+	diff := &DecisionDiff{
+		CycleA:            a.CycleNumber,
+		CycleB:            b.CycleNumber,
+		TimestampA:        a.Timestamp,
+		TimestampB:        b.Timestamp,
+		PositionsOpened:   []logger.PositionSnapshot{},
+		PositionsClosed:   []logger.PositionSnapshot{},
+		PositionsChanged:  []PositionChange{},
+		ActionChanges:     []ActionChange{},
+		CandidatesAdded:   []string{},
+		CandidatesRemoved: []string{},
+	}
+
+	// 持仓变化（按币种+方向匹配）
+	positionsA := make(map[string]logger.PositionSnapshot, len(a.Positions))
+	for _, pos := range a.Positions {
+		positionsA[pos.Symbol+"_"+pos.Side] = pos
+	}
+	positionsB := make(map[string]bool, len(b.Positions))
+	for _, pos := range b.Positions {
+		key := pos.Symbol + "_" + pos.Side
+		positionsB[key] = true
+		prev, exists := positionsA[key]
+		if !exists {
+			diff.PositionsOpened = append(diff.PositionsOpened, pos)
+			continue
+		}
+		if prev.PositionAmt != pos.PositionAmt {
+			diff.PositionsChanged = append(diff.PositionsChanged, PositionChange{
+				Symbol:            pos.Symbol,
+				Side:              pos.Side,
+				PositionAmtA:      prev.PositionAmt,
+				PositionAmtB:      pos.PositionAmt,
+				UnrealizedProfitA: prev.UnrealizedProfit,
+				UnrealizedProfitB: pos.UnrealizedProfit,
+			})
+		}
+	}
+	for _, pos := range a.Positions {
+		if !positionsB[pos.Symbol+"_"+pos.Side] {
+			diff.PositionsClosed = append(diff.PositionsClosed, pos)
+		}
+	}
+
+	// AI决策变化（按币种匹配）
+	actionsA, reasoningA := decisionsBySymbol(a.DecisionJSON)
+	actionsB, reasoningB := decisionsBySymbol(b.DecisionJSON)
+	symbols := make(map[string]bool, len(actionsA)+len(actionsB))
+	for symbol := range actionsA {
+		symbols[symbol] = true
+	}
+	for symbol := range actionsB {
+		symbols[symbol] = true
+	}
+	for symbol := range symbols {
+		if actionsA[symbol] == actionsB[symbol] {
+			continue
+		}
+		diff.ActionChanges = append(diff.ActionChanges, ActionChange{
+			Symbol:     symbol,
+			ActionA:    actionsA[symbol],
+			ActionB:    actionsB[symbol],
+			ReasoningA: reasoningA[symbol],
+			ReasoningB: reasoningB[symbol],
+		})
+	}
+	sort.Slice(diff.ActionChanges, func(i, j int) bool {
+		return diff.ActionChanges[i].Symbol < diff.ActionChanges[j].Symbol
+	})
+
+	// 候选币种变化
+	candidatesA := make(map[string]bool, len(a.CandidateCoins))
+	for _, symbol := range a.CandidateCoins {
+		candidatesA[symbol] = true
+	}
+	candidatesB := make(map[string]bool, len(b.CandidateCoins))
+	for _, symbol := range b.CandidateCoins {
+		candidatesB[symbol] = true
+		if !candidatesA[symbol] {
+			diff.CandidatesAdded = append(diff.CandidatesAdded, symbol)
+		}
+	}
+	for _, symbol := range a.CandidateCoins {
+		if !candidatesB[symbol] {
+			diff.CandidatesRemoved = append(diff.CandidatesRemoved, symbol)
+		}
+	}
+
+	// 账户状态变化
+	diff.Account = AccountDelta{
+		EquityA:             a.AccountState.TotalBalance,
+		EquityB:             b.AccountState.TotalBalance,
+		EquityChange:        b.AccountState.TotalBalance - a.AccountState.TotalBalance,
+		AvailableChange:     b.AccountState.AvailableBalance - a.AccountState.AvailableBalance,
+		TotalPnLChange:      b.AccountState.TotalUnrealizedProfit - a.AccountState.TotalUnrealizedProfit,
+		MarginUsedPctChange: b.AccountState.MarginUsedPct - a.AccountState.MarginUsedPct,
+		PositionCountChange: b.AccountState.PositionCount - a.AccountState.PositionCount,
+	}
+
+	return diff
+}
+
+// decisionsBySymbol 解析决策JSON，按币种汇总动作和第一条决策的理由（JSON为空或解析失败时返回空map）
+func decisionsBySymbol(decisionJSON string) (map[string]string, map[string]string) {
+	actions := make(map[string]string)
+	reasoning := make(map[string]string)
+	if decisionJSON == "" {
+		return actions, reasoning
+	}
+
+	var decisions []decision.Decision
+	if err := json.Unmarshal([]byte(decisionJSON), &decisions); err != nil {
+		return actions, reasoning
+	}
+
+	grouped := make(map[string][]string)
+	for _, d := range decisions {
+		grouped[d.Symbol] = append(grouped[d.Symbol], d.Action)
+		if _, exists := reasoning[d.Symbol]; !exists {
+			reasoning[d.Symbol] = d.Reasoning
+		}
+	}
+	for symbol, list := range grouped {
+		actions[symbol] = strings.Join(list, ",")
+	}
+	return actions, reasoning
+}
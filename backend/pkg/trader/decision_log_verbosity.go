@@ -0,0 +1,42 @@
+package trader
+
+import (
+	"backend/pkg/logger"
+	"backend/pkg/storage"
+)
+
+// 决策记录详细程度（log_verbosity）
+const (
+	LogVerbosityFull    = "full"    // 保存所有记录
+	LogVerbositySummary = "summary" // 只保存有操作或失败的周期
+	LogVerbosityMinimal = "minimal" // 保存所有记录，成功且无操作的周期不保存prompt和思维链
+)
+
+// applyLogVerbosity 按log_verbosity处理即将写入数据库的决策记录，返回false表示不写入
+// minimal模式下会清空成功且无操作周期的InputPrompt/CoTTrace/ModelTraces（保留账户和持仓快照，净值曲线不受影响）；
+// summary模式下不写入这类周期，净值曲线和表现分析只包含有操作的周期
+func (at *AutoTrader) applyLogVerbosity(dbRecord *storage.DecisionRecord, decisions []logger.DecisionAction) bool {
+	if !dbRecord.Success || hasEffectiveAction(decisions) {
+		return true
+	}
+
+	switch at.getConfig().LogVerbosity {
+	case LogVerbositySummary:
+		return false
+	case LogVerbosityMinimal:
+		dbRecord.InputPrompt = ""
+		dbRecord.CoTTrace = ""
+		dbRecord.ModelTraces = nil
+	}
+	return true
+}
+
+// hasEffectiveAction 是否包含hold/wait以外的操作（跳过的开仓也算，便于追溯为什么没有开仓）
+func hasEffectiveAction(decisions []logger.DecisionAction) bool {
+	for _, d := range decisions {
+		if d.Action != "hold" && d.Action != "wait" {
+			return true
+		}
+	}
+	return false
+}
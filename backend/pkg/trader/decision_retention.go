@@ -0,0 +1,27 @@
+package trader
+
+import (
+	"log"
+	"time"
+)
+
+// pruneDecisionRecords 删除早于保留时长的决策记录（存储层始终保留最近的记录）
+func (at *AutoTrader) pruneDecisionRecords() {
+	if at.storageAdapter == nil || at.getConfig().DecisionRetention <= 0 {
+		return
+	}
+	decisionStorage := at.storageAdapter.GetDecisionStorage()
+	if decisionStorage == nil {
+		return
+	}
+
+	cutoff := time.Now().Add(-at.getConfig().DecisionRetention)
+	deleted, err := decisionStorage.PruneOlderThan(at.id, cutoff)
+	if err != nil {
+		log.Printf("⚠️  [%s] 清理决策记录失败: %v", at.name, err)
+		return
+	}
+	if deleted > 0 {
+		log.Printf("🧹 [%s] 已清理 %d 条 %s 之前的决策记录", at.name, deleted, cutoff.Format("2006-01-02 15:04"))
+	}
+}
@@ -0,0 +1,75 @@
+package trader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"backend/pkg/logger"
+)
+
+// 决策记录Webhook配置
+const (
+	webhookTimeout        = 5 * time.Second
+	webhookMaxAttempts    = 3 // 最多发送次数（含首次）
+	webhookRetryBaseDelay = 1 * time.Second
+)
+
+// webhookClient 所有trader共用的Webhook HTTP客户端
+var webhookClient = &http.Client{Timeout: webhookTimeout}
+
+// postDecisionWebhook 异步将决策记录以JSON POST到配置的Webhook（未配置时不发送）
+// AI决策周期和10秒止损检查的记录都通过此函数推送，失败只记录日志，不影响交易
+func (at *AutoTrader) postDecisionWebhook(record *logger.DecisionRecord) {
+	url := at.getConfig().WebhookURL
+	if url == "" || record == nil {
+		return
+	}
+
+	// 在当前goroutine中序列化，避免异步发送时记录被修改
+	payload, err := json.Marshal(record)
+	if err != nil {
+		log.Printf("⚠️  序列化Webhook决策记录失败: %v", err)
+		return
+	}
+
+	go func() {
+		for attempt := 1; attempt <= webhookMaxAttempts; attempt++ {
+			err := at.sendWebhook(url, payload)
+			if err == nil {
+				return
+			}
+			if attempt == webhookMaxAttempts {
+				log.Printf("⚠️  推送决策记录到Webhook失败（已重试%d次）: %v", webhookMaxAttempts, err)
+				return
+			}
+			time.Sleep(webhookRetryBaseDelay * time.Duration(1<<(attempt-1)))
+		}
+	}()
+}
+
+// sendWebhook 发送一次Webhook请求（2xx视为成功）
+func (at *AutoTrader) sendWebhook(url string, payload []byte) error {
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Trader-ID", at.id)
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, string(body))
+	}
+	return nil
+}
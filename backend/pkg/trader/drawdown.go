@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"sort"
+	"time"
+)
+
+// EquitySample 某一时刻的账户净值
+type EquitySample struct {
+	Timestamp time.Time
+	Equity    float64
+}
+
+// DrawdownPoint 回撤曲线上的一个点
+type DrawdownPoint struct {
+	Timestamp   time.Time `json:"timestamp"`
+	Equity      float64   `json:"equity"`
+	Peak        float64   `json:"peak"`         // 截至该时刻的峰值净值
+	DrawdownPct float64   `json:"drawdown_pct"` // 相对峰值的回撤百分比（≥0）
+}
+
+// ComputeDrawdownCurve 根据净值序列重建滚动峰值和回撤曲线（按时间从旧到新输出）
+// 净值≤0的点（如构建上下文失败的周期没有账户数据）沿用上一个有效净值；
+// 序列开头还没有有效净值时跳过这些点
+func ComputeDrawdownCurve(samples []EquitySample) []DrawdownPoint {
+	sorted := make([]EquitySample, len(samples))
+	copy(sorted, samples)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Timestamp.Before(sorted[j].Timestamp)
+	})
+
+	curve := make([]DrawdownPoint, 0, len(sorted))
+	var equity, peak float64
+	for _, sample := range sorted {
+		if sample.Equity > 0 {
+			equity = sample.Equity
+		}
+		if equity <= 0 {
+			continue
+		}
+		if equity > peak {
+			peak = equity
+		}
+
+		curve = append(curve, DrawdownPoint{
+			Timestamp:   sample.Timestamp,
+			Equity:      equity,
+			Peak:        peak,
+			DrawdownPct: (peak - equity) / peak * 100,
+		})
+	}
+	return curve
+}
+
+// MaxDrawdownPct 回撤曲线中的最大回撤百分比
+func MaxDrawdownPct(curve []DrawdownPoint) float64 {
+	maxDrawdown := 0.0
+	for _, point := range curve {
+		if point.DrawdownPct > maxDrawdown {
+			maxDrawdown = point.DrawdownPct
+		}
+	}
+	return maxDrawdown
+}
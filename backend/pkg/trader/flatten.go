@@ -0,0 +1,111 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"backend/pkg/decision"
+)
+
+// ManualFlattenReason 手动紧急平仓的平仓原因（写入交易记录和通知）
+const ManualFlattenReason = "manual flatten via API"
+
+// FlattenResult 单个持仓的紧急平仓结果
+type FlattenResult struct {
+	Symbol  string  `json:"symbol"`
+	Side    string  `json:"side"`
+	Success bool    `json:"success"`
+	OrderID int64   `json:"order_id,omitempty"`
+	Price   float64 `json:"price,omitempty"` // 平仓时的市场价格
+	Error   string  `json:"error,omitempty"`
+}
+
+// FlattenAll 立即平掉所有持仓（紧急操作，可在交易周期进行中调用，单仓位平仓锁保证不会重复平仓）
+// pauseDuration>0 时同时暂停交易，避免AI在下一周期立即重新开仓
+func (at *AutoTrader) FlattenAll(pauseDuration time.Duration) ([]FlattenResult, error) {
+	log.Printf("🧹 [%s] 收到紧急平仓请求，正在平掉所有持仓...", at.name)
+
+	// 先暂停交易，防止平仓过程中本周期继续开仓
+	if pauseDuration > 0 {
+		at.riskMu.Lock()
+		at.stopUntil = time.Now().Add(pauseDuration)
+		at.riskMu.Unlock()
+		log.Printf("⏸ [%s] 紧急平仓后暂停交易 %.0f 分钟", at.name, pauseDuration.Minutes())
+	}
+
+	// 撤销未成交的限价开仓单，避免平仓后又成交开出新仓位
+	at.cancelAllPendingLimits()
+
+	ctx, err := at.flattenContext()
+	if err != nil {
+		return nil, err
+	}
+
+	_, results := at.forceCloseAllPositionsWithResults(ManualFlattenReason, ForcedCloseTriggerManualFlatten, ctx)
+
+	closed := 0
+	for _, result := range results {
+		if result.Success {
+			closed++
+		}
+	}
+	log.Printf("✓ [%s] 紧急平仓完成: %d/%d 个持仓已平仓", at.name, closed, len(results))
+
+	return results, nil
+}
+
+// flattenContext 构建只包含持仓列表的交易上下文（不构建完整交易上下文，避免拉取候选币种行情拖慢平仓）
+// 只需要symbol/side/positionAmt（不要求价格字段），字段无法解析的持仓记录日志后跳过，需要手动处理
+func (at *AutoTrader) flattenContext() (*decision.Context, error) {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	ctx := &decision.Context{}
+	for _, pos := range positions {
+		symbol, symbolOK := getString(pos, "symbol")
+		side, sideOK := getString(pos, "side")
+		quantity, quantityOK := getFloat(pos, "positionAmt")
+		if !symbolOK || !sideOK || !quantityOK || symbol == "" || side == "" {
+			log.Printf("⚠️  [%s] 持仓字段无法解析，跳过平仓，请手动检查: symbol=%v side=%v positionAmt=%v",
+				at.name, pos["symbol"], pos["side"], pos["positionAmt"])
+			continue
+		}
+		if math.Abs(quantity) == 0 {
+			continue
+		}
+		ctx.Positions = append(ctx.Positions, decision.PositionInfo{
+			Symbol:   symbol,
+			Side:     side,
+			Quantity: math.Abs(quantity),
+		})
+	}
+	return ctx, nil
+}
+
+// flattenForSessionBoundary 启动/停止时平掉所有持仓（flatten_on_startup / flatten_on_shutdown）
+// 同时撤销未成交的限价开仓单；失败只记录日志和通知，不阻止启动或停止
+func (at *AutoTrader) flattenForSessionBoundary(reason, triggerType string) {
+	at.cancelAllPendingLimits()
+
+	ctx, err := at.flattenContext()
+	if err != nil {
+		log.Printf("⚠️  [%s] %s失败: %v，请手动检查持仓", at.name, reason, err)
+		at.notifier.NotifyRiskTrigger(reason, fmt.Sprintf("获取持仓失败: %v\n请手动检查持仓", err))
+		return
+	}
+	if len(ctx.Positions) == 0 {
+		log.Printf("✓ [%s] %s：当前无持仓", at.name, reason)
+		return
+	}
+
+	log.Printf("🧹 [%s] %s：正在平掉 %d 个持仓...", at.name, reason, len(ctx.Positions))
+	actions, _ := at.forceCloseAllPositions(reason, triggerType, ctx)
+	log.Printf("✓ [%s] %s：已平仓 %d/%d 个持仓", at.name, reason, len(actions), len(ctx.Positions))
+	if len(actions) < len(ctx.Positions) {
+		log.Printf("🚨 [%s] %s：%d 个持仓平仓失败，请手动检查", at.name, reason, len(ctx.Positions)-len(actions))
+	}
+}
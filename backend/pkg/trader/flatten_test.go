@@ -0,0 +1,31 @@
+package trader
+
+import (
+	"testing"
+)
+
+func TestFlattenContextParsesPositionFields(t *testing.T) {
+	stub := &stubTrader{positions: []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long", "positionAmt": 0.5},
+		{"symbol": "ETHUSDT", "side": "short", "positionAmt": "-2"},
+		{"symbol": "SOLUSDT", "side": "long", "positionAmt": 0.0},
+		{"symbol": "XRPUSDT", "side": "long", "positionAmt": "abc"},
+		{"symbol": nil, "side": "long", "positionAmt": 1.0},
+	}}
+	at := &AutoTrader{name: "test", trader: stub}
+
+	ctx, err := at.flattenContext()
+	if err != nil {
+		t.Fatalf("flattenContext() error = %v", err)
+	}
+
+	want := map[string]float64{"BTCUSDT_long": 0.5, "ETHUSDT_short": 2}
+	if len(ctx.Positions) != len(want) {
+		t.Fatalf("positions = %+v, want %v", ctx.Positions, want)
+	}
+	for _, pos := range ctx.Positions {
+		if q, ok := want[pos.Symbol+"_"+pos.Side]; !ok || pos.Quantity != q {
+			t.Errorf("unexpected position %+v", pos)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"backend/pkg/storage"
+)
+
+// 强制平仓触发类型（随强制平仓记录保存，用于区分是哪条风控规则平的仓）
+const (
+	ForcedCloseTriggerAccountDrawdown    = "account_drawdown"     // 账户回撤超过max_drawdown
+	ForcedCloseTriggerDailyLoss          = "daily_loss"           // 账户日亏损超过max_daily_loss
+	ForcedCloseTriggerDeRisk             = "de_risk_drawdown"     // 回撤减仓（de_risk_drawdown）
+	ForcedCloseTriggerPositionStopLoss   = "position_stop_loss"   // 单仓位止损
+	ForcedCloseTriggerPositionTakeProfit = "position_take_profit" // 单仓位止盈
+	ForcedCloseTriggerPositionMaxLoss    = "position_max_loss"    // 单仓位最大亏损金额（position_max_loss_usd）
+	ForcedCloseTriggerTrailingStop       = "trailing_stop"        // 移动止损
+	ForcedCloseTriggerMaxHoldingTime     = "max_holding_time"     // 超过最长持仓时间
+	ForcedCloseTriggerRunLimit           = "run_limit"            // 达到运行上限（flatten_on_stop）
+	ForcedCloseTriggerManualFlatten      = "manual_flatten"       // 手动紧急平仓
+	ForcedCloseTriggerStartupFlatten     = "startup_flatten"      // 启动时平仓（flatten_on_startup）
+	ForcedCloseTriggerShutdownFlatten    = "shutdown_flatten"     // 停止时平仓（flatten_on_shutdown）
+	ForcedCloseTriggerMarketDataHalt     = "market_data_halt"     // 行情数据持续获取失败（币种停牌/下架）
+)
+
+// recordForcedClose 保存一条强制平仓记录（保存失败只记录日志，不影响平仓流程）
+func (at *AutoTrader) recordForcedClose(symbol, side, reason, triggerType string, pnl float64) {
+	if at.storageAdapter == nil || at.storageAdapter.GetForcedCloseStorage() == nil {
+		return
+	}
+
+	record := &storage.ForcedCloseRecord{
+		Timestamp:   time.Now(),
+		Symbol:      symbol,
+		Side:        side,
+		Reason:      reason,
+		PnL:         pnl,
+		TriggerType: triggerType,
+	}
+	if err := at.storageAdapter.GetForcedCloseStorage().LogForcedClose(at.id, record); err != nil {
+		log.Printf("⚠️  保存强制平仓记录失败 (%s %s): %v", symbol, side, err)
+	}
+}
+
+// GetForcedClosesFromDB 从数据库获取最近N条强制平仓记录（用于API接口）
+func (at *AutoTrader) GetForcedClosesFromDB(limit int) ([]*storage.ForcedCloseRecord, error) {
+	if at.storageAdapter == nil || at.storageAdapter.GetForcedCloseStorage() == nil {
+		return []*storage.ForcedCloseRecord{}, nil
+	}
+
+	records, err := at.storageAdapter.GetForcedCloseStorage().GetRecentForcedCloses(at.id, limit)
+	if err != nil {
+		return nil, fmt.Errorf("从数据库获取强制平仓记录失败: %w", err)
+	}
+	return records, nil
+}
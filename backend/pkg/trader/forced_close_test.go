@@ -0,0 +1,143 @@
+package trader
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/pkg/market"
+	"backend/pkg/storage"
+)
+
+// newForcedCloseTestTrader 创建使用stubTrader和临时数据库的AutoTrader
+func newForcedCloseTestTrader(t *testing.T, stub *stubTrader) *AutoTrader {
+	t.Helper()
+	storageAdapter, err := storage.NewStorageAdapter(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStorageAdapter() error = %v", err)
+	}
+	t.Cleanup(func() { storageAdapter.Close() })
+
+	return &AutoTrader{
+		id:                    "test",
+		trader:                stub,
+		storageAdapter:        storageAdapter,
+		positionLogicManager:  storage.NewPositionLogicWrapper(storageAdapter.GetPositionLogicStorage()),
+		positionFirstSeenTime: make(map[string]int64),
+		forcedClosedPositions: make(map[string]time.Time),
+		closingPositions:      make(map[string]*sync.Mutex),
+		lastCloseTime:         make(map[string]int64),
+	}
+}
+
+// failMarketData 模拟行情接口故障
+func failMarketData(t *testing.T) {
+	t.Helper()
+	original := forceCloseMarketData
+	forceCloseMarketData = func(symbol string) (*market.Data, error) {
+		return nil, errors.New("market data unavailable")
+	}
+	t.Cleanup(func() { forceCloseMarketData = original })
+}
+
+func TestForceClosePositionWithoutMarketDataUsesMarkPrice(t *testing.T) {
+	failMarketData(t)
+
+	tests := []struct {
+		side       string
+		posAmt     float64
+		wantAction string
+	}{
+		{"long", 0.5, "close_long"},
+		{"short", -0.5, "close_short"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.side, func(t *testing.T) {
+			stub := &stubTrader{positions: []map[string]interface{}{{
+				"symbol":           "BTCUSDT",
+				"side":             tt.side,
+				"positionAmt":      tt.posAmt,
+				"entryPrice":       50000.0,
+				"markPrice":        48000.0,
+				"unRealizedProfit": -1000.0,
+				"leverage":         5.0,
+			}}}
+			at := newForcedCloseTestTrader(t, stub)
+
+			action, err := at.forceClosePosition("BTCUSDT", tt.side, "test", ForcedCloseTriggerPositionStopLoss)
+			if err != nil {
+				t.Fatalf("forceClosePosition() error = %v", err)
+			}
+			if len(stub.closeCalls) != 1 || stub.closeCalls[0] != tt.wantAction+":BTCUSDT" {
+				t.Errorf("close calls = %v, want [%s:BTCUSDT]", stub.closeCalls, tt.wantAction)
+			}
+			if !action.Success || action.Action != tt.wantAction {
+				t.Errorf("action = %+v, want successful %s", action, tt.wantAction)
+			}
+			if action.Price != 48000 {
+				t.Errorf("Price = %v, want mark price 48000", action.Price)
+			}
+			if action.Quantity != 0.5 {
+				t.Errorf("Quantity = %v, want 0.5", action.Quantity)
+			}
+		})
+	}
+}
+
+func TestForceClosePositionWithoutMarketDataOrMarkPriceUsesFillPrice(t *testing.T) {
+	failMarketData(t)
+
+	// 持仓已不在交易所返回的列表中（没有标记价格），平仓价格从成交记录补全
+	stub := &stubTrader{accountTrades: []map[string]interface{}{{
+		"symbol":      "ETHUSDT",
+		"side":        "SELL",
+		"price":       "3000.5",
+		"realizedPnl": "-12.3",
+		"time":        float64(time.Now().UnixMilli()),
+	}}}
+	at := newForcedCloseTestTrader(t, stub)
+
+	action, err := at.forceClosePosition("ETHUSDT", "long", "test", ForcedCloseTriggerPositionStopLoss)
+	if err != nil {
+		t.Fatalf("forceClosePosition() error = %v", err)
+	}
+	if len(stub.closeCalls) != 1 {
+		t.Errorf("close calls = %v, want 1", stub.closeCalls)
+	}
+	if action.Price != 3000.5 {
+		t.Errorf("Price = %v, want fill price 3000.5", action.Price)
+	}
+}
+
+func TestStopForRunLimitFlattensFromPositionList(t *testing.T) {
+	// 行情接口故障时无法构建完整交易上下文，flatten_on_stop仍需按持仓列表平仓
+	failMarketData(t)
+
+	stub := &stubTrader{positions: []map[string]interface{}{{
+		"symbol":      "BTCUSDT",
+		"side":        "long",
+		"positionAmt": "0.5",
+		"entryPrice":  50000.0,
+		"markPrice":   48000.0,
+	}}}
+	at := newForcedCloseTestTrader(t, stub)
+	at.config = AutoTraderConfig{FlattenOnStop: true}
+	at.stopCh = make(chan struct{})
+	at.isRunning = 1
+
+	at.stopForRunLimit("已达到最大运行时长上限")
+
+	if len(stub.closeCalls) != 1 || stub.closeCalls[0] != "close_long:BTCUSDT" {
+		t.Errorf("close calls = %v, want [close_long:BTCUSDT]", stub.closeCalls)
+	}
+	if at.stopReason != "已达到最大运行时长上限" {
+		t.Errorf("stopReason = %q", at.stopReason)
+	}
+	select {
+	case <-at.stopCh:
+	default:
+		t.Error("stopCh not closed after stopForRunLimit")
+	}
+}
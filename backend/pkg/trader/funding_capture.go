@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"backend/pkg/decision"
+	"backend/pkg/market"
+)
+
+// 资金费率捕获模式的候选范围
+const (
+	fundingCaptureMaxSymbols = 5              // 最多展示的资金费率捕获机会
+	fundingCaptureWindow     = 2 * time.Hour  // 只展示在该时间内结算的币种
+	fundingCaptureSource     = "funding_rate" // 候选币种来源标记
+)
+
+// addFundingCaptureCandidates 资金费率捕获模式：筛选资金费率绝对值超过阈值且即将结算的币种，
+// 加入候选币种（已在候选中的只追加来源标记）并返回按资金费率绝对值排序的机会列表
+// 获取资金费率失败时不影响正常决策，只是本周期没有资金费率捕获机会
+func (at *AutoTrader) addFundingCaptureCandidates(candidates []decision.CandidateCoin) ([]decision.CandidateCoin, []decision.FundingOpportunity) {
+	cfg := at.getConfig()
+	if !cfg.FundingCaptureMode {
+		return candidates, nil
+	}
+
+	infos, err := market.GetAllFundingRates()
+	if err != nil {
+		log.Printf("⚠️  资金费率捕获：获取资金费率列表失败: %v", err)
+		return candidates, nil
+	}
+
+	now := time.Now()
+	var opportunities []decision.FundingOpportunity
+	for _, info := range infos {
+		untilSettlement := time.UnixMilli(info.NextFundingTime).Sub(now)
+		if math.Abs(info.Rate) < cfg.FundingCaptureMinRate || untilSettlement <= 0 || untilSettlement > fundingCaptureWindow {
+			continue
+		}
+		if at.symbolFilterSkipReason(info.Symbol) != "" {
+			continue
+		}
+		opportunities = append(opportunities, decision.FundingOpportunity{
+			Symbol:          info.Symbol,
+			FundingRate:     info.Rate,
+			NextFundingTime: info.NextFundingTime,
+		})
+	}
+	sort.Slice(opportunities, func(i, j int) bool {
+		return math.Abs(opportunities[i].FundingRate) > math.Abs(opportunities[j].FundingRate)
+	})
+	if len(opportunities) > fundingCaptureMaxSymbols {
+		opportunities = opportunities[:fundingCaptureMaxSymbols]
+	}
+
+	for _, opp := range opportunities {
+		found := false
+		for i := range candidates {
+			if candidates[i].Symbol == opp.Symbol {
+				candidates[i].Sources = append(candidates[i].Sources, fundingCaptureSource)
+				found = true
+				break
+			}
+		}
+		if !found {
+			candidates = append(candidates, decision.CandidateCoin{
+				Symbol:  opp.Symbol,
+				Sources: []string{fundingCaptureSource},
+			})
+		}
+	}
+
+	if len(opportunities) > 0 {
+		log.Printf("💰 资金费率捕获：%d 个币种资金费率 ≥ %.4f%% 且将在 %v 内结算", len(opportunities), cfg.FundingCaptureMinRate*100, fundingCaptureWindow)
+	}
+	return candidates, opportunities
+}
@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// 组件健康状态
+const (
+	HealthStatusOK   = "ok"
+	HealthStatusDown = "down"
+)
+
+// ComponentHealth 单个组件的探测结果
+type ComponentHealth struct {
+	Name      string `json:"name"`            // exchange / ai / ai:<模型> / database
+	Status    string `json:"status"`          // ok / down
+	Critical  bool   `json:"critical"`        // 关键组件不可用时trader无法正常交易
+	LatencyMs int64  `json:"latency_ms"`      // 探测耗时（毫秒）
+	Error     string `json:"error,omitempty"` // 不可用原因
+}
+
+// ProbeHealth 并发探测交易所、AI API和数据库是否可用（每个组件最多等待HealthProbeTimeout）
+// 多模型投票的成员模型单个失败时不参与投票即可，因此不是关键组件
+func (at *AutoTrader) ProbeHealth() []ComponentHealth {
+	type probe struct {
+		name     string
+		critical bool
+		run      func() error
+	}
+	probes := []probe{
+		{name: "exchange", critical: true, run: func() error {
+			_, err := at.trader.GetBalance()
+			return err
+		}},
+		{name: "ai", critical: true, run: func() error {
+			return at.mcpClient.Ping(HealthProbeTimeout)
+		}},
+		{name: "database", critical: true, run: func() error {
+			if at.storageAdapter == nil {
+				return fmt.Errorf("数据库存储未初始化")
+			}
+			return at.storageAdapter.CheckWritable()
+		}},
+	}
+	for _, member := range at.ensembleMembers {
+		client := member.Client
+		probes = append(probes, probe{name: "ai:" + member.Model, run: func() error {
+			return client.Ping(HealthProbeTimeout)
+		}})
+	}
+
+	results := make([]ComponentHealth, len(probes))
+	var wg sync.WaitGroup
+	for i, p := range probes {
+		wg.Add(1)
+		go func(i int, p probe) {
+			defer wg.Done()
+			results[i] = runHealthProbe(p.name, p.critical, p.run)
+		}(i, p)
+	}
+	wg.Wait()
+	return results
+}
+
+// runHealthProbe 执行单个探测并计时（超过HealthProbeTimeout视为不可用，探测本身在后台继续直到返回）
+func runHealthProbe(name string, critical bool, run func() error) ComponentHealth {
+	result := ComponentHealth{Name: name, Status: HealthStatusOK, Critical: critical}
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() { done <- run() }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(HealthProbeTimeout):
+		err = fmt.Errorf("探测超时（%v）", HealthProbeTimeout)
+	}
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Status = HealthStatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
@@ -3,7 +3,7 @@ package trader
 import "time"
 
 // Trader 交易器统一接口
-// 交易平台接口（支持Aster）
+// 交易平台接口（支持Aster和Binance U本位合约）
 type Trader interface {
 	// GetBalance 获取账户余额
 	GetBalance() (map[string]interface{}, error)
@@ -17,10 +17,16 @@ type Trader interface {
 	// OpenShort 开空仓
 	OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error)
 
-	// CloseLong 平多仓（quantity=0表示全部平仓）
+	// OpenLongLimit 限价开多仓（GTC挂单，成交由调用方查询持仓确认）
+	OpenLongLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error)
+
+	// OpenShortLimit 限价开空仓（GTC挂单，成交由调用方查询持仓确认）
+	OpenShortLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error)
+
+	// CloseLong 平多仓（quantity=0表示全部平仓），实现必须以reduceOnly提交，避免数量偏大时反向开仓
 	CloseLong(symbol string, quantity float64) (map[string]interface{}, error)
 
-	// CloseShort 平空仓（quantity=0表示全部平仓）
+	// CloseShort 平空仓（quantity=0表示全部平仓），实现必须以reduceOnly提交
 	CloseShort(symbol string, quantity float64) (map[string]interface{}, error)
 
 	// SetLeverage 设置杠杆
@@ -29,18 +35,44 @@ type Trader interface {
 	// GetMarketPrice 获取市场价格
 	GetMarketPrice(symbol string) (float64, error)
 
-	// SetStopLoss 设置止损单
+	// SetStopLoss 设置止损单（reduceOnly，持仓已平掉后触发也不会反向开仓）
 	SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error
 
-	// SetTakeProfit 设置止盈单
+	// SetTakeProfit 设置止盈单（reduceOnly）
 	SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error
 
+	// SetBracket 同时设置止损止盈（OCO联动订单，一边成交后另一边自动撤销）
+	// 交易所不支持OCO时退化为两个独立订单；stopPrice或takeProfitPrice≤0时跳过对应一边
+	SetBracket(symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error
+
 	// CancelAllOrders 取消该币种的所有挂单
 	CancelAllOrders(symbol string) error
 
+	// GetOpenOrders 获取当前挂单（symbol为""时获取所有交易对），返回交易所原始订单字段（orderId、symbol、type、side、reduceOnly等）
+	GetOpenOrders(symbol string) ([]map[string]interface{}, error)
+
+	// CancelOrder 取消指定订单（用于撤销超时未成交的限价开仓单，不影响止损止盈单）
+	CancelOrder(symbol string, orderID int64) error
+
+	// GetOrder 查询指定订单，返回交易所原始订单字段（status、executedQty、origQty等）
+	GetOrder(symbol string, orderID int64) (map[string]interface{}, error)
+
 	// FormatQuantity 格式化数量到正确的精度
 	FormatQuantity(symbol string, quantity float64) (string, error)
+
+	// FormatPrice 格式化价格到tick size的整数倍（下单、止损止盈触发价使用）
+	FormatPrice(symbol string, price float64) (string, error)
+
+	// GetSymbolStatus 获取交易对当前的交易状态（实时查询exchangeInfo，不使用缓存）：正常交易为"TRADING"，交易所已没有该交易对时为""
+	GetSymbolStatus(symbol string) (string, error)
+
+	// GetSymbolFilters 获取交易对的下单限制（来自exchangeInfo，带缓存）：最小下单金额、最小数量、数量步进值、价格步进值
+	// 交易所未返回的限制为0
+	GetSymbolFilters(symbol string) (minNotional, minQty, stepSize, tickSize float64, err error)
 	
+	// GetForceOrders 获取交易所强平订单历史（爆仓和自动减仓，clientOrderId以"autoclose-"或"adl_autoclose"开头）
+	GetForceOrders(symbol string, startTime time.Time) ([]map[string]interface{}, error)
+
 	// GetAccountTrades 获取账户交易历史
 	GetAccountTrades(symbol string, startTime, endTime time.Time, limit int) ([]map[string]interface{}, error)
 }
@@ -0,0 +1,192 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"backend/pkg/decision"
+	"backend/pkg/logger"
+	"backend/pkg/market"
+)
+
+// pendingLimitPrefix 限价单已挂出、等待成交的开仓决策在actionRecord.Error中的前缀（与SKIPPED类似，不视为失败）
+const pendingLimitPrefix = "PENDING: "
+
+// pendingLimitOrder 已挂出但尚未确认成交的限价开仓单
+// 只保存在内存中：重启后不再跟踪，挂单若之后成交，持仓由AI按无止损的持仓处理
+type pendingLimitOrder struct {
+	decision     decision.Decision
+	side         string
+	orderID      int64
+	quantity     float64
+	placedAt     time.Time
+	actionRecord logger.DecisionAction
+	marketData   *market.Data
+}
+
+// placeLimitOpen 挂限价开仓单并登记为待成交，成交确认和超时撤单由checkPendingLimitOrders处理
+func (at *AutoTrader) placeLimitOpen(dec *decision.Decision, side string, actionRecord *logger.DecisionAction, marketData *market.Data) error {
+	var order map[string]interface{}
+	var err error
+	if side == "long" {
+		order, err = at.trader.OpenLongLimit(dec.Symbol, actionRecord.Quantity, dec.LimitPrice, dec.Leverage)
+	} else {
+		order, err = at.trader.OpenShortLimit(dec.Symbol, actionRecord.Quantity, dec.LimitPrice, dec.Leverage)
+	}
+	if err != nil {
+		return fmt.Errorf("限价开仓下单失败: %w", err)
+	}
+
+	orderID, _ := orderIDFromResult(order)
+	actionRecord.OrderID = orderID
+
+	at.pendingLimitsMu.Lock()
+	at.pendingLimits[dec.Symbol+"_"+side] = &pendingLimitOrder{
+		decision:     *dec,
+		side:         side,
+		orderID:      orderID,
+		quantity:     actionRecord.Quantity,
+		placedAt:     time.Now(),
+		actionRecord: *actionRecord,
+		marketData:   marketData,
+	}
+	at.pendingLimitsMu.Unlock()
+
+	msg := fmt.Sprintf("限价单已挂出（价格 %.4f，数量 %.8f，订单ID: %v），%.0f分钟内未成交将撤单",
+		dec.LimitPrice, actionRecord.Quantity, order["orderId"], at.getConfig().LimitOrderTimeout.Minutes())
+	log.Printf("  ⏳ %s %s %s", dec.Symbol, side, msg)
+	actionRecord.Error = pendingLimitPrefix + msg
+	return nil
+}
+
+// pendingLimitSkipReason 该币种该方向已有未成交的限价开仓单时返回跳过原因（避免重复挂单）
+func (at *AutoTrader) pendingLimitSkipReason(symbol, side string) string {
+	at.pendingLimitsMu.Lock()
+	pending, exists := at.pendingLimits[symbol+"_"+side]
+	at.pendingLimitsMu.Unlock()
+	if !exists {
+		return ""
+	}
+	return fmt.Sprintf("%s %s 已有未成交的限价开仓单（价格 %.4f，已挂 %.0f 分钟）",
+		symbol, side, pending.decision.LimitPrice, time.Since(pending.placedAt).Minutes())
+}
+
+// checkPendingLimitOrders 检查限价开仓单是否成交（随单仓位止损检查执行，默认每10秒）
+// 持仓数量达到预期的90%或超时后，先撤销未成交的剩余部分（避免之后成交的数量没有止损止盈覆盖），
+// 再按撤单后的实际持仓数量设置止损止盈
+func (at *AutoTrader) checkPendingLimitOrders() {
+	at.pendingLimitsMu.Lock()
+	pendings := make(map[string]*pendingLimitOrder, len(at.pendingLimits))
+	for key, pending := range at.pendingLimits {
+		pendings[key] = pending
+	}
+	at.pendingLimitsMu.Unlock()
+
+	for key, pending := range pendings {
+		symbol := pending.decision.Symbol
+		filled, err := at.openPositionQuantity(symbol, pending.side)
+		if err != nil {
+			log.Printf("⚠️  查询 %s %s 限价单成交情况失败: %v", symbol, pending.side, err)
+			continue
+		}
+
+		if filled < pending.quantity*openVerifyMinFillRatio && time.Since(pending.placedAt) < at.getConfig().LimitOrderTimeout {
+			continue
+		}
+
+		// 撤销未成交的剩余部分后重新查询持仓（撤单前可能刚好成交）
+		if err := at.settlePendingLimitOrder(pending); err != nil {
+			log.Printf("⚠️  撤销 %s %s 限价单剩余部分失败（下次检查重试）: %v", symbol, pending.side, err)
+			continue
+		}
+		if filled, err = at.openPositionQuantity(symbol, pending.side); err != nil {
+			log.Printf("⚠️  撤单后查询 %s %s 持仓失败（下次检查重试）: %v", symbol, pending.side, err)
+			continue
+		}
+		if filled <= 0 {
+			at.removePendingLimit(key)
+			log.Printf("⌛ %s %s 限价单（%.4f）超时未成交，已撤单", symbol, pending.side, pending.decision.LimitPrice)
+			continue
+		}
+		if filled < pending.quantity*openVerifyMinFillRatio {
+			log.Printf("⌛ %s %s 限价单超时已撤单，部分成交 %.8f/%.8f", symbol, pending.side, filled, pending.quantity)
+		}
+
+		at.removePendingLimit(key)
+		at.onLimitOpenFilled(pending, filled)
+	}
+}
+
+// onLimitOpenFilled 限价开仓成交后设置止损止盈、保存逻辑并发送通知
+func (at *AutoTrader) onLimitOpenFilled(pending *pendingLimitOrder, filled float64) {
+	dec := &pending.decision
+	actionRecord := pending.actionRecord
+	actionRecord.Quantity = filled
+	actionRecord.Success = true
+	actionRecord.Error = ""
+
+	at.eventLog.Info(atomic.LoadInt64(&at.callCount), dec.Symbol, "open_"+pending.side,
+		fmt.Sprintf("✓ %s %s 限价开仓成交，订单ID: %d, 价格: %.4f, 数量: %.8f", dec.Symbol, pending.side, pending.orderID, dec.LimitPrice, filled),
+		"order_id", pending.orderID, "quantity", filled, "price", dec.LimitPrice, "leverage", dec.Leverage, "order_type", "limit")
+
+	marketData := pending.marketData
+	if latest, err := market.Get(dec.Symbol); err == nil {
+		marketData = latest
+	}
+	at.finishOpenPosition(dec, pending.side, &actionRecord, marketData)
+	at.notifier.NotifyTrade(actionRecord)
+}
+
+// cancelPendingLimitOrder 撤销限价开仓单（下单响应中没有订单ID时撤销该币种所有挂单，此时该币种尚无本方向持仓的保护单）
+func (at *AutoTrader) cancelPendingLimitOrder(pending *pendingLimitOrder) error {
+	if pending.orderID == 0 {
+		return at.trader.CancelAllOrders(pending.decision.Symbol)
+	}
+	return at.trader.CancelOrder(pending.decision.Symbol, pending.orderID)
+}
+
+// settlePendingLimitOrder 确认限价开仓单已结束，仍在挂单中时撤销剩余部分（没有订单ID时撤销该币种所有挂单）
+func (at *AutoTrader) settlePendingLimitOrder(pending *pendingLimitOrder) error {
+	if pending.orderID == 0 {
+		return at.cancelPendingLimitOrder(pending)
+	}
+	return at.settleOrderByID(pending.decision.Symbol, pending.orderID)
+}
+
+// cancelAllPendingLimits 撤销所有未成交的限价开仓单（紧急平仓时使用，撤单失败的保留到下次检查按超时处理）
+func (at *AutoTrader) cancelAllPendingLimits() {
+	at.pendingLimitsMu.Lock()
+	defer at.pendingLimitsMu.Unlock()
+	for key, pending := range at.pendingLimits {
+		if err := at.cancelPendingLimitOrder(pending); err != nil {
+			log.Printf("⚠️  撤销 %s %s 限价开仓单失败: %v", pending.decision.Symbol, pending.side, err)
+			continue
+		}
+		delete(at.pendingLimits, key)
+		log.Printf("  ✓ 已撤销 %s %s 限价开仓单", pending.decision.Symbol, pending.side)
+	}
+}
+
+// removePendingLimit 移除已处理的限价开仓单
+func (at *AutoTrader) removePendingLimit(key string) {
+	at.pendingLimitsMu.Lock()
+	delete(at.pendingLimits, key)
+	at.pendingLimitsMu.Unlock()
+}
+
+// orderIDFromResult 从下单响应中解析订单ID（JSON数字解码为float64，部分接口返回字符串）
+func orderIDFromResult(order map[string]interface{}) (int64, bool) {
+	switch v := order["orderId"].(type) {
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	case string:
+		id, err := strconv.ParseInt(v, 10, 64)
+		return id, err == nil
+	}
+	return 0, false
+}
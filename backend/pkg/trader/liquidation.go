@@ -0,0 +1,206 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"backend/pkg/logger"
+	"backend/pkg/storage"
+)
+
+// LiquidationCloseReason 被交易所强制平仓（爆仓）的交易记录平仓原因
+const LiquidationCloseReason = "liquidated"
+
+// liquidationLookback 持仓没有首次出现时间记录时，查询强平订单历史的时间范围
+const liquidationLookback = 24 * time.Hour
+
+// isLiquidationOrder 判断交易所订单是否为强平订单（爆仓的clientOrderId以"autoclose-"开头，自动减仓为"adl_autoclose"）
+func isLiquidationOrder(order map[string]interface{}) bool {
+	clientOrderID, ok := getString(order, "clientOrderId")
+	if !ok {
+		return false
+	}
+	id := strings.ToLower(clientOrderID)
+	return strings.HasPrefix(id, "autoclose-") || strings.HasPrefix(id, "adl_autoclose")
+}
+
+// detectLiquidations 检测已消失的持仓是否被交易所强平
+// 本地有首次出现时间记录、但交易所已没有的持仓，查询交易所强平订单历史确认：确认被强平的更新交易记录
+// （CloseReason="liquidated"、WasStopLoss=true）、清理本地持仓状态并告警；未被强平的按原流程作为普通平仓处理。
+// 每个消失的持仓只查询一次（查询失败时下次重试）
+func (at *AutoTrader) detectLiquidations(currentPositionKeys map[string]bool) {
+	closed := make(map[string]int64)
+	at.positionTimeMu.RLock()
+	for posKey, firstSeen := range at.positionFirstSeenTime {
+		if !currentPositionKeys[posKey] {
+			closed[posKey] = firstSeen
+		}
+	}
+	at.positionTimeMu.RUnlock()
+
+	var toCheck []string
+	at.liquidationCheckedMu.Lock()
+	for posKey := range at.liquidationChecked {
+		if _, exists := closed[posKey]; !exists {
+			delete(at.liquidationChecked, posKey)
+		}
+	}
+	for posKey, firstSeen := range closed {
+		if checked, exists := at.liquidationChecked[posKey]; exists && checked == firstSeen {
+			continue
+		}
+		at.liquidationChecked[posKey] = firstSeen
+		toCheck = append(toCheck, posKey)
+	}
+	at.liquidationCheckedMu.Unlock()
+
+	for _, posKey := range toCheck {
+		idx := strings.LastIndex(posKey, "_")
+		if idx <= 0 {
+			continue
+		}
+		symbol, side := posKey[:idx], posKey[idx+1:]
+
+		since := time.Now().Add(-liquidationLookback)
+		if firstSeen := closed[posKey]; firstSeen > 0 {
+			since = time.UnixMilli(firstSeen)
+		}
+		order, found, err := at.findLiquidationOrder(symbol, side, since)
+		if err != nil {
+			log.Printf("⚠️  查询 %s %s 强平记录失败（下次重试）: %v", symbol, side, err)
+			at.liquidationCheckedMu.Lock()
+			delete(at.liquidationChecked, posKey)
+			at.liquidationCheckedMu.Unlock()
+			continue
+		}
+		if found {
+			at.handleLiquidation(symbol, side, order)
+		}
+	}
+}
+
+// findLiquidationOrder 在交易所强平订单历史中查找since之后该持仓的强平订单（平多为SELL，平空为BUY），有多个时取最新的
+func (at *AutoTrader) findLiquidationOrder(symbol, side string, since time.Time) (map[string]interface{}, bool, error) {
+	orders, err := at.trader.GetForceOrders(symbol, since)
+	if err != nil {
+		return nil, false, err
+	}
+
+	closeSide := "SELL"
+	if side == "short" {
+		closeSide = "BUY"
+	}
+	var latest map[string]interface{}
+	latestTime := 0.0
+	for _, order := range orders {
+		if !isLiquidationOrder(order) {
+			continue
+		}
+		if orderSymbol, _ := getString(order, "symbol"); orderSymbol != symbol {
+			continue
+		}
+		if orderSide, _ := getString(order, "side"); orderSide != closeSide {
+			continue
+		}
+		orderTime, _ := getFloat(order, "time")
+		if latest == nil || orderTime > latestTime {
+			latest, latestTime = order, orderTime
+		}
+	}
+	return latest, latest != nil, nil
+}
+
+// handleLiquidation 持仓被交易所强平：更新交易记录、清理本地持仓状态（首次出现时间、移动止损和持仓逻辑）并告警
+func (at *AutoTrader) handleLiquidation(symbol, side string, order map[string]interface{}) {
+	price, _ := getFloat(order, "avgPrice")
+	if price <= 0 {
+		price, _ = getFloat(order, "price")
+	}
+	quantity, _ := getFloat(order, "executedQty")
+	closeTime := time.Now()
+	if ms, ok := getFloat(order, "updateTime"); ok && ms > 0 {
+		closeTime = time.UnixMilli(int64(ms))
+	} else if ms, ok := getFloat(order, "time"); ok && ms > 0 {
+		closeTime = time.UnixMilli(int64(ms))
+	}
+	orderID, _ := orderIDFromResult(order)
+
+	pnl := at.recordLiquidationTrade(symbol, side, price, quantity, orderID, closeTime)
+
+	posKey := symbol + "_" + side
+	at.positionTimeMu.Lock()
+	delete(at.positionFirstSeenTime, posKey)
+	at.positionTimeMu.Unlock()
+	at.clearTrailingStop(symbol, side)
+	if at.positionLogicManager != nil {
+		if err := at.positionLogicManager.DeleteLogic(symbol, side); err != nil {
+			log.Printf("  ⚠️  清理持仓逻辑失败: %v", err)
+		}
+	}
+	at.recordPositionClose(symbol, side)
+
+	at.eventLog.Warn(atomic.LoadInt64(&at.callCount), symbol, "liquidated_"+side,
+		fmt.Sprintf("🚨 持仓被交易所强制平仓: %s %s 强平价%.4f，数量%.8f，盈亏%.2f USDT", symbol, side, price, quantity, pnl),
+		"price", price, "quantity", quantity, "order_id", orderID, "pnl", pnl)
+	at.notifier.NotifyForcedClose(symbol, side, fmt.Sprintf("🚨 被交易所强制平仓（爆仓），强平价%.4f", price), pnl)
+}
+
+// recordLiquidationTrade 把该持仓未平仓的交易记录更新为被强平（CloseReason="liquidated"、WasStopLoss=true），返回盈亏
+// 没有未平仓的交易记录（如系统外开仓）时只告警，返回0
+func (at *AutoTrader) recordLiquidationTrade(symbol, side string, price, quantity float64, orderID int64, closeTime time.Time) float64 {
+	if at.storageAdapter == nil || at.storageAdapter.GetTradeStorage() == nil {
+		return 0
+	}
+	tradeStorage := at.storageAdapter.GetTradeStorage()
+	openTrade, err := tradeStorage.GetOpenTrade(symbol, side)
+	if err != nil || openTrade == nil {
+		log.Printf("⚠️  未找到 %s %s 的未平仓交易记录，无法记录强平: %v", symbol, side, err)
+		return 0
+	}
+
+	openAction := &logger.DecisionAction{
+		Price:     openTrade.OpenPrice,
+		Quantity:  openTrade.OpenQuantity,
+		Leverage:  openTrade.OpenLeverage,
+		OrderID:   openTrade.OpenOrderID,
+		Timestamp: openTrade.OpenTime,
+		Success:   true,
+	}
+	closeAction := &logger.DecisionAction{
+		Price:     price,
+		Quantity:  quantity,
+		OrderID:   orderID,
+		Timestamp: closeTime,
+		Success:   true,
+	}
+	forcedReason := "被交易所强制平仓（爆仓）"
+	trade := at.buildTradeRecord(symbol, side, openAction, closeAction, openTrade.OpenCycleNum, atomic.LoadInt64(&at.callCount),
+		true, forcedReason, openTrade.OpenReason, LiquidationCloseReason)
+
+	update := &storage.TradeRecord{
+		TradeID:          openTrade.TradeID,
+		Symbol:           symbol,
+		Side:             side,
+		CloseTime:        &closeTime,
+		ClosePrice:       trade.ClosePrice,
+		CloseQuantity:    trade.CloseQuantity,
+		CloseOrderID:     trade.CloseOrderID,
+		CloseReason:      LiquidationCloseReason,
+		CloseCycleNum:    trade.CloseCycleNum,
+		IsForced:         true,
+		ForcedReason:     forcedReason,
+		ForcedCloseLogic: forcedReason,
+		Duration:         trade.Duration,
+		PnL:              trade.PnL,
+		PnLPct:           trade.PnLPct,
+		WasStopLoss:      true,
+		Success:          true,
+	}
+	if err := tradeStorage.UpdateTrade(update); err != nil {
+		log.Printf("⚠️  记录 %s %s 强平交易失败: %v", symbol, side, err)
+	}
+	return trade.PnL
+}
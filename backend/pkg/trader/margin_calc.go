@@ -0,0 +1,98 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"backend/pkg/decision"
+)
+
+// MarginCalcResult 假设开仓的保证金试算结果
+type MarginCalcResult struct {
+	Symbol           string  `json:"symbol"`
+	PositionSizeUSD  float64 `json:"position_size_usd"`
+	Leverage         int     `json:"leverage"`
+	TotalEquity      float64 `json:"total_equity"`
+	AvailableBalance float64 `json:"available_balance"`
+	MarginImpact
+	// PassSafetyCheck 是否能通过开仓前的保证金和余额安全检查（按不设止损计算，强制平仓价距离与方向无关）
+	PassSafetyCheck bool   `json:"pass_safety_check"`
+	FailReason      string `json:"fail_reason,omitempty"`
+}
+
+// CalcMargin 试算以positionSizeUSD和leverage开仓symbol对保证金的影响（不下单）
+// 账户和持仓从交易所实时获取，是否通过安全检查与实际开仓使用同一个checkMarginAndBalanceSafety
+func (at *AutoTrader) CalcMargin(symbol string, positionSizeUSD float64, leverage int) (*MarginCalcResult, error) {
+	symbol = strings.ToUpper(symbol)
+	ctx, err := at.marginContext()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MarginCalcResult{
+		Symbol:           symbol,
+		PositionSizeUSD:  positionSizeUSD,
+		Leverage:         leverage,
+		TotalEquity:      ctx.Account.TotalEquity,
+		AvailableBalance: ctx.Account.AvailableBalance,
+		MarginImpact:     ComputeMarginImpact(ctx.Account, ctx.Positions, symbol, positionSizeUSD, leverage),
+	}
+
+	dec := &decision.Decision{
+		Symbol:          symbol,
+		Action:          "open_long",
+		Leverage:        leverage,
+		PositionSizeUSD: positionSizeUSD,
+	}
+	if err := at.checkMarginAndBalanceSafety(ctx, dec); err != nil {
+		result.FailReason = err.Error()
+	} else {
+		result.PassSafetyCheck = true
+	}
+	return result, nil
+}
+
+// marginContext 构建只包含账户和持仓的交易上下文（保证金按持仓价值/杠杆估算，与buildTradingContext一致）
+func (at *AutoTrader) marginContext() (*decision.Context, error) {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return nil, fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	wallet, _ := getFloat(balance, "totalWalletBalance")
+	unrealized, _ := getFloat(balance, "totalUnrealizedProfit")
+	available, _ := getFloat(balance, "availableBalance")
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	ctx := &decision.Context{}
+	marginUsed := 0.0
+	for _, pos := range positions {
+		p, parseErr := parsePosition(pos)
+		if parseErr != nil {
+			log.Printf("⚠️  跳过格式异常的持仓: %v", parseErr)
+			continue
+		}
+		marginUsed += (p.Quantity * p.MarkPrice) / float64(p.Leverage)
+		ctx.Positions = append(ctx.Positions, decision.PositionInfo{
+			Symbol:   p.Symbol,
+			Side:     p.Side,
+			Quantity: p.Quantity,
+		})
+	}
+
+	totalEquity := wallet + unrealized
+	ctx.Account = decision.AccountInfo{
+		TotalEquity:      totalEquity,
+		AvailableBalance: available,
+		MarginUsed:       marginUsed,
+		PositionCount:    len(ctx.Positions),
+	}
+	if totalEquity > 0 {
+		ctx.Account.MarginUsedPct = marginUsed / totalEquity * 100
+	}
+	return ctx, nil
+}
@@ -7,6 +7,64 @@ import (
 	"backend/pkg/market"
 )
 
+// MarginImpact 开仓对保证金的影响（开仓前检查和保证金计算接口共用同一套计算）
+type MarginImpact struct {
+	MarginRequired       float64 `json:"margin_required"`         // 新仓位需要的保证金（仓位价值/杠杆）
+	CurrentMarginUsedPct float64 `json:"current_margin_used_pct"` // 当前保证金使用率（%）
+	MarginUsedPctAfter   float64 `json:"margin_used_pct_after"`   // 开仓后保证金使用率（%）
+	MaxMarginUsedPct     float64 `json:"max_margin_used_pct"`     // 保证金使用率上限（单币种和多币种分别限制）
+	SingleSymbol         bool    `json:"single_symbol"`           // 开仓后是否只持有这一个币种
+	AvailableAfter       float64 `json:"available_balance_after"` // 扣除新仓位保证金后的可用余额
+	MinReserveBalance    float64 `json:"min_reserve_balance"`     // 需保留的最小可用余额（总净值的MinReserveBalancePct%）
+}
+
+// ComputeMarginImpact 计算以positionSizeUSD和leverage开仓后的保证金使用率和可用余额（纯计算，不请求交易所）
+func ComputeMarginImpact(account decision.AccountInfo, positions []decision.PositionInfo, symbol string, positionSizeUSD float64, leverage int) MarginImpact {
+	impact := MarginImpact{MaxMarginUsedPct: MaxMarginUsagePct}
+	if leverage > 0 {
+		impact.MarginRequired = positionSizeUSD / float64(leverage)
+	}
+	if account.TotalEquity > 0 {
+		impact.CurrentMarginUsedPct = (account.MarginUsed / account.TotalEquity) * 100
+		impact.MarginUsedPctAfter = ((account.MarginUsed + impact.MarginRequired) / account.TotalEquity) * 100
+	}
+
+	// 当前没有持仓，或当前持仓只有这个币种时，开仓后只有一个币种，使用单币种保证金使用率限制
+	if account.PositionCount == 0 {
+		impact.SingleSymbol = true
+	} else {
+		symbolSet := make(map[string]bool)
+		for _, pos := range positions {
+			symbolSet[pos.Symbol] = true
+		}
+		impact.SingleSymbol = len(symbolSet) == 1 && symbolSet[symbol]
+	}
+	if impact.SingleSymbol {
+		impact.MaxMarginUsedPct = MaxMarginUsagePctSingleSymbol
+	}
+
+	// 需要额外保留一些余额作为缓冲（至少保留总净值的MinReserveBalancePct%）
+	impact.MinReserveBalance = account.TotalEquity * (MinReserveBalancePct / 100.0)
+	impact.AvailableAfter = account.AvailableBalance - impact.MarginRequired
+	return impact
+}
+
+// Check 检查开仓后保证金使用率是否超限、可用余额是否足够
+func (m MarginImpact) Check() error {
+	if m.MarginUsedPctAfter > m.MaxMarginUsedPct {
+		return fmt.Errorf("❌ 保证金使用率超限: 开仓后预计使用%.1f%% > %.0f%%限制 (当前%.1f%% + 新仓位%.1f%% = %.1f%%)", 
+			m.MarginUsedPctAfter, m.MaxMarginUsedPct, 
+			m.CurrentMarginUsedPct, 
+			m.MarginUsedPctAfter-m.CurrentMarginUsedPct, 
+			m.MarginUsedPctAfter)
+	}
+	if m.AvailableAfter < m.MinReserveBalance {
+		return fmt.Errorf("❌ 可用余额不足: 开仓需要保证金%.2f USDT，剩余%.2f < 最小保留%.2f (总净值%.0f%%)", 
+			m.MarginRequired, m.AvailableAfter, m.MinReserveBalance, MinReserveBalancePct)
+	}
+	return nil
+}
+
 // checkMarginAndBalanceSafety 检查保证金和余额安全性（开仓前检查）
 func (at *AutoTrader) checkMarginAndBalanceSafety(ctx *decision.Context, decision *decision.Decision) error {
 	// 1. 获取当前价格
@@ -19,66 +77,13 @@ func (at *AutoTrader) checkMarginAndBalanceSafety(ctx *decision.Context, decisio
 		return fmt.Errorf("当前价格无效: %.4f", marketData.CurrentPrice)
 	}
 	
-	// 2. 计算新仓位需要的保证金
-	positionValue := decision.PositionSizeUSD
-	marginRequired := positionValue / float64(decision.Leverage)
-	
-	// 3. 计算开仓后的总保证金使用率
-	currentMarginUsed := ctx.Account.MarginUsed
-	totalMarginAfterOpen := currentMarginUsed + marginRequired
-	totalMarginUsedPct := 0.0
-	if ctx.Account.TotalEquity > 0 {
-		totalMarginUsedPct = (totalMarginAfterOpen / ctx.Account.TotalEquity) * 100
-	}
-	
-	// 3.5. 判断是否为单个币种交易
-	// 如果当前没有持仓，开仓后只有一个币种
-	// 如果当前有持仓，检查是否与要开的仓是同一个币种
-	isSingleSymbol := false
-	if ctx.Account.PositionCount == 0 {
-		// 当前没有持仓，开仓后只有一个币种
-		isSingleSymbol = true
-	} else {
-		// 检查当前持仓是否只有同一个币种
-		symbolSet := make(map[string]bool)
-		for _, pos := range ctx.Positions {
-			symbolSet[pos.Symbol] = true
-		}
-		// 如果当前持仓只有这个币种，或者没有持仓（开仓后只有这个币种）
-		if len(symbolSet) == 1 {
-			for symbol := range symbolSet {
-				if symbol == decision.Symbol {
-					isSingleSymbol = true
-					break
-				}
-			}
-		}
-	}
-	
-	// 4. 根据币种数量选择保证金使用率限制
-	maxMarginUsagePct := MaxMarginUsagePct
-	if isSingleSymbol {
-		maxMarginUsagePct = MaxMarginUsagePctSingleSymbol
-		log.Printf("  ℹ️  单币种交易模式: 保证金使用率限制为 %.0f%%", maxMarginUsagePct)
+	// 2-5. 计算开仓后的保证金使用率和剩余可用余额，并检查是否超限
+	impact := ComputeMarginImpact(ctx.Account, ctx.Positions, decision.Symbol, decision.PositionSizeUSD, decision.Leverage)
+	if impact.SingleSymbol {
+		log.Printf("  ℹ️  单币种交易模式: 保证金使用率限制为 %.0f%%", impact.MaxMarginUsedPct)
 	}
-	
-	// 检查保证金使用率是否超过限制
-	if totalMarginUsedPct > maxMarginUsagePct {
-		return fmt.Errorf("❌ 保证金使用率超限: 开仓后预计使用%.1f%% > %.0f%%限制 (当前%.1f%% + 新仓位%.1f%% = %.1f%%)", 
-			totalMarginUsedPct, maxMarginUsagePct, 
-			(currentMarginUsed/ctx.Account.TotalEquity)*100, 
-			(marginRequired/ctx.Account.TotalEquity)*100, 
-			totalMarginUsedPct)
-	}
-	
-	// 5. 检查可用余额是否足够
-	// 需要额外保留一些余额作为缓冲（至少保留总净值的MinReserveBalancePct%）
-	minReserveBalance := ctx.Account.TotalEquity * (MinReserveBalancePct / 100.0)
-	availableBalanceAfterMargin := ctx.Account.AvailableBalance - marginRequired
-	
-	if availableBalanceAfterMargin < minReserveBalance {
-		return fmt.Errorf("❌ 可用余额不足: 开仓需要保证金%.2f USDT，剩余%.2f < 最小保留%.2f (总净值5%%)", 
-			marginRequired, availableBalanceAfterMargin, minReserveBalance)
+	if err := impact.Check(); err != nil {
+		return err
 	}
 	
 	// 6. 预估强制平仓价格并检查是否过高（太接近当前价格）
@@ -143,8 +148,82 @@ func (at *AutoTrader) checkMarginAndBalanceSafety(ctx *decision.Context, decisio
 	
 	// 所有检查通过
 	log.Printf("  ✓ 风控检查通过: 保证金使用率%.1f%% < %.0f%%, 可用余额充足, 强制平仓价安全距离%.2f%%", 
-		totalMarginUsedPct, maxMarginUsagePct, priceDistancePct)
+		impact.MarginUsedPctAfter, impact.MaxMarginUsedPct, priceDistancePct)
 	
 	return nil
 }
 
+
+// fundingRateSkipReason 检查资金费率（开仓前检查）
+// 多头在资金费率为正时支付、空头在为负时支付；支付方向的费率超过MaxFundingRate时返回跳过原因
+// 未配置上限（0）或获取资金费率失败时返回空字符串（不阻止开仓）
+func (at *AutoTrader) fundingRateSkipReason(symbol, side string) string {
+	if at.getConfig().MaxFundingRate <= 0 {
+		return ""
+	}
+
+	fundingRate, err := market.GetFundingRate(symbol)
+	if err != nil {
+		log.Printf("  ⚠️  获取 %s 资金费率失败，跳过资金费率检查: %v", symbol, err)
+		return ""
+	}
+
+	// 换算为本方向需要支付的费率（为负表示收取资金费）
+	payingRate, sideName := fundingRate, "多"
+	if side == "short" {
+		payingRate, sideName = -fundingRate, "空"
+	}
+	if payingRate > at.getConfig().MaxFundingRate {
+		return fmt.Sprintf("%s 资金费率 %.4f%%，开%s需支付 %.4f%% > 上限 %.4f%%，拒绝开仓",
+			symbol, fundingRate*100, sideName, payingRate*100, at.getConfig().MaxFundingRate*100)
+	}
+	return ""
+}
+
+// staleDataSkipReason 检查行情数据新鲜度（开仓前检查）
+// 最新K线收盘时间早于阈值（未配置时为2倍K线周期）时返回跳过原因，避免币种停止交易时按失效价格开仓
+func (at *AutoTrader) staleDataSkipReason(data *market.Data, timeframe string) string {
+	maxAge := at.getConfig().MaxDataAge
+	if maxAge <= 0 {
+		maxAge = 2 * market.IntervalDuration(timeframe)
+	}
+	if maxAge <= 0 || data.DataAge <= maxAge {
+		return ""
+	}
+	return fmt.Sprintf("%s 行情数据已过期（最新%s K线收盘于 %.1f 分钟前 > %.1f 分钟），可能已停止交易，拒绝开仓",
+		data.Symbol, timeframe, data.DataAge.Minutes(), maxAge.Minutes())
+}
+
+// maxOpenPositionsSkipReason 检查持仓数量上限（开仓前检查）
+// 当前持仓数已达到MaxOpenPositions时返回跳过原因，未达上限或未配置上限（0）时返回空字符串
+func (at *AutoTrader) maxOpenPositionsSkipReason(positions []map[string]interface{}) string {
+	if at.getConfig().MaxOpenPositions <= 0 {
+		return ""
+	}
+	if len(positions) >= at.getConfig().MaxOpenPositions {
+		return fmt.Sprintf("当前持仓数 %d 已达上限 %d，拒绝新开仓", len(positions), at.getConfig().MaxOpenPositions)
+	}
+	return ""
+}
+
+// checkSymbolMinimums 检查下单数量是否满足交易所该币种的最小数量和最小下单金额（开仓前检查）
+// 获取交易对限制失败时退回全局最小仓位检查（MinPositionSizeUSD）
+func (at *AutoTrader) checkSymbolMinimums(symbol string, quantity, price float64) error {
+	minNotional, minQty, _, _, err := at.trader.GetSymbolFilters(symbol)
+	if err != nil {
+		log.Printf("⚠️  获取 %s 交易对限制失败，使用默认最小仓位检查: %v", symbol, err)
+		minQuantity := MinPositionSizeUSD / price
+		if quantity < minQuantity {
+			return fmt.Errorf("计算出的数量过小(%.8f)，小于最小要求(%.8f)。可能因为仓位大小过小或价格过高", quantity, minQuantity)
+		}
+		return nil
+	}
+
+	if minQty > 0 && quantity < minQty {
+		return fmt.Errorf("计算出的数量过小(%.8f)，小于%s的最小下单数量(%.8f)。可能因为仓位大小过小或价格过高", quantity, symbol, minQty)
+	}
+	if notional := quantity * price; minNotional > 0 && notional < minNotional {
+		return fmt.Errorf("下单金额过小(%.4f USDT)，小于%s的最小下单金额(%.2f USDT)，请增大仓位", notional, symbol, minNotional)
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package trader
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"backend/pkg/decision"
+)
+
+func TestComputeMarginImpact(t *testing.T) {
+	ethPosition := []decision.PositionInfo{{Symbol: "ETHUSDT", Side: "long"}}
+
+	tests := []struct {
+		name       string
+		account    decision.AccountInfo
+		positions  []decision.PositionInfo
+		symbol     string
+		sizeUSD    float64
+		leverage   int
+		wantSingle bool
+		wantMaxPct float64
+		wantPct    float64
+		wantErr    string // 空表示Check应通过
+	}{
+		{
+			name:       "no positions at single-symbol limit",
+			account:    decision.AccountInfo{TotalEquity: 1000, AvailableBalance: 1000},
+			symbol:     "BTCUSDT",
+			sizeUSD:    4000,
+			leverage:   5,
+			wantSingle: true,
+			wantMaxPct: MaxMarginUsagePctSingleSymbol,
+			wantPct:    80,
+		},
+		{
+			name:       "no positions over single-symbol limit",
+			account:    decision.AccountInfo{TotalEquity: 1000, AvailableBalance: 1000},
+			symbol:     "BTCUSDT",
+			sizeUSD:    4250,
+			leverage:   5,
+			wantSingle: true,
+			wantMaxPct: MaxMarginUsagePctSingleSymbol,
+			wantPct:    85,
+			wantErr:    "保证金使用率超限",
+		},
+		{
+			name:       "adding to the only held symbol uses single-symbol limit",
+			account:    decision.AccountInfo{TotalEquity: 1000, AvailableBalance: 700, MarginUsed: 300, PositionCount: 1},
+			positions:  ethPosition,
+			symbol:     "ETHUSDT",
+			sizeUSD:    2750,
+			leverage:   5,
+			wantSingle: true,
+			wantMaxPct: MaxMarginUsagePctSingleSymbol,
+			wantPct:    85,
+			wantErr:    "保证金使用率超限",
+		},
+		{
+			name:       "new symbol uses multi-symbol limit",
+			account:    decision.AccountInfo{TotalEquity: 1000, AvailableBalance: 700, MarginUsed: 300, PositionCount: 1},
+			positions:  ethPosition,
+			symbol:     "BTCUSDT",
+			sizeUSD:    2750,
+			leverage:   5,
+			wantSingle: false,
+			wantMaxPct: MaxMarginUsagePct,
+			wantPct:    85,
+		},
+		{
+			name:       "new symbol over multi-symbol limit",
+			account:    decision.AccountInfo{TotalEquity: 1000, AvailableBalance: 700, MarginUsed: 300, PositionCount: 1},
+			positions:  ethPosition,
+			symbol:     "BTCUSDT",
+			sizeUSD:    3250,
+			leverage:   5,
+			wantSingle: false,
+			wantMaxPct: MaxMarginUsagePct,
+			wantPct:    95,
+			wantErr:    "保证金使用率超限",
+		},
+		{
+			name:       "available balance exactly at reserve",
+			account:    decision.AccountInfo{TotalEquity: 1000, AvailableBalance: 100},
+			symbol:     "BTCUSDT",
+			sizeUSD:    250,
+			leverage:   5,
+			wantSingle: true,
+			wantMaxPct: MaxMarginUsagePctSingleSymbol,
+			wantPct:    5,
+		},
+		{
+			name:       "available balance below reserve",
+			account:    decision.AccountInfo{TotalEquity: 1000, AvailableBalance: 100},
+			symbol:     "BTCUSDT",
+			sizeUSD:    300,
+			leverage:   5,
+			wantSingle: true,
+			wantMaxPct: MaxMarginUsagePctSingleSymbol,
+			wantPct:    6,
+			wantErr:    "可用余额不足",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			impact := ComputeMarginImpact(tt.account, tt.positions, tt.symbol, tt.sizeUSD, tt.leverage)
+
+			if impact.SingleSymbol != tt.wantSingle {
+				t.Errorf("SingleSymbol = %v, want %v", impact.SingleSymbol, tt.wantSingle)
+			}
+			if impact.MaxMarginUsedPct != tt.wantMaxPct {
+				t.Errorf("MaxMarginUsedPct = %v, want %v", impact.MaxMarginUsedPct, tt.wantMaxPct)
+			}
+			if math.Abs(impact.MarginUsedPctAfter-tt.wantPct) > 1e-9 {
+				t.Errorf("MarginUsedPctAfter = %v, want %v", impact.MarginUsedPctAfter, tt.wantPct)
+			}
+			wantRequired := tt.sizeUSD / float64(tt.leverage)
+			if impact.MarginRequired != wantRequired {
+				t.Errorf("MarginRequired = %v, want %v", impact.MarginRequired, wantRequired)
+			}
+			if want := tt.account.TotalEquity * MinReserveBalancePct / 100; impact.MinReserveBalance != want {
+				t.Errorf("MinReserveBalance = %v, want %v", impact.MinReserveBalance, want)
+			}
+			if want := tt.account.AvailableBalance - wantRequired; impact.AvailableAfter != want {
+				t.Errorf("AvailableAfter = %v, want %v", impact.AvailableAfter, want)
+			}
+
+			err := impact.Check()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("Check() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("Check() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestComputeMarginImpactInvalidInputs(t *testing.T) {
+	impact := ComputeMarginImpact(decision.AccountInfo{}, nil, "BTCUSDT", 1000, 0)
+	if impact.MarginRequired != 0 || impact.CurrentMarginUsedPct != 0 || impact.MarginUsedPctAfter != 0 {
+		t.Errorf("zero leverage/equity should not divide: %+v", impact)
+	}
+}
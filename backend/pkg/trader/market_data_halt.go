@@ -0,0 +1,123 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"sync/atomic"
+
+	"backend/pkg/decision"
+	"backend/pkg/logger"
+	"backend/pkg/market"
+)
+
+// checkHaltedSymbols 检查持仓币种的行情数据是否持续获取失败（交易所停牌/下架）
+// 只有失败是该币种特有时才计入：其他持仓币种的行情获取正常，或交易所exchangeInfo中该交易对的状态不是TRADING；
+// 所有持仓币种同时失败且交易状态正常（或无法查询）时视为行情接口整体故障，不计入也不重新计数，避免行情故障时平掉所有持仓。
+// 连续失败周期数达到halted_symbol_failures时告警；halted_symbol_action为close时按交易所持仓的标记价格平仓
+// （平仓失败时forceClosePosition会设置重试标记，之后的周期继续尝试）
+func (at *AutoTrader) checkHaltedSymbols(ctx *decision.Context) []logger.DecisionAction {
+	cfg := at.getConfig()
+	threshold := cfg.HaltedSymbolFailures
+	if threshold <= 0 {
+		return nil
+	}
+
+	fetchErrs := make(map[string]error)
+	anyFetched := false
+	for _, pos := range ctx.Positions {
+		if _, done := fetchErrs[pos.Symbol]; done {
+			continue
+		}
+		_, err := market.Get(pos.Symbol)
+		fetchErrs[pos.Symbol] = err
+		if err == nil {
+			anyFetched = true
+		}
+	}
+
+	var actions []logger.DecisionAction
+	currentPositionKeys := make(map[string]bool)
+	for _, pos := range ctx.Positions {
+		posKey := pos.Symbol + "_" + pos.Side
+		currentPositionKeys[posKey] = true
+
+		err := fetchErrs[pos.Symbol]
+		if err != nil && !anyFetched && !at.symbolNotTrading(pos.Symbol) {
+			log.Printf("⚠️  所有持仓币种的行情数据都获取失败，视为行情接口故障，不计入停牌判定（%s: %v）", pos.Symbol, err)
+			continue
+		}
+
+		failures := at.trackMarketDataResult(posKey, err)
+		if err == nil {
+			continue
+		}
+		if failures < threshold {
+			log.Printf("⚠️  获取持仓币种 %s 行情数据失败（连续%d/%d个周期）: %v", pos.Symbol, failures, threshold, err)
+			continue
+		}
+
+		reason := fmt.Sprintf("行情数据连续%d个周期获取失败，疑似停牌/下架（标记价格 %.4f）", failures, pos.MarkPrice)
+		if failures == threshold {
+			at.eventLog.Warn(atomic.LoadInt64(&at.callCount), pos.Symbol, "risk_trigger",
+				fmt.Sprintf("🚨 [%s] %s %s %s", at.name, pos.Symbol, pos.Side, reason),
+				"reason", "market_data_halt", "side", pos.Side, "consecutive_failures", failures,
+				"mark_price", pos.MarkPrice, "error", err.Error())
+			at.notifier.NotifyRiskTrigger("疑似停牌/下架", fmt.Sprintf("%s %s %s\n最近错误: %v", pos.Symbol, pos.Side, reason, err))
+		}
+		if cfg.HaltedSymbolAction != "close" {
+			continue
+		}
+
+		action, closeErr := at.forceClosePosition(pos.Symbol, pos.Side, reason, ForcedCloseTriggerMarketDataHalt)
+		if closeErr != nil {
+			log.Printf("❌ 平仓疑似停牌/下架的持仓失败 (%s %s): %v", pos.Symbol, pos.Side, closeErr)
+			continue
+		}
+		actions = append(actions, action)
+	}
+
+	at.pruneMarketDataFailures(currentPositionKeys)
+	return actions
+}
+
+// symbolNotTrading 交易所exchangeInfo中该交易对的状态不是TRADING（已停牌、结算中或已下架）；查询失败时返回false
+func (at *AutoTrader) symbolNotTrading(symbol string) bool {
+	status, err := at.trader.GetSymbolStatus(symbol)
+	if err != nil {
+		log.Printf("⚠️  查询 %s 交易状态失败: %v", symbol, err)
+		return false
+	}
+	if status != "TRADING" {
+		log.Printf("⚠️  %s 交易状态为 %q（非TRADING）", symbol, status)
+		return true
+	}
+	return false
+}
+
+// trackMarketDataResult 更新持仓币种行情数据的连续失败次数，返回更新后的次数（成功时重新计数）
+func (at *AutoTrader) trackMarketDataResult(posKey string, err error) int {
+	at.marketDataFailuresMu.Lock()
+	defer at.marketDataFailuresMu.Unlock()
+
+	if err == nil {
+		if failures := at.marketDataFailures[posKey]; failures > 0 {
+			log.Printf("✓ %s 行情数据恢复正常（此前连续失败 %d 个周期）", posKey, failures)
+			delete(at.marketDataFailures, posKey)
+		}
+		return 0
+	}
+	at.marketDataFailures[posKey]++
+	return at.marketDataFailures[posKey]
+}
+
+// pruneMarketDataFailures 清理已不存在的持仓的失败计数
+func (at *AutoTrader) pruneMarketDataFailures(currentPositionKeys map[string]bool) {
+	at.marketDataFailuresMu.Lock()
+	defer at.marketDataFailuresMu.Unlock()
+
+	for posKey := range at.marketDataFailures {
+		if !currentPositionKeys[posKey] {
+			delete(at.marketDataFailures, posKey)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+)
+
+// 开仓成交验证配置
+const (
+	openVerifyDelay        = 500 * time.Millisecond // 下单后等待交易所处理订单的时间（与平仓验证一致）
+	openVerifyMinFillRatio = 0.9                    // 持仓数量达到预期的90%即视为开仓成功（允许精度和手续费误差）
+)
+
+// openPositionQuantity 获取交易所当前该方向的持仓数量（没有持仓时返回0）
+// positionAmt无法解析时返回错误而不是按0处理，避免误判为未成交而补单
+func (at *AutoTrader) openPositionQuantity(symbol, side string) (float64, error) {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return 0, err
+	}
+	for _, pos := range positions {
+		if pos["symbol"] == symbol && pos["side"] == side {
+			amt, ok := getFloat(pos, "positionAmt")
+			if !ok {
+				return 0, fmt.Errorf("%s %s 持仓的positionAmt字段缺失或无法解析: %v", symbol, side, pos["positionAmt"])
+			}
+			return math.Abs(amt), nil
+		}
+	}
+	return 0, nil
+}
+
+// placeOpenOrder 按方向下市价开仓单
+func (at *AutoTrader) placeOpenOrder(symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	if side == "long" {
+		return at.trader.OpenLong(symbol, quantity, leverage)
+	}
+	return at.trader.OpenShort(symbol, quantity, leverage)
+}
+
+// openAndVerify 下单开仓并确认持仓已出现且数量接近预期，返回订单和实际持仓数量
+// 市价单可能被拒绝或部分成交：验证不足时先按订单ID查询订单状态，仍在挂单中的先撤单（避免补单后与原订单重复成交），
+// 确认原订单已结束后补单一次（只补未成交的部分）。最终仍不足时按已成交的数量返回，由调用方为这部分持仓
+// 设置止损止盈和保存逻辑；完全没有成交才返回错误，避免为不存在的持仓写入逻辑和时间记录。调用前已确认该方向没有持仓
+func (at *AutoTrader) openAndVerify(symbol, side string, quantity float64, leverage int) (map[string]interface{}, float64, error) {
+	order, err := at.placeOpenOrder(symbol, side, quantity, leverage)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		time.Sleep(openVerifyDelay) // 等待交易所处理订单
+
+		filled, err := at.openPositionQuantity(symbol, side)
+		if err != nil {
+			// 无法查询持仓时不能判断是否成交，按已提交处理（与平仓验证一致），由下一周期的持仓同步纠正
+			log.Printf("  ⚠️  开仓后查询持仓失败，无法验证成交: %v", err)
+			return order, quantity, nil
+		}
+		if filled >= quantity*openVerifyMinFillRatio {
+			return order, filled, nil
+		}
+
+		// 确认订单已结束（未结束的撤单）后重新查询持仓：订单可能仍在成交中，不能直接补单
+		if err := at.settleOrder(symbol, order); err != nil {
+			log.Printf("  ⚠️  无法确认开仓订单（订单ID: %v）已结束，不补单: %v", order["orderId"], err)
+			return partialOpenResult(order, quantity, filled)
+		}
+		time.Sleep(openVerifyDelay)
+		if filled, err = at.openPositionQuantity(symbol, side); err != nil {
+			log.Printf("  ⚠️  撤单后查询持仓失败，无法验证成交: %v", err)
+			return order, quantity, nil
+		}
+		if filled >= quantity*openVerifyMinFillRatio {
+			return order, filled, nil
+		}
+		if attempt >= 2 {
+			return partialOpenResult(order, quantity, filled)
+		}
+
+		// 补单：只补未成交的部分
+		remainingStr, err := at.trader.FormatQuantity(symbol, quantity-filled)
+		if err != nil {
+			log.Printf("  ⚠️  格式化补单数量失败: %v", err)
+			return partialOpenResult(order, quantity, filled)
+		}
+		remaining, err := strconv.ParseFloat(remainingStr, 64)
+		if err != nil || remaining <= 0 {
+			log.Printf("  ⚠️  未成交部分低于交易精度，不补单（预期 %.8f，实际 %.8f）", quantity, filled)
+			return partialOpenResult(order, quantity, filled)
+		}
+
+		log.Printf("  ⚠️  开仓后持仓数量不足（预期 %.8f，实际 %.8f），原订单已结束，补单 %.8f", quantity, filled, remaining)
+		topUp, err := at.placeOpenOrder(symbol, side, remaining, leverage)
+		if err != nil {
+			log.Printf("  ⚠️  补单失败: %v", err)
+			return partialOpenResult(order, quantity, filled)
+		}
+		order = topUp
+	}
+}
+
+// settleOrder 确认开仓订单已结束：仍在挂单中（NEW/PARTIALLY_FILLED）时撤单
+// 下单响应中没有订单ID或查询/撤单失败时返回错误（此时不能确认订单不会继续成交）
+func (at *AutoTrader) settleOrder(symbol string, order map[string]interface{}) error {
+	orderID, ok := orderIDFromResult(order)
+	if !ok || orderID == 0 {
+		return fmt.Errorf("下单响应中没有订单ID")
+	}
+	return at.settleOrderByID(symbol, orderID)
+}
+
+// settleOrderByID 按订单ID确认订单已结束，仍在挂单中时撤单（已完全成交或已撤销的订单不再撤单）
+func (at *AutoTrader) settleOrderByID(symbol string, orderID int64) error {
+	status, err := at.trader.GetOrder(symbol, orderID)
+	if err != nil {
+		return err
+	}
+	state, _ := getString(status, "status")
+	if state != "NEW" && state != "PARTIALLY_FILLED" {
+		return nil
+	}
+
+	log.Printf("  ⏳ 开仓订单 %d 仍在挂单中（%s），撤销未成交部分", orderID, state)
+	if err := at.trader.CancelOrder(symbol, orderID); err != nil {
+		return fmt.Errorf("撤销订单失败: %w", err)
+	}
+	return nil
+}
+
+// partialOpenResult 开仓未达到预期数量时的结果：已部分成交的按实际数量返回（调用方照常设置止损止盈），完全没有成交时返回错误
+func partialOpenResult(order map[string]interface{}, quantity, filled float64) (map[string]interface{}, float64, error) {
+	if filled <= 0 {
+		return nil, 0, fmt.Errorf("开仓后未检测到持仓（订单ID: %v），订单可能被拒绝", order["orderId"])
+	}
+	log.Printf("  ⚠️  开仓部分成交：预期 %.8f，实际 %.8f，按实际数量设置止损止盈", quantity, filled)
+	return order, filled, nil
+}
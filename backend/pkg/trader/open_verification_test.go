@@ -0,0 +1,44 @@
+package trader
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOpenPositionQuantity(t *testing.T) {
+	tests := []struct {
+		name    string
+		amt     interface{}
+		want    float64
+		wantErr bool
+	}{
+		{"float64", -0.5, 0.5, false},
+		{"numeric string", "0.5", 0.5, false},
+		{"json.Number", json.Number("0.25"), 0.25, false},
+		{"unparsable string", "abc", 0, true},
+		{"null", nil, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubTrader{positions: []map[string]interface{}{
+				{"symbol": "ETHUSDT", "side": "long", "positionAmt": 1.0},
+				{"symbol": "BTCUSDT", "side": "long", "positionAmt": tt.amt},
+			}}
+			at := &AutoTrader{trader: stub}
+
+			got, err := at.openPositionQuantity("BTCUSDT", "long")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("openPositionQuantity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("openPositionQuantity() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	at := &AutoTrader{trader: &stubTrader{}}
+	if got, err := at.openPositionQuantity("BTCUSDT", "long"); err != nil || got != 0 {
+		t.Errorf("openPositionQuantity() without position = (%v, %v), want (0, nil)", got, err)
+	}
+}
@@ -0,0 +1,110 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+)
+
+// protectiveOrderTypes 止损止盈类订单类型（触发后只平仓，没有对应持仓时属于孤立订单）
+var protectiveOrderTypes = map[string]bool{
+	"STOP":                 true,
+	"STOP_MARKET":          true,
+	"TAKE_PROFIT":          true,
+	"TAKE_PROFIT_MARKET":   true,
+	"TRAILING_STOP_MARKET": true,
+}
+
+// reconcileOrphanOrders 检查并撤销孤立的止损止盈单（对应持仓已不存在，例如手动平仓或止损触发后剩下的止盈单）
+// 先查询挂单再查询持仓：查询持仓时挂单对应的持仓若仍存在一定能查到，避免误撤刚开仓后挂出的保护单；
+// 未成交的限价开仓单不是止损止盈单，不会被撤销
+func (at *AutoTrader) reconcileOrphanOrders() {
+	orders, err := at.trader.GetOpenOrders("")
+	if err != nil {
+		log.Printf("⚠️  孤立订单检查：%v", err)
+		return
+	}
+	if len(orders) == 0 {
+		return
+	}
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("⚠️  孤立订单检查：获取持仓失败: %v", err)
+		return
+	}
+	held := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		symbol, symbolOK := getString(pos, "symbol")
+		side, sideOK := getString(pos, "side")
+		if !symbolOK || !sideOK {
+			// 无法确定该持仓对应哪些保护单，本次不撤单，避免误撤仍有持仓的止损止盈单
+			log.Printf("⚠️  孤立订单检查：持仓字段无法解析（symbol=%v side=%v），跳过本次检查", pos["symbol"], pos["side"])
+			return
+		}
+		held[symbol+"_"+side] = true
+	}
+
+	var cancelled []string
+	for _, order := range orders {
+		if !isProtectiveOrder(order) {
+			continue
+		}
+		symbol, _ := order["symbol"].(string)
+		side := protectedPositionSide(order)
+		if symbol == "" || side == "" || held[symbol+"_"+side] {
+			continue
+		}
+
+		orderID, ok := orderIDFromResult(order)
+		if !ok {
+			continue
+		}
+		orderType, _ := order["type"].(string)
+		if err := at.trader.CancelOrder(symbol, orderID); err != nil {
+			log.Printf("⚠️  撤销孤立订单失败 (%s %s %s, 订单ID: %d): %v", symbol, side, orderType, orderID, err)
+			continue
+		}
+		desc := fmt.Sprintf("%s %s %s（触发价 %v，订单ID: %d）", symbol, side, orderType, order["stopPrice"], orderID)
+		cancelled = append(cancelled, desc)
+		log.Printf("🧹 已撤销孤立订单: %s，对应持仓已不存在", desc)
+	}
+
+	if len(cancelled) == 0 {
+		return
+	}
+	at.eventLog.Warn(atomic.LoadInt64(&at.callCount), "", "orphan_orders",
+		fmt.Sprintf("🧹 撤销 %d 个孤立的止损止盈单（对应持仓已不存在）", len(cancelled)),
+		"count", len(cancelled), "orders", cancelled)
+	at.notifier.NotifyRiskTrigger("孤立止损止盈单清理", "以下止损止盈单对应的持仓已不存在，已撤销:\n"+strings.Join(cancelled, "\n"))
+}
+
+// isProtectiveOrder 是否为止损止盈类订单（触发单类型，或只减仓/平仓的订单）
+func isProtectiveOrder(order map[string]interface{}) bool {
+	orderType, _ := order["type"].(string)
+	if protectiveOrderTypes[orderType] {
+		return true
+	}
+	reduceOnly, _ := order["reduceOnly"].(bool)
+	closePosition, _ := order["closePosition"].(bool)
+	return reduceOnly || closePosition
+}
+
+// protectedPositionSide 订单保护的持仓方向（long/short）
+// 双向持仓模式按positionSide判断；单向持仓模式（BOTH）下卖单平多、买单平空
+func protectedPositionSide(order map[string]interface{}) string {
+	switch positionSide, _ := order["positionSide"].(string); positionSide {
+	case "LONG":
+		return "long"
+	case "SHORT":
+		return "short"
+	}
+	switch side, _ := order["side"].(string); side {
+	case "SELL":
+		return "long"
+	case "BUY":
+		return "short"
+	}
+	return ""
+}
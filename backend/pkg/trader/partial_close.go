@@ -0,0 +1,135 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"backend/pkg/logger"
+	"backend/pkg/storage"
+)
+
+// partialCloseDustQty 平仓后剩余数量低于该值视为已全部平仓（允许小的精度误差）
+const partialCloseDustQty = 0.0001
+
+// resolveCloseQuantity 根据close_percent计算本次平仓数量
+// 返回0表示全部平仓（未指定比例或比例为100%）；同时把实际平仓数量记录到actionRecord
+func (at *AutoTrader) resolveCloseQuantity(symbol, side string, closePercent float64, actionRecord *logger.DecisionAction) (float64, error) {
+	foundPosition, positionSide, err := at.findPositionBySymbol(symbol)
+	if err != nil || positionSide != side {
+		// 找不到持仓时保持原有行为（全部平仓），由交易所返回具体错误
+		return 0, nil
+	}
+
+	positionAmt, ok := getFloat(foundPosition, "positionAmt")
+	if !ok {
+		// 数量无法解析时保持原有行为（全部平仓），由交易所按实际持仓处理
+		return 0, nil
+	}
+	positionAmt = math.Abs(positionAmt)
+	actionRecord.Quantity = positionAmt
+
+	if closePercent <= 0 || closePercent >= 100 {
+		return 0, nil
+	}
+
+	quantityStr, err := at.trader.FormatQuantity(symbol, positionAmt*closePercent/100)
+	if err != nil {
+		return 0, fmt.Errorf("格式化平仓数量失败: %w", err)
+	}
+	quantity, err := strconv.ParseFloat(quantityStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析格式化后的平仓数量失败: %w", err)
+	}
+	if quantity <= 0 {
+		return 0, fmt.Errorf("按%.0f%%计算的平仓数量过小（持仓 %.8f），低于交易精度", closePercent, positionAmt)
+	}
+	if quantity >= positionAmt {
+		// 格式化后不小于持仓数量，按全部平仓处理
+		return 0, nil
+	}
+
+	log.Printf("  ✂️  部分平仓: %s %s 平仓 %.0f%%，数量 %.8f / %.8f", symbol, side, closePercent, quantity, positionAmt)
+	actionRecord.Quantity = quantity
+	return quantity, nil
+}
+
+// restoreOrdersAfterPartialClose 部分平仓后为剩余持仓重新设置止损止盈
+// 平仓会取消该币种的全部挂单，需要按剩余数量和已保存的止损/止盈重新挂单
+func (at *AutoTrader) restoreOrdersAfterPartialClose(symbol, side string, remainingQty float64) {
+	logic := at.positionLogicManager.GetLogic(symbol, side)
+	if logic == nil || (logic.StopLoss <= 0 && logic.TakeProfit <= 0) {
+		log.Printf("  ⚠️  部分平仓后未找到已保存的止损/止盈，剩余持仓 %.8f 暂无保护订单", remainingQty)
+		return
+	}
+
+	sideStr := "LONG"
+	if side == "short" {
+		sideStr = "SHORT"
+	}
+
+	// 已设置阶梯止盈时按阶梯重新挂单（剩余档位按比例分配剩余持仓）
+	if err := at.placeProtectionOrders(symbol, sideStr, remainingQty, logic.StopLoss, logic.TakeProfit); err != nil {
+		log.Printf("  ⚠️  部分平仓后重新设置止损止盈失败: %v", err)
+		return
+	}
+	log.Printf("  ✓ 已为剩余持仓 %.8f 重新设置止损 %.4f / 止盈 %.4f", remainingQty, logic.StopLoss, logic.TakeProfit)
+}
+
+// logPartialCloseTrade 将部分平仓单独记录为一条已平仓的交易记录
+// TradeID追加_partial_<平仓时间>后缀，避免与仍未平仓的开仓记录冲突
+func (at *AutoTrader) logPartialCloseTrade(trade *logger.TradeRecord, side, closeLogic, updateSLLogic string) {
+	if at.storageAdapter == nil {
+		return
+	}
+	tradeStorage := at.storageAdapter.GetTradeStorage()
+	if tradeStorage == nil {
+		return
+	}
+
+	// 沿用开仓记录中的进场/出场逻辑
+	var entryLogic, exitLogic string
+	if existing, err := tradeStorage.GetOpenTradeByTimeAndSide(trade.Symbol, side, trade.OpenTime); err == nil && existing != nil {
+		entryLogic = existing.EntryLogic
+		exitLogic = existing.ExitLogic
+	}
+
+	closeTime := trade.CloseTime
+	dbTrade := &storage.TradeRecord{
+		TradeID:       fmt.Sprintf("%s_partial_%d", trade.TradeID, closeTime.Unix()),
+		Symbol:        trade.Symbol,
+		Side:          side,
+		OpenTime:      trade.OpenTime,
+		OpenPrice:     trade.OpenPrice,
+		OpenQuantity:  trade.OpenQuantity,
+		OpenLeverage:  trade.OpenLeverage,
+		OpenOrderID:   trade.OpenOrderID,
+		OpenReason:    trade.OpenReason,
+		OpenCycleNum:  trade.OpenCycleNum,
+		CloseTime:     &closeTime,
+		ClosePrice:    trade.ClosePrice,
+		CloseQuantity: trade.CloseQuantity,
+		CloseOrderID:  trade.CloseOrderID,
+		CloseReason:   closeLogic,
+		CloseCycleNum: trade.CloseCycleNum,
+		Duration:      trade.Duration,
+		PositionValue: trade.PositionValue,
+		MarginUsed:    trade.MarginUsed,
+		PnL:           trade.PnL,
+		PnLPct:        trade.PnLPct,
+		Success:       trade.Success,
+		Error:         trade.Error,
+		EntryLogic:    entryLogic,
+		ExitLogic:     exitLogic,
+		UpdateSLLogic: updateSLLogic,
+		CloseLogic:    closeLogic,
+		Strategy:      trade.Strategy,
+	}
+
+	if err := tradeStorage.LogTrade(dbTrade); err != nil {
+		log.Printf("⚠️  保存部分平仓记录失败: %v", err)
+		return
+	}
+	log.Printf("✅ 已记录部分平仓: %s %s 数量 %.8f，盈亏 %.2f USDT", trade.Symbol, side, trade.CloseQuantity, trade.PnL)
+}
@@ -86,9 +86,13 @@ func (at *AutoTrader) analyzePerformanceFromDB(records []*storage.DecisionRecord
 				
 				// 遍历所有持仓，寻找最匹配的开仓记录
 				for tradeID, openPos := range openPositions {
-					if openPos["symbol"].(string) == symbol && openPos["side"].(string) == side {
+					openSymbol, _ := getString(openPos, "symbol")
+					openSide, _ := getString(openPos, "side")
+					if openSymbol == symbol && openSide == side {
 						// 精确匹配，选择最早开仓的（FIFO）
-						if matchedOpenPos == nil || openPos["openTime"].(time.Time).Before(matchedOpenPos["openTime"].(time.Time)) {
+						openTime, _ := openPos["openTime"].(time.Time)
+						matchedTime, _ := matchedOpenPos["openTime"].(time.Time)
+						if matchedOpenPos == nil || openTime.Before(matchedTime) {
 							matchedTradeID = tradeID
 							matchedOpenPos = openPos
 						}
@@ -236,10 +240,7 @@ func (at *AutoTrader) analyzePerformanceFromDB(records []*storage.DecisionRecord
 
 // analyzePerformanceFromTrades 从交易记录分析历史表现（更准确）
 func (at *AutoTrader) analyzePerformanceFromTrades(trades []*storage.TradeRecord) *logger.PerformanceAnalysis {
-	analysis := &logger.PerformanceAnalysis{
-		RecentTrades: []logger.TradeOutcome{},
-		SymbolStats:  make(map[string]*logger.SymbolPerformance),
-	}
+	outcomes := []logger.TradeOutcome{}
 
 	for _, trade := range trades {
 		// 数据验证：确保关键字段有效
@@ -313,9 +314,25 @@ func (at *AutoTrader) analyzePerformanceFromTrades(trades []*storage.TradeRecord
 			ExitLogic:     trade.ExitLogic,         // 出场逻辑（开仓时规划的）
 			CloseLogic:    trade.CloseLogic,        // 平仓逻辑（直接平仓的理由）
 			ForcedCloseLogic: trade.ForcedCloseLogic, // 强制平仓逻辑
+			Strategy:      trade.Strategy,
 		}
 
-		analysis.RecentTrades = append(analysis.RecentTrades, outcome)
+		outcomes = append(outcomes, outcome)
+	}
+
+	return SummarizeTradeOutcomes(outcomes)
+}
+
+// SummarizeTradeOutcomes 根据已平仓交易（旧→新）统计胜率、盈亏比、夏普比率和各币种表现
+// 返回的RecentTrades为最新的在前（回测也使用此函数统计结果，与实盘口径一致）
+func SummarizeTradeOutcomes(outcomes []logger.TradeOutcome) *logger.PerformanceAnalysis {
+	analysis := &logger.PerformanceAnalysis{
+		RecentTrades: make([]logger.TradeOutcome, 0, len(outcomes)),
+		SymbolStats:  make(map[string]*logger.SymbolPerformance),
+	}
+
+	for _, trade := range outcomes {
+		analysis.RecentTrades = append(analysis.RecentTrades, trade)
 		analysis.TotalTrades++
 
 		// 分类交易
@@ -341,6 +358,26 @@ func (at *AutoTrader) analyzePerformanceFromTrades(trades []*storage.TradeRecord
 		} else if trade.PnL < 0 {
 			stats.LosingTrades++
 		}
+
+		// 更新策略统计（没有策略名称的交易不参与）
+		if trade.Strategy != "" {
+			if analysis.StrategyStats == nil {
+				analysis.StrategyStats = make(map[string]*logger.StrategyPerformance)
+			}
+			if _, exists := analysis.StrategyStats[trade.Strategy]; !exists {
+				analysis.StrategyStats[trade.Strategy] = &logger.StrategyPerformance{
+					Strategy: trade.Strategy,
+				}
+			}
+			strategyStats := analysis.StrategyStats[trade.Strategy]
+			strategyStats.TotalTrades++
+			strategyStats.TotalPnL += trade.PnL
+			if trade.PnL > 0 {
+				strategyStats.WinningTrades++
+			} else if trade.PnL < 0 {
+				strategyStats.LosingTrades++
+			}
+		}
 	}
 
 	// 计算统计指标
@@ -384,6 +421,12 @@ func (at *AutoTrader) analyzePerformanceFromTrades(trades []*storage.TradeRecord
 		}
 	}
 
+	// 计算各策略胜率和平均盈亏
+	for _, stats := range analysis.StrategyStats {
+		stats.WinRate = (float64(stats.WinningTrades) / float64(stats.TotalTrades)) * 100
+		stats.AvgPnL = stats.TotalPnL / float64(stats.TotalTrades)
+	}
+
 	// 计算夏普比率（使用历史交易盈亏率）
 	analysis.SharpeRatio = calculateSharpeRatio(analysis.RecentTrades)
 
@@ -0,0 +1,82 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// positionFields 持仓的常用字段（从交易所返回的持仓map中解析）
+type positionFields struct {
+	Symbol           string
+	Side             string // long / short
+	EntryPrice       float64
+	MarkPrice        float64
+	Quantity         float64 // 持仓数量（绝对值，空仓在交易所为负数）
+	UnrealizedPnl    float64
+	LiquidationPrice float64
+	Leverage         int
+}
+
+// parsePosition 解析持仓map，symbol/side/entryPrice/markPrice/positionAmt缺失或类型不符时返回错误
+// （交易所调整字段类型或返回null时跳过该持仓，而不是在类型断言处panic导致整个循环中断）；
+// unRealizedProfit和liquidationPrice缺失时为0，leverage缺失时按10倍估算
+func parsePosition(pos map[string]interface{}) (positionFields, error) {
+	var p positionFields
+	var ok bool
+	if p.Symbol, ok = getString(pos, "symbol"); !ok {
+		return p, fmt.Errorf("持仓缺少symbol字段或类型不符: %v", pos["symbol"])
+	}
+	if p.Side, ok = getString(pos, "side"); !ok {
+		return p, fmt.Errorf("%s 持仓缺少side字段或类型不符: %v", p.Symbol, pos["side"])
+	}
+	for _, field := range []struct {
+		key  string
+		dest *float64
+	}{
+		{"entryPrice", &p.EntryPrice},
+		{"markPrice", &p.MarkPrice},
+		{"positionAmt", &p.Quantity},
+	} {
+		if *field.dest, ok = getFloat(pos, field.key); !ok {
+			return p, fmt.Errorf("%s %s 持仓的%s字段缺失或无法解析: %v", p.Symbol, p.Side, field.key, pos[field.key])
+		}
+	}
+	p.Quantity = math.Abs(p.Quantity)
+	p.UnrealizedPnl, _ = getFloat(pos, "unRealizedProfit")
+	p.LiquidationPrice, _ = getFloat(pos, "liquidationPrice")
+
+	p.Leverage = 10
+	if lev, ok := getFloat(pos, "leverage"); ok && lev >= 1 {
+		p.Leverage = int(lev)
+	}
+	return p, nil
+}
+
+// getString 读取字符串字段（字段缺失、为null或不是字符串时返回false）
+func getString(m map[string]interface{}, key string) (string, bool) {
+	s, ok := m[key].(string)
+	return s, ok
+}
+
+// getFloat 读取数值字段，兼容float64、整数、json.Number和数字字符串（字段缺失、为null或无法解析时返回false）
+func getFloat(m map[string]interface{}, key string) (float64, bool) {
+	switch v := m[key].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,149 @@
+package trader
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func validPosition() map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":           "BTCUSDT",
+		"side":             "short",
+		"entryPrice":       50000.0,
+		"markPrice":        49000.0,
+		"positionAmt":      -0.5,
+		"unRealizedProfit": 500.0,
+		"liquidationPrice": 60000.0,
+		"leverage":         5.0,
+	}
+}
+
+func TestParsePosition(t *testing.T) {
+	p, err := parsePosition(validPosition())
+	if err != nil {
+		t.Fatalf("parsePosition() error = %v", err)
+	}
+	if p.Symbol != "BTCUSDT" || p.Side != "short" {
+		t.Errorf("symbol/side = %s/%s, want BTCUSDT/short", p.Symbol, p.Side)
+	}
+	if p.Quantity != 0.5 {
+		t.Errorf("Quantity = %v, want 0.5 (absolute value)", p.Quantity)
+	}
+	if p.EntryPrice != 50000 || p.MarkPrice != 49000 || p.UnrealizedPnl != 500 || p.LiquidationPrice != 60000 {
+		t.Errorf("unexpected prices: %+v", p)
+	}
+	if p.Leverage != 5 {
+		t.Errorf("Leverage = %d, want 5", p.Leverage)
+	}
+}
+
+func TestParsePositionRequiredFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		value interface{}
+	}{
+		{"missing symbol", "symbol", nil},
+		{"wrong-typed symbol", "symbol", 123.0},
+		{"missing side", "side", nil},
+		{"wrong-typed side", "side", true},
+		{"null entryPrice", "entryPrice", nil},
+		{"wrong-typed markPrice", "markPrice", []interface{}{1.0}},
+		{"unparsable positionAmt", "positionAmt", "abc"},
+		{"bool positionAmt", "positionAmt", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pos := validPosition()
+			pos[tt.key] = tt.value
+			if _, err := parsePosition(pos); err == nil {
+				t.Errorf("parsePosition() with %s = %v: expected error", tt.key, tt.value)
+			}
+
+			delete(pos, tt.key)
+			if _, err := parsePosition(pos); err == nil {
+				t.Errorf("parsePosition() without %s: expected error", tt.key)
+			}
+		})
+	}
+}
+
+func TestParsePositionOptionalFields(t *testing.T) {
+	pos := validPosition()
+	delete(pos, "unRealizedProfit")
+	pos["liquidationPrice"] = nil
+	pos["leverage"] = "not a number"
+
+	p, err := parsePosition(pos)
+	if err != nil {
+		t.Fatalf("parsePosition() error = %v", err)
+	}
+	if p.UnrealizedPnl != 0 || p.LiquidationPrice != 0 {
+		t.Errorf("missing optional fields should be 0, got pnl=%v liq=%v", p.UnrealizedPnl, p.LiquidationPrice)
+	}
+	if p.Leverage != 10 {
+		t.Errorf("Leverage = %d, want default 10", p.Leverage)
+	}
+}
+
+func TestParsePositionStringNumbers(t *testing.T) {
+	pos := validPosition()
+	pos["entryPrice"] = "50000.5"
+	pos["positionAmt"] = json.Number("0.25")
+	pos["leverage"] = "20"
+
+	p, err := parsePosition(pos)
+	if err != nil {
+		t.Fatalf("parsePosition() error = %v", err)
+	}
+	if p.EntryPrice != 50000.5 || p.Quantity != 0.25 || p.Leverage != 20 {
+		t.Errorf("unexpected values: %+v", p)
+	}
+}
+
+func TestGetFloat(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		want   float64
+		wantOK bool
+	}{
+		{"float64", 1.5, 1.5, true},
+		{"float32", float32(2.5), 2.5, true},
+		{"int", 3, 3, true},
+		{"int64", int64(4), 4, true},
+		{"json.Number", json.Number("5.5"), 5.5, true},
+		{"numeric string", "6.25", 6.25, true},
+		{"null", nil, 0, false},
+		{"non-numeric string", "abc", 0, false},
+		{"bool", true, 0, false},
+		{"map", map[string]interface{}{}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := getFloat(map[string]interface{}{"key": tt.value}, "key")
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("getFloat(%v) = (%v, %v), want (%v, %v)", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+
+	if _, ok := getFloat(map[string]interface{}{}, "missing"); ok {
+		t.Error("getFloat() on missing key should return false")
+	}
+}
+
+func TestGetString(t *testing.T) {
+	m := map[string]interface{}{"s": "BTCUSDT", "n": 1.0, "null": nil}
+
+	if got, ok := getString(m, "s"); !ok || got != "BTCUSDT" {
+		t.Errorf("getString(s) = (%q, %v), want (BTCUSDT, true)", got, ok)
+	}
+	for _, key := range []string{"n", "null", "missing"} {
+		if _, ok := getString(m, key); ok {
+			t.Errorf("getString(%s) should return false", key)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// recordPositionClose 记录持仓平仓时间（用于再入场冷却），同时持久化到PositionLogicManager
+func (at *AutoTrader) recordPositionClose(symbol, side string) {
+	closeTime := time.Now().UnixMilli()
+
+	at.lastCloseTimeMu.Lock()
+	at.lastCloseTime[symbol+"_"+side] = closeTime
+	at.lastCloseTimeMu.Unlock()
+
+	if at.positionLogicManager != nil {
+		if err := at.positionLogicManager.SaveLastCloseTime(symbol, side, closeTime); err != nil {
+			log.Printf("  ⚠ 保存平仓时间失败: %v", err)
+		}
+	}
+}
+
+// reentryCooldownSkipReason 检查再入场冷却（开仓前检查）
+// 冷却按币种生效、不区分方向（防止平多后立即开空的来回翻仓），仍在冷却期内时返回跳过原因
+func (at *AutoTrader) reentryCooldownSkipReason(symbol string) string {
+	cooldown := at.getConfig().ReentryCooldown
+	if cooldown <= 0 {
+		return ""
+	}
+
+	at.lastCloseTimeMu.Lock()
+	lastClose := at.lastCloseTime[symbol+"_long"]
+	if shortClose := at.lastCloseTime[symbol+"_short"]; shortClose > lastClose {
+		lastClose = shortClose
+	}
+	at.lastCloseTimeMu.Unlock()
+
+	if lastClose == 0 {
+		return ""
+	}
+
+	elapsed := time.Since(time.UnixMilli(lastClose))
+	if elapsed >= cooldown {
+		return ""
+	}
+
+	remaining := (cooldown - elapsed).Round(time.Second)
+	return fmt.Sprintf("%s 在 %s 刚平仓，再入场冷却中（冷却%v，还需等待%v）",
+		symbol, time.UnixMilli(lastClose).Format("15:04:05"), cooldown, remaining)
+}
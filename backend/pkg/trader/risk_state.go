@@ -0,0 +1,109 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+
+	"backend/pkg/storage"
+)
+
+// restoreRiskState 从数据库恢复峰值净值、日盈亏基准和历史最大回撤（重启后回撤风控继续以历史峰值为准）
+func (at *AutoTrader) restoreRiskState() {
+	riskStorage := at.riskStateStorage()
+	if riskStorage == nil {
+		return
+	}
+
+	state, err := riskStorage.LoadRiskState(at.id)
+	if err != nil {
+		log.Printf("⚠️  加载风控状态失败: %v", err)
+		return
+	}
+	if state == nil {
+		return
+	}
+
+	at.riskMu.Lock()
+	if state.PeakEquity > 0 {
+		at.peakEquity = state.PeakEquity
+	}
+	if state.DailyStartEquity > 0 {
+		at.dailyStartEquity = state.DailyStartEquity
+	}
+	at.maxDrawdownPct = state.MaxDrawdownPct
+	if at.initialBalance <= 0 && state.InitialBalance > 0 {
+		// 配置中未设置初始金额，沿用之前自动检测的值
+		at.initialBalance = state.InitialBalance
+		log.Printf("💰 已从数据库恢复自动检测的初始余额: %.2f USDT", state.InitialBalance)
+	}
+	if !state.LastResetTime.IsZero() {
+		at.lastResetTime = state.LastResetTime
+	}
+	at.riskMu.Unlock()
+
+	log.Printf("📅 已从数据库恢复风控状态: 峰值净值 %.2f USDT, 今日开盘净值 %.2f USDT (重置于 %s), 历史最大回撤 %.2f%%",
+		state.PeakEquity, state.DailyStartEquity, state.LastResetTime.Format("2006-01-02 15:04:05"), state.MaxDrawdownPct)
+}
+
+// saveRiskState 保存当前风控状态到数据库（峰值净值、日盈亏基准或最大回撤变化时调用，调用方不能持有riskMu）
+func (at *AutoTrader) saveRiskState() {
+	riskStorage := at.riskStateStorage()
+	if riskStorage == nil {
+		return
+	}
+
+	at.riskMu.RLock()
+	state := &storage.RiskState{
+		PeakEquity:       at.peakEquity,
+		DailyStartEquity: at.dailyStartEquity,
+		LastResetTime:    at.lastResetTime,
+		MaxDrawdownPct:   at.maxDrawdownPct,
+		InitialBalance:   at.initialBalance,
+	}
+	at.riskMu.RUnlock()
+
+	if err := riskStorage.SaveRiskState(at.id, state); err != nil {
+		log.Printf("⚠️  保存风控状态失败: %v", err)
+	}
+}
+
+// riskStateStorage 获取风控状态存储（未初始化存储时返回nil）
+func (at *AutoTrader) riskStateStorage() *storage.RiskStateStorage {
+	if at.storageAdapter == nil {
+		return nil
+	}
+	return at.storageAdapter.GetRiskStateStorage()
+}
+
+// detectInitialBalance 以当前钱包余额作为初始余额并保存到数据库（重启后沿用，不会重新检测）
+// 已有持仓时钱包余额不含持仓的未实现盈亏，基准可能偏离实际本金，仍然记录但输出警告
+func (at *AutoTrader) detectInitialBalance() error {
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return fmt.Errorf("自动检测初始余额失败: %w", err)
+	}
+	wallet, ok := balance["totalWalletBalance"].(float64)
+	if !ok || wallet <= 0 {
+		return fmt.Errorf("自动检测初始余额失败: 钱包余额无效 (%v)，请在配置中设置InitialBalance", balance["totalWalletBalance"])
+	}
+
+	if positions, err := at.trader.GetPositions(); err != nil {
+		log.Printf("⚠️  自动检测初始余额：获取持仓失败，无法确认是否已有持仓: %v", err)
+	} else if len(positions) > 0 {
+		log.Printf("⚠️  自动检测初始余额时已有 %d 个持仓，钱包余额不含其未实现盈亏，总盈亏统计可能有偏差（如需准确请在配置中设置InitialBalance）", len(positions))
+	}
+
+	at.riskMu.Lock()
+	at.initialBalance = wallet
+	if at.peakEquity <= 0 {
+		at.peakEquity = wallet
+	}
+	if at.dailyStartEquity <= 0 {
+		at.dailyStartEquity = wallet
+	}
+	at.riskMu.Unlock()
+	at.saveRiskState()
+
+	log.Printf("💰 已自动检测初始余额: %.2f USDT（已保存，重启后沿用）", wallet)
+	return nil
+}
@@ -0,0 +1,79 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Shutdown 优雅停止：停止主循环，等待进行中的交易周期和平仓操作完成，持久化持仓首次出现时间并关闭数据库
+// 交易所上的止损止盈挂单保留不撤销（进程退出后持仓仍受保护）
+// ctx超时后不再等待进行中的操作，但仍会持久化状态并关闭数据库
+func (at *AutoTrader) Shutdown(ctx context.Context) error {
+	at.Stop()
+
+	done := make(chan struct{})
+	go func() {
+		at.inFlight.Wait()
+		close(done)
+	}()
+
+	var waitErr error
+	select {
+	case <-done:
+		log.Printf("✓ [%s] 进行中的交易周期和平仓操作已完成", at.name)
+	case <-ctx.Done():
+		waitErr = fmt.Errorf("等待进行中的操作超时: %w", ctx.Err())
+		log.Printf("⚠️  [%s] %v，继续保存状态", at.name, waitErr)
+	}
+
+	// 主循环已退出，不会再开新仓，此时平仓（数据库关闭前，保证交易记录和强制平仓记录能写入）
+	if at.getConfig().FlattenOnShutdown {
+		at.flattenForSessionBoundary("停止时平仓（flatten_on_shutdown）", ForcedCloseTriggerShutdownFlatten)
+	}
+
+	at.flushPositionFirstSeenTimes()
+	at.saveRiskState()
+
+	if at.storageAdapter != nil {
+		if err := at.storageAdapter.Close(); err != nil {
+			log.Printf("⚠️  [%s] 关闭数据库失败: %v", at.name, err)
+			if waitErr == nil {
+				waitErr = fmt.Errorf("关闭数据库失败: %w", err)
+			}
+		}
+	}
+
+	log.Printf("👋 [%s] 已优雅停止", at.name)
+	return waitErr
+}
+
+// flushPositionFirstSeenTimes 把内存中的持仓首次出现时间全部写入数据库（正常情况下设置时已保存，这里兜底）
+func (at *AutoTrader) flushPositionFirstSeenTimes() {
+	if at.positionLogicManager == nil {
+		return
+	}
+
+	at.positionTimeMu.RLock()
+	times := make(map[string]int64, len(at.positionFirstSeenTime))
+	for key, firstSeen := range at.positionFirstSeenTime {
+		times[key] = firstSeen
+	}
+	at.positionTimeMu.RUnlock()
+
+	saved := 0
+	for key, firstSeen := range times {
+		// key格式: symbol_side
+		idx := strings.LastIndex(key, "_")
+		if idx <= 0 {
+			continue
+		}
+		if err := at.positionLogicManager.SaveFirstSeenTime(key[:idx], key[idx+1:], firstSeen); err != nil {
+			log.Printf("⚠️  [%s] 保存 %s 持仓首次出现时间失败: %v", at.name, key, err)
+			continue
+		}
+		saved++
+	}
+	log.Printf("💾 [%s] 已保存 %d 个持仓的首次出现时间", at.name, saved)
+}
@@ -0,0 +1,128 @@
+package trader
+
+import (
+	"log"
+
+	"backend/pkg/decision"
+)
+
+// StrategyInfo trader当前使用的策略和风控参数（用于API核对各trader实际运行的配置，不包含密钥）
+type StrategyInfo struct {
+	TraderID     string `json:"trader_id"`
+	TraderName   string `json:"trader_name"`
+	AIModel      string `json:"ai_model"`
+	StrategyName string `json:"strategy_name"`
+	AnalysisMode string `json:"analysis_mode"`
+
+	ValidationMode string   `json:"validation_mode"`
+	EnsembleModels []string `json:"ensemble_models,omitempty"`
+
+	Leverage StrategyLeverage `json:"leverage"`
+	Risk     StrategyRisk     `json:"risk"`
+
+	// SystemPrompt 按当前账户净值和持仓重新渲染的System Prompt（与下一次决策使用的一致）
+	SystemPrompt string  `json:"system_prompt"`
+	PromptEquity float64 `json:"prompt_equity"` // 渲染prompt使用的账户净值（获取余额失败时为初始余额）
+}
+
+// StrategyLeverage 杠杆配置
+type StrategyLeverage struct {
+	BTCETH          int            `json:"btc_eth"`
+	Altcoin         int            `json:"altcoin"`
+	SymbolOverrides map[string]int `json:"symbol_overrides,omitempty"`
+}
+
+// StrategyRisk 风控参数（百分比字段与配置文件一致，0表示不限制）
+type StrategyRisk struct {
+	MaxDailyLossPct        float64 `json:"max_daily_loss_pct"`
+	MaxDrawdownPct         float64 `json:"max_drawdown_pct"`
+	DeRiskDrawdownPct      float64 `json:"de_risk_drawdown_pct"`
+	PositionStopLossPct    float64 `json:"position_stop_loss_pct"`
+	PositionTakeProfitPct  float64 `json:"position_take_profit_pct"`
+	PositionMaxLossUSD     float64 `json:"position_max_loss_usd"`
+	MaxOpenPositions       int     `json:"max_open_positions"`
+	ReentryCooldownMinutes float64 `json:"reentry_cooldown_minutes"`
+	MaxHoldingHours        float64 `json:"max_holding_hours"`
+	MaxFundingRate         float64 `json:"max_funding_rate"`
+	RiskPerTradePct        float64 `json:"risk_per_trade_pct"`
+	MinRiskReward          float64 `json:"min_risk_reward"`
+	MinStopATRMultiple     float64 `json:"min_stop_atr_multiple"`
+	MaxPositionNotionalPct float64 `json:"max_position_notional_pct"`
+	MaxCorrelation         float64 `json:"max_correlation"`
+	StopTradingMinutes     float64 `json:"stop_trading_minutes"`
+}
+
+// GetStrategyInfo 汇总当前策略、杠杆和风控参数，并重新渲染System Prompt
+// 渲染prompt需要当前账户净值和持仓（决定单币种/多币种仓位说明），获取失败时使用初始余额和空持仓
+func (at *AutoTrader) GetStrategyInfo() *StrategyInfo {
+	cfg := at.getConfig()
+
+	at.riskMu.RLock()
+	equity := at.initialBalance
+	at.riskMu.RUnlock()
+	if balance, err := at.trader.GetBalance(); err != nil {
+		log.Printf("⚠️  渲染策略prompt：获取账户余额失败，使用初始余额: %v", err)
+	} else if wallet, ok := getFloat(balance, "totalWalletBalance"); ok {
+		unrealized, _ := getFloat(balance, "totalUnrealizedProfit")
+		equity = wallet + unrealized
+	}
+
+	var positions []decision.PositionInfo
+	if rawPositions, err := at.trader.GetPositions(); err != nil {
+		log.Printf("⚠️  渲染策略prompt：获取持仓失败，按无持仓渲染: %v", err)
+	} else {
+		for _, pos := range rawPositions {
+			if p, parseErr := parsePosition(pos); parseErr == nil {
+				positions = append(positions, decision.PositionInfo{Symbol: p.Symbol, Side: p.Side})
+			}
+		}
+	}
+
+	ctx := &decision.Context{
+		BTCETHLeverage:          cfg.BTCETHLeverage,
+		AltcoinLeverage:         cfg.AltcoinLeverage,
+		SymbolLeverageOverrides: cfg.SymbolLeverageOverrides,
+		Account:                 decision.AccountInfo{TotalEquity: equity},
+		Positions:               positions,
+		StrategyName:            cfg.StrategyName,
+		RiskPerTradePct:         cfg.RiskPerTradePct,
+		MinRiskReward:           cfg.MinRiskReward,
+		MinStopATRMultiple:      cfg.MinStopATRMultiple,
+		MaxPositionNotionalPct:  cfg.MaxPositionNotionalPct,
+	}
+
+	return &StrategyInfo{
+		TraderID:       at.id,
+		TraderName:     at.name,
+		AIModel:        at.aiModel,
+		StrategyName:   cfg.StrategyName,
+		AnalysisMode:   cfg.AnalysisMode,
+		ValidationMode: cfg.ValidationMode,
+		EnsembleModels: cfg.EnsembleModels,
+		Leverage: StrategyLeverage{
+			BTCETH:          cfg.BTCETHLeverage,
+			Altcoin:         cfg.AltcoinLeverage,
+			SymbolOverrides: cfg.SymbolLeverageOverrides,
+		},
+		Risk: StrategyRisk{
+			MaxDailyLossPct:        cfg.MaxDailyLoss,
+			MaxDrawdownPct:         cfg.MaxDrawdown,
+			DeRiskDrawdownPct:      cfg.DeRiskDrawdown,
+			PositionStopLossPct:    cfg.PositionStopLossPct,
+			PositionTakeProfitPct:  cfg.PositionTakeProfitPct,
+			PositionMaxLossUSD:     cfg.PositionMaxLossUSD,
+			MaxOpenPositions:       cfg.MaxOpenPositions,
+			ReentryCooldownMinutes: cfg.ReentryCooldown.Minutes(),
+			MaxHoldingHours:        cfg.MaxHoldingTime.Hours(),
+			MaxFundingRate:         cfg.MaxFundingRate,
+			RiskPerTradePct:        cfg.RiskPerTradePct,
+			MinRiskReward:          cfg.MinRiskReward,
+			MinStopATRMultiple:     cfg.MinStopATRMultiple,
+			MaxPositionNotionalPct: cfg.MaxPositionNotionalPct,
+			MaxCorrelation:         cfg.MaxCorrelation,
+			StopTradingMinutes:     cfg.StopTradingTime.Minutes(),
+		},
+		SystemPrompt: decision.BuildSystemPrompt(ctx),
+		PromptEquity: equity,
+	}
+}
@@ -0,0 +1,111 @@
+package trader
+
+import (
+	"sync"
+	"time"
+)
+
+// stubTrader 测试用Trader：持仓和成交记录由测试预置，记录平仓调用
+type stubTrader struct {
+	mu            sync.Mutex
+	positions     []map[string]interface{}
+	accountTrades []map[string]interface{}
+	closeCalls    []string  // 平仓调用（"close_long:BTCUSDT"）
+	bracketStops  []float64 // SetBracket设置的止损价
+}
+
+func (s *stubTrader) GetBalance() (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *stubTrader) GetPositions() ([]map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.positions, nil
+}
+
+func (s *stubTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *stubTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *stubTrader) OpenLongLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *stubTrader) OpenShortLimit(symbol string, quantity, price float64, leverage int) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *stubTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return s.close("close_long", symbol)
+}
+
+func (s *stubTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return s.close("close_short", symbol)
+}
+
+func (s *stubTrader) close(action, symbol string) (map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeCalls = append(s.closeCalls, action+":"+symbol)
+	return map[string]interface{}{"orderId": int64(len(s.closeCalls))}, nil
+}
+
+func (s *stubTrader) SetLeverage(symbol string, leverage int) error { return nil }
+
+func (s *stubTrader) GetMarketPrice(symbol string) (float64, error) { return 0, nil }
+
+func (s *stubTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return nil
+}
+
+func (s *stubTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return nil
+}
+
+func (s *stubTrader) SetBracket(symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bracketStops = append(s.bracketStops, stopPrice)
+	return nil
+}
+
+func (s *stubTrader) CancelAllOrders(symbol string) error { return nil }
+
+func (s *stubTrader) GetOpenOrders(symbol string) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (s *stubTrader) CancelOrder(symbol string, orderID int64) error { return nil }
+
+func (s *stubTrader) GetOrder(symbol string, orderID int64) (map[string]interface{}, error) {
+	return map[string]interface{}{}, nil
+}
+
+func (s *stubTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return "", nil
+}
+
+func (s *stubTrader) FormatPrice(symbol string, price float64) (string, error) {
+	return "", nil
+}
+
+func (s *stubTrader) GetSymbolStatus(symbol string) (string, error) { return "TRADING", nil }
+
+func (s *stubTrader) GetSymbolFilters(symbol string) (minNotional, minQty, stepSize, tickSize float64, err error) {
+	return 0, 0, 0, 0, nil
+}
+
+func (s *stubTrader) GetForceOrders(symbol string, startTime time.Time) ([]map[string]interface{}, error) {
+	return nil, nil
+}
+
+func (s *stubTrader) GetAccountTrades(symbol string, startTime, endTime time.Time, limit int) ([]map[string]interface{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accountTrades, nil
+}
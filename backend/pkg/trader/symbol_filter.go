@@ -0,0 +1,50 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"backend/pkg/decision"
+)
+
+// filterCandidateCoins 按币种黑白名单过滤候选币种（黑名单优先，白名单非空时只保留白名单中的币种）
+func (at *AutoTrader) filterCandidateCoins(candidates []decision.CandidateCoin) []decision.CandidateCoin {
+	if len(at.getConfig().SymbolAllowlist) == 0 && len(at.getConfig().SymbolDenylist) == 0 {
+		return candidates
+	}
+
+	filtered := make([]decision.CandidateCoin, 0, len(candidates))
+	for _, coin := range candidates {
+		if at.symbolFilterSkipReason(coin.Symbol) != "" {
+			continue
+		}
+		filtered = append(filtered, coin)
+	}
+
+	if removed := len(candidates) - len(filtered); removed > 0 {
+		log.Printf("🚫 币种黑白名单过滤了 %d 个候选币种（剩余 %d 个）", removed, len(filtered))
+	}
+	return filtered
+}
+
+// symbolFilterSkipReason 检查币种是否被黑白名单禁止开仓，禁止时返回跳过原因
+func (at *AutoTrader) symbolFilterSkipReason(symbol string) string {
+	if containsSymbol(at.getConfig().SymbolDenylist, symbol) {
+		return fmt.Sprintf("%s 在币种黑名单中", symbol)
+	}
+	if len(at.getConfig().SymbolAllowlist) > 0 && !containsSymbol(at.getConfig().SymbolAllowlist, symbol) {
+		return fmt.Sprintf("%s 不在币种白名单中", symbol)
+	}
+	return ""
+}
+
+// containsSymbol 判断币种是否在列表中（不区分大小写）
+func containsSymbol(list []string, symbol string) bool {
+	for _, s := range list {
+		if strings.EqualFold(strings.TrimSpace(s), symbol) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,48 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// symbolLossStreakSkipReason 检查单币种连续亏损冷却（开仓前检查，不区分方向）
+// 从交易记录中取该币种窗口期内平仓的交易，最近连续亏损笔数达到阈值且距最后一笔亏损平仓未超过冷却时长时返回跳过原因；
+// 查询交易记录失败时不拦截开仓
+func (at *AutoTrader) symbolLossStreakSkipReason(symbol string) string {
+	cfg := at.getConfig()
+	if cfg.SymbolLossStreak <= 0 || at.storageAdapter == nil || at.storageAdapter.GetTradeStorage() == nil {
+		return ""
+	}
+
+	trades, err := at.storageAdapter.GetTradeStorage().GetClosedTradesBySymbolSince(symbol, time.Now().Add(-cfg.SymbolLossWindow))
+	if err != nil {
+		log.Printf("⚠️  单币种连续亏损检查：%v", err)
+		return ""
+	}
+
+	// trades按平仓时间降序，从最近一笔开始数连续亏损
+	streak := 0
+	var lastLossClose time.Time
+	for _, trade := range trades {
+		if trade.PnL >= 0 || trade.CloseTime == nil {
+			break
+		}
+		if streak == 0 {
+			lastLossClose = *trade.CloseTime
+		}
+		streak++
+	}
+	if streak < cfg.SymbolLossStreak {
+		return ""
+	}
+
+	elapsed := time.Since(lastLossClose)
+	if elapsed >= cfg.SymbolLossCooldown {
+		return ""
+	}
+
+	remaining := (cfg.SymbolLossCooldown - elapsed).Round(time.Minute)
+	return fmt.Sprintf("%s 最近%v内连续亏损%d笔（最后一笔平仓于 %s），暂停该币种开仓（冷却%v，还需等待%v）",
+		symbol, cfg.SymbolLossWindow, streak, lastLossClose.Format("01-02 15:04"), cfg.SymbolLossCooldown, remaining)
+}
@@ -0,0 +1,127 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"backend/pkg/decision"
+)
+
+// executeSetTakeProfitLadder 设置阶梯止盈：止损覆盖全部持仓，每档止盈按比例挂reduce-only止盈单，并保存阶梯到持仓逻辑
+// 调用前需已取消该币种的旧挂单
+func (at *AutoTrader) executeSetTakeProfitLadder(symbol, side string, quantity, stopLoss float64, levels []decision.TakeProfitLevel) error {
+	if err := at.placeTakeProfitLadder(symbol, strings.ToUpper(side), quantity, stopLoss, levels); err != nil {
+		return err
+	}
+
+	if err := at.positionLogicManager.SaveTakeProfitLevels(symbol, side, levels); err != nil {
+		log.Printf("  ⚠ 保存阶梯止盈失败: %v", err)
+	}
+	log.Printf("  ✓ 阶梯止盈设置成功: %s %s %s", symbol, side, decision.FormatTakeProfitLevels(levels))
+	return nil
+}
+
+// placeTakeProfitLadder 按阶梯挂止损和多档止盈单
+// 价格已越过的档位视为已成交并跳过（持仓已被部分止盈），剩余档位按原比例分配剩余持仓；
+// 比例合计为100%时最后一档平掉剩余全部数量，避免精度截断留下零头
+func (at *AutoTrader) placeTakeProfitLadder(symbol, positionSide string, quantity, stopLoss float64, levels []decision.TakeProfitLevel) error {
+	var errs []string
+
+	if stopLoss > 0 {
+		if err := at.trader.SetStopLoss(symbol, positionSide, quantity, stopLoss); err != nil {
+			errs = append(errs, fmt.Sprintf("设置止损失败: %v", err))
+		}
+	}
+
+	pending := levels
+	filledPct := 0.0
+	if currentPrice, err := at.trader.GetMarketPrice(symbol); err == nil && currentPrice > 0 {
+		pending = nil
+		for _, level := range levels {
+			reached := (positionSide == "LONG" && level.Price <= currentPrice) || (positionSide == "SHORT" && level.Price >= currentPrice)
+			if reached {
+				filledPct += level.Pct
+				continue
+			}
+			pending = append(pending, level)
+		}
+	}
+	if len(pending) == 0 || filledPct >= 100 {
+		return joinLadderErrors(errs)
+	}
+
+	totalPct := 0.0
+	for _, level := range levels {
+		totalPct += level.Pct
+	}
+
+	remaining := quantity
+	for i, level := range pending {
+		qty := quantity * level.Pct / (100 - filledPct)
+		if i == len(pending)-1 && totalPct >= 99.99 {
+			qty = remaining
+		}
+		qty = math.Min(qty, remaining)
+
+		qtyStr, err := at.trader.FormatQuantity(symbol, qty)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("格式化第%d档止盈数量失败: %v", i+1, err))
+			continue
+		}
+		qty, _ = strconv.ParseFloat(qtyStr, 64)
+		if qty <= 0 {
+			errs = append(errs, fmt.Sprintf("第%d档止盈数量过小（%.0f%%），低于交易精度", i+1, level.Pct))
+			continue
+		}
+
+		if err := at.trader.SetTakeProfit(symbol, positionSide, qty, level.Price); err != nil {
+			errs = append(errs, fmt.Sprintf("设置第%d档止盈失败: %v", i+1, err))
+			continue
+		}
+		remaining -= qty
+		log.Printf("  ✓ 第%d档止盈: %.4f 平仓 %.8f", i+1, level.Price, qty)
+	}
+
+	return joinLadderErrors(errs)
+}
+
+// placeProtectionOrders 为持仓挂止损止盈单（调用前需已取消旧挂单）
+// 已保存阶梯止盈且止盈价与最远一档一致（或未指定止盈）时按阶梯挂单，否则挂单一止盈；
+// 单一止盈与已保存的阶梯不一致时，挂单成功后清除阶梯
+func (at *AutoTrader) placeProtectionOrders(symbol, positionSide string, quantity, stopLoss, takeProfit float64) error {
+	side := strings.ToLower(positionSide)
+	var levels []decision.TakeProfitLevel
+	if logic := at.positionLogicManager.GetLogic(symbol, side); logic != nil {
+		levels = logic.TakeProfitLevels
+	}
+
+	if len(levels) > 0 {
+		finalPrice := levels[len(levels)-1].Price
+		if takeProfit <= 0 || math.Abs(takeProfit-finalPrice) <= finalPrice*1e-9 {
+			return at.placeTakeProfitLadder(symbol, positionSide, quantity, stopLoss, levels)
+		}
+	}
+
+	if err := at.trader.SetBracket(symbol, positionSide, quantity, stopLoss, takeProfit); err != nil {
+		return err
+	}
+	if len(levels) > 0 {
+		if err := at.positionLogicManager.SaveTakeProfitLevels(symbol, side, nil); err != nil {
+			log.Printf("  ⚠ 清除阶梯止盈失败: %v", err)
+		} else {
+			log.Printf("  ℹ️  止盈已改为单一价格 %.4f，已清除阶梯止盈", takeProfit)
+		}
+	}
+	return nil
+}
+
+// joinLadderErrors 合并阶梯挂单过程中的错误（没有错误时返回nil）
+func joinLadderErrors(errs []string) error {
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(errs, "; "))
+}
@@ -0,0 +1,261 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"backend/pkg/decision"
+	"backend/pkg/logger"
+)
+
+// trailingStopState 移动止损状态（distancePct为0表示该持仓未启用移动止损）
+type trailingStopState struct {
+	distancePct float64 // 距最优价的回撤百分比
+	bestPrice   float64 // 持仓以来的最优价（做多为最高价，做空为最低价）
+	stopPrice   float64 // 当前已设置到交易所的止损价
+}
+
+// trailingStopPrice 根据最优价和回撤距离计算移动止损价
+func trailingStopPrice(side string, bestPrice, distancePct float64) float64 {
+	if side == "long" {
+		return bestPrice * (1 - distancePct/100)
+	}
+	return bestPrice * (1 + distancePct/100)
+}
+
+// isMoreFavorable 判断price是否比ref对持仓更有利（做多更高，做空更低）
+func isMoreFavorable(side string, price, ref float64) bool {
+	if side == "long" {
+		return price > ref
+	}
+	return price < ref
+}
+
+// getTrailingStop 获取持仓的移动止损状态
+// 内存中没有时从PositionLogicManager恢复（重启后继续跟踪），未启用时缓存为distancePct=0避免重复查询
+func (at *AutoTrader) getTrailingStop(symbol, side string) (trailingStopState, bool) {
+	posKey := symbol + "_" + side
+
+	at.trailingStopsMu.Lock()
+	state, exists := at.trailingStops[posKey]
+	at.trailingStopsMu.Unlock()
+	if exists {
+		return state, state.distancePct > 0
+	}
+
+	state = trailingStopState{}
+	if at.positionLogicManager != nil {
+		if logic := at.positionLogicManager.GetLogic(symbol, side); logic != nil && logic.TrailingDistancePct > 0 {
+			state = trailingStopState{
+				distancePct: logic.TrailingDistancePct,
+				bestPrice:   logic.TrailingBestPrice,
+				stopPrice:   logic.StopLoss,
+			}
+			log.Printf("🔄 已从数据库恢复 %s %s 的移动止损: 距离%.2f%%, 最优价%.4f, 止损%.4f",
+				symbol, side, state.distancePct, state.bestPrice, state.stopPrice)
+		}
+	}
+
+	at.trailingStopsMu.Lock()
+	at.trailingStops[posKey] = state
+	at.trailingStopsMu.Unlock()
+
+	return state, state.distancePct > 0
+}
+
+// setTrailingStop 更新内存中的移动止损状态
+func (at *AutoTrader) setTrailingStop(symbol, side string, state trailingStopState) {
+	at.trailingStopsMu.Lock()
+	at.trailingStops[symbol+"_"+side] = state
+	at.trailingStopsMu.Unlock()
+}
+
+// clearTrailingStop 关闭持仓的移动止损（新开仓时调用，避免沿用上一笔持仓的跟踪状态）
+func (at *AutoTrader) clearTrailingStop(symbol, side string) {
+	at.trailingStopsMu.Lock()
+	delete(at.trailingStops, symbol+"_"+side)
+	at.trailingStopsMu.Unlock()
+
+	if at.positionLogicManager != nil {
+		if err := at.positionLogicManager.SaveTrailingStop(symbol, side, 0, 0); err != nil {
+			log.Printf("  ⚠ 清除移动止损状态失败: %v", err)
+		}
+	}
+}
+
+// pruneTrailingStops 清理已不存在的持仓的移动止损状态
+func (at *AutoTrader) pruneTrailingStops(currentPositionKeys map[string]bool) {
+	at.trailingStopsMu.Lock()
+	defer at.trailingStopsMu.Unlock()
+
+	for posKey := range at.trailingStops {
+		if !currentPositionKeys[posKey] {
+			delete(at.trailingStops, posKey)
+		}
+	}
+}
+
+// executeTrailingStopLoss 执行update_sl_trailing：启用（或调整）移动止损
+// 止损价 = 持仓以来最优价 ×（1 ∓ 回撤距离），由单仓位止损检查持续跟踪
+func (at *AutoTrader) executeTrailingStopLoss(dec *decision.Decision, actionRecord *logger.DecisionAction) error {
+	log.Printf("  📋 开始设置移动止损: %s 回撤距离 %.2f%%", dec.Symbol, dec.TrailingDistancePct)
+
+	// 步骤1: 验证参数
+	if dec.TrailingDistancePct < decision.MinTrailingDistancePct || dec.TrailingDistancePct > decision.MaxTrailingDistancePct {
+		return fmt.Errorf("移动止损距离必须在%.1f%%-%.0f%%之间: %.2f%%",
+			decision.MinTrailingDistancePct, decision.MaxTrailingDistancePct, dec.TrailingDistancePct)
+	}
+
+	// 步骤2: 查找持仓
+	foundPosition, positionSide, err := at.findPositionBySymbol(dec.Symbol)
+	if err != nil {
+		return fmt.Errorf("未找到 %s 的持仓，无法设置移动止损: %w", dec.Symbol, err)
+	}
+
+	p, err := parsePosition(foundPosition)
+	if err != nil {
+		return fmt.Errorf("解析 %s 持仓失败，无法设置移动止损: %w", dec.Symbol, err)
+	}
+	quantity, entryPrice, markPrice := p.Quantity, p.EntryPrice, p.MarkPrice
+	if quantity <= 0 {
+		return fmt.Errorf("持仓数量无效: %.4f", quantity)
+	}
+	if markPrice <= 0 {
+		return fmt.Errorf("获取到的 %s 当前价格无效: %.4f", dec.Symbol, markPrice)
+	}
+	actionRecord.Price = markPrice
+	actionRecord.Quantity = quantity
+
+	// 步骤3: 确定最优价（入场价、当前价、已跟踪的最优价中最有利的一个）
+	bestPrice := entryPrice
+	if isMoreFavorable(positionSide, markPrice, bestPrice) {
+		bestPrice = markPrice
+	}
+	if state, exists := at.getTrailingStop(dec.Symbol, positionSide); exists && isMoreFavorable(positionSide, state.bestPrice, bestPrice) {
+		bestPrice = state.bestPrice
+	}
+
+	// 步骤4: 计算止损价（移动止损只能收紧，已有止损更有利时保留原止损）
+	var oldStopLoss float64
+	if logic := at.positionLogicManager.GetLogic(dec.Symbol, positionSide); logic != nil {
+		oldStopLoss = logic.StopLoss
+	}
+	newStopLoss := trailingStopPrice(positionSide, bestPrice, dec.TrailingDistancePct)
+	if oldStopLoss > 0 && isMoreFavorable(positionSide, oldStopLoss, newStopLoss) {
+		log.Printf("  ℹ️  当前止损 %.4f 比移动止损 %.4f 更紧，保留当前止损", oldStopLoss, newStopLoss)
+		newStopLoss = oldStopLoss
+	}
+
+	// 止损必须位于当前价的亏损一侧
+	if !isMoreFavorable(positionSide, markPrice, newStopLoss) {
+		return fmt.Errorf("当前价(%.4f)已越过移动止损价(%.4f)，请直接平仓", markPrice, newStopLoss)
+	}
+
+	// 步骤5: 设置止损订单
+	if math.Abs(newStopLoss-oldStopLoss) > 0 {
+		if err := at.placeTrailingStopOrder(dec.Symbol, positionSide, quantity, newStopLoss, oldStopLoss); err != nil {
+			return err
+		}
+	}
+
+	// 步骤6: 保存移动止损状态（内存 + 数据库）
+	at.setTrailingStop(dec.Symbol, positionSide, trailingStopState{
+		distancePct: dec.TrailingDistancePct,
+		bestPrice:   bestPrice,
+		stopPrice:   newStopLoss,
+	})
+	if err := at.positionLogicManager.SaveTrailingStop(dec.Symbol, positionSide, dec.TrailingDistancePct, bestPrice); err != nil {
+		log.Printf("  ⚠ 保存移动止损状态失败: %v", err)
+	}
+
+	log.Printf("  ✓ 移动止损已启用: %s %s 回撤距离 %.2f%%，最优价 %.4f，当前止损 %.4f",
+		dec.Symbol, positionSide, dec.TrailingDistancePct, bestPrice, newStopLoss)
+	return nil
+}
+
+// placeTrailingStopOrder 取消旧订单并以联动订单设置新止损（保留已保存的止盈），失败时回滚到旧订单
+func (at *AutoTrader) placeTrailingStopOrder(symbol, side string, quantity, newStopLoss, oldStopLoss float64) error {
+	var takeProfit float64
+	if logic := at.positionLogicManager.GetLogic(symbol, side); logic != nil {
+		takeProfit = logic.TakeProfit
+	}
+
+	if err := at.trader.CancelAllOrders(symbol); err != nil {
+		errStr := strings.ToLower(err.Error())
+		if !strings.Contains(errStr, "no orders") &&
+			!strings.Contains(errStr, "not found") &&
+			!strings.Contains(errStr, "没有订单") {
+			return fmt.Errorf("取消旧订单失败，无法更新移动止损: %w", err)
+		}
+	}
+
+	sideStr := "LONG"
+	if side == "short" {
+		sideStr = "SHORT"
+	}
+
+	if err := at.placeProtectionOrders(symbol, sideStr, quantity, newStopLoss, takeProfit); err != nil {
+		if rollbackErr := at.rollbackOrders(symbol, sideStr, quantity, oldStopLoss, takeProfit); rollbackErr != nil {
+			return fmt.Errorf("设置移动止损失败且回滚失败: %w (回滚错误: %v)", err, rollbackErr)
+		}
+		return fmt.Errorf("设置移动止损失败，已恢复旧订单: %w", err)
+	}
+
+	if err := at.positionLogicManager.SaveStopLossAndTakeProfit(symbol, side, newStopLoss, 0); err != nil {
+		log.Printf("  ⚠ 保存移动止损价格失败: %v", err)
+	}
+	return nil
+}
+
+// checkTrailingStop 每次单仓位止损检查时检查移动止损：价格创出新的最优价时收紧止损，价格越过止损时返回true（需要强制平仓）
+// 返回值：是否触发 + 当前生效的止损价
+func (at *AutoTrader) checkTrailingStop(symbol, side string, markPrice, quantity float64) (bool, float64) {
+	state, enabled := at.getTrailingStop(symbol, side)
+	if !enabled || markPrice <= 0 {
+		return false, 0
+	}
+	if state.bestPrice <= 0 {
+		state.bestPrice = markPrice
+	}
+
+	// 生效止损取计算值与已设置止损中更紧的一个
+	stopPrice := trailingStopPrice(side, state.bestPrice, state.distancePct)
+	if state.stopPrice > 0 && isMoreFavorable(side, state.stopPrice, stopPrice) {
+		stopPrice = state.stopPrice
+	}
+
+	// 价格越过止损：需要强制平仓
+	if !isMoreFavorable(side, markPrice, stopPrice) {
+		return true, stopPrice
+	}
+
+	// 价格创出新的最优价：更新最优价并收紧止损
+	if isMoreFavorable(side, markPrice, state.bestPrice) {
+		state.bestPrice = markPrice
+		newStopLoss := trailingStopPrice(side, state.bestPrice, state.distancePct)
+
+		// 移动止损只能收紧（已设置的止损可能比计算值更紧），且变化超过最小步长才更新交易所订单，避免频繁撤单挂单
+		if state.stopPrice <= 0 || (isMoreFavorable(side, newStopLoss, state.stopPrice) &&
+			math.Abs(newStopLoss-state.stopPrice)/state.stopPrice*100 >= TrailingStopMinStepPct) {
+			if err := at.placeTrailingStopOrder(symbol, side, quantity, newStopLoss, state.stopPrice); err != nil {
+				log.Printf("⚠️  移动止损更新失败 (%s %s): %v", symbol, side, err)
+			} else {
+				log.Printf("📈 [止损检查] 移动止损跟踪: %s %s 最优价 %.4f，止损 %.4f → %.4f",
+					symbol, side, state.bestPrice, state.stopPrice, newStopLoss)
+				state.stopPrice = newStopLoss
+			}
+		}
+		if isMoreFavorable(side, newStopLoss, stopPrice) {
+			stopPrice = newStopLoss
+		}
+
+		at.setTrailingStop(symbol, side, state)
+		if err := at.positionLogicManager.SaveTrailingStop(symbol, side, state.distancePct, state.bestPrice); err != nil {
+			log.Printf("⚠️  保存移动止损状态失败: %v", err)
+		}
+	}
+
+	return false, stopPrice
+}
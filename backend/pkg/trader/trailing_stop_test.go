@@ -0,0 +1,64 @@
+package trader
+
+import (
+	"testing"
+)
+
+func newTrailingStopTestTrader(t *testing.T, stub *stubTrader, state trailingStopState) *AutoTrader {
+	t.Helper()
+	at := newForcedCloseTestTrader(t, stub)
+	at.trailingStops = map[string]trailingStopState{"BTCUSDT_long": state}
+	return at
+}
+
+func TestCheckTrailingStopKeepsTighterStop(t *testing.T) {
+	// 入场100，原止损99比5%移动止损（95）更紧，executeTrailingStopLoss保留了99
+	stub := &stubTrader{}
+	at := newTrailingStopTestTrader(t, stub, trailingStopState{distancePct: 5, bestPrice: 100, stopPrice: 99})
+
+	triggered, stopPrice := at.checkTrailingStop("BTCUSDT", "long", 101, 1)
+	if triggered {
+		t.Fatal("checkTrailingStop() triggered at 101 with stop 99")
+	}
+	if stopPrice != 99 {
+		t.Errorf("stop = %v, want 99 (tighter existing stop kept)", stopPrice)
+	}
+	if len(stub.bracketStops) != 0 {
+		t.Errorf("stop order replaced with %v, want no update (new stop 95.95 is looser)", stub.bracketStops)
+	}
+
+	state, _ := at.getTrailingStop("BTCUSDT", "long")
+	if state.bestPrice != 101 {
+		t.Errorf("bestPrice = %v, want 101", state.bestPrice)
+	}
+	if state.stopPrice != 99 {
+		t.Errorf("state.stopPrice = %v, want 99", state.stopPrice)
+	}
+}
+
+func TestCheckTrailingStopTightensStop(t *testing.T) {
+	stub := &stubTrader{}
+	at := newTrailingStopTestTrader(t, stub, trailingStopState{distancePct: 5, bestPrice: 100, stopPrice: 99})
+
+	// 110 × 95% = 104.5，比99更紧，需要更新交易所订单
+	triggered, stopPrice := at.checkTrailingStop("BTCUSDT", "long", 110, 1)
+	if triggered {
+		t.Fatal("checkTrailingStop() triggered at new high")
+	}
+	if stopPrice != 104.5 {
+		t.Errorf("stop = %v, want 104.5", stopPrice)
+	}
+	if len(stub.bracketStops) != 1 || stub.bracketStops[0] != 104.5 {
+		t.Errorf("stop orders = %v, want [104.5]", stub.bracketStops)
+	}
+
+	state, _ := at.getTrailingStop("BTCUSDT", "long")
+	if state.stopPrice != 104.5 || state.bestPrice != 110 {
+		t.Errorf("state = %+v, want stopPrice 104.5 bestPrice 110", state)
+	}
+
+	// 回落到止损下方触发平仓
+	if triggered, _ := at.checkTrailingStop("BTCUSDT", "long", 104, 1); !triggered {
+		t.Error("checkTrailingStop() at 104 should trigger with stop 104.5")
+	}
+}
@@ -443,17 +443,19 @@ function App() {
               {currentPage === 'trader' && status && (
                 <div
                   className="flex items-center gap-1.5 sm:gap-2 px-2 sm:px-3 py-1.5 sm:py-2 rounded"
-                  style={status.is_running
-                    ? { background: 'rgba(14, 203, 129, 0.1)', color: '#0ECB81', border: '1px solid rgba(14, 203, 129, 0.2)' }
-                    : { background: 'rgba(246, 70, 93, 0.1)', color: '#F6465D', border: '1px solid rgba(246, 70, 93, 0.2)' }
+                  style={!status.is_running
+                    ? { background: 'rgba(246, 70, 93, 0.1)', color: '#F6465D', border: '1px solid rgba(246, 70, 93, 0.2)' }
+                    : status.is_paused
+                      ? { background: 'rgba(240, 185, 11, 0.1)', color: '#F0B90B', border: '1px solid rgba(240, 185, 11, 0.2)' }
+                      : { background: 'rgba(14, 203, 129, 0.1)', color: '#0ECB81', border: '1px solid rgba(14, 203, 129, 0.2)' }
                   }
                 >
                   <div
-                    className={`w-2 h-2 rounded-full ${status.is_running ? 'pulse-glow' : ''}`}
-                    style={{ background: status.is_running ? '#0ECB81' : '#F6465D' }}
+                    className={`w-2 h-2 rounded-full ${status.is_running && !status.is_paused ? 'pulse-glow' : ''}`}
+                    style={{ background: !status.is_running ? '#F6465D' : status.is_paused ? '#F0B90B' : '#0ECB81' }}
                   />
                   <span className="font-semibold mono text-xs">
-                    {t(status.is_running ? 'running' : 'stopped', language)}
+                    {t(!status.is_running ? 'stopped' : status.is_paused ? 'paused' : 'running', language)}
                   </span>
                 </div>
               )}
@@ -594,6 +596,12 @@ function TraderDetailsPage({
               <span>{t('cyclesLabel', language)}: {status.call_count}</span>
               <span>•</span>
               <span>{t('runtime', language)}: {status.runtime_minutes} {t('min', language)}</span>
+              {status.max_drawdown_pct !== undefined && (
+                <>
+                  <span>•</span>
+                  <span>{t('maxDrawdown', language)}: {status.max_drawdown_pct.toFixed(2)}%</span>
+                </>
+              )}
             </>
           )}
         </div>
@@ -8,6 +8,7 @@ export const translations = {
     competition: 'Competition',
     details: 'Details',
     running: 'RUNNING',
+    paused: 'PAUSED',
     stopped: 'STOPPED',
 
     // Footer
@@ -24,6 +25,7 @@ export const translations = {
     aiModel: 'AI Model',
     cyclesLabel: 'Cycles',
     runtime: 'Runtime',
+    maxDrawdown: 'Max Drawdown',
     lastUpdate: 'Last Update',
     available: 'Available',
     min: 'min',
@@ -158,6 +160,7 @@ export const translations = {
     competition: '竞赛',
     details: '详情',
     running: '运行中',
+    paused: '已暂停',
     stopped: '已停止',
 
     // Footer
@@ -174,6 +177,7 @@ export const translations = {
     aiModel: 'AI模型',
     cyclesLabel: '周期数',
     runtime: '运行时长',
+    maxDrawdown: '最大回撤',
     lastUpdate: '最后更新',
     available: '可用',
     min: '分钟',
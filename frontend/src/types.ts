@@ -3,6 +3,7 @@ export interface SystemStatus {
   trader_name: string;
   ai_model: string;
   is_running: boolean;
+  is_paused?: boolean;
   start_time: string;
   runtime_minutes: number;
   call_count: number;
@@ -10,6 +11,8 @@ export interface SystemStatus {
   scan_interval: string;
   stop_until: string;
   last_reset_time: string;
+  peak_equity?: number;
+  max_drawdown_pct?: number;
   ai_provider: string;
 }
 
@@ -99,12 +102,21 @@ export interface AccountSnapshot {
   margin_used_pct: number;
 }
 
+// 多模型投票中单个模型的原始输出
+export interface ModelTrace {
+  model: string;
+  cot_trace: string;
+  decision_json?: string;
+  error?: string;
+}
+
 export interface DecisionRecord {
   timestamp: string;
   cycle_number: number;
   input_prompt: string;
   cot_trace: string;
   decision_json: string;
+  model_traces?: ModelTrace[]; // 多模型投票时每个模型的原始输出
   account_state: AccountSnapshot;
   positions: any[];
   candidate_coins: string[];
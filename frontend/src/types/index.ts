@@ -1,6 +1,7 @@
 // 系统状态
 export interface SystemStatus {
   is_running: boolean;
+  is_paused?: boolean;
   start_time: string;
   runtime_minutes: number;
   call_count: number;
@@ -8,6 +9,8 @@ export interface SystemStatus {
   scan_interval: string;
   stop_until: string;
   last_reset_time: string;
+  peak_equity?: number;
+  max_drawdown_pct?: number;
   ai_provider: string;
 }
 
@@ -54,6 +57,14 @@ export interface DecisionAction {
   forced_reason?: string;     // 强制平仓原因
 }
 
+// 多模型投票中单个模型的原始输出
+export interface ModelTrace {
+  model: string;
+  cot_trace: string;
+  decision_json?: string;
+  error?: string;
+}
+
 // 决策记录
 export interface DecisionRecord {
   timestamp: string;
@@ -61,6 +72,7 @@ export interface DecisionRecord {
   input_prompt: string;
   cot_trace: string;
   decision_json: string;
+  model_traces?: ModelTrace[]; // 多模型投票时每个模型的原始输出
   account_state: {
     total_balance: number;
     available_balance: number;